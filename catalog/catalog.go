@@ -0,0 +1,105 @@
+package catalog
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	productModel "github.com/YassinNouh21/GoShopCart-Ecommerce/models/product"
+)
+
+/*
+	Package catalog imports and exports the product catalog in bulk, as CSV or JSON Lines (NDJSON).
+
+	Every NDJSON line is a product in the JSON form of the API. A CSV file has a header row naming
+	its columns, among CSVColumns, and one row per product, or one row per variant for products with
+	variants: consecutive or not, the rows with the same product_name describe the same product.
+	Imports match existing products by name, then by the SKU of one of their variants, and replace
+	them; other products are created. Exports write the whole catalog in the same format, so an
+	exported file can be edited and imported back.
+*/
+
+const (
+	// MaxImportRows is the largest number of rows, or lines, an import accepts.
+	MaxImportRows = 10000
+	// MaxNDJSONLineSize is the largest line of an NDJSON import, in bytes.
+	MaxNDJSONLineSize = 1 << 20
+	// exportPageSize is the number of products read at a time by an export.
+	exportPageSize = 100
+)
+
+var (
+	// ErrUnknownFormat is returned for a format other than csv and ndjson.
+	ErrUnknownFormat = errors.New("unknown format, use csv or ndjson")
+
+	// ErrTooManyRows is returned when an import exceeds MaxImportRows.
+	ErrTooManyRows = fmt.Errorf("an import accepts at most %d rows", MaxImportRows)
+
+	// ErrUnreadableFile wraps the errors of an import file that cannot be read at all, as opposed to
+	// the errors of the database.
+	ErrUnreadableFile = errors.New("the file cannot be read")
+)
+
+// Format is the file format of an import or export.
+type Format string
+
+const (
+	// FormatCSV is comma separated values with a header row.
+	FormatCSV Format = "csv"
+	// FormatNDJSON is one JSON product per line.
+	FormatNDJSON Format = "ndjson"
+)
+
+// ParseFormat returns the format with the given name. "jsonl" is accepted for NDJSON.
+func ParseFormat(name string) (Format, error) {
+	switch strings.ToLower(name) {
+	case "csv":
+		return FormatCSV, nil
+	case "ndjson", "jsonl":
+		return FormatNDJSON, nil
+	default:
+		return "", ErrUnknownFormat
+	}
+}
+
+// FormatOfContentType returns the format of a request body with the given Content-Type.
+func FormatOfContentType(contentType string) (Format, error) {
+	mediaType := strings.TrimSpace(strings.Split(contentType, ";")[0])
+	switch strings.ToLower(mediaType) {
+	case "text/csv":
+		return FormatCSV, nil
+	case "application/x-ndjson", "application/jsonl", "application/json-lines":
+		return FormatNDJSON, nil
+	default:
+		return "", ErrUnknownFormat
+	}
+}
+
+// ContentType returns the MIME type of files in the format.
+func (f Format) ContentType() string {
+	if f == FormatCSV {
+		return "text/csv; charset=utf-8"
+	}
+	return "application/x-ndjson"
+}
+
+// Extension returns the file extension of the format, with its dot.
+func (f Format) Extension() string {
+	if f == FormatCSV {
+		return ".csv"
+	}
+	return ".ndjson"
+}
+
+// Row is a product read from an import, with the line it starts on.
+type Row struct {
+	Line    int
+	Product productModel.Product
+}
+
+// RowError is a problem with a product of an import.
+type RowError struct {
+	Line        int    `json:"line"`
+	ProductName string `json:"product_name,omitempty"`
+	Error       string `json:"error"`
+}
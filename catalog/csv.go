@@ -0,0 +1,344 @@
+package catalog
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+
+	productModel "github.com/YassinNouh21/GoShopCart-Ecommerce/models/product"
+	"github.com/YassinNouh21/GoShopCart-Ecommerce/money"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// CSVColumns are the columns of the CSV format. The product columns are read from the first row of
+// a product; the columns starting with sku describe the variant of the row.
+//   - price and variant_price are decimal amounts in the store currency, such as 19.99.
+//   - price_overrides and variant_price_overrides hold prices in other currencies as currency=amount
+//     pairs separated by "|", such as EUR=18.50|GBP=15.90.
+//   - category_ids holds the category IDs separated by "|".
+//   - weight is the shipping weight in grams and dimensions the package size in millimetres as
+//     LxWxH, such as 300x200x50.
+//   - options holds the option values of the variant as name=value pairs separated by ";", such as
+//     size=M;color=Red. The options of the product are collected from its variants.
+var CSVColumns = []string{
+	"product_name", "description", "price", "image", "stock", "category_ids",
+	"sku", "options", "variant_price", "variant_stock", "variant_image",
+	"price_overrides", "variant_price_overrides", "weight", "dimensions",
+}
+
+// requiredCSVColumns must appear in the header of an import.
+var requiredCSVColumns = []string{"product_name", "price"}
+
+// csvRecord is a data row of a CSV import, by column name.
+type csvRecord struct {
+	line   int
+	fields map[string]string
+}
+
+// ReadCSV reads the products of a CSV import. Malformed rows are reported as row errors; an error is
+// returned if the file itself cannot be read.
+func ReadCSV(r io.Reader) ([]Row, []RowError, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+	header, err := reader.Read()
+	if err == io.EOF {
+		return nil, nil, fmt.Errorf("the file is empty, it needs a header row")
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+	known := map[string]bool{}
+	for _, column := range CSVColumns {
+		known[column] = true
+	}
+	present := map[string]bool{}
+	for i, column := range header {
+		column = strings.ToLower(strings.TrimSpace(strings.TrimPrefix(column, "\ufeff")))
+		if !known[column] {
+			return nil, nil, fmt.Errorf("unknown column %q, the columns are %s", column, strings.Join(CSVColumns, ", "))
+		}
+		if present[column] {
+			return nil, nil, fmt.Errorf("duplicate column %q", column)
+		}
+		present[column] = true
+		header[i] = column
+	}
+	for _, column := range requiredCSVColumns {
+		if !present[column] {
+			return nil, nil, fmt.Errorf("missing column %q", column)
+		}
+	}
+
+	// Group the rows by product name, in the order the products first appear
+	groups := map[string][]csvRecord{}
+	names := []string{}
+	rowErrors := []RowError{}
+	count := 0
+	for {
+		values, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, nil, err
+		}
+		line, _ := reader.FieldPos(0)
+		if count++; count > MaxImportRows {
+			return nil, nil, ErrTooManyRows
+		}
+		if len(values) != len(header) {
+			rowErrors = append(rowErrors, RowError{Line: line, Error: fmt.Sprintf("expected %d fields, found %d", len(header), len(values))})
+			continue
+		}
+		record := csvRecord{line: line, fields: map[string]string{}}
+		for i, value := range values {
+			record.fields[header[i]] = strings.TrimSpace(value)
+		}
+		name := record.fields["product_name"]
+		if name == "" {
+			rowErrors = append(rowErrors, RowError{Line: line, Error: "product_name is required"})
+			continue
+		}
+		if groups[name] == nil {
+			names = append(names, name)
+		}
+		groups[name] = append(groups[name], record)
+	}
+
+	rows := []Row{}
+	for _, name := range names {
+		product, rowError := csvProduct(groups[name])
+		if rowError != nil {
+			rowErrors = append(rowErrors, *rowError)
+			continue
+		}
+		rows = append(rows, Row{Line: groups[name][0].line, Product: product})
+	}
+	sort.SliceStable(rowErrors, func(i, j int) bool { return rowErrors[i].Line < rowErrors[j].Line })
+	return rows, rowErrors, nil
+}
+
+// csvProduct builds the product described by its rows.
+func csvProduct(records []csvRecord) (productModel.Product, *RowError) {
+	first := records[0]
+	name := first.fields["product_name"]
+	fail := func(record csvRecord, format string, args ...interface{}) (productModel.Product, *RowError) {
+		return productModel.Product{}, &RowError{Line: record.line, ProductName: name, Error: fmt.Sprintf(format, args...)}
+	}
+
+	product := productModel.Product{
+		ProductName: name,
+		Description: first.fields["description"],
+		ImageUrl:    first.fields["image"],
+		CategoryIDs: []primitive.ObjectID{},
+		Options:     []productModel.ProductOption{},
+		Variants:    []productModel.Variant{},
+	}
+	price, err := money.Parse(first.fields["price"], money.StoreCurrency())
+	if err != nil {
+		return fail(first, "invalid price %q", first.fields["price"])
+	}
+	product.Price = price
+	if product.PriceOverrides, err = parseOverrides(first.fields["price_overrides"]); err != nil {
+		return fail(first, "invalid price_overrides %q, use currency=amount pairs", first.fields["price_overrides"])
+	}
+	if raw := first.fields["stock"]; raw != "" {
+		if product.Stock, err = strconv.Atoi(raw); err != nil {
+			return fail(first, "invalid stock %q", raw)
+		}
+	}
+	if raw := first.fields["weight"]; raw != "" {
+		if product.Weight, err = strconv.Atoi(raw); err != nil {
+			return fail(first, "invalid weight %q", raw)
+		}
+	}
+	if raw := first.fields["dimensions"]; raw != "" {
+		if product.Dimensions, err = parseDimensions(raw); err != nil {
+			return fail(first, "invalid dimensions %q, use LxWxH in millimetres", raw)
+		}
+	}
+	for _, raw := range splitList(first.fields["category_ids"], "|") {
+		categoryID, err := primitive.ObjectIDFromHex(raw)
+		if err != nil {
+			return fail(first, "invalid category ID %q", raw)
+		}
+		product.CategoryIDs = append(product.CategoryIDs, categoryID)
+	}
+
+	optionIndex := map[string]int{}
+	for _, record := range records {
+		sku := record.fields["sku"]
+		if sku == "" {
+			if len(records) > 1 {
+				return fail(record, "product %q has several rows, every row needs the sku of a variant", name)
+			}
+			continue
+		}
+		variant := productModel.Variant{SKU: sku, Options: map[string]string{}, ImageUrl: record.fields["variant_image"]}
+		for _, pair := range splitList(record.fields["options"], ";") {
+			optionName, value, found := strings.Cut(pair, "=")
+			optionName, value = strings.TrimSpace(optionName), strings.TrimSpace(value)
+			if !found || optionName == "" || value == "" {
+				return fail(record, "invalid option %q, use name=value", pair)
+			}
+			variant.Options[optionName] = value
+			i, known := optionIndex[optionName]
+			if !known {
+				i = len(product.Options)
+				optionIndex[optionName] = i
+				product.Options = append(product.Options, productModel.ProductOption{Name: optionName, Values: []string{}})
+			}
+			if !containsString(product.Options[i].Values, value) {
+				product.Options[i].Values = append(product.Options[i].Values, value)
+			}
+		}
+		if raw := record.fields["variant_price"]; raw != "" {
+			variantPrice, err := money.Parse(raw, money.StoreCurrency())
+			if err != nil {
+				return fail(record, "invalid variant_price %q", raw)
+			}
+			variant.Price = &variantPrice
+		}
+		if raw := record.fields["variant_price_overrides"]; raw != "" {
+			if variant.PriceOverrides, err = parseOverrides(raw); err != nil {
+				return fail(record, "invalid variant_price_overrides %q, use currency=amount pairs", raw)
+			}
+		}
+		if raw := record.fields["variant_stock"]; raw != "" {
+			if variant.Stock, err = strconv.Atoi(raw); err != nil {
+				return fail(record, "invalid variant_stock %q", raw)
+			}
+		}
+		product.Variants = append(product.Variants, variant)
+	}
+	if len(product.Variants) > 0 {
+		product.Stock = 0
+	}
+	return product, nil
+}
+
+// WriteCSVHeader writes the header row of a CSV export.
+func WriteCSVHeader(writer *csv.Writer) error {
+	return writer.Write(CSVColumns)
+}
+
+// WriteCSVProduct writes the rows of the product: one row, or one row per variant.
+func WriteCSVProduct(writer *csv.Writer, product productModel.Product) error {
+	categoryIDs := make([]string, len(product.CategoryIDs))
+	for i, categoryID := range product.CategoryIDs {
+		categoryIDs[i] = categoryID.Hex()
+	}
+	row := []string{
+		product.ProductName,
+		product.Description,
+		product.Price.Decimal(),
+		product.ImageUrl,
+		strconv.Itoa(product.Stock),
+		strings.Join(categoryIDs, "|"),
+		"", "", "", "", "",
+		formatOverrides(product.PriceOverrides), "",
+		strconv.Itoa(product.Weight), formatDimensions(product.Dimensions),
+	}
+	if !product.HasVariants() {
+		return writer.Write(row)
+	}
+	for _, variant := range product.Variants {
+		// Write the options in the order of the product, so the export is stable
+		pairs := []string{}
+		for _, option := range product.Options {
+			if value, found := variant.Options[option.Name]; found {
+				pairs = append(pairs, option.Name+"="+value)
+			}
+		}
+		variantPrice := ""
+		if variant.Price != nil {
+			variantPrice = variant.Price.Decimal()
+		}
+		row[4] = ""
+		copy(row[6:], []string{variant.SKU, strings.Join(pairs, ";"), variantPrice, strconv.Itoa(variant.Stock), variant.ImageUrl})
+		row[12] = formatOverrides(variant.PriceOverrides)
+		if err := writer.Write(row); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// parseOverrides reads price overrides written as currency=amount pairs separated by "|".
+func parseOverrides(value string) ([]money.Money, error) {
+	overrides := []money.Money{}
+	for _, pair := range splitList(value, "|") {
+		code, amount, found := strings.Cut(pair, "=")
+		if !found {
+			return nil, fmt.Errorf("invalid price override %q", pair)
+		}
+		currency, err := money.ParseCurrency(code)
+		if err != nil {
+			return nil, err
+		}
+		override, err := money.Parse(amount, currency)
+		if err != nil {
+			return nil, err
+		}
+		overrides = append(overrides, override)
+	}
+	return overrides, nil
+}
+
+// formatOverrides writes price overrides as currency=amount pairs separated by "|".
+func formatOverrides(overrides []money.Money) string {
+	pairs := make([]string, len(overrides))
+	for i, override := range overrides {
+		pairs[i] = string(override.Currency) + "=" + override.Decimal()
+	}
+	return strings.Join(pairs, "|")
+}
+
+// parseDimensions reads a package size written as LxWxH.
+func parseDimensions(value string) (*productModel.Dimensions, error) {
+	parts := strings.Split(strings.ToLower(value), "x")
+	if len(parts) != 3 {
+		return nil, fmt.Errorf("invalid dimensions %q", value)
+	}
+	sizes := make([]int, 3)
+	for i, part := range parts {
+		size, err := strconv.Atoi(strings.TrimSpace(part))
+		if err != nil {
+			return nil, err
+		}
+		sizes[i] = size
+	}
+	return &productModel.Dimensions{Length: sizes[0], Width: sizes[1], Height: sizes[2]}, nil
+}
+
+// formatDimensions writes a package size as LxWxH, or nothing for a product without one.
+func formatDimensions(dimensions *productModel.Dimensions) string {
+	if dimensions == nil {
+		return ""
+	}
+	return fmt.Sprintf("%dx%dx%d", dimensions.Length, dimensions.Width, dimensions.Height)
+}
+
+// splitList splits the value at the separator, dropping empty items.
+func splitList(value, separator string) []string {
+	items := []string{}
+	for _, item := range strings.Split(value, separator) {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
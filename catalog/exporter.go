@@ -0,0 +1,57 @@
+package catalog
+
+import (
+	"bufio"
+	"context"
+	"encoding/csv"
+	"io"
+	"net/http"
+
+	"github.com/YassinNouh21/GoShopCart-Ecommerce/repositories"
+)
+
+// Export writes every product of the catalog in the format, oldest first. The catalog is read and
+// written one page at a time, and the output is flushed after every page when w is an http.Flusher,
+// so large catalogs are streamed without being held in memory.
+func Export(ctx context.Context, products repositories.ProductRepository, format Format, w io.Writer) error {
+	buffered := bufio.NewWriter(w)
+	csvWriter := csv.NewWriter(buffered)
+	if format == FormatCSV {
+		if err := WriteCSVHeader(csvWriter); err != nil {
+			return err
+		}
+	}
+
+	oldest, _ := repositories.ParseProductSort("oldest")
+	listOptions := repositories.ProductListOptions{Sort: oldest, Limit: exportPageSize}
+	for {
+		page, err := products.List(ctx, repositories.ProductFilter{}, listOptions)
+		if err != nil {
+			return err
+		}
+		for _, product := range page.Products {
+			if format == FormatCSV {
+				err = WriteCSVProduct(csvWriter, product)
+			} else {
+				err = WriteNDJSONProduct(buffered, product)
+			}
+			if err != nil {
+				return err
+			}
+		}
+		csvWriter.Flush()
+		if err := csvWriter.Error(); err != nil {
+			return err
+		}
+		if err := buffered.Flush(); err != nil {
+			return err
+		}
+		if flusher, ok := w.(http.Flusher); ok {
+			flusher.Flush()
+		}
+		if page.NextCursor == "" {
+			return nil
+		}
+		listOptions.Cursor = page.NextCursor
+	}
+}
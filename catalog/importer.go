@@ -0,0 +1,243 @@
+package catalog
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"time"
+
+	productModel "github.com/YassinNouh21/GoShopCart-Ecommerce/models/product"
+	"github.com/YassinNouh21/GoShopCart-Ecommerce/repositories"
+	"github.com/go-playground/validator/v10"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// ImportReport describes the outcome of an import, or of a dry run.
+type ImportReport struct {
+	DryRun bool `json:"dry_run"`
+	// Products is the number of products read from the file.
+	Products int `json:"products"`
+	// Created and Updated count the products created and replaced, or that would be in a dry run.
+	// Both are zero when an import is rejected because of invalid products.
+	Created int        `json:"created"`
+	Updated int        `json:"updated"`
+	Errors  []RowError `json:"errors"`
+	// Imported holds the products that were written, so indexes can be updated.
+	Imported []productModel.Product `json:"-"`
+}
+
+// Importer validates imported products and writes them to the catalog.
+type Importer struct {
+	products   repositories.ProductRepository
+	categories repositories.CategoryRepository
+}
+
+// NewImporter creates an Importer on top of the product and category repositories.
+func NewImporter(products repositories.ProductRepository, categories repositories.CategoryRepository) *Importer {
+	return &Importer{products: products, categories: categories}
+}
+
+/*
+Import reads the products from the file and creates or replaces them in the catalog.
+
+	Every product is validated like the product endpoints do, its categories must exist and its SKUs
+	must not belong to another product. An existing product with the same name, or else with the SKU
+	of one of the variants, is replaced: it keeps its ID, rating, reviews and images, and its variants
+	keep their IDs by SKU, so carts keep pointing at them.
+
+	Nothing is written when dryRun is set or when a product is invalid; the report then lists every
+	problem found. Otherwise the products are written with bulk writes, and those modified by another
+	request during the import are reported as errors. A file that cannot be read at all, such as a CSV
+	file with an unknown column, fails with an error wrapping ErrUnreadableFile.
+*/
+func (im *Importer) Import(ctx context.Context, format Format, r io.Reader, dryRun bool) (*ImportReport, error) {
+	var rows []Row
+	var rowErrors []RowError
+	var err error
+	if format == FormatCSV {
+		rows, rowErrors, err = ReadCSV(r)
+	} else {
+		rows, rowErrors, err = ReadNDJSON(r)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrUnreadableFile, err)
+	}
+
+	report := &ImportReport{DryRun: dryRun, Products: len(rows), Errors: rowErrors, Imported: []productModel.Product{}}
+	categories := map[primitive.ObjectID]bool{}
+	names := map[string]int{}
+	skus := map[string]string{}
+	upserts := []repositories.ProductUpsert{}
+	now := time.Now().UTC()
+	for _, row := range rows {
+		product := row.Product
+		fail := func(format string, args ...interface{}) {
+			report.Errors = append(report.Errors, RowError{Line: row.Line, ProductName: product.ProductName, Error: fmt.Sprintf(format, args...)})
+		}
+		if line, seen := names[product.ProductName]; seen {
+			fail("product %q is already imported on line %d", product.ProductName, line)
+			continue
+		}
+		names[product.ProductName] = row.Line
+
+		existing, err := im.findExisting(ctx, product)
+		if err != nil {
+			return nil, err
+		}
+		upsert := repositories.ProductUpsert{Product: &product, Existing: existing != nil}
+		if existing != nil {
+			mergeExisting(&product, existing)
+			upsert.Version = existing.Version
+		} else {
+			product.ProductID = primitive.NewObjectID()
+			product.CreatedAt = now
+			product.Version = 1
+			product.Images = []productModel.ProductImage{}
+		}
+		product.UpdatedAt = now
+		for i := range product.Variants {
+			if product.Variants[i].VariantID == primitive.NilObjectID {
+				product.Variants[i].VariantID = primitive.NewObjectID()
+			}
+		}
+
+		if err := validator.New().Struct(product); err != nil {
+			fail("%s", err.Error())
+			continue
+		}
+		if err := product.ValidatePrices(); err != nil {
+			fail("%s", err.Error())
+			continue
+		}
+		if err := product.ValidateVariants(); err != nil {
+			fail("%s", err.Error())
+			continue
+		}
+		if message, err := im.checkCategories(ctx, product, categories); err != nil {
+			return nil, err
+		} else if message != "" {
+			fail("%s", message)
+			continue
+		}
+		if message, err := im.checkSKUs(ctx, product, skus); err != nil {
+			return nil, err
+		} else if message != "" {
+			fail("%s", message)
+			continue
+		}
+		upserts = append(upserts, upsert)
+	}
+	for _, upsert := range upserts {
+		if upsert.Existing {
+			report.Updated++
+		} else {
+			report.Created++
+		}
+	}
+	if dryRun {
+		return report, nil
+	}
+	if len(report.Errors) > 0 {
+		report.Created, report.Updated = 0, 0
+		return report, nil
+	}
+
+	conflicts, err := im.products.BulkUpsert(ctx, upserts)
+	if err != nil {
+		return nil, err
+	}
+	conflicting := map[primitive.ObjectID]bool{}
+	for _, productID := range conflicts {
+		conflicting[productID] = true
+	}
+	for i, upsert := range upserts {
+		if conflicting[upsert.Product.ProductID] {
+			report.Updated--
+			report.Errors = append(report.Errors, RowError{
+				Line:        names[upsert.Product.ProductName],
+				ProductName: upsert.Product.ProductName,
+				Error:       "the product was modified by another request during the import",
+			})
+			continue
+		}
+		report.Imported = append(report.Imported, *upserts[i].Product)
+	}
+	return report, nil
+}
+
+// findExisting returns the product the imported one replaces: the product with the same name, or
+// else the product with the SKU of one of its variants. It returns nil for a new product.
+func (im *Importer) findExisting(ctx context.Context, product productModel.Product) (*productModel.Product, error) {
+	existing, err := im.products.FindByName(ctx, product.ProductName)
+	if err != repositories.ErrNotFound {
+		return existing, err
+	}
+	for _, variant := range product.Variants {
+		existing, err := im.products.FindBySKU(ctx, variant.SKU)
+		if err != repositories.ErrNotFound {
+			return existing, err
+		}
+	}
+	return nil, nil
+}
+
+// mergeExisting keeps what the import does not change from the product being replaced: its ID,
+// creation time, rating, images and the IDs of the variants with the same SKU.
+func mergeExisting(product *productModel.Product, existing *productModel.Product) {
+	product.ProductID = existing.ProductID
+	product.CreatedAt = existing.CreatedAt
+	product.Version = existing.Version + 1
+	product.Rating = existing.Rating
+	product.ReviewCount = existing.ReviewCount
+	product.Images = existing.Images
+	if len(existing.Images) > 0 {
+		product.ImageUrl = existing.ImageUrl
+	}
+	variantIDs := map[string]primitive.ObjectID{}
+	for _, variant := range existing.Variants {
+		variantIDs[variant.SKU] = variant.VariantID
+	}
+	for i := range product.Variants {
+		product.Variants[i].VariantID = variantIDs[product.Variants[i].SKU]
+	}
+}
+
+// checkCategories checks that every category of the product exists, remembering the known ones.
+// It returns the problem found, if any.
+func (im *Importer) checkCategories(ctx context.Context, product productModel.Product, known map[primitive.ObjectID]bool) (string, error) {
+	for _, categoryID := range product.CategoryIDs {
+		if known[categoryID] {
+			continue
+		}
+		_, err := im.categories.FindByID(ctx, categoryID)
+		if err == repositories.ErrNotFound {
+			return "Category not found: " + categoryID.Hex(), nil
+		}
+		if err != nil {
+			return "", err
+		}
+		known[categoryID] = true
+	}
+	return "", nil
+}
+
+// checkSKUs checks that no other product of the catalog or of the import uses a SKU of the product.
+// It returns the problem found, if any.
+func (im *Importer) checkSKUs(ctx context.Context, product productModel.Product, imported map[string]string) (string, error) {
+	for _, variant := range product.Variants {
+		if owner, found := imported[variant.SKU]; found {
+			return fmt.Sprintf("SKU %s is already used by %q in this import", variant.SKU, owner), nil
+		}
+		owner, err := im.products.FindBySKU(ctx, variant.SKU)
+		if err == nil && owner.ProductID != product.ProductID {
+			return fmt.Sprintf("SKU already exists: %s (%q)", variant.SKU, owner.ProductName), nil
+		}
+		if err != nil && err != repositories.ErrNotFound {
+			return "", err
+		}
+	}
+	for _, variant := range product.Variants {
+		imported[variant.SKU] = product.ProductName
+	}
+	return "", nil
+}
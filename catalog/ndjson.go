@@ -0,0 +1,85 @@
+package catalog
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+
+	productModel "github.com/YassinNouh21/GoShopCart-Ecommerce/models/product"
+	"github.com/YassinNouh21/GoShopCart-Ecommerce/money"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// ReadNDJSON reads the products of an NDJSON import, one per non-empty line. Only the fields a
+// product is created with are read; the fields maintained by the server, such as the ID, rating or
+// images, are ignored, so an export can be imported back. Malformed lines are reported as row errors;
+// an error is returned if the file itself cannot be read.
+func ReadNDJSON(r io.Reader) ([]Row, []RowError, error) {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), MaxNDJSONLineSize)
+	rows := []Row{}
+	rowErrors := []RowError{}
+	count := 0
+	for line := 1; scanner.Scan(); line++ {
+		data := bytes.TrimSpace(scanner.Bytes())
+		if len(data) == 0 {
+			continue
+		}
+		if count++; count > MaxImportRows {
+			return nil, nil, ErrTooManyRows
+		}
+		var decoded productModel.Product
+		if err := json.Unmarshal(data, &decoded); err != nil {
+			rowErrors = append(rowErrors, RowError{Line: line, Error: "invalid JSON: " + err.Error()})
+			continue
+		}
+		product := productModel.Product{
+			ProductName:    decoded.ProductName,
+			Description:    decoded.Description,
+			Price:          decoded.Price,
+			PriceOverrides: decoded.PriceOverrides,
+			ImageUrl:       decoded.ImageUrl,
+			Stock:          decoded.Stock,
+			Weight:         decoded.Weight,
+			Dimensions:     decoded.Dimensions,
+			CategoryIDs:    decoded.CategoryIDs,
+			Options:        decoded.Options,
+			Variants:       []productModel.Variant{},
+		}
+		for _, variant := range decoded.Variants {
+			variant.VariantID = primitive.NilObjectID
+			variant.DisplayPrice = nil
+			product.Variants = append(product.Variants, variant)
+		}
+		if product.CategoryIDs == nil {
+			product.CategoryIDs = []primitive.ObjectID{}
+		}
+		if product.PriceOverrides == nil {
+			product.PriceOverrides = []money.Money{}
+		}
+		if product.Options == nil {
+			product.Options = []productModel.ProductOption{}
+		}
+		rows = append(rows, Row{Line: line, Product: product})
+	}
+	if errors.Is(scanner.Err(), bufio.ErrTooLong) {
+		return nil, nil, fmt.Errorf("a line is longer than %d bytes", MaxNDJSONLineSize)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, nil, err
+	}
+	return rows, rowErrors, nil
+}
+
+// WriteNDJSONProduct writes the product as one line of JSON.
+func WriteNDJSONProduct(w io.Writer, product productModel.Product) error {
+	data, err := json.Marshal(product)
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(append(data, '\n'))
+	return err
+}
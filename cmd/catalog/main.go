@@ -0,0 +1,139 @@
+// Command catalog imports and exports the product catalog in bulk, as CSV or NDJSON, directly
+// against the MongoDB database configured by MONGO_URI.
+//
+// Usage:
+//
+//	catalog import [-format csv|ndjson] [-dry-run] FILE
+//	catalog export [-format csv|ndjson] [-o FILE]
+//
+// The format defaults to the extension of the file, or to csv for exports to the standard output.
+// An import prints its report as JSON and exits with status 1 if a product was not imported. The
+// embedded search index and the suggestions of a running server are rebuilt from the catalog when
+// it restarts; the MongoDB search backend sees imported products at once.
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/YassinNouh21/GoShopCart-Ecommerce/catalog"
+	"github.com/YassinNouh21/GoShopCart-Ecommerce/database"
+	"github.com/YassinNouh21/GoShopCart-Ecommerce/migrations"
+	"github.com/YassinNouh21/GoShopCart-Ecommerce/repositories"
+)
+
+func main() {
+	if len(os.Args) < 2 {
+		usage()
+	}
+	var err error
+	switch os.Args[1] {
+	case "import":
+		err = runImport(os.Args[2:])
+	case "export":
+		err = runExport(os.Args[2:])
+	default:
+		usage()
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "catalog:", err)
+		os.Exit(1)
+	}
+}
+
+func usage() {
+	fmt.Fprintln(os.Stderr, "usage: catalog import [-format csv|ndjson] [-dry-run] FILE")
+	fmt.Fprintln(os.Stderr, "       catalog export [-format csv|ndjson] [-o FILE]")
+	os.Exit(2)
+}
+
+func runImport(args []string) error {
+	flags := flag.NewFlagSet("import", flag.ExitOnError)
+	formatName := flags.String("format", "", "csv or ndjson, by default the extension of the file")
+	dryRun := flags.Bool("dry-run", false, "only validate the file")
+	flags.Parse(args)
+	if flags.NArg() != 1 {
+		usage()
+	}
+	path := flags.Arg(0)
+	format, err := formatOf(*formatName, path)
+	if err != nil {
+		return err
+	}
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	repos, err := connect()
+	if err != nil {
+		return err
+	}
+	report, err := catalog.NewImporter(repos.Products, repos.Categories).Import(context.Background(), format, file, *dryRun)
+	if err != nil {
+		return err
+	}
+	output, _ := json.MarshalIndent(report, "", "  ")
+	fmt.Println(string(output))
+	if len(report.Errors) > 0 {
+		os.Exit(1)
+	}
+	return nil
+}
+
+func runExport(args []string) error {
+	flags := flag.NewFlagSet("export", flag.ExitOnError)
+	formatName := flags.String("format", "", "csv or ndjson, by default the extension of the output file")
+	outputPath := flags.String("o", "", "the output file, the standard output by default")
+	flags.Parse(args)
+	if flags.NArg() != 0 {
+		usage()
+	}
+	format, err := formatOf(*formatName, *outputPath)
+	if err != nil {
+		return err
+	}
+	var output io.Writer = os.Stdout
+	if *outputPath != "" {
+		file, err := os.Create(*outputPath)
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+		output = file
+	}
+	repos, err := connect()
+	if err != nil {
+		return err
+	}
+	return catalog.Export(context.Background(), repos.Products, format, output)
+}
+
+// formatOf returns the format named by the flag, or else the format of the extension of the file.
+func formatOf(name, path string) (catalog.Format, error) {
+	if name != "" {
+		return catalog.ParseFormat(name)
+	}
+	if extension := strings.TrimPrefix(filepath.Ext(path), "."); extension != "" {
+		return catalog.ParseFormat(extension)
+	}
+	return catalog.FormatCSV, nil
+}
+
+// connect connects to MongoDB and returns the repositories on top of it.
+// connect connects to the database, bringing its documents up to date first, and returns the
+// repositories on top of it.
+func connect() (repositories.Repositories, error) {
+	collections := database.InitializeMongoDBCollections(database.MongoInstance())
+	if _, err := migrations.Run(context.Background(), collections.Client.Database(database.DatabaseName), migrations.All); err != nil {
+		return repositories.Repositories{}, err
+	}
+	return repositories.NewMongoRepositories(collections), nil
+}
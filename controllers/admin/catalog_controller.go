@@ -0,0 +1,132 @@
+package admin
+
+import (
+	"context"
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/YassinNouh21/GoShopCart-Ecommerce/catalog"
+	"github.com/YassinNouh21/GoShopCart-Ecommerce/repositories"
+	"github.com/YassinNouh21/GoShopCart-Ecommerce/search"
+
+	"github.com/gin-gonic/gin"
+)
+
+// MaxImportSize is the largest import file, in bytes.
+const MaxImportSize = 32 << 20
+
+var (
+	ErrUnknownFormat   = errors.New("Unknown format, use csv or ndjson in the format parameter or the Content-Type header")
+	ErrInvalidDryRun   = errors.New("Invalid dry_run value")
+	ErrImportTooLarge  = errors.New("Import too large, it must be at most 32 MB")
+	ErrImportFailed    = errors.New("Failed to import products")
+	ErrImportHasErrors = errors.New("No product was imported, fix the errors and try again")
+)
+
+// CatalogController serves the admin endpoints that import and export the catalog in bulk.
+type CatalogController struct {
+	products repositories.ProductRepository
+	importer *catalog.Importer
+	indexers []search.Indexer
+}
+
+// NewCatalogController creates a CatalogController on top of the product and category repositories.
+// The imported products are added to the indexers, such as the search backend and the suggester.
+func NewCatalogController(products repositories.ProductRepository, categories repositories.CategoryRepository, indexers ...search.Indexer) *CatalogController {
+	return &CatalogController{products: products, importer: catalog.NewImporter(products, categories), indexers: indexers}
+}
+
+/*
+ImportProducts creates or replaces products from a CSV or NDJSON file sent as the request body.
+
+	The format is given by the format query parameter or else by the Content-Type header (text/csv or
+	application/x-ndjson). With dry_run=true the file is only validated. The response reports the
+	number of products created and updated, or that would be, and the errors with their line; nothing
+	is imported when a product is invalid.
+
+Possible Errors:
+  - Unknown format: If neither the format parameter nor the Content-Type names a known format.
+  - Invalid dry_run value: If dry_run is not a boolean.
+  - Import too large: If the file exceeds 32 MB.
+  - The file cannot be read: If it is not valid CSV, lacks a required column or has too many rows.
+  - No product was imported: If a product is invalid (422, with the report).
+  - Failed to import products: If the database cannot be read or written.
+*/
+func (cc *CatalogController) ImportProducts(c *gin.Context) {
+	format, err := catalog.ParseFormat(c.Query("format"))
+	if c.Query("format") == "" {
+		format, err = catalog.FormatOfContentType(c.GetHeader("Content-Type"))
+	}
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": ErrUnknownFormat.Error()})
+		return
+	}
+	dryRun := false
+	if value := c.Query("dry_run"); value != "" {
+		if dryRun, err = strconv.ParseBool(value); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": ErrInvalidDryRun.Error()})
+			return
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Minute)
+	defer cancel()
+
+	body := http.MaxBytesReader(c.Writer, c.Request.Body, MaxImportSize)
+	report, err := cc.importer.Import(ctx, format, body, dryRun)
+	var tooLarge *http.MaxBytesError
+	if errors.As(err, &tooLarge) {
+		c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": ErrImportTooLarge.Error()})
+		return
+	}
+	if errors.Is(err, catalog.ErrUnreadableFile) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		log.Printf("catalog: import: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": ErrImportFailed.Error()})
+		return
+	}
+	for _, product := range report.Imported {
+		for _, indexer := range cc.indexers {
+			if err := indexer.Index(ctx, product); err != nil {
+				log.Printf("search: index product %s: %v", product.ProductID.Hex(), err)
+			}
+		}
+	}
+	if !report.DryRun && report.Created+report.Updated == 0 && len(report.Errors) > 0 {
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": ErrImportHasErrors.Error(), "report": report})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"report": report})
+}
+
+/*
+ExportProducts streams the whole catalog as CSV or NDJSON, given by the format query parameter
+(csv by default), as a file download.
+
+Possible Errors:
+  - Unknown format: If the format parameter is not csv or ndjson.
+*/
+func (cc *CatalogController) ExportProducts(c *gin.Context) {
+	format, err := catalog.ParseFormat(c.DefaultQuery("format", string(catalog.FormatCSV)))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": ErrUnknownFormat.Error()})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Minute)
+	defer cancel()
+
+	c.Header("Content-Type", format.ContentType())
+	c.Header("Content-Disposition", `attachment; filename="products`+format.Extension()+`"`)
+	c.Status(http.StatusOK)
+	// The status is sent with the first page, so a failure can only end the download early
+	if err := catalog.Export(ctx, cc.products, format, c.Writer); err != nil {
+		log.Printf("catalog: export: %v", err)
+	}
+}
@@ -0,0 +1,91 @@
+package admin
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	productModel "github.com/YassinNouh21/GoShopCart-Ecommerce/models/product"
+	userModel "github.com/YassinNouh21/GoShopCart-Ecommerce/models/user"
+	"github.com/YassinNouh21/GoShopCart-Ecommerce/money"
+	"github.com/YassinNouh21/GoShopCart-Ecommerce/repositories"
+	"github.com/YassinNouh21/GoShopCart-Ecommerce/search"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestImportProducts(t *testing.T) {
+	// Every test starts with a mug at 10.00 in the catalog
+	const (
+		valid   = "product_name,price,stock\nMug,12.00,4\nPlate,8.50,6\n"
+		invalid = "product_name,price,stock\nMug,12.00,4\nPlate,free,6\n"
+	)
+	tests := []struct {
+		name    string
+		query   string
+		body    string
+		status  int
+		created float64
+		updated float64
+		errors  []float64
+		written bool
+	}{
+		{name: "dry run", query: "?format=csv&dry_run=true", body: valid, status: http.StatusOK, created: 1, updated: 1},
+		{name: "import", query: "?format=csv", body: valid, status: http.StatusOK, created: 1, updated: 1, written: true},
+		// A dry run still counts the valid rows, so the outcome of the fixed file is known
+		{name: "dry run with an invalid row", query: "?format=csv&dry_run=true", body: invalid, status: http.StatusOK, updated: 1, errors: []float64{3}},
+		{name: "import with an invalid row", query: "?format=csv", body: invalid, status: http.StatusUnprocessableEntity, errors: []float64{3}},
+		{
+			name: "NDJSON dry run", query: "?format=ndjson&dry_run=1",
+			body:   `{"product_name":"Mug","price":"12.00","stock":4}` + "\n" + `{"product_name":"Plate","price":"8.50","stock":6}` + "\n",
+			status: http.StatusOK, created: 1, updated: 1,
+		},
+		{name: "invalid dry_run", query: "?format=csv&dry_run=maybe", body: valid, status: http.StatusBadRequest},
+		{name: "unknown format", query: "?format=xlsx", body: valid, status: http.StatusBadRequest},
+		{name: "unknown column", query: "?format=csv&dry_run=true", body: "product_name,price,colour\nMug,12.00,red\n", status: http.StatusBadRequest},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			ctx := context.Background()
+			repos := repositories.NewMemoryRepositories()
+			suggester := search.NewSuggester()
+			mug := &productModel.Product{ProductID: primitive.NewObjectID(), ProductName: "Mug", Price: money.New(1000, money.USD), Stock: 3, Version: 1}
+			if err := repos.Products.Create(ctx, mug); err != nil {
+				t.Fatal(err)
+			}
+			router := signedIn(primitive.NewObjectID(), userModel.RoleAdmin)
+			router.POST("/admin/products/import", NewCatalogController(repos.Products, repos.Categories, suggester).ImportProducts)
+
+			status, response := serve(t, router, http.MethodPost, "/admin/products/import"+test.query, test.body)
+			if status != test.status {
+				t.Fatalf("status = %d, want %d: %v", status, test.status, response)
+			}
+			if report, ok := response["report"].(map[string]interface{}); ok {
+				if report["created"] != test.created || report["updated"] != test.updated {
+					t.Errorf("report created %v and updated %v, want %v and %v", report["created"], report["updated"], test.created, test.updated)
+				}
+				var lines []float64
+				for _, rowError := range report["errors"].([]interface{}) {
+					lines = append(lines, rowError.(map[string]interface{})["line"].(float64))
+				}
+				if len(lines) != len(test.errors) || (len(lines) > 0 && lines[0] != test.errors[0]) {
+					t.Errorf("errors on lines %v, want %v", lines, test.errors)
+				}
+			}
+
+			stored, err := repos.Products.FindByID(ctx, mug.ProductID)
+			if err != nil {
+				t.Fatal(err)
+			}
+			_, plateErr := repos.Products.FindByName(ctx, "Plate")
+			written := stored.Price.Amount == 1200 && stored.Stock == 4 && plateErr == nil
+			unchanged := stored.Price.Amount == 1000 && stored.Stock == 3 && plateErr == repositories.ErrNotFound
+			if (test.written && !written) || (!test.written && !unchanged) {
+				t.Errorf("mug at %s with %d in stock, plate lookup error %v, want the import written %t", stored.Price, stored.Stock, plateErr, test.written)
+			}
+			if indexed := len(suggester.Complete("plate", 1)) == 1; indexed != test.written {
+				t.Errorf("plate suggested = %t, want %t", indexed, test.written)
+			}
+		})
+	}
+}
@@ -0,0 +1,134 @@
+package admin
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/YassinNouh21/GoShopCart-Ecommerce/money"
+	"github.com/YassinNouh21/GoShopCart-Ecommerce/repositories"
+
+	"github.com/gin-gonic/gin"
+)
+
+var (
+	ErrInvalidCurrency        = errors.New("Invalid currency, it must be a supported ISO 4217 code other than the store currency")
+	ErrInvalidExchangeRate    = errors.New("Invalid rate, it must be a positive decimal number with at most 10 decimals")
+	ErrInvalidRounding        = errors.New("Invalid rounding, it must be a positive amount of the currency such as 0.05")
+	ErrExchangeRateNotFound   = errors.New("Exchange rate not found")
+	ErrFailedFetchRates       = errors.New("Failed to fetch exchange rates")
+	ErrExchangeRateNotSaved   = errors.New("Failed to save exchange rate")
+	ErrExchangeRateNotDeleted = errors.New("Failed to delete exchange rate")
+)
+
+// ExchangeRateController serves the admin endpoints that manage the exchange rates.
+type ExchangeRateController struct {
+	exchangeRates repositories.ExchangeRateRepository
+}
+
+// NewExchangeRateController creates an ExchangeRateController on top of the given repository.
+func NewExchangeRateController(exchangeRates repositories.ExchangeRateRepository) *ExchangeRateController {
+	return &ExchangeRateController{exchangeRates: exchangeRates}
+}
+
+// SetExchangeRateRequest represents the request body for setting the exchange rate of a currency.
+type SetExchangeRateRequest struct {
+	Rate     string `json:"rate" binding:"required"`
+	Rounding string `json:"rounding"`
+}
+
+/*
+GetExchangeRates returns the store currency under base and the exchange rates from it under rates.
+
+Possible Errors:
+  - Failed to fetch exchange rates: If the exchange rates cannot be read.
+*/
+func (ec *ExchangeRateController) GetExchangeRates(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	rates, err := ec.exchangeRates.FindAll(ctx)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": ErrFailedFetchRates.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"base": money.StoreCurrency(), "rates": rates})
+}
+
+/*
+SetExchangeRate creates or replaces the exchange rate of the currency in the path.
+
+	The rate is the amount of the currency one unit of the store currency is worth, sent as a decimal
+	string such as "0.92" so it is kept exactly. The optional rounding is the step converted prices are
+	rounded to, such as "0.05"; by default they are rounded to the minor unit of the currency. The new
+	rate applies to the next requests; orders keep the rate they were placed at.
+
+Possible Errors:
+  - ErrInvalidCurrency: If the currency is unknown or is the store currency.
+  - ErrInvalidExchangeRate: If the rate is not a positive decimal number.
+  - ErrInvalidRounding: If the rounding is not a positive amount of the currency.
+  - Failed to save exchange rate: If the exchange rate cannot be stored.
+*/
+func (ec *ExchangeRateController) SetExchangeRate(c *gin.Context) {
+	currency, err := money.ParseCurrency(c.Param("currency"))
+	if err != nil || currency == money.StoreCurrency() {
+		c.JSON(http.StatusBadRequest, gin.H{"error": ErrInvalidCurrency.Error()})
+		return
+	}
+	var request SetExchangeRateRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": ErrInvalidExchangeRate.Error()})
+		return
+	}
+	if _, err := money.ParseRate(request.Rate); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": ErrInvalidExchangeRate.Error()})
+		return
+	}
+	if _, err := money.RoundingStep(request.Rounding, currency); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": ErrInvalidRounding.Error()})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	rate := money.ExchangeRate{Currency: currency, Rate: strings.TrimSpace(request.Rate), Rounding: strings.TrimSpace(request.Rounding), UpdatedAt: time.Now().UTC()}
+	if err := ec.exchangeRates.Save(ctx, &rate); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": ErrExchangeRateNotSaved.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Exchange rate saved successfully", "rate": rate})
+}
+
+/*
+DeleteExchangeRate removes the exchange rate of the currency in the path. Prices can no longer be
+requested in the currency; users who prefer it see the store currency again.
+
+Possible Errors:
+  - ErrInvalidCurrency: If the currency is unknown or is the store currency.
+  - ErrExchangeRateNotFound: If the currency has no exchange rate.
+  - Failed to delete exchange rate: If the exchange rate cannot be removed.
+*/
+func (ec *ExchangeRateController) DeleteExchangeRate(c *gin.Context) {
+	currency, err := money.ParseCurrency(c.Param("currency"))
+	if err != nil || currency == money.StoreCurrency() {
+		c.JSON(http.StatusBadRequest, gin.H{"error": ErrInvalidCurrency.Error()})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	err = ec.exchangeRates.Delete(ctx, currency)
+	if err == repositories.ErrNotFound {
+		c.JSON(http.StatusNotFound, gin.H{"error": ErrExchangeRateNotFound.Error()})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": ErrExchangeRateNotDeleted.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Exchange rate deleted successfully"})
+}
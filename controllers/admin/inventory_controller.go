@@ -0,0 +1,132 @@
+package admin
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/YassinNouh21/GoShopCart-Ecommerce/repositories"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// DefaultLowStockThreshold is the stock at or below which a product is reported as low on stock.
+const DefaultLowStockThreshold = 5
+
+var (
+	ErrInvalidProductID  = errors.New("Invalid product ID")
+	ErrInvalidThreshold  = errors.New("Invalid threshold")
+	ErrProductNotFound   = errors.New("Product not found")
+	ErrInsufficientStock = errors.New("Stock cannot drop below zero")
+	ErrStockNotUpdated   = errors.New("Failed to update stock")
+	ErrFailedFetchStock  = errors.New("Failed to fetch products")
+)
+
+// InventoryController serves the admin endpoints that manage the stock of the products.
+type InventoryController struct {
+	products repositories.ProductRepository
+}
+
+// NewInventoryController creates an InventoryController on top of the given product repository.
+func NewInventoryController(products repositories.ProductRepository) *InventoryController {
+	return &InventoryController{products: products}
+}
+
+// AdjustStockRequest represents the request body for changing the stock of a product.
+// The variant ID selects the variant whose stock changes, for products with variants.
+type AdjustStockRequest struct {
+	Delta     int                 `json:"delta" binding:"required"`
+	VariantID *primitive.ObjectID `json:"variant_id"`
+}
+
+/*
+GetLowStock returns the products whose stock is at or below the threshold query parameter,
+lowest stock first. The threshold defaults to DefaultLowStockThreshold.
+
+Possible Errors:
+  - Invalid threshold: If the threshold is not a non-negative integer.
+  - Failed to fetch products: If the products cannot be read.
+*/
+func (ic *InventoryController) GetLowStock(c *gin.Context) {
+	threshold := DefaultLowStockThreshold
+	if value := c.Query("threshold"); value != "" {
+		parsed, err := strconv.Atoi(value)
+		if err != nil || parsed < 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": ErrInvalidThreshold.Error()})
+			return
+		}
+		threshold = parsed
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	products, err := ic.products.FindLowStock(ctx, threshold)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": ErrFailedFetchStock.Error()})
+		return
+	}
+
+	c.IndentedJSON(http.StatusOK, gin.H{"message": products})
+}
+
+/*
+AdjustStock adds the delta of the request body to the stock of a product or of one of its variants,
+for example when goods are received (positive delta) or found damaged (negative delta).
+
+	The change is applied atomically on top of the current stock, so it is safe while checkouts
+	are reserving stock of the same product.
+
+Possible Errors:
+  - Invalid product ID: If the product ID in the path is not a valid ObjectID.
+  - Invalid request body: If the body does not contain a non-zero delta.
+  - Product not found: If no product with the given ID exists.
+  - Variant not found: If the variant does not belong to the product.
+  - A variant of the product must be selected: If the product has variants and none is given.
+  - Stock cannot drop below zero: If the delta is larger than the stock left.
+*/
+func (ic *InventoryController) AdjustStock(c *gin.Context) {
+	productID, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": ErrInvalidProductID.Error()})
+		return
+	}
+
+	var request AdjustStockRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": ErrInvalidRequest.Error()})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	product, err := ic.products.FindByID(ctx, productID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": ErrProductNotFound.Error()})
+		return
+	}
+	if _, err := product.ResolveVariant(request.VariantID); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	product, err = ic.products.AdjustStock(ctx, productID, request.VariantID, request.Delta)
+	if err == repositories.ErrNotFound {
+		c.JSON(http.StatusNotFound, gin.H{"error": ErrProductNotFound.Error()})
+		return
+	}
+	if err == repositories.ErrInsufficientStock {
+		c.JSON(http.StatusConflict, gin.H{"error": ErrInsufficientStock.Error()})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": ErrStockNotUpdated.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Stock updated successfully", "product": product})
+}
@@ -0,0 +1,96 @@
+package admin
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	productModel "github.com/YassinNouh21/GoShopCart-Ecommerce/models/product"
+	userModel "github.com/YassinNouh21/GoShopCart-Ecommerce/models/user"
+	"github.com/YassinNouh21/GoShopCart-Ecommerce/money"
+	"github.com/YassinNouh21/GoShopCart-Ecommerce/repositories"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestAdjustStock(t *testing.T) {
+	tests := []struct {
+		name   string
+		body   interface{}
+		status int
+		stock  int
+	}{
+		{name: "goods received", body: gin.H{"delta": 10}, status: http.StatusOK, stock: 13},
+		{name: "goods written off", body: gin.H{"delta": -3}, status: http.StatusOK, stock: 0},
+		{name: "below zero", body: gin.H{"delta": -4}, status: http.StatusConflict, stock: 3},
+		{name: "zero delta", body: gin.H{"delta": 0}, status: http.StatusBadRequest, stock: 3},
+		{name: "variant of a product without variants", body: gin.H{"delta": 1, "variant_id": primitive.NewObjectID()}, status: http.StatusBadRequest, stock: 3},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			ctx := context.Background()
+			products := repositories.NewMemoryRepositories().Products
+			product := &productModel.Product{ProductID: primitive.NewObjectID(), ProductName: "Mug", Price: money.New(1000, money.USD), Stock: 3}
+			if err := products.Create(ctx, product); err != nil {
+				t.Fatal(err)
+			}
+			router := signedIn(primitive.NewObjectID(), userModel.RoleStaff)
+			router.POST("/admin/products/:id/stock", NewInventoryController(products).AdjustStock)
+
+			status, response := serve(t, router, http.MethodPost, "/admin/products/"+product.ProductID.Hex()+"/stock", test.body)
+			if status != test.status {
+				t.Fatalf("status = %d, want %d: %v", status, test.status, response)
+			}
+			stored, err := products.FindByID(ctx, product.ProductID)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if stored.Stock != test.stock {
+				t.Errorf("stock = %d, want %d", stored.Stock, test.stock)
+			}
+		})
+	}
+}
+
+func TestGetLowStock(t *testing.T) {
+	ctx := context.Background()
+	products := repositories.NewMemoryRepositories().Products
+	for name, stock := range map[string]int{"Mug": 0, "Plate": 5, "Bowl": 6} {
+		product := &productModel.Product{ProductID: primitive.NewObjectID(), ProductName: name, Price: money.New(1000, money.USD), Stock: stock}
+		if err := products.Create(ctx, product); err != nil {
+			t.Fatal(err)
+		}
+	}
+	router := signedIn(primitive.NewObjectID(), userModel.RoleStaff)
+	router.GET("/admin/products/low-stock", NewInventoryController(products).GetLowStock)
+
+	tests := []struct {
+		query  string
+		status int
+		names  []string
+	}{
+		{query: "", status: http.StatusOK, names: []string{"Mug", "Plate"}},
+		{query: "?threshold=0", status: http.StatusOK, names: []string{"Mug"}},
+		{query: "?threshold=-1", status: http.StatusBadRequest},
+		{query: "?threshold=few", status: http.StatusBadRequest},
+	}
+	for _, test := range tests {
+		status, response := serve(t, router, http.MethodGet, "/admin/products/low-stock"+test.query, "")
+		if status != test.status {
+			t.Errorf("GET %q status = %d, want %d: %v", test.query, status, test.status, response)
+			continue
+		}
+		if test.names == nil {
+			continue
+		}
+		found, _ := response["message"].([]interface{})
+		var names []string
+		for _, product := range found {
+			names = append(names, product.(map[string]interface{})["product_name"].(string))
+		}
+		if len(names) != len(test.names) || (len(names) > 0 && names[0] != test.names[0]) {
+			t.Errorf("GET %q = %v, want %v lowest stock first", test.query, names, test.names)
+		}
+	}
+}
@@ -0,0 +1,192 @@
+package admin
+
+import (
+	"context"
+	"errors"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/YassinNouh21/GoShopCart-Ecommerce/helpers"
+	"github.com/YassinNouh21/GoShopCart-Ecommerce/inventory"
+	userModel "github.com/YassinNouh21/GoShopCart-Ecommerce/models/user"
+	"github.com/YassinNouh21/GoShopCart-Ecommerce/payments"
+	"github.com/YassinNouh21/GoShopCart-Ecommerce/repositories"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-playground/validator/v10"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+var (
+	ErrInvalidOrderID     = errors.New("Invalid order ID")
+	ErrInvalidOrderStatus = errors.New("Invalid order status")
+	ErrOrderNotFound      = errors.New("Order not found")
+	ErrOrderChanged       = errors.New("Order status was changed by another request")
+	ErrOrderNotUpdated    = errors.New("Failed to update order")
+	ErrFailedFetchOrders  = errors.New("Failed to fetch orders")
+	ErrPaymentNotReleased = errors.New("Order status updated but the payment could not be voided or refunded")
+	ErrPaymentNotCaptured = errors.New("Order cannot be marked paid before its payment is captured")
+)
+
+// OrderController serves the admin endpoints that manage orders.
+type OrderController struct {
+	orders    repositories.OrderRepository
+	payments  payments.PaymentProvider
+	inventory *inventory.Reservations
+}
+
+// NewOrderController creates an OrderController on top of the given order repository, the payment
+// provider that voids and refunds the payments of cancelled and refunded orders and the reservations
+// that give back the stock of cancelled orders.
+func NewOrderController(orders repositories.OrderRepository, provider payments.PaymentProvider, reservations *inventory.Reservations) *OrderController {
+	return &OrderController{orders: orders, payments: provider, inventory: reservations}
+}
+
+// UpdateOrderStatusRequest represents the request body for changing the status of an order.
+type UpdateOrderStatusRequest struct {
+	Status userModel.OrderStatus `json:"status" validate:"required"`
+	Note   string                `json:"note"`
+}
+
+/*
+GetOrders returns every order, newest first, optionally filtered by the status query parameter.
+
+Possible Errors:
+  - Invalid order status: If the status query parameter is not a known status.
+  - Failed to fetch orders: If the orders cannot be read.
+*/
+func (oc *OrderController) GetOrders(c *gin.Context) {
+	status := userModel.OrderStatus(c.Query("status"))
+	if status != "" && !status.Valid() {
+		c.JSON(http.StatusBadRequest, gin.H{"error": ErrInvalidOrderStatus.Error()})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	orders, err := oc.orders.FindByStatus(ctx, status)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": ErrFailedFetchOrders.Error()})
+		return
+	}
+
+	c.IndentedJSON(http.StatusOK, gin.H{"message": orders})
+}
+
+/*
+GetOrder returns an order with its status history.
+
+Possible Errors:
+  - Invalid order ID: If the order ID is not a valid ObjectID.
+  - Order not found: If no order with the given ID exists.
+*/
+func (oc *OrderController) GetOrder(c *gin.Context) {
+	orderID, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": ErrInvalidOrderID.Error()})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	order, err := oc.orders.FindByID(ctx, orderID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": ErrOrderNotFound.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, order)
+}
+
+/*
+UpdateOrderStatus moves an order to a new status.
+
+	The move must be allowed by the order transition table. The change is recorded in the status
+	history of the order together with the admin who made it and the optional note. An order awaiting
+	payment can only be marked paid once its payment is captured. Cancelling or refunding an order
+	then voids its authorized payment or refunds its captured payment, and cancelling it gives its
+	stock back.
+
+Possible Errors:
+  - Invalid order ID: If the order ID is not a valid ObjectID.
+  - Invalid request body / Invalid order status: If the body does not contain a known status.
+  - Order not found: If no order with the given ID exists.
+  - Invalid order status transition: If the order cannot move from its status to the new one.
+  - Order cannot be marked paid before its payment is captured: If the payment of the order is not captured.
+  - Order status was changed by another request: If the status changed while it was being updated.
+  - Order status updated but the payment could not be voided or refunded: If the payment provider
+    cannot release the payment after the order was cancelled or refunded. The updated order is returned.
+*/
+func (oc *OrderController) UpdateOrderStatus(c *gin.Context) {
+	orderID, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": ErrInvalidOrderID.Error()})
+		return
+	}
+
+	var request UpdateOrderStatusRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": ErrInvalidRequest.Error()})
+		return
+	}
+	if err := validator.New().Struct(request); err != nil || !request.Status.Valid() {
+		c.JSON(http.StatusBadRequest, gin.H{"error": ErrInvalidOrderStatus.Error()})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	order, err := oc.orders.FindByID(ctx, orderID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": ErrOrderNotFound.Error()})
+		return
+	}
+	change, err := order.Transition(request.Status, c.GetString("user_id"), request.Note)
+	if err != nil {
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		return
+	}
+	if change.To == userModel.OrderStatusPaid && (order.Payment == nil || order.Payment.Status != userModel.PaymentStatusCaptured) {
+		c.JSON(http.StatusConflict, gin.H{"error": ErrPaymentNotCaptured.Error()})
+		return
+	}
+	// The conditional status update comes first, so the payment is only released for the change that
+	// was actually applied
+	err = oc.orders.UpdateStatus(ctx, orderID, change)
+	if err == repositories.ErrVersionConflict {
+		c.JSON(http.StatusConflict, gin.H{"error": ErrOrderChanged.Error()})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": ErrOrderNotUpdated.Error()})
+		return
+	}
+	if change.To == userModel.OrderStatusCancelled {
+		oc.inventory.Release(ctx, *order)
+	}
+
+	order.Status = change.To
+	order.UpdatedAt = change.ChangedAt
+	order.StatusHistory = append(order.StatusHistory, change)
+	if change.To == userModel.OrderStatusCancelled || change.To == userModel.OrderStatusRefunded {
+		released, err := helpers.ReleasePayment(ctx, oc.payments, order.Payment)
+		if err != nil {
+			log.Println("release payment of order", orderID.Hex(), ":", err)
+			c.JSON(http.StatusBadGateway, gin.H{"error": ErrPaymentNotReleased.Error(), "order": order})
+			return
+		}
+		if released != nil {
+			if err := oc.orders.UpdatePayment(ctx, orderID, *released); err != nil {
+				log.Println("record payment of order", orderID.Hex(), ":", err)
+				c.JSON(http.StatusInternalServerError, gin.H{"error": ErrOrderNotUpdated.Error()})
+				return
+			}
+			order.Payment = released
+		}
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Order status updated successfully", "order": order})
+}
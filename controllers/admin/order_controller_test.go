@@ -0,0 +1,165 @@
+package admin
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/YassinNouh21/GoShopCart-Ecommerce/inventory"
+	productModel "github.com/YassinNouh21/GoShopCart-Ecommerce/models/product"
+	userModel "github.com/YassinNouh21/GoShopCart-Ecommerce/models/user"
+	"github.com/YassinNouh21/GoShopCart-Ecommerce/money"
+	"github.com/YassinNouh21/GoShopCart-Ecommerce/payments"
+	"github.com/YassinNouh21/GoShopCart-Ecommerce/repositories"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestUpdateOrderStatus(t *testing.T) {
+	// Every order holds 2 units of a product with 3 left in stock, reserved at checkout
+	tests := []struct {
+		name    string
+		from    userModel.OrderStatus
+		payment userModel.PaymentStatus
+		body    interface{}
+		code    int
+		want    userModel.OrderStatus
+		paid    userModel.PaymentStatus
+		stock   int
+	}{
+		{
+			name: "mark paid with a captured payment", from: userModel.OrderStatusPendingPayment, payment: userModel.PaymentStatusCaptured,
+			body: gin.H{"status": "paid"}, code: http.StatusOK, want: userModel.OrderStatusPaid, paid: userModel.PaymentStatusCaptured, stock: 3,
+		},
+		{
+			name: "mark paid with an authorized payment", from: userModel.OrderStatusPendingPayment, payment: userModel.PaymentStatusAuthorized,
+			body: gin.H{"status": "paid"}, code: http.StatusConflict, want: userModel.OrderStatusPendingPayment, paid: userModel.PaymentStatusAuthorized, stock: 3,
+		},
+		{
+			name: "start fulfilling", from: userModel.OrderStatusPaid, payment: userModel.PaymentStatusCaptured,
+			body: gin.H{"status": "fulfilling", "note": "Packing"}, code: http.StatusOK, want: userModel.OrderStatusFulfilling, paid: userModel.PaymentStatusCaptured, stock: 3,
+		},
+		{
+			name: "cancel an order awaiting payment voids it and gives the stock back", from: userModel.OrderStatusPendingPayment, payment: userModel.PaymentStatusAuthorized,
+			body: gin.H{"status": "cancelled"}, code: http.StatusOK, want: userModel.OrderStatusCancelled, paid: userModel.PaymentStatusVoided, stock: 5,
+		},
+		{
+			name: "cancel a paid order refunds it", from: userModel.OrderStatusPaid, payment: userModel.PaymentStatusCaptured,
+			body: gin.H{"status": "cancelled"}, code: http.StatusOK, want: userModel.OrderStatusCancelled, paid: userModel.PaymentStatusRefunded, stock: 5,
+		},
+		{
+			name: "refund a delivered order keeps the stock sold", from: userModel.OrderStatusDelivered, payment: userModel.PaymentStatusCaptured,
+			body: gin.H{"status": "refunded"}, code: http.StatusOK, want: userModel.OrderStatusRefunded, paid: userModel.PaymentStatusRefunded, stock: 3,
+		},
+		{
+			name: "move back from shipped", from: userModel.OrderStatusShipped, payment: userModel.PaymentStatusCaptured,
+			body: gin.H{"status": "paid"}, code: http.StatusConflict, want: userModel.OrderStatusShipped, paid: userModel.PaymentStatusCaptured, stock: 3,
+		},
+		{
+			name: "leave a final status", from: userModel.OrderStatusCancelled, payment: userModel.PaymentStatusVoided,
+			body: gin.H{"status": "refunded"}, code: http.StatusConflict, want: userModel.OrderStatusCancelled, paid: userModel.PaymentStatusVoided, stock: 3,
+		},
+		{
+			name: "unknown status", from: userModel.OrderStatusPaid, payment: userModel.PaymentStatusCaptured,
+			body: gin.H{"status": "lost"}, code: http.StatusBadRequest, want: userModel.OrderStatusPaid, paid: userModel.PaymentStatusCaptured, stock: 3,
+		},
+		{
+			name: "malformed body", from: userModel.OrderStatusPaid, payment: userModel.PaymentStatusCaptured,
+			body: "{", code: http.StatusBadRequest, want: userModel.OrderStatusPaid, paid: userModel.PaymentStatusCaptured, stock: 3,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			ctx := context.Background()
+			repos := repositories.NewMemoryRepositories()
+			provider := payments.NewFakeProvider(payments.FakeSucceed, "whsec")
+			reservations := inventory.NewReservations(repos.Products, repos.Orders, repos.Promotions, provider, 15*time.Minute)
+			order := storeOrder(t, repos, provider, test.from, test.payment)
+			adminID := primitive.NewObjectID()
+			router := signedIn(adminID, userModel.RoleAdmin)
+			router.PUT("/admin/orders/:id/status", NewOrderController(repos.Orders, provider, reservations).UpdateOrderStatus)
+
+			status, response := serve(t, router, http.MethodPut, "/admin/orders/"+order.OrderID.Hex()+"/status", test.body)
+			if status != test.code {
+				t.Fatalf("status = %d, want %d: %v", status, test.code, response)
+			}
+			stored, err := repos.Orders.FindByID(ctx, order.OrderID)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if stored.Status != test.want || stored.Payment.Status != test.paid {
+				t.Errorf("order %s with payment %s, want %s with payment %s", stored.Status, stored.Payment.Status, test.want, test.paid)
+			}
+			if changed := len(stored.StatusHistory) == 2; changed != (test.code == http.StatusOK) {
+				t.Errorf("got history %+v after a %d response", stored.StatusHistory, status)
+			} else if changed && (stored.StatusHistory[1].From != test.from || stored.StatusHistory[1].ChangedBy != adminID.Hex()) {
+				t.Errorf("got history entry %+v, want a change from %s by the admin", stored.StatusHistory[1], test.from)
+			}
+			product, err := repos.Products.FindByID(ctx, order.Items[0].ProductID)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if product.Stock != test.stock {
+				t.Errorf("stock = %d, want %d", product.Stock, test.stock)
+			}
+		})
+	}
+}
+
+func TestUpdateOrderStatusNotFound(t *testing.T) {
+	repos := repositories.NewMemoryRepositories()
+	provider := payments.NewFakeProvider(payments.FakeSucceed, "whsec")
+	reservations := inventory.NewReservations(repos.Products, repos.Orders, repos.Promotions, provider, 15*time.Minute)
+	router := signedIn(primitive.NewObjectID(), userModel.RoleAdmin)
+	router.PUT("/admin/orders/:id/status", NewOrderController(repos.Orders, provider, reservations).UpdateOrderStatus)
+
+	if status, response := serve(t, router, http.MethodPut, "/admin/orders/"+primitive.NewObjectID().Hex()+"/status", gin.H{"status": "paid"}); status != http.StatusNotFound {
+		t.Errorf("status = %d, want 404: %v", status, response)
+	}
+	if status, response := serve(t, router, http.MethodPut, "/admin/orders/abc/status", gin.H{"status": "paid"}); status != http.StatusBadRequest {
+		t.Errorf("status = %d, want 400: %v", status, response)
+	}
+}
+
+// storeOrder stores a product with 3 units left and an order of 2 more units of it in the status,
+// reserved at checkout, whose payment at the fake provider is in the payment status.
+func storeOrder(t *testing.T, repos repositories.Repositories, provider *payments.FakeProvider, status userModel.OrderStatus, paymentStatus userModel.PaymentStatus) *userModel.Order {
+	t.Helper()
+	ctx := context.Background()
+	product := &productModel.Product{ProductID: primitive.NewObjectID(), ProductName: "Mug", Price: money.New(1000, money.USD), Stock: 3}
+	if err := repos.Products.Create(ctx, product); err != nil {
+		t.Fatal(err)
+	}
+	total := money.New(2000, money.USD)
+	transaction, err := provider.Authorize(ctx, payments.AuthorizationRequest{Amount: total, PaymentMethod: "card"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	switch paymentStatus {
+	case userModel.PaymentStatusCaptured:
+		_, err = provider.Capture(ctx, transaction.ID)
+	case userModel.PaymentStatusVoided:
+		_, err = provider.Void(ctx, transaction.ID)
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+	reservedUntil := time.Now().UTC().Add(15 * time.Minute)
+	order := &userModel.Order{
+		OrderID:       primitive.NewObjectID(),
+		UserID:        primitive.NewObjectID(),
+		Items:         []userModel.OrderItem{{ProductID: product.ProductID, ProductName: product.ProductName, Quantity: 2, UnitPrice: product.Price}},
+		Total:         total,
+		PaymentMethod: "card",
+		Payment:       &userModel.OrderPayment{Provider: provider.Name(), TransactionID: transaction.ID, Status: paymentStatus, Amount: total},
+		Status:        status,
+		StatusHistory: []userModel.OrderStatusChange{{To: status, ChangedBy: userModel.SystemActor, ChangedAt: time.Now().UTC()}},
+		ReservedUntil: &reservedUntil,
+	}
+	if err := repos.Orders.Create(ctx, order); err != nil {
+		t.Fatal(err)
+	}
+	return order
+}
@@ -0,0 +1,253 @@
+package admin
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/YassinNouh21/GoShopCart-Ecommerce/models/promotion"
+	"github.com/YassinNouh21/GoShopCart-Ecommerce/money"
+	"github.com/YassinNouh21/GoShopCart-Ecommerce/repositories"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-playground/validator/v10"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+var (
+	ErrInvalidPromotionID  = errors.New("Invalid promotion ID")
+	ErrPromotionNotFound   = errors.New("Promotion not found")
+	ErrDuplicateCoupon     = errors.New("Another promotion already has this code")
+	ErrPromotionBusy       = errors.New("Promotion is being used by many orders, please try again")
+	ErrFailedFetchPromos   = errors.New("Failed to fetch promotions")
+	ErrPromotionNotSaved   = errors.New("Failed to save promotion")
+	ErrPromotionNotDeleted = errors.New("Failed to delete promotion")
+)
+
+// promotionUpdateAttempts is how many times a promotion is read and saved again when orders use it
+// while it is being updated.
+const promotionUpdateAttempts = 3
+
+// PromotionController serves the admin endpoints that manage the promotions and coupons.
+type PromotionController struct {
+	promotions repositories.PromotionRepository
+}
+
+// NewPromotionController creates a PromotionController on top of the given repository.
+func NewPromotionController(promotions repositories.PromotionRepository) *PromotionController {
+	return &PromotionController{promotions: promotions}
+}
+
+/*
+PromotionRequest represents the request body for creating or replacing a promotion. The usage count
+and the timestamps are managed by the server.
+
+	Amounts are in the store currency. Active defaults to true, so a new promotion applies right away
+	unless it has a later start.
+*/
+type PromotionRequest struct {
+	Code              string               `json:"code"`
+	Name              string               `json:"name"`
+	Kind              promotion.Kind       `json:"kind"`
+	Percent           int                  `json:"percent"`
+	Amount            *money.Money         `json:"amount"`
+	BuyQuantity       int                  `json:"buy_quantity"`
+	GetQuantity       int                  `json:"get_quantity"`
+	MinimumSpend      *money.Money         `json:"minimum_spend"`
+	ProductIDs        []primitive.ObjectID `json:"product_ids"`
+	CategoryIDs       []primitive.ObjectID `json:"category_ids"`
+	UsageLimit        int                  `json:"usage_limit"`
+	UsageLimitPerUser int                  `json:"usage_limit_per_user"`
+	StartsAt          *time.Time           `json:"starts_at"`
+	EndsAt            *time.Time           `json:"ends_at"`
+	Stackable         bool                 `json:"stackable"`
+	Active            *bool                `json:"active"`
+}
+
+// apply copies the definition of the request onto the promotion.
+func (r PromotionRequest) apply(p *promotion.Promotion) {
+	p.Code = promotion.NormalizeCode(r.Code)
+	p.Name = r.Name
+	p.Kind = r.Kind
+	p.Percent = r.Percent
+	p.Amount = r.Amount
+	p.BuyQuantity = r.BuyQuantity
+	p.GetQuantity = r.GetQuantity
+	p.MinimumSpend = r.MinimumSpend
+	p.ProductIDs = r.ProductIDs
+	p.CategoryIDs = r.CategoryIDs
+	if p.ProductIDs == nil {
+		p.ProductIDs = []primitive.ObjectID{}
+	}
+	if p.CategoryIDs == nil {
+		p.CategoryIDs = []primitive.ObjectID{}
+	}
+	p.UsageLimit = r.UsageLimit
+	p.UsageLimitPerUser = r.UsageLimitPerUser
+	p.StartsAt = r.StartsAt
+	p.EndsAt = r.EndsAt
+	p.Stackable = r.Stackable
+	p.Active = r.Active == nil || *r.Active
+}
+
+// validatePromotion checks the fields of the promotion and the rules of its kind.
+func validatePromotion(p promotion.Promotion) error {
+	if err := validator.New().Struct(p); err != nil {
+		return err
+	}
+	return p.Validate()
+}
+
+/*
+GetPromotions returns every promotion, newest first, with how many orders use each.
+
+Possible Errors:
+  - Failed to fetch promotions: If the promotions cannot be read.
+*/
+func (pc *PromotionController) GetPromotions(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	promotions, err := pc.promotions.List(ctx)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": ErrFailedFetchPromos.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"promotions": promotions})
+}
+
+/*
+CreatePromotion creates a promotion. With a code it is a coupon customers apply to their cart;
+without one it applies to every eligible cart.
+
+Possible Errors:
+  - ErrInvalidRequest: If the request body is not valid JSON.
+  - The validation error: If a field is invalid or missing for the kind of the promotion.
+  - ErrDuplicateCoupon: If another promotion has the code.
+  - Failed to save promotion: If the promotion cannot be stored.
+*/
+func (pc *PromotionController) CreatePromotion(c *gin.Context) {
+	var request PromotionRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": ErrInvalidRequest.Error()})
+		return
+	}
+	now := time.Now().UTC()
+	p := promotion.Promotion{PromotionID: primitive.NewObjectID(), CreatedAt: now, UpdatedAt: now}
+	request.apply(&p)
+	if err := validatePromotion(p); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	err := pc.promotions.Create(ctx, &p)
+	if err == repositories.ErrDuplicate {
+		c.JSON(http.StatusConflict, gin.H{"error": ErrDuplicateCoupon.Error()})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": ErrPromotionNotSaved.Error()})
+		return
+	}
+	c.JSON(http.StatusCreated, gin.H{"message": "Promotion created successfully", "promotion": p})
+}
+
+/*
+UpdatePromotion replaces the definition of the promotion in the path. Its usage count is kept, and
+orders already placed keep the discount they got.
+
+Possible Errors:
+  - ErrInvalidPromotionID: If the ID is not a valid ObjectID.
+  - ErrInvalidRequest: If the request body is not valid JSON.
+  - The validation error: If a field is invalid or missing for the kind of the promotion.
+  - ErrPromotionNotFound: If the promotion does not exist.
+  - ErrDuplicateCoupon: If another promotion has the code.
+  - ErrPromotionBusy: If orders kept using the promotion while it was being saved.
+  - Failed to save promotion: If the promotion cannot be stored.
+*/
+func (pc *PromotionController) UpdatePromotion(c *gin.Context) {
+	id, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": ErrInvalidPromotionID.Error()})
+		return
+	}
+	var request PromotionRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": ErrInvalidRequest.Error()})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	for attempt := 0; attempt < promotionUpdateAttempts; attempt++ {
+		p, err := pc.promotions.FindByID(ctx, id)
+		if err == repositories.ErrNotFound {
+			c.JSON(http.StatusNotFound, gin.H{"error": ErrPromotionNotFound.Error()})
+			return
+		}
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": ErrPromotionNotSaved.Error()})
+			return
+		}
+		request.apply(p)
+		p.UpdatedAt = time.Now().UTC()
+		if err := validatePromotion(*p); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		err = pc.promotions.Update(ctx, p)
+		switch err {
+		case nil:
+			c.JSON(http.StatusOK, gin.H{"message": "Promotion updated successfully", "promotion": p})
+			return
+		case repositories.ErrVersionConflict:
+			continue
+		case repositories.ErrNotFound:
+			c.JSON(http.StatusNotFound, gin.H{"error": ErrPromotionNotFound.Error()})
+			return
+		case repositories.ErrDuplicate:
+			c.JSON(http.StatusConflict, gin.H{"error": ErrDuplicateCoupon.Error()})
+			return
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": ErrPromotionNotSaved.Error()})
+			return
+		}
+	}
+	c.JSON(http.StatusConflict, gin.H{"error": ErrPromotionBusy.Error()})
+}
+
+/*
+DeletePromotion removes the promotion in the path. Carts no longer get it and its coupon code no
+longer applies; orders already placed keep the discount they got.
+
+Possible Errors:
+  - ErrInvalidPromotionID: If the ID is not a valid ObjectID.
+  - ErrPromotionNotFound: If the promotion does not exist.
+  - Failed to delete promotion: If the promotion cannot be removed.
+*/
+func (pc *PromotionController) DeletePromotion(c *gin.Context) {
+	id, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": ErrInvalidPromotionID.Error()})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	err = pc.promotions.Delete(ctx, id)
+	if err == repositories.ErrNotFound {
+		c.JSON(http.StatusNotFound, gin.H{"error": ErrPromotionNotFound.Error()})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": ErrPromotionNotDeleted.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Promotion deleted successfully"})
+}
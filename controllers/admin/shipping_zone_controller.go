@@ -0,0 +1,226 @@
+package admin
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"time"
+
+	shippingModel "github.com/YassinNouh21/GoShopCart-Ecommerce/models/shipping"
+	"github.com/YassinNouh21/GoShopCart-Ecommerce/money"
+	"github.com/YassinNouh21/GoShopCart-Ecommerce/repositories"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-playground/validator/v10"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+var (
+	ErrInvalidZoneID    = errors.New("Invalid shipping zone ID")
+	ErrZoneNotFound     = errors.New("Shipping zone not found")
+	ErrFailedFetchZones = errors.New("Failed to fetch shipping zones")
+	ErrZoneNotSaved     = errors.New("Failed to save shipping zone")
+	ErrZoneNotDeleted   = errors.New("Failed to delete shipping zone")
+)
+
+// ShippingZoneController serves the admin endpoints that manage the shipping zones and their methods.
+type ShippingZoneController struct {
+	zones repositories.ShippingZoneRepository
+}
+
+// NewShippingZoneController creates a ShippingZoneController on top of the given repository.
+func NewShippingZoneController(zones repositories.ShippingZoneRepository) *ShippingZoneController {
+	return &ShippingZoneController{zones: zones}
+}
+
+// ShippingZoneRequest represents the request body for creating or replacing a shipping zone.
+type ShippingZoneRequest struct {
+	Name    string                  `json:"name"`
+	Regions []shippingModel.Region  `json:"regions"`
+	Methods []ShippingMethodRequest `json:"methods"`
+}
+
+/*
+ShippingMethodRequest represents a shipping method of a zone request. Amounts are in the store currency.
+
+	A method sent with its method_id keeps it, so clients can go on choosing it by its ID; methods
+	without one get a new ID. Active defaults to true.
+*/
+type ShippingMethodRequest struct {
+	MethodID    *primitive.ObjectID        `json:"method_id"`
+	Name        string                     `json:"name"`
+	RateType    shippingModel.RateType     `json:"rate_type"`
+	Rate        *money.Money               `json:"rate"`
+	WeightTiers []shippingModel.WeightTier `json:"weight_tiers"`
+	PriceTiers  []shippingModel.PriceTier  `json:"price_tiers"`
+	FreeAbove   *money.Money               `json:"free_above"`
+	Active      *bool                      `json:"active"`
+}
+
+// apply copies the definition of the request onto the zone, normalizing its regions.
+func (r ShippingZoneRequest) apply(zone *shippingModel.Zone) {
+	zone.Name = r.Name
+	zone.Regions = []shippingModel.Region{}
+	for _, region := range r.Regions {
+		zone.Regions = append(zone.Regions, shippingModel.NormalizeRegion(region))
+	}
+	zone.Methods = []shippingModel.Method{}
+	for _, method := range r.Methods {
+		id := primitive.NewObjectID()
+		if method.MethodID != nil {
+			id = *method.MethodID
+		}
+		zone.Methods = append(zone.Methods, shippingModel.Method{
+			MethodID:    id,
+			Name:        method.Name,
+			RateType:    method.RateType,
+			Rate:        method.Rate,
+			WeightTiers: method.WeightTiers,
+			PriceTiers:  method.PriceTiers,
+			FreeAbove:   method.FreeAbove,
+			Active:      method.Active == nil || *method.Active,
+		})
+	}
+}
+
+// validateZone checks the fields of the zone, its regions and the rates of its methods.
+func validateZone(zone shippingModel.Zone) error {
+	if err := validator.New().Struct(zone); err != nil {
+		return err
+	}
+	return zone.Validate()
+}
+
+/*
+GetShippingZones returns every shipping zone with its methods, sorted by name.
+
+Possible Errors:
+  - Failed to fetch shipping zones: If the shipping zones cannot be read.
+*/
+func (sc *ShippingZoneController) GetShippingZones(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	zones, err := sc.zones.FindAll(ctx)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": ErrFailedFetchZones.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"shipping_zones": zones})
+}
+
+/*
+CreateShippingZone creates a shipping zone with its regions and methods.
+
+Possible Errors:
+  - ErrInvalidRequest: If the request body is not valid JSON.
+  - The validation error: If a region or a method is invalid.
+  - Failed to save shipping zone: If the zone cannot be stored.
+*/
+func (sc *ShippingZoneController) CreateShippingZone(c *gin.Context) {
+	var request ShippingZoneRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": ErrInvalidRequest.Error()})
+		return
+	}
+	now := time.Now().UTC()
+	zone := shippingModel.Zone{ZoneID: primitive.NewObjectID(), CreatedAt: now, UpdatedAt: now}
+	request.apply(&zone)
+	if err := validateZone(zone); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	if err := sc.zones.Create(ctx, &zone); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": ErrZoneNotSaved.Error()})
+		return
+	}
+	c.JSON(http.StatusCreated, gin.H{"message": "Shipping zone created successfully", "shipping_zone": zone})
+}
+
+/*
+UpdateShippingZone replaces the regions and methods of the shipping zone in the path. Orders already
+placed keep the shipping they were charged.
+
+Possible Errors:
+  - ErrInvalidZoneID: If the ID is not a valid ObjectID.
+  - ErrInvalidRequest: If the request body is not valid JSON.
+  - The validation error: If a region or a method is invalid.
+  - ErrZoneNotFound: If the zone does not exist.
+  - Failed to save shipping zone: If the zone cannot be stored.
+*/
+func (sc *ShippingZoneController) UpdateShippingZone(c *gin.Context) {
+	id, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": ErrInvalidZoneID.Error()})
+		return
+	}
+	var request ShippingZoneRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": ErrInvalidRequest.Error()})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	zone, err := sc.zones.FindByID(ctx, id)
+	if err == repositories.ErrNotFound {
+		c.JSON(http.StatusNotFound, gin.H{"error": ErrZoneNotFound.Error()})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": ErrZoneNotSaved.Error()})
+		return
+	}
+	request.apply(zone)
+	zone.UpdatedAt = time.Now().UTC()
+	if err := validateZone(*zone); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	err = sc.zones.Update(ctx, zone)
+	if err == repositories.ErrNotFound {
+		c.JSON(http.StatusNotFound, gin.H{"error": ErrZoneNotFound.Error()})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": ErrZoneNotSaved.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Shipping zone updated successfully", "shipping_zone": zone})
+}
+
+/*
+DeleteShippingZone removes the shipping zone in the path. Its addresses fall in the next zone that
+covers them, if any.
+
+Possible Errors:
+  - ErrInvalidZoneID: If the ID is not a valid ObjectID.
+  - ErrZoneNotFound: If the zone does not exist.
+  - Failed to delete shipping zone: If the zone cannot be removed.
+*/
+func (sc *ShippingZoneController) DeleteShippingZone(c *gin.Context) {
+	id, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": ErrInvalidZoneID.Error()})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	err = sc.zones.Delete(ctx, id)
+	if err == repositories.ErrNotFound {
+		c.JSON(http.StatusNotFound, gin.H{"error": ErrZoneNotFound.Error()})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": ErrZoneNotDeleted.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Shipping zone deleted successfully"})
+}
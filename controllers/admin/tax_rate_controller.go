@@ -0,0 +1,137 @@
+package admin
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"time"
+
+	taxModel "github.com/YassinNouh21/GoShopCart-Ecommerce/models/tax"
+	"github.com/YassinNouh21/GoShopCart-Ecommerce/repositories"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-playground/validator/v10"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+var (
+	ErrInvalidTaxRateID  = errors.New("Invalid tax rate ID")
+	ErrTaxRateNotFound   = errors.New("Tax rate not found")
+	ErrFailedFetchTaxes  = errors.New("Failed to fetch tax rates")
+	ErrTaxRateNotSaved   = errors.New("Failed to save tax rate")
+	ErrTaxRateNotDeleted = errors.New("Failed to delete tax rate")
+)
+
+// TaxRateController serves the admin endpoints that manage the table of tax rates.
+type TaxRateController struct {
+	rates repositories.TaxRateRepository
+}
+
+// NewTaxRateController creates a TaxRateController on top of the given repository.
+func NewTaxRateController(rates repositories.TaxRateRepository) *TaxRateController {
+	return &TaxRateController{rates: rates}
+}
+
+// TaxRateRequest represents the request body for setting a tax rate. The rate is identified by its
+// country, state and tax class.
+type TaxRateRequest struct {
+	CountryCode string `json:"country_code"`
+	State       string `json:"state"`
+	TaxClass    string `json:"tax_class"`
+	Name        string `json:"name"`
+	Percent     string `json:"percent"`
+	Inclusive   bool   `json:"inclusive"`
+}
+
+/*
+GetTaxRates returns every tax rate, sorted by country, state and tax class.
+
+Possible Errors:
+  - Failed to fetch tax rates: If the tax rates cannot be read.
+*/
+func (tc *TaxRateController) GetTaxRates(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	rates, err := tc.rates.FindAll(ctx)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": ErrFailedFetchTaxes.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"tax_rates": rates})
+}
+
+/*
+SetTaxRate creates the tax rate of a country, state and tax class, or replaces the existing one.
+Carts are taxed with the new rate right away; orders already placed keep the tax they were charged.
+
+Possible Errors:
+  - ErrInvalidRequest: If the request body is not valid JSON.
+  - The validation error: If the country code, tax class, name or percent is invalid.
+  - Failed to save tax rate: If the tax rate cannot be stored.
+*/
+func (tc *TaxRateController) SetTaxRate(c *gin.Context) {
+	var request TaxRateRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": ErrInvalidRequest.Error()})
+		return
+	}
+	rate := taxModel.Rate{
+		RateID:      primitive.NewObjectID(),
+		CountryCode: taxModel.NormalizeRegion(request.CountryCode),
+		State:       taxModel.NormalizeRegion(request.State),
+		TaxClass:    taxModel.NormalizeClass(request.TaxClass),
+		Name:        request.Name,
+		Percent:     request.Percent,
+		Inclusive:   request.Inclusive,
+		UpdatedAt:   time.Now().UTC(),
+	}
+	if err := validator.New().Struct(rate); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err := rate.Validate(); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	if err := tc.rates.Save(ctx, &rate); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": ErrTaxRateNotSaved.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Tax rate saved successfully", "tax_rate": rate})
+}
+
+/*
+DeleteTaxRate removes the tax rate in the path. The products it applied to fall back to the next
+rate that matches them, or are no longer taxed.
+
+Possible Errors:
+  - ErrInvalidTaxRateID: If the ID is not a valid ObjectID.
+  - ErrTaxRateNotFound: If the tax rate does not exist.
+  - Failed to delete tax rate: If the tax rate cannot be removed.
+*/
+func (tc *TaxRateController) DeleteTaxRate(c *gin.Context) {
+	id, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": ErrInvalidTaxRateID.Error()})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	err = tc.rates.Delete(ctx, id)
+	if err == repositories.ErrNotFound {
+		c.JSON(http.StatusNotFound, gin.H{"error": ErrTaxRateNotFound.Error()})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": ErrTaxRateNotDeleted.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Tax rate deleted successfully"})
+}
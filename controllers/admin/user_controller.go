@@ -0,0 +1,151 @@
+package admin
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"time"
+
+	userModel "github.com/YassinNouh21/GoShopCart-Ecommerce/models/user"
+	"github.com/YassinNouh21/GoShopCart-Ecommerce/repositories"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+var (
+	ErrInvalidUserID  = errors.New("Invalid user ID")
+	ErrInvalidRole    = errors.New("Invalid role")
+	ErrUserNotFound   = errors.New("User not found")
+	ErrOwnRoleChange  = errors.New("Admins cannot change their own role")
+	ErrRoleNotUpdated = errors.New("Failed to update role")
+	ErrInvalidRequest = errors.New("Invalid request body")
+	ErrUserNotUpdated = errors.New("Failed to update user")
+)
+
+// UserController serves the admin endpoints that manage users.
+type UserController struct {
+	users repositories.UserRepository
+}
+
+// NewUserController creates a UserController on top of the given user repository.
+func NewUserController(users repositories.UserRepository) *UserController {
+	return &UserController{users: users}
+}
+
+// UpdateRoleRequest represents the request body for changing the role of a user.
+type UpdateRoleRequest struct {
+	Role userModel.Role `json:"role" binding:"required"`
+}
+
+/*
+UpdateUserRole changes the role of a user.
+
+	It reads the user ID from the path and the new role from the request body. Admins cannot change
+	their own role, so the last admin cannot lock everyone out by demoting themselves.
+
+Possible Errors:
+  - Invalid user ID: If the user ID in the path is not a valid ObjectID.
+  - Invalid request body / Invalid role: If the body does not contain a known role.
+  - Admins cannot change their own role: If the target user is the requesting admin.
+  - User not found: If no user with the provided ID exists.
+*/
+func (uc *UserController) UpdateUserRole(c *gin.Context) {
+	userID, err := primitive.ObjectIDFromHex(c.Param("user_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": ErrInvalidUserID.Error()})
+		return
+	}
+
+	var request UpdateRoleRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": ErrInvalidRequest.Error()})
+		return
+	}
+	if !request.Role.Valid() {
+		c.JSON(http.StatusBadRequest, gin.H{"error": ErrInvalidRole.Error()})
+		return
+	}
+	if c.GetString("user_id") == userID.Hex() {
+		c.JSON(http.StatusBadRequest, gin.H{"error": ErrOwnRoleChange.Error()})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	err = uc.users.UpdateRole(ctx, userID, request.Role)
+	if err == repositories.ErrNotFound {
+		c.JSON(http.StatusNotFound, gin.H{"error": ErrUserNotFound.Error()})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": ErrRoleNotUpdated.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Role updated successfully", "role": request.Role})
+}
+
+// UpdateTaxExemptRequest represents the request body for changing whether a user pays tax.
+type UpdateTaxExemptRequest struct {
+	TaxExempt *bool `json:"tax_exempt" binding:"required"`
+}
+
+/*
+UpdateTaxExempt sets whether a user pays tax, for example for a business customer with a valid VAT
+exemption. Carts and orders of an exempt user get no tax, and their prices that include a tax are
+reduced by it.
+
+Possible Errors:
+  - Invalid user ID: If the user ID in the path is not a valid ObjectID.
+  - Invalid request body: If the body does not contain tax_exempt.
+  - User not found: If no user with the provided ID exists.
+  - Failed to update user: If the user cannot be updated.
+*/
+func (uc *UserController) UpdateTaxExempt(c *gin.Context) {
+	userID, err := primitive.ObjectIDFromHex(c.Param("user_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": ErrInvalidUserID.Error()})
+		return
+	}
+	var request UpdateTaxExemptRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": ErrInvalidRequest.Error()})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	err = uc.users.UpdateTaxExempt(ctx, userID, *request.TaxExempt)
+	if err == repositories.ErrNotFound {
+		c.JSON(http.StatusNotFound, gin.H{"error": ErrUserNotFound.Error()})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": ErrUserNotUpdated.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Tax exemption updated successfully", "tax_exempt": *request.TaxExempt})
+}
+
+// BootstrapAdmin grants the admin role to the user with the given email.
+// It is used at startup to create the first admin; an empty email does nothing.
+func BootstrapAdmin(users repositories.UserRepository, email string) error {
+	if email == "" {
+		return nil
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	user, err := users.FindByEmail(ctx, email)
+	if err != nil {
+		return err
+	}
+	if user.Role == userModel.RoleAdmin {
+		return nil
+	}
+	return users.UpdateRole(ctx, user.ID, userModel.RoleAdmin)
+}
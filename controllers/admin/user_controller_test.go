@@ -0,0 +1,106 @@
+package admin
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	userModel "github.com/YassinNouh21/GoShopCart-Ecommerce/models/user"
+	"github.com/YassinNouh21/GoShopCart-Ecommerce/repositories"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func init() {
+	gin.SetMode(gin.TestMode)
+}
+
+// serve sends the request with the JSON body to the router as the signed in user and decodes the JSON response.
+func serve(t *testing.T, router *gin.Engine, method, path string, body interface{}) (int, map[string]interface{}) {
+	t.Helper()
+	var payload bytes.Buffer
+	if text, ok := body.(string); ok {
+		payload.WriteString(text)
+	} else if err := json.NewEncoder(&payload).Encode(body); err != nil {
+		t.Fatal(err)
+	}
+	request := httptest.NewRequest(method, path, &payload)
+	request.Header.Set("Content-Type", "application/json")
+	recorder := httptest.NewRecorder()
+	router.ServeHTTP(recorder, request)
+	response := map[string]interface{}{}
+	json.Unmarshal(recorder.Body.Bytes(), &response)
+	return recorder.Code, response
+}
+
+// signedIn returns a router whose requests are made by the user with the ID and role.
+func signedIn(userID primitive.ObjectID, role userModel.Role) *gin.Engine {
+	router := gin.New()
+	router.Use(func(c *gin.Context) {
+		c.Set("user_id", userID.Hex())
+		c.Set("user_type", string(role))
+		c.Next()
+	})
+	return router
+}
+
+func TestUpdateUserRole(t *testing.T) {
+	tests := []struct {
+		name   string
+		target string
+		body   interface{}
+		status int
+		role   userModel.Role
+	}{
+		{name: "promote a customer", target: "customer", body: gin.H{"role": "staff"}, status: http.StatusOK, role: userModel.RoleStaff},
+		{name: "demote another admin", target: "admin", body: gin.H{"role": "customer"}, status: http.StatusOK, role: userModel.RoleCustomer},
+		{name: "own role", target: "self", body: gin.H{"role": "customer"}, status: http.StatusBadRequest, role: userModel.RoleAdmin},
+		{name: "own role unchanged", target: "self", body: gin.H{"role": "admin"}, status: http.StatusBadRequest, role: userModel.RoleAdmin},
+		{name: "unknown role", target: "customer", body: gin.H{"role": "owner"}, status: http.StatusBadRequest, role: userModel.RoleCustomer},
+		{name: "missing role", target: "customer", body: gin.H{}, status: http.StatusBadRequest, role: userModel.RoleCustomer},
+		{name: "unknown user", target: "unknown", body: gin.H{"role": "staff"}, status: http.StatusNotFound},
+		{name: "invalid user ID", target: "invalid", body: gin.H{"role": "staff"}, status: http.StatusBadRequest},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			ctx := context.Background()
+			users := repositories.NewMemoryRepositories().Users
+			accounts := map[string]*userModel.User{
+				"self":     {ID: primitive.NewObjectID(), FirstName: "Alice", Email: "alice@example.com", Role: userModel.RoleAdmin},
+				"admin":    {ID: primitive.NewObjectID(), FirstName: "Bob", Email: "bob@example.com", Role: userModel.RoleAdmin},
+				"customer": {ID: primitive.NewObjectID(), FirstName: "Carol", Email: "carol@example.com", Role: userModel.RoleCustomer},
+			}
+			for _, account := range accounts {
+				if err := users.Create(ctx, account); err != nil {
+					t.Fatal(err)
+				}
+			}
+			router := signedIn(accounts["self"].ID, userModel.RoleAdmin)
+			router.PUT("/admin/users/:user_id/role", NewUserController(users).UpdateUserRole)
+
+			target := "abc"
+			if account, found := accounts[test.target]; found {
+				target = account.ID.Hex()
+			} else if test.target == "unknown" {
+				target = primitive.NewObjectID().Hex()
+			}
+			status, response := serve(t, router, http.MethodPut, "/admin/users/"+target+"/role", test.body)
+			if status != test.status {
+				t.Fatalf("status = %d, want %d: %v", status, test.status, response)
+			}
+			if account, found := accounts[test.target]; found {
+				stored, err := users.FindByID(ctx, account.ID)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if stored.Role != test.role {
+					t.Errorf("role = %q, want %q", stored.Role, test.role)
+				}
+			}
+		})
+	}
+}
@@ -4,42 +4,60 @@ import (
 	goContext "context"
 	"errors"
 	"fmt"
-	"github.com/YassinNouh21/GoShopCart-Ecommerce/database"
 	helpers "github.com/YassinNouh21/GoShopCart-Ecommerce/helpers"
 	userModel "github.com/YassinNouh21/GoShopCart-Ecommerce/models/user"
+	"github.com/YassinNouh21/GoShopCart-Ecommerce/money"
+	"github.com/YassinNouh21/GoShopCart-Ecommerce/repositories"
+	"log"
 	"net/http"
 	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/go-playground/validator/v10"
-	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
 var (
-	errInvalidRequestBody = errors.New("invalid request body")
-	errUserAlreadyExists  = errors.New("user with that email already exists")
-	errUserNotFound       = errors.New("user not found")
-	errHashingPassword    = errors.New("error while hashing password")
-	errIncorrectPassword  = errors.New("password is incorrect")
-	errGeneratingToken    = errors.New("error while generating token")
-	errUpdatingToken      = errors.New("error while updating token")
-	errUserNotFoundByID   = errors.New("user not found with this ID")
+	errInvalidRequestBody  = errors.New("invalid request body")
+	errUserAlreadyExists   = errors.New("user with that email already exists")
+	errUserNotFound        = errors.New("user not found")
+	errHashingPassword     = errors.New("error while hashing password")
+	errIncorrectPassword   = errors.New("password is incorrect")
+	errGeneratingToken     = errors.New("error while generating token")
+	errUpdatingToken       = errors.New("error while updating token")
+	errUserNotFoundByID    = errors.New("user not found with this ID")
+	errUnsupportedCurrency = errors.New("unsupported currency, it needs an exchange rate")
 )
 
+// AuthController serves the authentication endpoints on top of a UserRepository.
+type AuthController struct {
+	users      repositories.UserRepository
+	products   repositories.ProductRepository
+	carts      repositories.CartRepository
+	guestCarts repositories.GuestCartRepository
+	transactor repositories.Transactor
+}
+
+// NewAuthController creates an AuthController that stores users in the given repository and merges
+// the guest carts of the visitors who sign in into their carts, within the stock of the products.
+func NewAuthController(users repositories.UserRepository, products repositories.ProductRepository, carts repositories.CartRepository, guestCarts repositories.GuestCartRepository, transactor repositories.Transactor) *AuthController {
+	return &AuthController{users: users, products: products, carts: carts, guestCarts: guestCarts, transactor: transactor}
+}
+
 /*
-SignUpController handles the user registration process.
+SignUp handles the user registration process.
 
 It parses the JSON request body into a user model, validates the request body, checks if the user already exists, generates a token, and creates a new user record in the database.
 
 Errors:
 	- Invalid request body: If the request body is not in the expected format or contains invalid data.
+	- Unsupported currency: If the preferred currency is unknown or has no exchange rate.
 	- User already exists: If a user with the provided email already exists in the database.
 	- Error while generating token: If an error occurs while generating the authentication token.
 	- Error while inserting user: If an error occurs while inserting the new user record into the database.
 */
 
-func SignUpController(context *gin.Context) {
+func (ac *AuthController) SignUp(context *gin.Context) {
 	ctx, cancel := goContext.WithTimeout(goContext.Background(), 10*time.Second)
 	defer cancel()
 
@@ -58,14 +76,26 @@ func SignUpController(context *gin.Context) {
 		return
 	}
 
-	count, err := database.DB.UserCollection.CountDocuments(ctx, bson.M{"email": user.Email})
+	if user.Currency != "" {
+		_, rates := helpers.RequestCurrency(context)
+		currency, err := money.ParseCurrency(string(user.Currency))
+		if err != nil || !rates.Supports(currency) {
+			context.JSON(http.StatusBadRequest, gin.H{
+				"error": errUnsupportedCurrency.Error(),
+			})
+			return
+		}
+		user.Currency = currency
+	}
+
+	exists, err := ac.users.ExistsByEmail(ctx, user.Email)
 	if err != nil {
 		context.JSON(http.StatusInternalServerError, gin.H{
 			"error": err.Error(),
 		})
 		return
 	}
-	if count > 0 {
+	if exists {
 		context.JSON(http.StatusInternalServerError, gin.H{
 			"error": errUserAlreadyExists.Error(),
 		})
@@ -78,9 +108,12 @@ func SignUpController(context *gin.Context) {
 	user.UpdatedAt = timeUpdateted
 	user.Password, _ = helpers.HashPassword(user.Password)
 
+	// Roles and tax exemptions are only granted by admins, whatever the request body says
+	user.Role = userModel.RoleCustomer
+	user.TaxExempt = false
 	user.ID = primitive.NewObjectID()
 	userID := user.ID.Hex()
-	userClaims := helpers.CreateUserClaims(user.Email, user.FirstName, userID)
+	userClaims := helpers.CreateUserClaims(user.Email, user.FirstName, userID, user.Role)
 	tokenGenerated, refreshToken, err := helpers.GenerateToken(*userClaims)
 	user.Token = tokenGenerated
 	user.RefreshToken = refreshToken
@@ -94,9 +127,8 @@ func SignUpController(context *gin.Context) {
 
 	user.AddressDetails = []userModel.Address{}
 	user.OrderStatus = []userModel.Order{}
-	user.UserCart = []userModel.Cart{}
 
-	_, err = database.DB.UserCollection.InsertOne(ctx, user)
+	err = ac.users.Create(ctx, &user)
 	if err != nil {
 		context.JSON(http.StatusInternalServerError, gin.H{
 			"error": err.Error(),
@@ -111,14 +143,19 @@ func SignUpController(context *gin.Context) {
 }
 
 // SignInResponse represents the response structure for the signing request.
+// MergedItems is the number of items of the guest cart merged into the user's cart, if any, and
+// CappedItems the number of them reduced to the available stock or left out for lack of it.
 type SignInResponse struct {
 	AccessToken  string `json:"access_token"`
 	RefreshToken string `json:"refresh_token"`
+	MergedItems  int    `json:"merged_items,omitempty"`
+	CappedItems  int    `json:"capped_items,omitempty"`
 }
 
 /*
-SignInController handles the user login process.
+SignIn handles the user login process.
 	It parses the JSON request body into a user model, validates the request body, retrieves the user from the database, verifies the password, generates a new access token, and updates the user's tokens.
+	When the request carries the cart token of a guest cart in the X-Cart-Token header, the guest cart is merged into the user's cart and removed. A guest cart that expired or cannot be merged does not fail the sign in.
 
 Errors:
 	- Invalid request body: If the request body is not in the expected format or contains invalid data.
@@ -129,12 +166,11 @@ Errors:
 	- Password is incorrect: If the provided password does not match the user's stored password.
 */
 
-func SignInController(context *gin.Context) {
+func (ac *AuthController) SignIn(context *gin.Context) {
 	ctx, cancel := goContext.WithTimeout(goContext.Background(), 30*time.Second)
 	defer cancel()
 
 	var user userModel.User
-	var loginUser userModel.User
 
 	if err := context.ShouldBindJSON(&user); err != nil {
 		context.JSON(http.StatusBadRequest, gin.H{
@@ -149,7 +185,7 @@ func SignInController(context *gin.Context) {
 		return
 	}
 
-	err := database.DB.UserCollection.FindOne(ctx, bson.M{"email": user.Email}).Decode(&loginUser)
+	loginUser, err := ac.users.FindByEmail(ctx, user.Email)
 	defer cancel()
 
 	if err != nil {
@@ -168,7 +204,7 @@ func SignInController(context *gin.Context) {
 
 	isValid := helpers.VerifyPassword(loginUser.Password, user.Password)
 	userId := loginUser.ID.Hex()
-	userClaim := *helpers.CreateUserClaims(loginUser.Email, loginUser.FirstName, userId)
+	userClaim := *helpers.CreateUserClaims(loginUser.Email, loginUser.FirstName, userId, userModel.RoleOf(*loginUser))
 	accessToken, refreshToken, err := helpers.GenerateToken(userClaim)
 	defer cancel()
 	if err != nil {
@@ -178,7 +214,7 @@ func SignInController(context *gin.Context) {
 		return
 	}
 
-	err = helpers.UpdateToken(accessToken, refreshToken, loginUser.ID)
+	err = helpers.UpdateToken(ac.users, accessToken, refreshToken, loginUser.ID)
 	if !isValid {
 		context.JSON(http.StatusInternalServerError, gin.H{
 			"error": errIncorrectPassword.Error(),
@@ -197,26 +233,118 @@ func SignInController(context *gin.Context) {
 		AccessToken:  accessToken,
 		RefreshToken: refreshToken,
 	}
+	if cartToken := context.GetHeader(helpers.CartTokenHeader); cartToken != "" {
+		merged, capped, err := ac.mergeGuestCart(ctx, loginUser.ID, cartToken)
+		if err != nil {
+			log.Println("merge guest cart", loginUser.ID.Hex(), ":", err)
+		}
+		signInRes.MergedItems = merged
+		signInRes.CappedItems = capped
+	}
 
 	context.JSON(http.StatusOK, gin.H{
 		"message": signInRes,
 	})
 }
 
+// mergeGuestCart moves the items of the guest cart of the cart token into the cart of the user, in a
+// transaction, and removes the guest cart. The quantity of a product and variant the user already has
+// in their cart is increased by the quantity in the guest cart, up to the stock of the product or
+// variant like the cart endpoints allow; items of products that were deleted or have no stock left
+// are left out. It returns how many items were merged, which is zero if the guest cart does not exist
+// or expired, and how many of the guest cart items were capped to the stock or left out.
+func (ac *AuthController) mergeGuestCart(ctx goContext.Context, userID primitive.ObjectID, cartToken string) (int, int, error) {
+	cartID, err := helpers.ValidateCartToken(cartToken)
+	if err != nil {
+		return 0, 0, err
+	}
+	merged, capped := 0, 0
+	err = ac.transactor.WithTransaction(ctx, func(ctx goContext.Context) error {
+		merged, capped = 0, 0
+		guestCart, err := ac.guestCarts.FindByID(ctx, cartID)
+		if err == repositories.ErrNotFound {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		items, err := ac.carts.GetItems(ctx, userID)
+		if err != nil {
+			return err
+		}
+		for _, item := range guestCart.Items {
+			stock, err := ac.stockOf(ctx, item)
+			if err != nil {
+				return err
+			}
+			inCart, quantity := false, 0
+			for _, existing := range items {
+				if existing.Holds(item.ProductID, item.VariantID) {
+					inCart = true
+					quantity += existing.Quantity
+				}
+			}
+			if quantity+item.Quantity > stock {
+				capped++
+				item.Quantity = stock - quantity
+			}
+			if item.Quantity <= 0 {
+				continue
+			}
+			if inCart {
+				err = ac.carts.IncrementQuantity(ctx, userID, item.ProductID, item.VariantID, item.Quantity)
+			} else {
+				item.UpdatedAt = time.Now().UTC()
+				err = ac.carts.AddItem(ctx, userID, item)
+				items = append(items, item)
+			}
+			if err != nil {
+				return err
+			}
+			merged++
+		}
+		return ac.guestCarts.Delete(ctx, cartID)
+	})
+	if err != nil {
+		return 0, 0, err
+	}
+	return merged, capped, nil
+}
+
+// stockOf returns the stock of the product and variant of the cart item, which is zero if the product
+// or the variant no longer exists.
+func (ac *AuthController) stockOf(ctx goContext.Context, item userModel.Cart) (int, error) {
+	product, err := ac.products.FindByID(ctx, item.ProductID)
+	if err == repositories.ErrNotFound {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+	variant, err := product.ResolveVariant(item.VariantID)
+	if err != nil {
+		return 0, nil
+	}
+	return product.StockOf(variant), nil
+}
+
 /*
-GetUserIdController handles the retrieval of user information by user ID.
+GetUserId handles the retrieval of user information by user ID.
 
 	It retrieves the user ID from the request parameters, queries the database for the user with the corresponding ID, and returns a response indicating whether the user was found.
 
 Errors:
   - User not found: If no user with the provided ID exists in the database.
 */
-func GetUserIdController(context *gin.Context) {
+func (ac *AuthController) GetUserId(context *gin.Context) {
 	userId := context.Param("user_id")
 	ctx, cancel := goContext.WithTimeout(goContext.Background(), 10*time.Second)
 	defer cancel()
 
-	err := database.GetCollectionMongoDB("users").FindOne(ctx, bson.M{"_id": userId}).Decode(&userId)
+	userIdPrimitive, err := primitive.ObjectIDFromHex(userId)
+	if err == nil {
+		_, err = ac.users.FindByID(ctx, userIdPrimitive)
+	}
 	if err != nil {
 		context.JSON(http.StatusInternalServerError, gin.H{
 			"error": fmt.Errorf("%s: %s", errUserNotFoundByID.Error(), userId),
@@ -235,7 +363,7 @@ type TokenRefreshResponse struct {
 }
 
 /*
-TokenRefreshController handles the token refresh process.
+TokenRefresh handles the token refresh process.
 
 	It parses the JSON request body containing the refresh token, validates the request body, generates a new access token using the refresh token, and returns the new access token.
 
@@ -243,7 +371,7 @@ Errors:
   - Invalid request body: If the request body is not in the expected format or contains invalid data.
   - Error while generating token: If an error occurs while generating the new authentication token.
 */
-func TokenRefreshController(context *gin.Context) {
+func (ac *AuthController) TokenRefresh(context *gin.Context) {
 	var refreshToken TokenRefreshResponse
 
 	if err := context.ShouldBindJSON(&refreshToken); err != nil {
@@ -260,7 +388,7 @@ func TokenRefreshController(context *gin.Context) {
 		return
 	}
 	// request a new access token
-	accessToken, err := helpers.GenerateNewAccessToken(refreshToken.RefreshToken)
+	accessToken, err := helpers.GenerateNewAccessToken(ac.users, refreshToken.RefreshToken)
 	if err != nil {
 		context.JSON(http.StatusInternalServerError, gin.H{
 			"error": err.Error(),
@@ -0,0 +1,187 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/YassinNouh21/GoShopCart-Ecommerce/helpers"
+	"github.com/YassinNouh21/GoShopCart-Ecommerce/middlewares"
+	productModel "github.com/YassinNouh21/GoShopCart-Ecommerce/models/product"
+	userModel "github.com/YassinNouh21/GoShopCart-Ecommerce/models/user"
+	"github.com/YassinNouh21/GoShopCart-Ecommerce/money"
+	"github.com/YassinNouh21/GoShopCart-Ecommerce/repositories"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestMergeGuestCart(t *testing.T) {
+	tests := []struct {
+		name     string
+		stock    int
+		deleted  bool
+		inCart   int
+		guest    int
+		noCart   bool
+		quantity int
+		merged   int
+		capped   int
+	}{
+		{name: "new item", stock: 5, guest: 2, quantity: 2, merged: 1},
+		{name: "adds to the item in the cart", stock: 5, inCart: 1, guest: 2, quantity: 3, merged: 1},
+		{name: "capped at the stock", stock: 5, guest: 7, quantity: 5, merged: 1, capped: 1},
+		{name: "capped with the item in the cart", stock: 5, inCart: 4, guest: 3, quantity: 5, merged: 1, capped: 1},
+		{name: "cart already at the stock", stock: 5, inCart: 5, guest: 1, quantity: 5, capped: 1},
+		{name: "out of stock", stock: 0, guest: 1, quantity: 0, capped: 1},
+		{name: "deleted product", stock: 5, deleted: true, guest: 1, quantity: 0, capped: 1},
+		{name: "expired guest cart", stock: 5, inCart: 1, guest: 2, noCart: true, quantity: 1},
+	}
+	t.Setenv("SECRET_JWT", "test-secret")
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			ctx := context.Background()
+			repos := repositories.NewMemoryRepositories()
+			controller := NewAuthController(repos.Users, repos.Products, repos.Carts, repos.GuestCarts, repos.Transactor)
+			userID := primitive.NewObjectID()
+			now := time.Now().UTC()
+
+			product := &productModel.Product{ProductID: primitive.NewObjectID(), ProductName: "Mug", Price: money.New(1000, money.USD), Stock: test.stock}
+			if !test.deleted {
+				if err := repos.Products.Create(ctx, product); err != nil {
+					t.Fatal(err)
+				}
+			}
+			if test.inCart > 0 {
+				item := userModel.Cart{CartID: primitive.NewObjectID(), ProductID: product.ProductID, Quantity: test.inCart, CreatedAt: now, UpdatedAt: now}
+				if err := repos.Carts.AddItem(ctx, userID, item); err != nil {
+					t.Fatal(err)
+				}
+			}
+			guestCart := &userModel.GuestCart{
+				GuestCartID: primitive.NewObjectID(),
+				Items:       []userModel.Cart{{CartID: primitive.NewObjectID(), ProductID: product.ProductID, Quantity: test.guest, CreatedAt: now, UpdatedAt: now}},
+				CreatedAt:   now,
+				UpdatedAt:   now,
+				ExpiresAt:   now.Add(time.Hour),
+			}
+			if !test.noCart {
+				if err := repos.GuestCarts.Create(ctx, guestCart); err != nil {
+					t.Fatal(err)
+				}
+			}
+			token, err := helpers.GenerateCartToken(guestCart.GuestCartID)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			merged, capped, err := controller.mergeGuestCart(ctx, userID, token)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if merged != test.merged || capped != test.capped {
+				t.Errorf("merged %d and capped %d items, want %d and %d", merged, capped, test.merged, test.capped)
+			}
+			items, err := repos.Carts.GetItems(ctx, userID)
+			if err != nil {
+				t.Fatal(err)
+			}
+			quantity := 0
+			for _, item := range items {
+				quantity += item.Quantity
+			}
+			if quantity != test.quantity || len(items) > 1 {
+				t.Errorf("got cart %+v, want one item of %d", items, test.quantity)
+			}
+			if _, err := repos.GuestCarts.FindByID(ctx, guestCart.GuestCartID); err != repositories.ErrNotFound {
+				t.Errorf("finding the guest cart after the merge = %v, want ErrNotFound", err)
+			}
+		})
+	}
+}
+
+func TestMergeGuestCartInvalidToken(t *testing.T) {
+	t.Setenv("SECRET_JWT", "test-secret")
+	repos := repositories.NewMemoryRepositories()
+	controller := NewAuthController(repos.Users, repos.Products, repos.Carts, repos.GuestCarts, repos.Transactor)
+	if _, _, err := controller.mergeGuestCart(context.Background(), primitive.NewObjectID(), "not-a-token"); !errors.Is(err, helpers.ErrInvalidCartToken) {
+		t.Errorf("merging with an invalid cart token = %v, want ErrInvalidCartToken", err)
+	}
+}
+
+func TestSignUpCurrency(t *testing.T) {
+	tests := []struct {
+		currency string
+		status   int
+		stored   money.Currency
+	}{
+		{currency: "", status: http.StatusOK, stored: ""},
+		{currency: "eur", status: http.StatusOK, stored: money.EUR},
+		{currency: "USD", status: http.StatusOK, stored: money.USD},
+		{currency: "GBP", status: http.StatusBadRequest},
+		{currency: "XYZ", status: http.StatusBadRequest},
+	}
+	t.Setenv("SECRET_JWT", "test-secret")
+	for _, test := range tests {
+		t.Run(test.currency, func(t *testing.T) {
+			ctx := context.Background()
+			repos := repositories.NewMemoryRepositories()
+			if err := repos.ExchangeRates.Save(ctx, &money.ExchangeRate{Currency: money.EUR, Rate: "0.92"}); err != nil {
+				t.Fatal(err)
+			}
+			controller := NewAuthController(repos.Users, repos.Products, repos.Carts, repos.GuestCarts, repos.Transactor)
+			router := gin.New()
+			router.POST("/auth/signup", middlewares.Currency(repos.Users, repos.ExchangeRates), controller.SignUp)
+
+			body := `{"first_name": "Alice", "email": "alice@example.com", "password": "secret1", "currency": "` + test.currency + `"}`
+			request := httptest.NewRequest(http.MethodPost, "/auth/signup", strings.NewReader(body))
+			request.Header.Set("Content-Type", "application/json")
+			recorder := httptest.NewRecorder()
+			router.ServeHTTP(recorder, request)
+			if recorder.Code != test.status {
+				t.Fatalf("status = %d, want %d: %s", recorder.Code, test.status, recorder.Body)
+			}
+			user, err := repos.Users.FindByEmail(ctx, "alice@example.com")
+			if test.status != http.StatusOK {
+				if err != repositories.ErrNotFound {
+					t.Errorf("got user %+v, error %v after a rejected sign up", user, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if user.Currency != test.stored {
+				t.Errorf("currency = %q, want %q", user.Currency, test.stored)
+			}
+		})
+	}
+}
+
+func TestSignUpIgnoresAdminFields(t *testing.T) {
+	t.Setenv("SECRET_JWT", "test-secret")
+	ctx := context.Background()
+	repos := repositories.NewMemoryRepositories()
+	router := gin.New()
+	router.POST("/auth/signup", NewAuthController(repos.Users, repos.Products, repos.Carts, repos.GuestCarts, repos.Transactor).SignUp)
+
+	body := `{"first_name": "Alice", "email": "alice@example.com", "password": "secret1", "role": "admin", "tax_exempt": true}`
+	request := httptest.NewRequest(http.MethodPost, "/auth/signup", strings.NewReader(body))
+	request.Header.Set("Content-Type", "application/json")
+	recorder := httptest.NewRecorder()
+	router.ServeHTTP(recorder, request)
+	if recorder.Code != http.StatusOK {
+		t.Fatalf("status = %d: %s", recorder.Code, recorder.Body)
+	}
+	user, err := repos.Users.FindByEmail(ctx, "alice@example.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if user.Role != userModel.RoleCustomer || user.TaxExempt {
+		t.Errorf("signed up with role %q and tax exempt %t, want a customer paying tax", user.Role, user.TaxExempt)
+	}
+}
@@ -0,0 +1,155 @@
+package payment
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/YassinNouh21/GoShopCart-Ecommerce/inventory"
+	userModel "github.com/YassinNouh21/GoShopCart-Ecommerce/models/user"
+	"github.com/YassinNouh21/GoShopCart-Ecommerce/payments"
+	"github.com/YassinNouh21/GoShopCart-Ecommerce/repositories"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// SignatureHeader is the request header carrying the signature of a webhook payload.
+const SignatureHeader = "X-Payment-Signature"
+
+var (
+	ErrInvalidPayload      = errors.New("Invalid webhook payload")
+	ErrInvalidSignature    = errors.New("Invalid webhook signature")
+	ErrOrderNotFound       = errors.New("Order not found")
+	ErrTransactionMismatch = errors.New("Transaction does not belong to the order")
+	ErrUnknownEvent        = errors.New("Unknown webhook event")
+	ErrOrderChanged        = errors.New("Order status was changed by another request")
+	ErrOrderNotUpdated     = errors.New("Failed to update order")
+)
+
+// WebhookController serves the webhook the payment provider calls to report payment updates.
+type WebhookController struct {
+	orders    repositories.OrderRepository
+	provider  payments.PaymentProvider
+	inventory *inventory.Reservations
+}
+
+// NewWebhookController creates a WebhookController for the given provider.
+// Orders cancelled by a failed payment give their stock back through the reservations.
+func NewWebhookController(orders repositories.OrderRepository, provider payments.PaymentProvider, reservations *inventory.Reservations) *WebhookController {
+	return &WebhookController{orders: orders, provider: provider, inventory: reservations}
+}
+
+// webhookOutcome is the payment status and order status a webhook event leads to.
+type webhookOutcome struct {
+	payment userModel.PaymentStatus
+	order   userModel.OrderStatus
+	note    string
+}
+
+// webhookOutcomes maps each event type to its outcome.
+var webhookOutcomes = map[payments.EventType]webhookOutcome{
+	payments.EventPaymentCaptured: {userModel.PaymentStatusCaptured, userModel.OrderStatusPaid, "Payment captured"},
+	payments.EventPaymentFailed:   {userModel.PaymentStatusFailed, userModel.OrderStatusCancelled, "Payment failed"},
+	payments.EventPaymentRefunded: {userModel.PaymentStatusRefunded, userModel.OrderStatusRefunded, "Payment refunded"},
+}
+
+/*
+HandleWebhook applies a payment update reported by the payment provider.
+
+	The payload must be signed by the provider in the X-Payment-Signature header. The payment of the
+	order moves to the status of the event and the order follows it: a captured payment marks an order
+	awaiting payment as paid, a failed payment cancels it and gives its stock back, and a refunded
+	payment refunds the order.
+	Events that do not apply to the current payment status, such as a repeated delivery of the same
+	event, are acknowledged without changes so the provider stops retrying them.
+
+Possible Errors:
+  - Invalid webhook payload: If the body cannot be read or does not name a valid order.
+  - Invalid webhook signature: If the signature does not match the payload.
+  - Unknown webhook event: If the event type is not supported.
+  - Order not found: If the order of the event does not exist.
+  - Transaction does not belong to the order: If the transaction is not the payment of the order.
+  - Order status was changed by another request: If the order changed while it was being updated.
+*/
+func (wc *WebhookController) HandleWebhook(c *gin.Context) {
+	payload, err := io.ReadAll(c.Request.Body)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": ErrInvalidPayload.Error()})
+		return
+	}
+	event, err := wc.provider.VerifyWebhook(payload, c.GetHeader(SignatureHeader))
+	if err == payments.ErrInvalidSignature {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": ErrInvalidSignature.Error()})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": ErrInvalidPayload.Error()})
+		return
+	}
+	outcome, found := webhookOutcomes[event.Type]
+	if !found {
+		c.JSON(http.StatusBadRequest, gin.H{"error": ErrUnknownEvent.Error()})
+		return
+	}
+	orderID, err := primitive.ObjectIDFromHex(event.OrderID)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": ErrInvalidPayload.Error()})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	order, err := wc.orders.FindByID(ctx, orderID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": ErrOrderNotFound.Error()})
+		return
+	}
+	if order.Payment == nil || order.Payment.TransactionID != event.TransactionID {
+		c.JSON(http.StatusBadRequest, gin.H{"error": ErrTransactionMismatch.Error()})
+		return
+	}
+	if !order.Payment.Status.CanTransitionTo(outcome.payment) {
+		c.JSON(http.StatusOK, gin.H{"message": "Webhook already processed"})
+		return
+	}
+
+	payment := *order.Payment
+	payment.Status = outcome.payment
+	payment.UpdatedAt = time.Now().UTC()
+	if err := wc.orders.UpdatePayment(ctx, orderID, payment); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": ErrOrderNotUpdated.Error()})
+		return
+	}
+	if appliesTo(event.Type, order.Status) {
+		change, err := order.Transition(outcome.order, userModel.SystemActor, outcome.note)
+		if err == nil {
+			err = wc.orders.UpdateStatus(ctx, orderID, change)
+		}
+		if err == repositories.ErrVersionConflict {
+			c.JSON(http.StatusConflict, gin.H{"error": ErrOrderChanged.Error()})
+			return
+		}
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": ErrOrderNotUpdated.Error()})
+			return
+		}
+		if change.To == userModel.OrderStatusCancelled {
+			wc.inventory.Release(ctx, *order)
+		}
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Webhook processed"})
+}
+
+// appliesTo reports whether an event moves an order in the given status.
+// Captures and failures only settle orders awaiting payment, refunds apply whenever the order can be refunded.
+func appliesTo(eventType payments.EventType, status userModel.OrderStatus) bool {
+	if eventType == payments.EventPaymentRefunded {
+		return status.CanTransitionTo(userModel.OrderStatusRefunded)
+	}
+	return status == userModel.OrderStatusPendingPayment
+}
@@ -0,0 +1,99 @@
+package payment
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/YassinNouh21/GoShopCart-Ecommerce/inventory"
+	userModel "github.com/YassinNouh21/GoShopCart-Ecommerce/models/user"
+	"github.com/YassinNouh21/GoShopCart-Ecommerce/payments"
+	"github.com/YassinNouh21/GoShopCart-Ecommerce/repositories"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestHandleWebhookSignature(t *testing.T) {
+	tests := []struct {
+		name      string
+		signature func(provider *payments.FakeProvider, payload []byte) string
+		status    int
+		paid      bool
+	}{
+		{
+			name:      "valid signature",
+			signature: func(provider *payments.FakeProvider, payload []byte) string { return provider.Sign(payload) },
+			status:    http.StatusOK, paid: true,
+		},
+		{
+			name: "signed with another secret",
+			signature: func(provider *payments.FakeProvider, payload []byte) string {
+				return payments.NewFakeProvider(payments.FakeSucceed, "other").Sign(payload)
+			},
+			status: http.StatusUnauthorized,
+		},
+		{
+			name: "signature of another payload",
+			signature: func(provider *payments.FakeProvider, payload []byte) string {
+				return provider.Sign(append(payload, ' '))
+			},
+			status: http.StatusUnauthorized,
+		},
+		{
+			name:      "not hex",
+			signature: func(provider *payments.FakeProvider, payload []byte) string { return "not-a-signature" },
+			status:    http.StatusUnauthorized,
+		},
+		{
+			name:      "missing",
+			signature: func(provider *payments.FakeProvider, payload []byte) string { return "" },
+			status:    http.StatusUnauthorized,
+		},
+	}
+	gin.SetMode(gin.TestMode)
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			ctx := context.Background()
+			repos := repositories.NewMemoryRepositories()
+			provider := payments.NewFakeProvider(payments.FakeSucceed, "whsec")
+			reservations := inventory.NewReservations(repos.Products, repos.Orders, repos.Promotions, provider, 15*time.Minute)
+			order := &userModel.Order{
+				OrderID:       primitive.NewObjectID(),
+				UserID:        primitive.NewObjectID(),
+				PaymentMethod: "card",
+				Status:        userModel.OrderStatusPendingPayment,
+				Payment:       &userModel.OrderPayment{Provider: provider.Name(), TransactionID: "fake_txn_000001", Status: userModel.PaymentStatusAuthorized},
+			}
+			if err := repos.Orders.Create(ctx, order); err != nil {
+				t.Fatal(err)
+			}
+			router := gin.New()
+			router.POST("/webhooks/payments", NewWebhookController(repos.Orders, provider, reservations).HandleWebhook)
+
+			payload, err := json.Marshal(payments.WebhookEvent{Type: payments.EventPaymentCaptured, TransactionID: "fake_txn_000001", OrderID: order.OrderID.Hex()})
+			if err != nil {
+				t.Fatal(err)
+			}
+			request := httptest.NewRequest(http.MethodPost, "/webhooks/payments", bytes.NewReader(payload))
+			request.Header.Set(SignatureHeader, test.signature(provider, payload))
+			recorder := httptest.NewRecorder()
+			router.ServeHTTP(recorder, request)
+
+			if recorder.Code != test.status {
+				t.Fatalf("status = %d, want %d: %s", recorder.Code, test.status, recorder.Body)
+			}
+			stored, err := repos.Orders.FindByID(ctx, order.OrderID)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if paid := stored.Status == userModel.OrderStatusPaid && stored.Payment.Status == userModel.PaymentStatusCaptured; paid != test.paid {
+				t.Errorf("order %s with payment %s, want paid %t", stored.Status, stored.Payment.Status, test.paid)
+			}
+		})
+	}
+}
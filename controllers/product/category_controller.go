@@ -0,0 +1,346 @@
+package product
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"time"
+
+	productModel "github.com/YassinNouh21/GoShopCart-Ecommerce/models/product"
+	taxModel "github.com/YassinNouh21/GoShopCart-Ecommerce/models/tax"
+	"github.com/YassinNouh21/GoShopCart-Ecommerce/repositories"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-playground/validator/v10"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+var (
+	errInvalidCategoryID    = errors.New("Invalid category ID")
+	errCategoryNotFound     = errors.New("Category not found")
+	errParentNotFound       = errors.New("Parent category not found")
+	errInvalidSlug          = errors.New("Slug must only contain lowercase letters, digits and single dashes")
+	errInvalidTaxClass      = errors.New("Tax class must only contain lowercase letters and digits separated by dashes or underscores")
+	errSlugTaken            = errors.New("Slug is already used by another category")
+	errCategoryCycle        = errors.New("A category cannot be moved below itself or its descendants")
+	errCategoryHasChildren  = errors.New("Category has subcategories, move or delete them first")
+	errFailedSaveCategory   = errors.New("Failed to save category")
+	errFailedFetchCategory  = errors.New("Failed to fetch categories")
+	errInvalidCategoryInput = errors.New("Invalid request body")
+)
+
+// CategoryController serves the endpoints of the product taxonomy.
+type CategoryController struct {
+	categories repositories.CategoryRepository
+	products   repositories.ProductRepository
+	transactor repositories.Transactor
+}
+
+// NewCategoryController creates a CategoryController from the repositories it reads and writes.
+func NewCategoryController(categories repositories.CategoryRepository, products repositories.ProductRepository, transactor repositories.Transactor) *CategoryController {
+	return &CategoryController{categories: categories, products: products, transactor: transactor}
+}
+
+// CategoryRequest represents the request body for creating or replacing a category.
+// The slug is derived from the name when empty, and a missing parent makes a root category.
+// Without a tax class the category takes the one of its parent.
+type CategoryRequest struct {
+	Name     string              `json:"name" validate:"required"`
+	Slug     string              `json:"slug"`
+	ParentID *primitive.ObjectID `json:"parent_id"`
+	TaxClass string              `json:"tax_class"`
+}
+
+// GetCategories returns every category with its ancestors, sorted by slug.
+func (cc *CategoryController) GetCategories(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	categories, err := cc.categories.FindAll(ctx)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": errFailedFetchCategory.Error()})
+		return
+	}
+
+	c.IndentedJSON(http.StatusOK, gin.H{"message": categories})
+}
+
+/*
+GetProductsByCategory returns a page of the products of the category with the given slug and of all
+its descendants, together with the category and its breadcrumbs. Products are listed newest first
+unless another sort is requested.
+
+Possible Errors:
+  - Category not found: If no category has the given slug.
+  - Invalid limit, offset, sort, cursor or fields: If a listing parameter is invalid.
+  - Failed to fetch products: If the products cannot be read.
+*/
+func (cc *CategoryController) GetProductsByCategory(c *gin.Context) {
+	listOptions, ok := bindListOptions(c, "newest")
+	if !ok {
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	category, err := cc.categories.FindBySlug(ctx, c.Param("slug"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": errCategoryNotFound.Error()})
+		return
+	}
+	categoryIDs, err := categoryTree(ctx, cc.categories, category)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": errFailedFetchProducts.Error()})
+		return
+	}
+	page, err := listPage(ctx, cc.products, repositories.ProductFilter{CategoryIDs: categoryIDs}, nil, listOptions)
+	if err != nil {
+		writeListError(c, err)
+		return
+	}
+
+	response := pageResponse(c, page, listOptions.Fields)
+	response["category"] = category
+	response["breadcrumbs"] = category.Breadcrumbs()
+	c.IndentedJSON(http.StatusOK, response)
+}
+
+// categoryTree returns the IDs of the category and of all its descendants.
+func categoryTree(ctx context.Context, categories repositories.CategoryRepository, category *productModel.Category) ([]primitive.ObjectID, error) {
+	descendants, err := categories.FindDescendants(ctx, category.CategoryID)
+	if err != nil {
+		return nil, err
+	}
+	categoryIDs := []primitive.ObjectID{category.CategoryID}
+	for _, descendant := range descendants {
+		categoryIDs = append(categoryIDs, descendant.CategoryID)
+	}
+	return categoryIDs, nil
+}
+
+/*
+CreateCategory creates a category, at the root of the taxonomy or below the given parent.
+
+Possible Errors:
+  - Invalid request body: If the body does not contain a name.
+  - Slug must only contain...: If the slug, given or derived from the name, is not valid.
+  - Tax class must only contain...: If the tax class is not valid.
+  - Slug is already used by another category: If another category has the same slug.
+  - Parent category not found: If the parent does not exist.
+*/
+func (cc *CategoryController) CreateCategory(c *gin.Context) {
+	request, ok := bindCategoryRequest(c)
+	if !ok {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	now := time.Now().UTC()
+	category := productModel.Category{
+		CategoryID: primitive.NewObjectID(),
+		Name:       request.Name,
+		Slug:       request.Slug,
+		ParentID:   request.ParentID,
+		TaxClass:   request.TaxClass,
+		Ancestors:  []productModel.CategoryRef{},
+		CreatedAt:  now,
+		UpdatedAt:  now,
+	}
+	if !cc.placeCategory(c, ctx, &category) {
+		return
+	}
+	if err := cc.categories.Create(ctx, &category); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": errFailedSaveCategory.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"message": "Category created successfully", "category": category})
+}
+
+/*
+UpdateCategory replaces the name, slug and parent of a category (PUT).
+
+	Renaming or moving a category rewrites the breadcrumbs of all its descendants in the same
+	transaction. A category cannot be moved below itself or one of its descendants.
+
+Possible Errors:
+  - Invalid category ID: If the ID in the path is not a valid ObjectID.
+  - Invalid request body: If the body does not contain a name.
+  - Category not found: If no category with the given ID exists.
+  - Slug must only contain...: If the slug, given or derived from the name, is not valid.
+  - Tax class must only contain...: If the tax class is not valid.
+  - Slug is already used by another category: If another category has the same slug.
+  - Parent category not found: If the parent does not exist.
+  - A category cannot be moved below itself or its descendants: If the move would create a cycle.
+*/
+func (cc *CategoryController) UpdateCategory(c *gin.Context) {
+	categoryID, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": errInvalidCategoryID.Error()})
+		return
+	}
+	request, ok := bindCategoryRequest(c)
+	if !ok {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
+	defer cancel()
+
+	category, err := cc.categories.FindByID(ctx, categoryID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": errCategoryNotFound.Error()})
+		return
+	}
+	category.Name = request.Name
+	category.Slug = request.Slug
+	category.ParentID = request.ParentID
+	category.TaxClass = request.TaxClass
+	category.UpdatedAt = time.Now().UTC()
+	if !cc.placeCategory(c, ctx, category) {
+		return
+	}
+
+	err = cc.transactor.WithTransaction(ctx, func(ctx context.Context) error {
+		if err := cc.categories.Replace(ctx, category); err != nil {
+			return err
+		}
+		descendants, err := cc.categories.FindDescendants(ctx, category.CategoryID)
+		if err != nil {
+			return err
+		}
+		breadcrumbs := category.Breadcrumbs()
+		for _, descendant := range descendants {
+			descendant.Ancestors = rebaseAncestors(descendant.Ancestors, category.CategoryID, breadcrumbs)
+			if err := cc.categories.Replace(ctx, &descendant); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err == repositories.ErrNotFound {
+		c.JSON(http.StatusNotFound, gin.H{"error": errCategoryNotFound.Error()})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": errFailedSaveCategory.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Category updated successfully", "category": category})
+}
+
+/*
+DeleteCategory deletes a category without subcategories and removes it from its products.
+
+Possible Errors:
+  - Invalid category ID: If the ID in the path is not a valid ObjectID.
+  - Category has subcategories: If other categories are below this one.
+  - Category not found: If no category with the given ID exists.
+*/
+func (cc *CategoryController) DeleteCategory(c *gin.Context) {
+	categoryID, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": errInvalidCategoryID.Error()})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
+	defer cancel()
+
+	hasChildren, err := cc.categories.HasChildren(ctx, categoryID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": errFailedSaveCategory.Error()})
+		return
+	}
+	if hasChildren {
+		c.JSON(http.StatusConflict, gin.H{"error": errCategoryHasChildren.Error()})
+		return
+	}
+	err = cc.transactor.WithTransaction(ctx, func(ctx context.Context) error {
+		if err := cc.categories.Delete(ctx, categoryID); err != nil {
+			return err
+		}
+		return cc.products.RemoveCategory(ctx, categoryID)
+	})
+	if err == repositories.ErrNotFound {
+		c.JSON(http.StatusNotFound, gin.H{"error": errCategoryNotFound.Error()})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": errFailedSaveCategory.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Category deleted successfully"})
+}
+
+// bindCategoryRequest reads and validates the category request body, derives the slug from the name
+// when it is empty and normalizes the tax class. It writes the error response and returns false if the body is invalid.
+func bindCategoryRequest(c *gin.Context) (CategoryRequest, bool) {
+	var request CategoryRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": errInvalidCategoryInput.Error()})
+		return request, false
+	}
+	if err := validator.New().Struct(request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return request, false
+	}
+	if request.Slug == "" {
+		request.Slug = productModel.Slugify(request.Name)
+	}
+	if !productModel.ValidSlug(request.Slug) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": errInvalidSlug.Error()})
+		return request, false
+	}
+	request.TaxClass = taxModel.NormalizeClass(request.TaxClass)
+	if !taxModel.ValidClass(request.TaxClass) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": errInvalidTaxClass.Error()})
+		return request, false
+	}
+	return request, true
+}
+
+// placeCategory checks that the slug of the category is free and sets its ancestors from its parent.
+// It writes the error response and returns false if the category cannot be placed.
+func (cc *CategoryController) placeCategory(c *gin.Context, ctx context.Context, category *productModel.Category) bool {
+	existing, err := cc.categories.FindBySlug(ctx, category.Slug)
+	if err == nil && existing.CategoryID != category.CategoryID {
+		c.JSON(http.StatusConflict, gin.H{"error": errSlugTaken.Error()})
+		return false
+	}
+	if err != nil && err != repositories.ErrNotFound {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": errFailedSaveCategory.Error()})
+		return false
+	}
+
+	category.Ancestors = []productModel.CategoryRef{}
+	if category.ParentID == nil {
+		return true
+	}
+	parent, err := cc.categories.FindByID(ctx, *category.ParentID)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": errParentNotFound.Error()})
+		return false
+	}
+	if parent.CategoryID == category.CategoryID || parent.HasAncestor(category.CategoryID) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": errCategoryCycle.Error()})
+		return false
+	}
+	category.Ancestors = parent.Breadcrumbs()
+	return true
+}
+
+// rebaseAncestors replaces the ancestors of a descendant down to the given category by its new breadcrumbs.
+func rebaseAncestors(ancestors []productModel.CategoryRef, categoryID primitive.ObjectID, breadcrumbs []productModel.CategoryRef) []productModel.CategoryRef {
+	for i, ancestor := range ancestors {
+		if ancestor.CategoryID == categoryID {
+			rebased := append([]productModel.CategoryRef{}, breadcrumbs...)
+			return append(rebased, ancestors[i+1:]...)
+		}
+	}
+	return ancestors
+}
@@ -0,0 +1,207 @@
+package product
+
+import (
+	"net/http"
+	"testing"
+
+	productModel "github.com/YassinNouh21/GoShopCart-Ecommerce/models/product"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func (f *fixture) categoryController() *CategoryController {
+	return NewCategoryController(f.repos.Categories, f.repos.Products, f.repos.Transactor)
+}
+
+// categoryTaxonomy creates the categories Home, Home > Kitchen and Home > Kitchen > Mugs through the
+// admin endpoint and returns them by name.
+func (f *fixture) categoryTaxonomy() map[string]*productModel.Category {
+	f.t.Helper()
+	// Gin sizes the path parameters of its contexts on the first request, so the taxonomy is created
+	// through a router of its own and the routes of the test can still be registered afterwards
+	router := f.router
+	f.router = gin.New()
+	defer func() { f.router = router }()
+	f.router.POST("/admin/categories", f.categoryController().CreateCategory)
+	categories := map[string]*productModel.Category{}
+	var parentID *primitive.ObjectID
+	for _, name := range []string{"Home", "Kitchen", "Mugs"} {
+		status, response := f.serve(http.MethodPost, "/admin/categories", gin.H{"name": name, "parent_id": parentID})
+		if status != http.StatusCreated {
+			f.t.Fatalf("creating %s: status = %d: %v", name, status, response)
+		}
+		created := response["category"].(map[string]interface{})
+		categoryID, err := primitive.ObjectIDFromHex(created["category_id"].(string))
+		if err != nil {
+			f.t.Fatal(err)
+		}
+		category, err := f.repos.Categories.FindByID(f.context, categoryID)
+		if err != nil {
+			f.t.Fatal(err)
+		}
+		categories[name] = category
+		parentID = &category.CategoryID
+	}
+	return categories
+}
+
+// breadcrumbs returns the names of the ancestors of the stored category followed by its own name.
+func (f *fixture) breadcrumbs(categoryID primitive.ObjectID) []string {
+	f.t.Helper()
+	category, err := f.repos.Categories.FindByID(f.context, categoryID)
+	if err != nil {
+		f.t.Fatal(err)
+	}
+	var names []string
+	for _, ref := range category.Breadcrumbs() {
+		names = append(names, ref.Name)
+	}
+	return names
+}
+
+func TestUpdateCategory(t *testing.T) {
+	tests := []struct {
+		name     string
+		category string
+		body     func(categories map[string]*productModel.Category) gin.H
+		status   int
+		mugs     []string
+	}{
+		{
+			name: "rename rewrites the breadcrumbs of the descendants", category: "Kitchen",
+			body: func(c map[string]*productModel.Category) gin.H {
+				return gin.H{"name": "Kitchenware", "parent_id": c["Home"].CategoryID}
+			},
+			status: http.StatusOK, mugs: []string{"Home", "Kitchenware", "Mugs"},
+		},
+		{
+			name: "move to the root", category: "Mugs",
+			body:   func(c map[string]*productModel.Category) gin.H { return gin.H{"name": "Mugs"} },
+			status: http.StatusOK, mugs: []string{"Mugs"},
+		},
+		{
+			name: "move below itself", category: "Kitchen",
+			body: func(c map[string]*productModel.Category) gin.H {
+				return gin.H{"name": "Kitchen", "parent_id": c["Kitchen"].CategoryID}
+			},
+			status: http.StatusBadRequest, mugs: []string{"Home", "Kitchen", "Mugs"},
+		},
+		{
+			name: "move below its child", category: "Kitchen",
+			body: func(c map[string]*productModel.Category) gin.H {
+				return gin.H{"name": "Kitchen", "parent_id": c["Mugs"].CategoryID}
+			},
+			status: http.StatusBadRequest, mugs: []string{"Home", "Kitchen", "Mugs"},
+		},
+		{
+			name: "move the root below its grandchild", category: "Home",
+			body: func(c map[string]*productModel.Category) gin.H {
+				return gin.H{"name": "Home", "parent_id": c["Mugs"].CategoryID}
+			},
+			status: http.StatusBadRequest, mugs: []string{"Home", "Kitchen", "Mugs"},
+		},
+		{
+			name: "unknown parent", category: "Mugs",
+			body: func(c map[string]*productModel.Category) gin.H {
+				return gin.H{"name": "Mugs", "parent_id": primitive.NewObjectID()}
+			},
+			status: http.StatusBadRequest, mugs: []string{"Home", "Kitchen", "Mugs"},
+		},
+		{
+			name: "slug of another category", category: "Mugs",
+			body: func(c map[string]*productModel.Category) gin.H {
+				return gin.H{"name": "Mugs", "slug": "kitchen", "parent_id": c["Kitchen"].CategoryID}
+			},
+			status: http.StatusConflict, mugs: []string{"Home", "Kitchen", "Mugs"},
+		},
+		{
+			name: "invalid slug", category: "Mugs",
+			body: func(c map[string]*productModel.Category) gin.H {
+				return gin.H{"name": "Mugs", "slug": "Big Mugs", "parent_id": c["Kitchen"].CategoryID}
+			},
+			status: http.StatusBadRequest, mugs: []string{"Home", "Kitchen", "Mugs"},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			f := newFixture(t)
+			categories := f.categoryTaxonomy()
+			f.router.PUT("/admin/categories/:id", f.categoryController().UpdateCategory)
+
+			path := "/admin/categories/" + categories[test.category].CategoryID.Hex()
+			status, response := f.serve(http.MethodPut, path, test.body(categories))
+			if status != test.status {
+				t.Fatalf("status = %d, want %d: %v", status, test.status, response)
+			}
+			got := f.breadcrumbs(categories["Mugs"].CategoryID)
+			if len(got) != len(test.mugs) {
+				t.Fatalf("breadcrumbs of Mugs = %v, want %v", got, test.mugs)
+			}
+			for i := range got {
+				if got[i] != test.mugs[i] {
+					t.Fatalf("breadcrumbs of Mugs = %v, want %v", got, test.mugs)
+				}
+			}
+		})
+	}
+}
+
+func TestDeleteCategory(t *testing.T) {
+	f := newFixture(t)
+	categories := f.categoryTaxonomy()
+	f.router.DELETE("/admin/categories/:id", f.categoryController().DeleteCategory)
+	product := f.product("Mug", "10.00", 5)
+	product.CategoryIDs = []primitive.ObjectID{categories["Mugs"].CategoryID}
+	if err := f.repos.Products.Replace(f.context, product, product.Version); err != nil {
+		t.Fatal(err)
+	}
+
+	if status, response := f.serve(http.MethodDelete, "/admin/categories/"+categories["Kitchen"].CategoryID.Hex(), nil); status != http.StatusConflict {
+		t.Errorf("deleting a category with subcategories: status = %d, want 409: %v", status, response)
+	}
+	if status, response := f.serve(http.MethodDelete, "/admin/categories/"+categories["Mugs"].CategoryID.Hex(), nil); status != http.StatusOK {
+		t.Fatalf("deleting a leaf category: status = %d: %v", status, response)
+	}
+	stored, err := f.repos.Products.FindByID(f.context, product.ProductID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(stored.CategoryIDs) != 0 {
+		t.Errorf("categories of the product = %v, want the deleted category removed", stored.CategoryIDs)
+	}
+}
+
+func TestGetProductsByCategory(t *testing.T) {
+	f := newFixture(t)
+	categories := f.categoryTaxonomy()
+	f.router.GET("/product/category/:slug", f.categoryController().GetProductsByCategory)
+	for name, category := range map[string]string{"Mug": "Mugs", "Kettle": "Kitchen", "Lamp": "Home"} {
+		product := f.product(name, "10.00", 5)
+		product.CategoryIDs = []primitive.ObjectID{categories[category].CategoryID}
+		if err := f.repos.Products.Replace(f.context, product, product.Version); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		slug   string
+		status int
+		count  int
+	}{
+		{slug: "home", status: http.StatusOK, count: 3},
+		{slug: "kitchen", status: http.StatusOK, count: 2},
+		{slug: "mugs", status: http.StatusOK, count: 1},
+		{slug: "garden", status: http.StatusNotFound},
+	}
+	for _, test := range tests {
+		status, response := f.serve(http.MethodGet, "/product/category/"+test.slug, nil)
+		if status != test.status {
+			t.Errorf("%s: status = %d, want %d: %v", test.slug, status, test.status, response)
+			continue
+		}
+		if products, _ := response["data"].([]interface{}); test.status == http.StatusOK && len(products) != test.count {
+			t.Errorf("%s: got %d products, want %d: %v", test.slug, len(products), test.count, response)
+		}
+	}
+}
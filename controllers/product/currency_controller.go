@@ -0,0 +1,18 @@
+package product
+
+import (
+	"net/http"
+
+	"github.com/YassinNouh21/GoShopCart-Ecommerce/helpers"
+
+	"github.com/gin-gonic/gin"
+)
+
+/*
+GetCurrencies returns the currencies prices can be requested in, the store currency first, and the
+currency of the request, selected by the X-Currency header or the preference of the user.
+*/
+func (pc *ProductController) GetCurrencies(c *gin.Context) {
+	currency, rates := helpers.RequestCurrency(c)
+	c.JSON(http.StatusOK, gin.H{"currencies": rates.Currencies(), "currency": currency})
+}
@@ -0,0 +1,102 @@
+package product
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	productModel "github.com/YassinNouh21/GoShopCart-Ecommerce/models/product"
+	"github.com/YassinNouh21/GoShopCart-Ecommerce/money"
+	"github.com/YassinNouh21/GoShopCart-Ecommerce/repositories"
+	"github.com/YassinNouh21/GoShopCart-Ecommerce/search"
+	"github.com/YassinNouh21/GoShopCart-Ecommerce/storage"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func init() {
+	gin.SetMode(gin.TestMode)
+}
+
+// fixture is the in-memory repositories and services of the product controllers, with a router
+// serving the handlers registered by the test.
+type fixture struct {
+	t         *testing.T
+	repos     repositories.Repositories
+	search    search.Backend
+	suggester *search.Suggester
+	blobs     storage.BlobStore
+	router    *gin.Engine
+	context   context.Context
+}
+
+func newFixture(t *testing.T) *fixture {
+	return &fixture{
+		t:         t,
+		repos:     repositories.NewMemoryRepositories(),
+		search:    search.NewIndexBackend(),
+		suggester: search.NewSuggester(),
+		blobs:     storage.NewLocalStore(t.TempDir()),
+		router:    gin.New(),
+		context:   context.Background(),
+	}
+}
+
+func (f *fixture) productController() *ProductController {
+	return NewProductController(f.repos.Products, f.repos.Categories, f.search, f.suggester, f.blobs)
+}
+
+// product stores a product of the price in USD with the stock, at version 1, and indexes it for search.
+func (f *fixture) product(name string, price string, stock int) *productModel.Product {
+	f.t.Helper()
+	amount, err := money.Parse(price, money.USD)
+	if err != nil {
+		f.t.Fatal(err)
+	}
+	now := time.Now().UTC()
+	product := &productModel.Product{
+		ProductID:   primitive.NewObjectID(),
+		ProductName: name,
+		Price:       amount,
+		Stock:       stock,
+		Images:      []productModel.ProductImage{},
+		CreatedAt:   now,
+		UpdatedAt:   now,
+		Version:     1,
+	}
+	if err := f.repos.Products.Create(f.context, product); err != nil {
+		f.t.Fatal(err)
+	}
+	if err := f.search.Index(f.context, *product); err != nil {
+		f.t.Fatal(err)
+	}
+	return product
+}
+
+// serve sends the request with the JSON body and the header name and value pairs to the router and
+// decodes the JSON response.
+func (f *fixture) serve(method, path string, body interface{}, headers ...string) (int, map[string]interface{}) {
+	f.t.Helper()
+	var payload bytes.Buffer
+	if text, ok := body.(string); ok {
+		payload.WriteString(text)
+	} else if body != nil {
+		if err := json.NewEncoder(&payload).Encode(body); err != nil {
+			f.t.Fatal(err)
+		}
+	}
+	request := httptest.NewRequest(method, path, &payload)
+	request.Header.Set("Content-Type", "application/json")
+	for i := 0; i+1 < len(headers); i += 2 {
+		request.Header.Set(headers[i], headers[i+1])
+	}
+	recorder := httptest.NewRecorder()
+	f.router.ServeHTTP(recorder, request)
+	response := map[string]interface{}{}
+	json.Unmarshal(recorder.Body.Bytes(), &response)
+	return recorder.Code, response
+}
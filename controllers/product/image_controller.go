@@ -0,0 +1,373 @@
+package product
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/YassinNouh21/GoShopCart-Ecommerce/imaging"
+	productModel "github.com/YassinNouh21/GoShopCart-Ecommerce/models/product"
+	"github.com/YassinNouh21/GoShopCart-Ecommerce/repositories"
+	"github.com/YassinNouh21/GoShopCart-Ecommerce/storage"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const (
+	// ImagesPath is the path the blobs of the images are served from.
+	ImagesPath = "/images/"
+	// maxImageUpdateAttempts is the number of times the images of a product are written before
+	// giving up when concurrent updates keep changing the product.
+	maxImageUpdateAttempts = 3
+)
+
+var (
+	errNoImageProvided      = errors.New("No image provided, send it in the image field of a multipart form")
+	errImageTooLarge        = errors.New("Image too large, it must be at most 5 MB and 6000 pixels wide and high")
+	errUnsupportedImageType = errors.New("Unsupported image type, use JPEG, PNG or GIF")
+	errInvalidImage         = errors.New("Invalid image")
+	errInvalidImagePosition = errors.New("Invalid position value")
+	errInvalidImageID       = errors.New("Invalid image ID")
+	errImageNotFound        = errors.New("Image not found")
+	errInvalidImageOrder    = errors.New("image_ids must list every image of the product exactly once")
+	errFailedSaveImage      = errors.New("Failed to save image")
+	errProductBusy          = errors.New("Product was modified by another request")
+)
+
+// ImageController serves the endpoints that upload, order and serve the images of the products.
+type ImageController struct {
+	products repositories.ProductRepository
+	blobs    storage.BlobStore
+}
+
+// NewImageController creates an ImageController that keeps the images in the blob store and their
+// details on the products.
+func NewImageController(products repositories.ProductRepository, blobs storage.BlobStore) *ImageController {
+	return &ImageController{products: products, blobs: blobs}
+}
+
+// ImageOrderRequest represents the request body for reordering the images of a product.
+type ImageOrderRequest struct {
+	ImageIDs []primitive.ObjectID `json:"image_ids"`
+}
+
+/*
+UploadProductImage adds an image to a product.
+
+	The image is sent in the image field of a multipart form and must be a JPEG, PNG or GIF image of
+	at most 5 MB. It is stored with a large, a medium and a small thumbnail and appended to the images
+	of the product, or inserted at the optional position field (0 for the first image). The first
+	image of a product is its main image.
+
+Possible Errors:
+  - Invalid product ID: If the ID in the path is not a valid ObjectID.
+  - No image provided: If the form has no image field.
+  - Image too large: If the image exceeds 5 MB or 6000 pixels in width or height.
+  - Unsupported image type: If the content of the file is not a JPEG, PNG or GIF image.
+  - Invalid image: If the image cannot be decoded.
+  - Invalid position value: If position is not a non-negative integer.
+  - Product not found: If no product with the given ID exists.
+*/
+func (ic *ImageController) UploadProductImage(c *gin.Context) {
+	productID, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": errInvalidProductID.Error()})
+		return
+	}
+	// Leave room for the rest of the form, the image itself is checked on its own below
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, imaging.MaxImageSize+1<<20)
+	header, err := c.FormFile("image")
+	var tooLarge *http.MaxBytesError
+	if errors.As(err, &tooLarge) {
+		c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": errImageTooLarge.Error()})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": errNoImageProvided.Error()})
+		return
+	}
+	position := -1
+	if raw := c.PostForm("position"); raw != "" {
+		position, err = strconv.Atoi(raw)
+		if err != nil || position < 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": errInvalidImagePosition.Error()})
+			return
+		}
+	}
+	file, err := header.Open()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": errNoImageProvided.Error()})
+		return
+	}
+	defer file.Close()
+	data, err := io.ReadAll(io.LimitReader(file, imaging.MaxImageSize+1))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": errNoImageProvided.Error()})
+		return
+	}
+
+	processed, err := imaging.Process(data)
+	switch err {
+	case nil:
+	case imaging.ErrImageTooLarge:
+		c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": errImageTooLarge.Error()})
+		return
+	case imaging.ErrUnsupportedImageType:
+		c.JSON(http.StatusUnsupportedMediaType, gin.H{"error": errUnsupportedImageType.Error()})
+		return
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": errInvalidImage.Error()})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	if exists, err := ic.products.Exists(ctx, productID); err != nil || !exists {
+		c.JSON(http.StatusNotFound, gin.H{"error": errProductNotFound.Error()})
+		return
+	}
+	image, err := ic.storeImage(ctx, productID, processed)
+	if err != nil {
+		log.Printf("storage: store image of product %s: %v", productID.Hex(), err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": errFailedSaveImage.Error()})
+		return
+	}
+
+	product, err := ic.updateImages(ctx, productID, func(images []productModel.ProductImage) ([]productModel.ProductImage, error) {
+		at := position
+		if at < 0 || at > len(images) {
+			at = len(images)
+		}
+		updated := append([]productModel.ProductImage{}, images[:at]...)
+		updated = append(updated, image)
+		return append(updated, images[at:]...), nil
+	})
+	if err != nil {
+		// The image is not attached to the product, so its blobs are of no use
+		ic.deleteBlobs(ctx, image)
+		ic.writeUpdateError(c, err)
+		return
+	}
+	c.JSON(http.StatusCreated, gin.H{"message": "Image uploaded successfully", "image": image, "version": product.Version})
+}
+
+/*
+ReorderProductImages changes the order of the images of a product.
+
+	The body lists the IDs of every image of the product in the new order: {"image_ids": [...]}.
+	The first image becomes the main image of the product.
+
+Possible Errors:
+  - Invalid product ID: If the ID in the path is not a valid ObjectID.
+  - Invalid request body: If the body is not a list of image IDs.
+  - image_ids must list every image of the product exactly once: If an image is missing, repeated or unknown.
+  - Product not found: If no product with the given ID exists.
+*/
+func (ic *ImageController) ReorderProductImages(c *gin.Context) {
+	productID, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": errInvalidProductID.Error()})
+		return
+	}
+	var request ImageOrderRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
+	defer cancel()
+
+	product, err := ic.updateImages(ctx, productID, func(images []productModel.ProductImage) ([]productModel.ProductImage, error) {
+		if len(request.ImageIDs) != len(images) {
+			return nil, errInvalidImageOrder
+		}
+		byID := map[primitive.ObjectID]productModel.ProductImage{}
+		for _, image := range images {
+			byID[image.ImageID] = image
+		}
+		reordered := []productModel.ProductImage{}
+		for _, imageID := range request.ImageIDs {
+			image, found := byID[imageID]
+			if !found {
+				return nil, errInvalidImageOrder
+			}
+			delete(byID, imageID)
+			reordered = append(reordered, image)
+		}
+		return reordered, nil
+	})
+	if err != nil {
+		ic.writeUpdateError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Images reordered successfully", "images": product.Images, "version": product.Version})
+}
+
+/*
+DeleteProductImage removes an image from a product and deletes it with its thumbnails.
+
+Possible Errors:
+  - Invalid product ID / Invalid image ID: If an ID in the path is not a valid ObjectID.
+  - Product not found: If no product with the given ID exists.
+  - Image not found: If the product has no image with the given ID.
+*/
+func (ic *ImageController) DeleteProductImage(c *gin.Context) {
+	productID, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": errInvalidProductID.Error()})
+		return
+	}
+	imageID, err := primitive.ObjectIDFromHex(c.Param("image_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": errInvalidImageID.Error()})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
+	defer cancel()
+
+	var removed productModel.ProductImage
+	product, err := ic.updateImages(ctx, productID, func(images []productModel.ProductImage) ([]productModel.ProductImage, error) {
+		remaining := []productModel.ProductImage{}
+		for _, image := range images {
+			if image.ImageID == imageID {
+				removed = image
+			} else {
+				remaining = append(remaining, image)
+			}
+		}
+		if len(remaining) == len(images) {
+			return nil, errImageNotFound
+		}
+		return remaining, nil
+	})
+	if err != nil {
+		ic.writeUpdateError(c, err)
+		return
+	}
+	ic.deleteBlobs(ctx, removed)
+	c.JSON(http.StatusOK, gin.H{"message": "Image deleted successfully", "version": product.Version})
+}
+
+/*
+ServeImage serves a product image or thumbnail from the blob store.
+
+	The keys of the blobs are never reused, so the response can be cached indefinitely.
+
+Possible Errors:
+  - Image not found: If no blob is stored under the key.
+*/
+func (ic *ImageController) ServeImage(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	blob, err := ic.blobs.Get(ctx, strings.TrimPrefix(c.Param("key"), "/"))
+	if err == storage.ErrBlobNotFound {
+		c.JSON(http.StatusNotFound, gin.H{"error": errImageNotFound.Error()})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch image"})
+		return
+	}
+	c.Header("Cache-Control", "public, max-age=31536000, immutable")
+	c.Data(http.StatusOK, blob.ContentType, blob.Data)
+}
+
+// storeImage stores the image and its thumbnails in the blob store under
+// products/<product>/<image><extension> and products/<product>/<image>-<size><extension>.
+func (ic *ImageController) storeImage(ctx context.Context, productID primitive.ObjectID, processed *imaging.Processed) (productModel.ProductImage, error) {
+	imageID := primitive.NewObjectID()
+	prefix := "products/" + productID.Hex() + "/" + imageID.Hex()
+	image := productModel.ProductImage{
+		ImageID:     imageID,
+		Key:         prefix + processed.Original.Extension,
+		ContentType: processed.Original.ContentType,
+		Width:       processed.Original.Width,
+		Height:      processed.Original.Height,
+		Thumbnails:  []productModel.ImageThumbnail{},
+	}
+	image.URL = ImagesPath + image.Key
+	blobs := []imaging.Encoded{processed.Original}
+	for _, thumbnail := range processed.Thumbnails {
+		key := prefix + "-" + thumbnail.Size + thumbnail.Extension
+		image.Thumbnails = append(image.Thumbnails, productModel.ImageThumbnail{
+			Size:   thumbnail.Size,
+			URL:    ImagesPath + key,
+			Key:    key,
+			Width:  thumbnail.Width,
+			Height: thumbnail.Height,
+		})
+		blobs = append(blobs, thumbnail.Encoded)
+	}
+	for i, key := range image.BlobKeys() {
+		if err := ic.blobs.Put(ctx, key, storage.Blob{Data: blobs[i].Data, ContentType: blobs[i].ContentType}); err != nil {
+			ic.deleteBlobs(ctx, image)
+			return productModel.ProductImage{}, err
+		}
+	}
+	return image, nil
+}
+
+// updateImages replaces the images of the product with the ones returned by change and makes the
+// first image the main image of the product. The product is stored if its version did not change
+// since it was read, and read again otherwise, so concurrent uploads are never lost.
+func (ic *ImageController) updateImages(ctx context.Context, productID primitive.ObjectID, change func([]productModel.ProductImage) ([]productModel.ProductImage, error)) (*productModel.Product, error) {
+	for attempt := 0; attempt < maxImageUpdateAttempts; attempt++ {
+		product, err := ic.products.FindByID(ctx, productID)
+		if err != nil {
+			return nil, err
+		}
+		images, err := change(product.Images)
+		if err != nil {
+			return nil, err
+		}
+		product.Images = images
+		product.ImageUrl = ""
+		if len(images) > 0 {
+			product.ImageUrl = images[0].URL
+		}
+		version := product.Version
+		product.Version++
+		product.UpdatedAt = time.Now().UTC()
+		err = ic.products.Replace(ctx, product, version)
+		if err != repositories.ErrVersionConflict {
+			return product, err
+		}
+	}
+	return nil, repositories.ErrVersionConflict
+}
+
+// writeUpdateError writes the response for an error returned by updateImages.
+func (ic *ImageController) writeUpdateError(c *gin.Context, err error) {
+	switch err {
+	case repositories.ErrNotFound:
+		c.JSON(http.StatusNotFound, gin.H{"error": errProductNotFound.Error()})
+	case errImageNotFound:
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case errInvalidImageOrder:
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case repositories.ErrVersionConflict:
+		c.JSON(http.StatusConflict, gin.H{"error": errProductBusy.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": errFailedSaveImage.Error()})
+	}
+}
+
+// deleteBlobs deletes the image and its thumbnails from the blob store. Failures are only logged, since
+// the image is no longer referenced by the product.
+func (ic *ImageController) deleteBlobs(ctx context.Context, image productModel.ProductImage) {
+	for _, key := range image.BlobKeys() {
+		if err := ic.blobs.Delete(ctx, key); err != nil {
+			log.Printf("storage: delete %s: %v", key, err)
+		}
+	}
+}
@@ -0,0 +1,189 @@
+package product
+
+import (
+	"bytes"
+	"encoding/json"
+	"image"
+	"image/png"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/YassinNouh21/GoShopCart-Ecommerce/imaging"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// encodePNG returns a blank PNG image of the size.
+func encodePNG(t *testing.T, width, height int) []byte {
+	t.Helper()
+	var data bytes.Buffer
+	if err := png.Encode(&data, image.NewRGBA(image.Rect(0, 0, width, height))); err != nil {
+		t.Fatal(err)
+	}
+	return data.Bytes()
+}
+
+// upload posts a multipart form with the data in its image field, unless it is nil, and the other
+// fields, and decodes the JSON response.
+func (f *fixture) upload(path string, data []byte, fields map[string]string) (int, map[string]interface{}) {
+	f.t.Helper()
+	var body bytes.Buffer
+	form := multipart.NewWriter(&body)
+	for name, value := range fields {
+		if err := form.WriteField(name, value); err != nil {
+			f.t.Fatal(err)
+		}
+	}
+	if data != nil {
+		part, err := form.CreateFormFile("image", "image.png")
+		if err != nil {
+			f.t.Fatal(err)
+		}
+		part.Write(data)
+	}
+	if err := form.Close(); err != nil {
+		f.t.Fatal(err)
+	}
+	request := httptest.NewRequest(http.MethodPost, path, &body)
+	request.Header.Set("Content-Type", form.FormDataContentType())
+	recorder := httptest.NewRecorder()
+	f.router.ServeHTTP(recorder, request)
+	response := map[string]interface{}{}
+	json.Unmarshal(recorder.Body.Bytes(), &response)
+	return recorder.Code, response
+}
+
+func TestUploadProductImage(t *testing.T) {
+	tests := []struct {
+		name    string
+		data    func(t *testing.T) []byte
+		fields  map[string]string
+		unknown bool
+		status  int
+	}{
+		{name: "PNG image", data: func(t *testing.T) []byte { return encodePNG(t, 40, 20) }, status: http.StatusCreated},
+		{name: "PNG image at a position", data: func(t *testing.T) []byte { return encodePNG(t, 40, 20) }, fields: map[string]string{"position": "0"}, status: http.StatusCreated},
+		{name: "text named like an image", data: func(t *testing.T) []byte { return []byte("not an image at all") }, status: http.StatusUnsupportedMediaType},
+		{name: "SVG image", data: func(t *testing.T) []byte { return []byte(`<svg xmlns="http://www.w3.org/2000/svg"></svg>`) }, status: http.StatusUnsupportedMediaType},
+		{name: "truncated PNG image", data: func(t *testing.T) []byte { return encodePNG(t, 40, 20)[:40] }, status: http.StatusBadRequest},
+		{name: "wider than the limit", data: func(t *testing.T) []byte { return encodePNG(t, imaging.MaxImageDimension+1, 1) }, status: http.StatusRequestEntityTooLarge},
+		{
+			name: "larger than 5 MB",
+			data: func(t *testing.T) []byte {
+				return append(encodePNG(t, 1, 1), make([]byte, imaging.MaxImageSize)...)
+			},
+			status: http.StatusRequestEntityTooLarge,
+		},
+		{
+			name: "larger than the form limit",
+			data: func(t *testing.T) []byte {
+				return append(encodePNG(t, 1, 1), make([]byte, imaging.MaxImageSize+2<<20)...)
+			},
+			status: http.StatusRequestEntityTooLarge,
+		},
+		{name: "no image", data: func(t *testing.T) []byte { return nil }, status: http.StatusBadRequest},
+		{name: "negative position", data: func(t *testing.T) []byte { return encodePNG(t, 40, 20) }, fields: map[string]string{"position": "-1"}, status: http.StatusBadRequest},
+		{name: "unknown product", data: func(t *testing.T) []byte { return encodePNG(t, 40, 20) }, unknown: true, status: http.StatusNotFound},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			f := newFixture(t)
+			controller := NewImageController(f.repos.Products, f.blobs)
+			f.router.POST("/product/:id/images", controller.UploadProductImage)
+			f.router.GET(ImagesPath+"*key", controller.ServeImage)
+			product := f.product("Mug", "10.00", 5)
+			productID := product.ProductID
+			if test.unknown {
+				productID = primitive.NewObjectID()
+			}
+
+			status, response := f.upload("/product/"+productID.Hex()+"/images", test.data(t), test.fields)
+			if status != test.status {
+				t.Fatalf("status = %d, want %d: %v", status, test.status, response)
+			}
+			stored, err := f.repos.Products.FindByID(f.context, product.ProductID)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if status != http.StatusCreated {
+				if len(stored.Images) != 0 || stored.Version != product.Version {
+					t.Errorf("got images %+v at version %d after a rejected upload", stored.Images, stored.Version)
+				}
+				return
+			}
+			if len(stored.Images) != 1 || stored.ImageUrl != stored.Images[0].URL || stored.Version != product.Version+1 {
+				t.Fatalf("got images %+v, main image %q at version %d, want the upload as the main image", stored.Images, stored.ImageUrl, stored.Version)
+			}
+			uploaded := stored.Images[0]
+			if uploaded.ContentType != "image/png" || uploaded.Width != 40 || uploaded.Height != 20 || len(uploaded.Thumbnails) != len(imaging.ThumbnailSizes) {
+				t.Errorf("got image %+v, want a 40x20 PNG image with every thumbnail", uploaded)
+			}
+			for _, key := range uploaded.BlobKeys() {
+				request := httptest.NewRequest(http.MethodGet, ImagesPath+key, nil)
+				recorder := httptest.NewRecorder()
+				f.router.ServeHTTP(recorder, request)
+				if recorder.Code != http.StatusOK || recorder.Body.Len() == 0 {
+					t.Errorf("GET %s status = %d with %d bytes", key, recorder.Code, recorder.Body.Len())
+				}
+			}
+		})
+	}
+}
+
+func TestProductImageOrder(t *testing.T) {
+	f := newFixture(t)
+	controller := NewImageController(f.repos.Products, f.blobs)
+	f.router.POST("/product/:id/images", controller.UploadProductImage)
+	f.router.PUT("/product/:id/images", controller.ReorderProductImages)
+	f.router.DELETE("/product/:id/images/:image_id", controller.DeleteProductImage)
+	product := f.product("Mug", "10.00", 5)
+	path := "/product/" + product.ProductID.Hex() + "/images"
+	images := func() []string {
+		t.Helper()
+		stored, err := f.repos.Products.FindByID(f.context, product.ProductID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var ids []string
+		for _, image := range stored.Images {
+			ids = append(ids, image.ImageID.Hex())
+		}
+		if len(ids) > 0 && stored.ImageUrl != stored.Images[0].URL {
+			t.Errorf("main image %q, want the first image %q", stored.ImageUrl, stored.Images[0].URL)
+		}
+		return ids
+	}
+
+	f.upload(path, encodePNG(t, 10, 10), nil)
+	f.upload(path, encodePNG(t, 20, 20), map[string]string{"position": "0"})
+	got := images()
+	if len(got) != 2 {
+		t.Fatalf("got images %v, want 2", got)
+	}
+	second, first := got[0], got[1]
+
+	if status, response := f.serve(http.MethodPut, path, map[string]interface{}{"image_ids": []string{first}}); status != http.StatusBadRequest {
+		t.Errorf("reordering with an image missing: status = %d, want 400: %v", status, response)
+	}
+	if status, response := f.serve(http.MethodPut, path, map[string]interface{}{"image_ids": []string{first, first}}); status != http.StatusBadRequest {
+		t.Errorf("reordering with a repeated image: status = %d, want 400: %v", status, response)
+	}
+	if status, response := f.serve(http.MethodPut, path, map[string]interface{}{"image_ids": []string{first, second}}); status != http.StatusOK {
+		t.Fatalf("reordering: status = %d: %v", status, response)
+	}
+	if got := images(); got[0] != first || got[1] != second {
+		t.Errorf("images = %v, want %s first", got, first)
+	}
+
+	if status, response := f.serve(http.MethodDelete, path+"/"+first, nil); status != http.StatusOK {
+		t.Fatalf("deleting: status = %d: %v", status, response)
+	}
+	if status, response := f.serve(http.MethodDelete, path+"/"+first, nil); status != http.StatusNotFound {
+		t.Errorf("deleting again: status = %d, want 404: %v", status, response)
+	}
+	if got := images(); len(got) != 1 || got[0] != second {
+		t.Errorf("images = %v, want only %s", got, second)
+	}
+}
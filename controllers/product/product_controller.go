@@ -2,24 +2,60 @@ package product
 
 import (
 	"context"
-	"github.com/YassinNouh21/GoShopCart-Ecommerce/database"
+	"encoding/json"
+	"errors"
+	"github.com/YassinNouh21/GoShopCart-Ecommerce/helpers"
 	productModel "github.com/YassinNouh21/GoShopCart-Ecommerce/models/product"
+	"github.com/YassinNouh21/GoShopCart-Ecommerce/repositories"
+	"github.com/YassinNouh21/GoShopCart-Ecommerce/search"
+	"github.com/YassinNouh21/GoShopCart-Ecommerce/storage"
+	"log"
+	"math"
 	"net/http"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/gin-gonic/gin"
-	"go.mongodb.org/mongo-driver/bson"
+	"github.com/go-playground/validator/v10"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
+var (
+	errVersionRequired = errors.New("Product version is required (If-Match header or version field)")
+	errInvalidIfMatch  = errors.New("Invalid If-Match header")
+	errInvalidVersion  = errors.New("Product version must be a non-negative integer")
+)
+
+// ProductController serves the product endpoints on top of a ProductRepository.
+type ProductController struct {
+	products   repositories.ProductRepository
+	categories repositories.CategoryRepository
+	search     search.Backend
+	suggester  *search.Suggester
+	blobs      storage.BlobStore
+}
+
+// NewProductController creates a ProductController that stores products in the given repository,
+// checks their categories against the category repository, keeps the search backend and the
+// suggester up to date and deletes the images of deleted products from the blob store.
+func NewProductController(products repositories.ProductRepository, categories repositories.CategoryRepository, backend search.Backend, suggester *search.Suggester, blobs storage.BlobStore) *ProductController {
+	return &ProductController{products: products, categories: categories, search: backend, suggester: suggester, blobs: blobs}
+}
+
 /*
-CreateProductController handles the creation of a new product.
+CreateProduct handles the creation of a new product.
 
 	It binds the request body to the Product model and returns an error if the request body is invalid.
 	It checks if the product already exists and returns an error if it does.
+	It checks that the prices are positive amounts in the store currency and that the price overrides are valid.
+	It checks that every category of the product exists.
+	It assigns IDs to the variants and checks them against the options and the SKUs of other products.
+	The rating and review count start at zero; they are maintained from the reviews of the product.
+	Images are uploaded separately once the product exists.
 	It creates the new product and returns the ID of the inserted product.
 */
-func CreateProductController(c *gin.Context) {
+func (pc *ProductController) CreateProduct(c *gin.Context) {
 	// Bind the request body to the Product model
 
 	var product productModel.Product
@@ -34,39 +70,55 @@ func CreateProductController(c *gin.Context) {
 		return
 	}
 	product.ProductID = primitive.NewObjectID()
+	product.CreatedAt = time.Now().UTC()
+	product.UpdatedAt = product.CreatedAt
+	product.Version = 1
+	product.Rating = 0
+	product.ReviewCount = 0
+	product.Images = []productModel.ProductImage{}
 
 	// Check if the product already exists
 	ctx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
 	defer cancel()
 
-	existingProduct := productModel.Product{}
-	err := database.DB.ProductCollection.FindOne(ctx, bson.M{"name": product.ProductName}).Decode(&existingProduct)
+	_, err := pc.products.FindByName(ctx, product.ProductName)
 
 	if err == nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "Product already exists"})
 		c.Abort()
 		return
 	}
+	if err := product.ValidatePrices(); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if !pc.checkCategories(c, ctx, &product) {
+		return
+	}
+	if !pc.checkVariants(c, ctx, &product) {
+		return
+	}
 
 	// Create the new product
-	result, err := database.DB.ProductCollection.InsertOne(ctx, product)
+	err = pc.products.Create(ctx, &product)
 
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create product"})
 		c.Abort()
 		return
 	}
+	pc.indexProduct(ctx, product)
 
-	c.JSON(http.StatusCreated, gin.H{"message": "Product created successfully", "id": result.InsertedID})
+	c.JSON(http.StatusCreated, gin.H{"message": "Product created successfully", "id": product.ProductID})
 }
 
 /*
-GetProductController retrieves a product from the database by its ID.
+GetProduct retrieves a product from the database by its ID.
 
 	It takes a product ID as input and returns a Product object and an error.
 	If the product is not found in the database, it returns a "not found" error.
 */
-func GetProductController(c *gin.Context) {
+func (pc *ProductController) GetProduct(c *gin.Context) {
 	productID := c.Param("id")
 
 	objectID, err := primitive.ObjectIDFromHex(productID)
@@ -77,25 +129,310 @@ func GetProductController(c *gin.Context) {
 	}
 	ctx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
 	defer cancel()
-	var product productModel.Product
-	err = database.DB.ProductCollection.FindOne(ctx, bson.M{"_id": objectID}).Decode(&product)
+	product, err := pc.products.FindByID(ctx, objectID)
 	if err != nil {
 		c.JSON(http.StatusNotFound, gin.H{"error": "Product not found"})
 		return
 	}
 
+	localizeProduct(c, product)
+	c.Header("ETag", productETag(product.Version))
 	c.JSON(http.StatusOK, product)
 }
 
-func UpdateProductController(c *gin.Context) {}
+/*
+UpdateProduct replaces a product with the one provided in the request body (PUT).
+
+	Every field is replaced, so the body must describe the whole product and pass validation. The
+	rating and review count are maintained from the reviews and keep their stored values, and so do
+	the uploaded images, which are managed by the image endpoints.
+	The current version must be sent in the If-Match header or the version field; if the product
+	was modified since that version the update is rejected, with 412 Precondition Failed for an
+	If-Match header and 409 Conflict for a version field.
+
+Possible Errors:
+  - Invalid product ID: If the ID in the path is not a valid ObjectID or differs from the body.
+  - Invalid request body: If the body is not a valid product.
+  - Product version is required: If neither If-Match nor version is provided.
+  - Invalid If-Match header: If the If-Match header is not a product version.
+  - Product not found: If no product with the given ID exists.
+  - Product was modified by another request: If the provided version is stale.
+*/
+func (pc *ProductController) UpdateProduct(c *gin.Context) {
+	objectID, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid product ID"})
+		return
+	}
+
+	body, err := c.GetRawData()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+		return
+	}
+	var product productModel.Product
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(body, &product); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+		return
+	}
+	if err := json.Unmarshal(body, &fields); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+		return
+	}
+	if product.ProductID != primitive.NilObjectID && product.ProductID != objectID {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Product ID in the body does not match the path"})
+		return
+	}
+	_, hasVersion := fields["version"]
+	version, status, err := expectedVersion(c, product.Version, hasVersion)
+	if err != nil {
+		c.JSON(status, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
+	defer cancel()
+
+	existingProduct, err := pc.products.FindByID(ctx, objectID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Product not found"})
+		return
+	}
+
+	product.ProductID = objectID
+	product.CreatedAt = existingProduct.CreatedAt
+	product.Rating = existingProduct.Rating
+	product.ReviewCount = existingProduct.ReviewCount
+	keepImages(&product, existingProduct)
+	pc.replaceProduct(c, ctx, product, version)
+}
 
 /*
-DeleteProductController deletes a specific product by ID.
+PatchProduct applies a JSON Merge Patch (RFC 7386) to a product (PATCH).
+
+	Only the fields present in the body are changed; a field set to null is cleared. The patched
+	product must still pass validation. Like the PUT handler, the current version must be sent in
+	the If-Match header or the version field.
+
+Possible Errors:
+  - Invalid product ID: If the ID in the path is not a valid ObjectID.
+  - Invalid request body: If the body is not a JSON object or the patched product is invalid.
+  - Product fields cannot be patched: If the patch touches product_id, rating, review_count, images, created_at or updated_at.
+  - Product version must be a non-negative integer: If the version field is not a version.
+  - Product version is required: If neither If-Match nor version is provided.
+  - Invalid If-Match header: If the If-Match header is not a product version.
+  - Product not found: If no product with the given ID exists.
+  - Product was modified by another request: If the provided version is stale.
+*/
+func (pc *ProductController) PatchProduct(c *gin.Context) {
+	objectID, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid product ID"})
+		return
+	}
+
+	var patch map[string]interface{}
+	if err := c.ShouldBindJSON(&patch); err != nil || patch == nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+		return
+	}
+	for _, field := range []string{"product_id", "rating", "review_count", "images", "created_at", "updated_at"} {
+		if _, found := patch[field]; found {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Product fields cannot be patched: " + field})
+			return
+		}
+	}
+	rawVersion, hasVersion := patch["version"]
+	bodyVersion, isNumber := rawVersion.(float64)
+	if hasVersion && (!isNumber || bodyVersion < 0 || bodyVersion != math.Trunc(bodyVersion)) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": errInvalidVersion.Error()})
+		return
+	}
+	delete(patch, "version")
+	version, status, err := expectedVersion(c, int(bodyVersion), hasVersion)
+	if err != nil {
+		c.JSON(status, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
+	defer cancel()
+
+	existingProduct, err := pc.products.FindByID(ctx, objectID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Product not found"})
+		return
+	}
+
+	// Round-trip the stored product through its JSON form so the patch uses the API field names
+	var document map[string]interface{}
+	encoded, _ := json.Marshal(existingProduct)
+	if err := json.Unmarshal(encoded, &document); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update product"})
+		return
+	}
+	encoded, _ = json.Marshal(helpers.MergePatch(document, patch))
+
+	var product productModel.Product
+	if err := json.Unmarshal(encoded, &product); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+		return
+	}
+	product.ProductID = objectID
+	product.CreatedAt = existingProduct.CreatedAt
+	product.Rating = existingProduct.Rating
+	product.ReviewCount = existingProduct.ReviewCount
+	keepImages(&product, existingProduct)
+	pc.replaceProduct(c, ctx, product, version)
+}
+
+// replaceProduct validates the product and stores it if the stored version still matches.
+// It writes the response for the update handlers.
+func (pc *ProductController) replaceProduct(c *gin.Context, ctx context.Context, product productModel.Product, version int) {
+	product.UpdatedAt = time.Now().UTC()
+	product.Version = version + 1
+	if err := validator.New().Struct(product); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err := product.ValidatePrices(); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if !pc.checkCategories(c, ctx, &product) {
+		return
+	}
+	if !pc.checkVariants(c, ctx, &product) {
+		return
+	}
+
+	err := pc.products.Replace(ctx, &product, version)
+	if err == repositories.ErrNotFound {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Product not found"})
+		return
+	}
+	if err == repositories.ErrVersionConflict && c.GetHeader("If-Match") != "" {
+		c.JSON(http.StatusPreconditionFailed, gin.H{"error": "Product was modified by another request"})
+		return
+	}
+	if err == repositories.ErrVersionConflict {
+		c.JSON(http.StatusConflict, gin.H{"error": "Product was modified by another request"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update product"})
+		return
+	}
+	pc.indexProduct(ctx, product)
+
+	c.Header("ETag", productETag(product.Version))
+	c.JSON(http.StatusOK, gin.H{"message": "Product updated successfully", "product": product})
+}
+
+// keepImages copies the uploaded images of the stored product to its replacement. While the product
+// has uploaded images its main image stays the first of them.
+func keepImages(product *productModel.Product, existing *productModel.Product) {
+	product.Images = existing.Images
+	if len(existing.Images) > 0 {
+		product.ImageUrl = existing.ImageUrl
+	}
+}
+
+// indexProduct updates the search backend and the suggester with a stored product. The product is
+// already stored, so a failure is only logged; the product is searchable again after its next update
+// or a restart.
+func (pc *ProductController) indexProduct(ctx context.Context, product productModel.Product) {
+	for _, indexer := range []search.Indexer{pc.search, pc.suggester} {
+		if err := indexer.Index(ctx, product); err != nil {
+			log.Printf("search: index product %s: %v", product.ProductID.Hex(), err)
+		}
+	}
+}
+
+// checkCategories checks that every category of the product exists and removes duplicates.
+// It writes the error response and returns false if a category is unknown.
+func (pc *ProductController) checkCategories(c *gin.Context, ctx context.Context, product *productModel.Product) bool {
+	categoryIDs := []primitive.ObjectID{}
+	seen := map[primitive.ObjectID]bool{}
+	for _, categoryID := range product.CategoryIDs {
+		if seen[categoryID] {
+			continue
+		}
+		if _, err := pc.categories.FindByID(ctx, categoryID); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": errCategoryNotFound.Error() + ": " + categoryID.Hex()})
+			return false
+		}
+		seen[categoryID] = true
+		categoryIDs = append(categoryIDs, categoryID)
+	}
+	product.CategoryIDs = categoryIDs
+	return true
+}
+
+// checkVariants assigns IDs to new variants and checks that the variants match the options of the
+// product and that no other product uses one of their SKUs.
+// It writes the error response and returns false if the variants are invalid.
+func (pc *ProductController) checkVariants(c *gin.Context, ctx context.Context, product *productModel.Product) bool {
+	if product.Options == nil {
+		product.Options = []productModel.ProductOption{}
+	}
+	if product.Variants == nil {
+		product.Variants = []productModel.Variant{}
+	}
+	for i := range product.Variants {
+		if product.Variants[i].VariantID == primitive.NilObjectID {
+			product.Variants[i].VariantID = primitive.NewObjectID()
+		}
+	}
+	if err := product.ValidateVariants(); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return false
+	}
+	for _, variant := range product.Variants {
+		owner, err := pc.products.FindBySKU(ctx, variant.SKU)
+		if err == nil && owner.ProductID != product.ProductID {
+			c.JSON(http.StatusConflict, gin.H{"error": "SKU already exists: " + variant.SKU})
+			return false
+		}
+		if err != nil && err != repositories.ErrNotFound {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to check SKU"})
+			return false
+		}
+	}
+	return true
+}
+
+// expectedVersion returns the product version the client based its update on, or the status and
+// error of the response if it is missing or the If-Match header cannot be parsed.
+// The If-Match header takes precedence over the version sent in the body.
+func expectedVersion(c *gin.Context, bodyVersion int, hasBodyVersion bool) (int, int, error) {
+	if ifMatch := c.GetHeader("If-Match"); ifMatch != "" {
+		ifMatch = strings.Trim(strings.TrimPrefix(ifMatch, "W/"), `"`)
+		version, err := strconv.Atoi(ifMatch)
+		if err != nil {
+			return 0, http.StatusBadRequest, errInvalidIfMatch
+		}
+		return version, http.StatusOK, nil
+	}
+	if !hasBodyVersion {
+		return 0, http.StatusPreconditionRequired, errVersionRequired
+	}
+	return bodyVersion, http.StatusOK, nil
+}
+
+// productETag formats the product version as an ETag header value.
+func productETag(version int) string {
+	return `"` + strconv.Itoa(version) + `"`
+}
+
+/*
+DeleteProduct deletes a specific product by ID.
 
 	It takes a product ID as input and returns an error if the product is not found in the database.
-	It deletes the product and returns a success message.
+	It deletes the product and its images and returns a success message.
 */
-func DeleteProductController(c *gin.Context) {
+func (pc *ProductController) DeleteProduct(c *gin.Context) {
 	productID := c.Param("id")
 
 	objectID, err := primitive.ObjectIDFromHex(productID)
@@ -106,21 +443,33 @@ func DeleteProductController(c *gin.Context) {
 	ctx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
 	defer cancel()
 
-	// check if the product exists first
-	errFind := database.DB.ProductCollection.FindOne(ctx, bson.M{"_id": objectID}).Decode(&productModel.Product{})
-
-	if errFind != nil {
+	product, err := pc.products.FindByID(ctx, objectID)
+	if err == nil {
+		err = pc.products.Delete(ctx, objectID)
+	}
+	if err == repositories.ErrNotFound {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "Product is not exist"})
 		c.Abort()
 		return
 	}
-	_, err = database.DB.ProductCollection.DeleteOne(context.Background(), bson.M{"_id": objectID})
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete product"})
 		c.Abort()
 
 		return
 	}
+	for _, indexer := range []search.Indexer{pc.search, pc.suggester} {
+		if err := indexer.Remove(ctx, objectID); err != nil {
+			log.Printf("search: remove product %s: %v", objectID.Hex(), err)
+		}
+	}
+	for _, image := range product.Images {
+		for _, key := range image.BlobKeys() {
+			if err := pc.blobs.Delete(ctx, key); err != nil {
+				log.Printf("storage: delete %s: %v", key, err)
+			}
+		}
+	}
 
 	c.JSON(http.StatusOK, gin.H{"message": "Product deleted successfully"})
 }
@@ -0,0 +1,177 @@
+package product
+
+import (
+	"net/http"
+	"testing"
+
+	productModel "github.com/YassinNouh21/GoShopCart-Ecommerce/models/product"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestUpdateProductVersion(t *testing.T) {
+	// Every test starts with a product at version 2
+	tests := []struct {
+		name    string
+		method  string
+		body    gin.H
+		ifMatch string
+		status  int
+		version int
+	}{
+		{name: "patch with If-Match", method: http.MethodPatch, body: gin.H{"stock": 7}, ifMatch: `"2"`, status: http.StatusOK, version: 3},
+		{name: "patch with weak If-Match", method: http.MethodPatch, body: gin.H{"stock": 7}, ifMatch: `W/"2"`, status: http.StatusOK, version: 3},
+		{name: "patch with version field", method: http.MethodPatch, body: gin.H{"stock": 7, "version": 2}, status: http.StatusOK, version: 3},
+		{name: "If-Match wins over the version field", method: http.MethodPatch, body: gin.H{"stock": 7, "version": 1}, ifMatch: `"2"`, status: http.StatusOK, version: 3},
+		{name: "patch with stale If-Match", method: http.MethodPatch, body: gin.H{"stock": 7}, ifMatch: `"1"`, status: http.StatusPreconditionFailed, version: 2},
+		{name: "patch with stale version field", method: http.MethodPatch, body: gin.H{"stock": 7, "version": 1}, status: http.StatusConflict, version: 2},
+		{name: "patch without version", method: http.MethodPatch, body: gin.H{"stock": 7}, status: http.StatusPreconditionRequired, version: 2},
+		{name: "patch with malformed If-Match", method: http.MethodPatch, body: gin.H{"stock": 7}, ifMatch: `"two"`, status: http.StatusBadRequest, version: 2},
+		{name: "patch with string version", method: http.MethodPatch, body: gin.H{"stock": 7, "version": "2"}, status: http.StatusBadRequest, version: 2},
+		{name: "patch with negative version", method: http.MethodPatch, body: gin.H{"stock": 7, "version": -1}, status: http.StatusBadRequest, version: 2},
+		{name: "patch with fractional version", method: http.MethodPatch, body: gin.H{"stock": 7, "version": 1.5}, status: http.StatusBadRequest, version: 2},
+		{name: "patch with null version", method: http.MethodPatch, body: gin.H{"stock": 7, "version": nil}, status: http.StatusBadRequest, version: 2},
+		{name: "patch of a maintained field", method: http.MethodPatch, body: gin.H{"rating": 5}, ifMatch: `"2"`, status: http.StatusBadRequest, version: 2},
+		{name: "patch making the product invalid", method: http.MethodPatch, body: gin.H{"stock": -1}, ifMatch: `"2"`, status: http.StatusBadRequest, version: 2},
+		{name: "put with If-Match", method: http.MethodPut, body: gin.H{"product_name": "Mug", "price": "12.00", "stock": 7}, ifMatch: `"2"`, status: http.StatusOK, version: 3},
+		{name: "put with stale If-Match", method: http.MethodPut, body: gin.H{"product_name": "Mug", "price": "12.00", "stock": 7}, ifMatch: `"1"`, status: http.StatusPreconditionFailed, version: 2},
+		{name: "put without version", method: http.MethodPut, body: gin.H{"product_name": "Mug", "price": "12.00", "stock": 7}, status: http.StatusPreconditionRequired, version: 2},
+		{name: "put without required field", method: http.MethodPut, body: gin.H{"price": "12.00", "stock": 7}, ifMatch: `"2"`, status: http.StatusBadRequest, version: 2},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			f := newFixture(t)
+			controller := f.productController()
+			f.router.PUT("/product/:id", controller.UpdateProduct)
+			f.router.PATCH("/product/:id", controller.PatchProduct)
+			product := f.product("Mug", "10.00", 5)
+			product.Version = 2
+			if err := f.repos.Products.Replace(f.context, product, 1); err != nil {
+				t.Fatal(err)
+			}
+
+			var headers []string
+			if test.ifMatch != "" {
+				headers = []string{"If-Match", test.ifMatch}
+			}
+			status, response := f.serve(test.method, "/product/"+product.ProductID.Hex(), test.body, headers...)
+			if status != test.status {
+				t.Fatalf("status = %d, want %d: %v", status, test.status, response)
+			}
+			stored, err := f.repos.Products.FindByID(f.context, product.ProductID)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if stored.Version != test.version {
+				t.Errorf("version = %d, want %d", stored.Version, test.version)
+			}
+			if changed := stored.Stock == 7; changed != (test.status == http.StatusOK) {
+				t.Errorf("stock = %d after a %d response", stored.Stock, status)
+			}
+		})
+	}
+}
+
+func TestUpdateProductNotFound(t *testing.T) {
+	f := newFixture(t)
+	controller := f.productController()
+	f.router.PATCH("/product/:id", controller.PatchProduct)
+
+	if status, response := f.serve(http.MethodPatch, "/product/64b7f0c2a1b2c3d4e5f60718", gin.H{"stock": 1}, "If-Match", `"1"`); status != http.StatusNotFound {
+		t.Errorf("status = %d, want 404: %v", status, response)
+	}
+	if status, response := f.serve(http.MethodPatch, "/product/abc", gin.H{"stock": 1}, "If-Match", `"1"`); status != http.StatusBadRequest {
+		t.Errorf("status = %d, want 400: %v", status, response)
+	}
+}
+
+func TestCreateProductVariants(t *testing.T) {
+	sizes := []gin.H{{"name": "size", "values": []string{"S", "M"}}}
+	tests := []struct {
+		name     string
+		options  []gin.H
+		variants []gin.H
+		status   int
+	}{
+		{
+			name: "one variant per value", options: sizes,
+			variants: []gin.H{{"sku": "TEE-S", "options": gin.H{"size": "S"}, "stock": 3}, {"sku": "TEE-M", "options": gin.H{"size": "M"}, "price": "12.00"}},
+			status:   http.StatusCreated,
+		},
+		{
+			name: "value outside the options", options: sizes,
+			variants: []gin.H{{"sku": "TEE-XL", "options": gin.H{"size": "XL"}}},
+			status:   http.StatusBadRequest,
+		},
+		{
+			name: "unknown option", options: sizes,
+			variants: []gin.H{{"sku": "TEE-S", "options": gin.H{"size": "S", "color": "red"}}},
+			status:   http.StatusBadRequest,
+		},
+		{
+			name: "repeated combination", options: sizes,
+			variants: []gin.H{{"sku": "TEE-S", "options": gin.H{"size": "S"}}, {"sku": "TEE-S2", "options": gin.H{"size": "S"}}},
+			status:   http.StatusBadRequest,
+		},
+		{
+			name: "repeated SKU", options: sizes,
+			variants: []gin.H{{"sku": "TEE-S", "options": gin.H{"size": "S"}}, {"sku": "TEE-S", "options": gin.H{"size": "M"}}},
+			status:   http.StatusBadRequest,
+		},
+		{
+			name:     "variants without options",
+			variants: []gin.H{{"sku": "TEE-S", "options": gin.H{}}},
+			status:   http.StatusBadRequest,
+		},
+		{
+			name: "options without variants", options: sizes,
+			status: http.StatusBadRequest,
+		},
+		{
+			name: "SKU of another product", options: sizes,
+			variants: []gin.H{{"sku": "MUG-RED", "options": gin.H{"size": "S"}}},
+			status:   http.StatusConflict,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			f := newFixture(t)
+			f.router.POST("/admin/products", f.productController().CreateProduct)
+			mug := f.product("Mug", "10.00", 0)
+			mug.Options = []productModel.ProductOption{{Name: "color", Values: []string{"red"}}}
+			mug.Variants = []productModel.Variant{{VariantID: primitive.NewObjectID(), SKU: "MUG-RED", Options: map[string]string{"color": "red"}}}
+			if err := f.repos.Products.Replace(f.context, mug, mug.Version); err != nil {
+				t.Fatal(err)
+			}
+
+			body := gin.H{"product_name": "Tee", "price": "10.00", "options": test.options, "variants": test.variants}
+			status, response := f.serve(http.MethodPost, "/admin/products", body)
+			if status != test.status {
+				t.Fatalf("status = %d, want %d: %v", status, test.status, response)
+			}
+			if status != http.StatusCreated {
+				return
+			}
+			productID, err := primitive.ObjectIDFromHex(response["id"].(string))
+			if err != nil {
+				t.Fatal(err)
+			}
+			stored, err := f.repos.Products.FindByID(f.context, productID)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(stored.Variants) != len(test.variants) {
+				t.Fatalf("got variants %+v, want %d", stored.Variants, len(test.variants))
+			}
+			for _, variant := range stored.Variants {
+				if variant.VariantID == primitive.NilObjectID {
+					t.Errorf("variant %s has no ID", variant.SKU)
+				}
+			}
+			if owner, err := f.repos.Products.FindBySKU(f.context, "TEE-M"); err != nil || owner.ProductID != productID {
+				t.Errorf("FindBySKU(TEE-M) = %v, %v, want the new product", owner, err)
+			}
+		})
+	}
+}
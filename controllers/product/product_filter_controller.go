@@ -3,53 +3,62 @@ package product
 import (
 	"context"
 	"errors"
-	"github.com/YassinNouh21/GoShopCart-Ecommerce/database"
-	"github.com/YassinNouh21/GoShopCart-Ecommerce/models/product"
 	"net/http"
 	"strconv"
 	"time"
 
+	productModel "github.com/YassinNouh21/GoShopCart-Ecommerce/models/product"
+	"github.com/YassinNouh21/GoShopCart-Ecommerce/money"
+	"github.com/YassinNouh21/GoShopCart-Ecommerce/repositories"
+	"github.com/YassinNouh21/GoShopCart-Ecommerce/search"
 	"github.com/gin-gonic/gin"
-	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
-	"go.mongodb.org/mongo-driver/mongo/options"
 )
 
 var (
-	errFailedFetchProducts  = errors.New("Failed to fetch products")
-	errFailedDecodeProducts = errors.New("Failed to decode products")
-	errInvalidMinPrice      = errors.New("Invalid minPrice value")
-	errInvalidMaxPrice      = errors.New("Invalid maxPrice value")
-	errInvalidPriceRange    = errors.New("Invalid price range")
-	errNoProductsFound      = errors.New("No products found")
-	errNoPriceProvided      = errors.New("No price provided")
+	errFailedFetchProducts = errors.New("Failed to fetch products")
+	errInvalidMinPrice     = errors.New("Invalid minPrice value")
+	errInvalidMaxPrice     = errors.New("Invalid maxPrice value")
+	errInvalidPriceRange   = errors.New("Invalid price range")
+	errInvalidMinRating    = errors.New("Invalid minRating value")
+	errInvalidInStock      = errors.New("Invalid in_stock value")
+	errNoProductsFound     = errors.New("No products found")
+	errNoPriceProvided     = errors.New("No price provided")
 )
 
-// GetProductsByKeyword retrieves products based on a keyword search
-func GetProductsByKeyword(c *gin.Context) {
+// GetProductsByKeyword retrieves a page of the products found by a full-text search of the keyword,
+// most relevant first by default
+func (pc *ProductController) GetProductsByKeyword(c *gin.Context) {
 	keyword := c.Query("keyword")
+	listOptions, ok := bindListOptions(c, relevanceSort)
+	if !ok {
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
 
-	filter := bson.M{"product_name": bson.M{"$regex": keyword, "$options": "i"}}
-
-	products, err := database.DB.ProductCollection.Find(context.Background(), filter)
+	filter := repositories.ProductFilter{}
+	hits, err := searchProducts(ctx, pc.search, keyword, &filter)
+	if err == nil && hits == nil {
+		err = search.ErrEmptyQuery
+	}
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": errFailedFetchProducts.Error()})
+		writeListError(c, err)
 		c.Abort()
 		return
 	}
-
-	var result []product.Product
-	if err := products.All(context.Background(), &result); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": errFailedDecodeProducts.Error()})
+	page, err := listPage(ctx, pc.products, filter, hits, listOptions)
+	if err != nil {
+		writeListError(c, err)
 		c.Abort()
 		return
 	}
 
-	c.JSON(http.StatusOK, result)
+	c.JSON(http.StatusOK, pageResponse(c, page, listOptions.Fields))
 }
 
-// GetProductsByPriceRange retrieves products within a price range
-func GetProductsByPriceRangeController(c *gin.Context) {
+// GetProductsByPriceRange retrieves a page of products within a price range, cheapest first by default
+func (pc *ProductController) GetProductsByPriceRange(c *gin.Context) {
 	minPriceStr := c.Query("minPrice")
 	maxPriceStr := c.Query("maxPrice")
 	// Set a timeout for the function execution
@@ -57,70 +66,55 @@ func GetProductsByPriceRangeController(c *gin.Context) {
 
 	defer cancel() // Cancel the context to release resources
 
-	minPrice, err := strconv.ParseFloat(minPriceStr, 32)
-	if err != nil && minPriceStr != "" {
+	minBound, err := parsePrice(minPriceStr)
+	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": errInvalidMinPrice.Error()})
 		c.Abort()
 		return
 	}
 
-	maxPrice, err := strconv.ParseFloat(maxPriceStr, 32)
+	maxBound, err := parsePrice(maxPriceStr)
 
-	if err != nil && maxPriceStr != "" {
+	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": errInvalidMaxPrice.Error()})
 		c.Abort()
 
 		return
 	}
-	if minPriceStr == "" && maxPriceStr == "" {
+	if minBound == nil && maxBound == nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": errInvalidPriceRange.Error()})
 		c.Abort()
 		return
 	}
-	var filter primitive.M
-	if minPriceStr == "" {
-		filter = bson.M{"price": bson.M{"$lte": maxPrice}}
-
-	} else if maxPriceStr == "" {
-		filter = bson.M{"price": bson.M{"$gte": minPrice}}
-	} else {
-		if minPrice > maxPrice {
-			c.JSON(http.StatusBadRequest, gin.H{"error": errInvalidPriceRange})
-			c.Abort()
-			return
-		}
-		filter = bson.M{"price": bson.M{"$gte": minPrice, "$lte": maxPrice}}
-	}
-
-	options := options.Find().SetSort(bson.D{{Key: "price", Value: 1}})
-
-	products, err := database.DB.ProductCollection.Find(ctx, filter, options)
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": errFailedFetchProducts.Error()})
+	if minBound != nil && maxBound != nil && minBound.Cmp(*maxBound) > 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": errInvalidPriceRange.Error()})
 		c.Abort()
-
+		return
+	}
+	listOptions, ok := bindListOptions(c, "price")
+	if !ok {
 		return
 	}
 
-	var result []product.Product
-	if err := products.All(ctx, &result); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": errFailedDecodeProducts.Error()})
+	page, err := listPage(ctx, pc.products, repositories.ProductFilter{MinPrice: minBound, MaxPrice: maxBound}, nil, listOptions)
+	if err != nil {
+		writeListError(c, err)
 		c.Abort()
 
 		return
 	}
 
-	if len(result) == 0 {
+	if page.Total == 0 {
 		c.JSON(http.StatusNotFound, gin.H{"error": errNoProductsFound.Error()})
 		c.Abort()
 		return
 	}
 
-	c.JSON(http.StatusOK, result)
+	c.JSON(http.StatusOK, pageResponse(c, page, listOptions.Fields))
 }
 
-// GetProductsByPriceRange retrieves products within a price range
-func GetProductsByPriceController(c *gin.Context) {
+// GetProductsByPrice retrieves a page of products with an exact price, in the store currency
+func (pc *ProductController) GetProductsByPrice(c *gin.Context) {
 	priceStr := c.Param("price")
 	// Set a timeout for the function execution
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
@@ -131,35 +125,188 @@ func GetProductsByPriceController(c *gin.Context) {
 		c.Abort()
 		return
 	}
-	price, err := strconv.ParseFloat(priceStr, 16)
+	price, err := parsePrice(priceStr)
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": errInvalidPriceRange.Error()})
 		c.Abort()
 		return
 	}
 
-	filter := bson.M{"price": price}
-	options := options.Find().SetSort(bson.D{{Key: "price", Value: 1}})
+	listOptions, ok := bindListOptions(c, "price")
+	if !ok {
+		return
+	}
 
-	products, err := database.DB.ProductCollection.Find(ctx, filter, options)
+	page, err := listPage(ctx, pc.products, repositories.ProductFilter{MinPrice: price, MaxPrice: price}, nil, listOptions)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": errFailedFetchProducts.Error()})
+		writeListError(c, err)
 		c.Abort()
 		return
 	}
 
-	var result []product.Product
-	if err := products.All(ctx, &result); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": errFailedDecodeProducts.Error()})
+	if page.Total == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": errNoProductsFound.Error()})
 		c.Abort()
 		return
 	}
 
-	if len(result) == 0 {
-		c.JSON(http.StatusNotFound, gin.H{"error": errNoProductsFound.Error()})
-		c.Abort()
+	c.JSON(http.StatusOK, pageResponse(c, page, listOptions.Fields))
+}
+
+/*
+SearchProducts retrieves a page of the products matching every given filter, together with facet
+counts over all the matching products. It accepts the listing parameters of the other product
+listings, sorts by relevance with a keyword and newest first without, and the filters:
+
+	keyword: A full-text search query over the name and description of the product.
+	minPrice, maxPrice: The price is within the range, in the store currency; either bound can be omitted.
+	minRating: The rating is at least this value.
+	category: The product is listed in the category with this slug or in one of its subcategories.
+	in_stock: When true, the product or one of its variants has stock left.
+
+Possible Errors:
+  - Invalid minPrice, maxPrice, minRating or in_stock value: If a filter cannot be parsed.
+  - Search query must contain a word or a phrase: If the keyword has nothing to search for.
+  - Invalid price range: If minPrice is greater than maxPrice.
+  - Category not found: If no category has the given slug.
+  - Invalid limit, offset, sort, cursor or fields: If a listing parameter is invalid.
+  - Failed to fetch products: If the products or the facets cannot be read.
+*/
+func (pc *ProductController) SearchProducts(c *gin.Context) {
+	keyword := c.Query("keyword")
+	defaultSort := "newest"
+	if keyword != "" {
+		defaultSort = relevanceSort
+	}
+	listOptions, ok := bindListOptions(c, defaultSort)
+	if !ok {
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	filter, ok := pc.bindSearchFilter(c, ctx)
+	if !ok {
+		return
+	}
+	hits, err := searchProducts(ctx, pc.search, keyword, &filter)
+	if err != nil {
+		writeListError(c, err)
+		return
+	}
+	page, err := listPage(ctx, pc.products, filter, hits, listOptions)
+	if err != nil {
+		writeListError(c, err)
+		return
+	}
+	facets, err := pc.products.Facets(ctx, filter)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": errFailedFetchProducts.Error()})
+		return
+	}
+	categoryFacets, err := pc.categoryFacets(ctx, facets.Categories)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": errFailedFetchProducts.Error()})
 		return
 	}
 
-	c.JSON(http.StatusOK, result)
+	response := pageResponse(c, page, listOptions.Fields)
+	response["facets"] = gin.H{"prices": facets.Prices, "ratings": facets.Ratings, "categories": categoryFacets}
+	c.JSON(http.StatusOK, response)
+}
+
+// bindSearchFilter reads the filters of a product search from the query parameters.
+// It writes the error response and returns false if a filter is invalid.
+func (pc *ProductController) bindSearchFilter(c *gin.Context, ctx context.Context) (repositories.ProductFilter, bool) {
+	filter := repositories.ProductFilter{}
+	bounds := []struct {
+		name  string
+		err   error
+		value **money.Money
+	}{
+		{"minPrice", errInvalidMinPrice, &filter.MinPrice},
+		{"maxPrice", errInvalidMaxPrice, &filter.MaxPrice},
+	}
+	for _, bound := range bounds {
+		value, err := parsePrice(c.Query(bound.name))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": bound.err.Error()})
+			return filter, false
+		}
+		*bound.value = value
+	}
+	if raw := c.Query("minRating"); raw != "" {
+		minRating, err := strconv.ParseFloat(raw, 64)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": errInvalidMinRating.Error()})
+			return filter, false
+		}
+		filter.MinRating = &minRating
+	}
+	if filter.MinPrice != nil && filter.MaxPrice != nil && filter.MinPrice.Cmp(*filter.MaxPrice) > 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": errInvalidPriceRange.Error()})
+		return filter, false
+	}
+	if raw := c.Query("in_stock"); raw != "" {
+		inStock, err := strconv.ParseBool(raw)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": errInvalidInStock.Error()})
+			return filter, false
+		}
+		filter.InStock = inStock
+	}
+	if slug := c.Query("category"); slug != "" {
+		category, err := pc.categories.FindBySlug(ctx, slug)
+		if err == repositories.ErrNotFound {
+			c.JSON(http.StatusNotFound, gin.H{"error": errCategoryNotFound.Error()})
+			return filter, false
+		}
+		if err == nil {
+			filter.CategoryIDs, err = categoryTree(ctx, pc.categories, category)
+		}
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": errFailedFetchProducts.Error()})
+			return filter, false
+		}
+	}
+	return filter, true
+}
+
+// parsePrice reads a price filter in the store currency, such as 19.99. It returns nil for an empty value.
+func parsePrice(raw string) (*money.Money, error) {
+	if raw == "" {
+		return nil, nil
+	}
+	price, err := money.Parse(raw, money.StoreCurrency())
+	if err != nil {
+		return nil, err
+	}
+	return &price, nil
+}
+
+// categoryFacets adds the name and slug of every category to its product count.
+// Categories deleted since the products were read are left out.
+func (pc *ProductController) categoryFacets(ctx context.Context, counts []repositories.CategoryCount) ([]gin.H, error) {
+	categories, err := pc.categories.FindAll(ctx)
+	if err != nil {
+		return nil, err
+	}
+	byID := map[primitive.ObjectID]productModel.Category{}
+	for _, category := range categories {
+		byID[category.CategoryID] = category
+	}
+	facets := []gin.H{}
+	for _, count := range counts {
+		category, found := byID[count.CategoryID]
+		if !found {
+			continue
+		}
+		facets = append(facets, gin.H{
+			"category_id": count.CategoryID,
+			"name":        category.Name,
+			"slug":        category.Slug,
+			"count":       count.Count,
+		})
+	}
+	return facets, nil
 }
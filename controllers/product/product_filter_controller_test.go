@@ -0,0 +1,128 @@
+package product
+
+import (
+	"net/http"
+	"sort"
+	"strings"
+	"testing"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// catalog stores a mug in Home > Kitchen > Mugs, a kettle out of stock in Home > Kitchen and a lamp
+// in Home, for the taxonomy of categoryTaxonomy.
+func (f *fixture) catalog() {
+	f.t.Helper()
+	categories := f.categoryTaxonomy()
+	for _, product := range []struct {
+		name, price, category string
+		stock                 int
+		rating                float32
+	}{
+		{name: "Mug", price: "10.00", category: "Mugs", stock: 3, rating: 4.5},
+		{name: "Kettle", price: "60.00", category: "Kitchen", rating: 3.2},
+		{name: "Lamp", price: "250.00", category: "Home", stock: 1},
+	} {
+		stored := f.product(product.name, product.price, product.stock)
+		stored.Rating = product.rating
+		stored.CategoryIDs = []primitive.ObjectID{categories[product.category].CategoryID}
+		if err := f.repos.Products.Replace(f.context, stored, stored.Version); err != nil {
+			f.t.Fatal(err)
+		}
+	}
+}
+
+func TestSearchProductsFilters(t *testing.T) {
+	f := newFixture(t)
+	f.router.GET("/product/search", f.productController().SearchProducts)
+	f.catalog()
+
+	tests := []struct {
+		query  string
+		status int
+		names  string
+	}{
+		{query: "", status: http.StatusOK, names: "Kettle Lamp Mug"},
+		{query: "minPrice=20", status: http.StatusOK, names: "Kettle Lamp"},
+		{query: "minPrice=20&maxPrice=100", status: http.StatusOK, names: "Kettle"},
+		{query: "minRating=3", status: http.StatusOK, names: "Kettle Mug"},
+		{query: "category=kitchen", status: http.StatusOK, names: "Kettle Mug"},
+		{query: "category=kitchen&in_stock=true", status: http.StatusOK, names: "Mug"},
+		{query: "keyword=kettle&category=kitchen", status: http.StatusOK, names: "Kettle"},
+		{query: "keyword=lamp&category=kitchen", status: http.StatusOK, names: ""},
+		{query: "category=garden", status: http.StatusNotFound},
+		{query: "minPrice=100&maxPrice=20", status: http.StatusBadRequest},
+		{query: "minPrice=cheap", status: http.StatusBadRequest},
+		{query: "minRating=good", status: http.StatusBadRequest},
+		{query: "in_stock=maybe", status: http.StatusBadRequest},
+		{query: "keyword=-", status: http.StatusBadRequest},
+	}
+	for _, test := range tests {
+		status, response := f.serve(http.MethodGet, "/product/search?"+test.query, nil)
+		if status != test.status {
+			t.Errorf("GET ?%s status = %d, want %d: %v", test.query, status, test.status, response)
+			continue
+		}
+		if status != http.StatusOK {
+			continue
+		}
+		var names []string
+		for _, product := range response["data"].([]interface{}) {
+			names = append(names, product.(map[string]interface{})["product_name"].(string))
+		}
+		sort.Strings(names)
+		if got := strings.Join(names, " "); got != test.names {
+			t.Errorf("GET ?%s = %q, want %q", test.query, got, test.names)
+		}
+		if total := response["total"].(float64); int(total) != len(names) {
+			t.Errorf("GET ?%s total = %v, want %d", test.query, total, len(names))
+		}
+	}
+}
+
+func TestSearchProductsFacets(t *testing.T) {
+	f := newFixture(t)
+	f.router.GET("/product/search", f.productController().SearchProducts)
+	f.catalog()
+
+	status, response := f.serve(http.MethodGet, "/product/search?category=kitchen&limit=1", nil)
+	if status != http.StatusOK {
+		t.Fatalf("status = %d: %v", status, response)
+	}
+	facets := response["facets"].(map[string]interface{})
+	counts := func(name string) []float64 {
+		var counts []float64
+		for _, bucket := range facets[name].([]interface{}) {
+			counts = append(counts, bucket.(map[string]interface{})["count"].(float64))
+		}
+		return counts
+	}
+	// The facets count every matching product, not only the page, and leave the lamp out
+	if got, want := counts("prices"), []float64{1, 0, 1, 0, 0, 0}; !equalCounts(got, want) {
+		t.Errorf("price facet counts = %v, want %v for 0, 25, 50, 100, 200 and 500", got, want)
+	}
+	if got, want := counts("ratings"), []float64{1, 2, 2, 2}; !equalCounts(got, want) {
+		t.Errorf("rating facet counts = %v, want %v for 4, 3, 2 and 1 stars and up", got, want)
+	}
+	categories := map[string]float64{}
+	for _, category := range facets["categories"].([]interface{}) {
+		facet := category.(map[string]interface{})
+		categories[facet["slug"].(string)] = facet["count"].(float64)
+	}
+	if len(categories) != 2 || categories["kitchen"] != 1 || categories["mugs"] != 1 {
+		t.Errorf("category facets = %v, want one product in kitchen and one in mugs", categories)
+	}
+}
+
+// equalCounts reports whether the facet counts are the same, in the same order.
+func equalCounts(got, want []float64) bool {
+	if len(got) != len(want) {
+		return false
+	}
+	for i := range got {
+		if got[i] != want[i] {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,249 @@
+package product
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/YassinNouh21/GoShopCart-Ecommerce/helpers"
+	productModel "github.com/YassinNouh21/GoShopCart-Ecommerce/models/product"
+	"github.com/YassinNouh21/GoShopCart-Ecommerce/repositories"
+	"github.com/YassinNouh21/GoShopCart-Ecommerce/search"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const (
+	// DefaultPageLimit is the number of products on a page when the limit parameter is omitted.
+	DefaultPageLimit = 20
+	// MaxPageLimit is the largest number of products a page can hold.
+	MaxPageLimit = 100
+
+	// relevanceSort orders the results of a keyword search by relevance, most relevant first.
+	relevanceSort = "relevance"
+	// relevanceCursorPrefix starts the decoded cursors of relevance pages, followed by an offset.
+	relevanceCursorPrefix = "relevance:"
+)
+
+var (
+	errInvalidLimit  = errors.New("Invalid limit value, it must be between 1 and 100")
+	errInvalidOffset = errors.New("Invalid offset value")
+	errInvalidSort   = errors.New("Invalid sort, use price, rating or name (prefixed with - for descending), newest, oldest or relevance")
+	errInvalidCursor = errors.New("Invalid cursor")
+	errInvalidFields = errors.New("Invalid fields")
+	errNoKeyword     = errors.New("Sorting by relevance requires a keyword")
+)
+
+// listRequest holds the parameters of a product listing.
+type listRequest struct {
+	repositories.ProductListOptions
+	// relevance orders the products by their relevance to the keyword instead of by Sort.
+	relevance bool
+}
+
+// bindListOptions reads the pagination, sort and projection parameters of a product listing:
+// limit, cursor, offset, sort and fields (a comma separated list of product fields).
+// It writes the error response and returns false if a parameter is invalid.
+func bindListOptions(c *gin.Context, defaultSort string) (listRequest, bool) {
+	listOptions := listRequest{ProductListOptions: repositories.ProductListOptions{Limit: DefaultPageLimit, Cursor: c.Query("cursor")}}
+	if limit := c.Query("limit"); limit != "" {
+		value, err := strconv.Atoi(limit)
+		if err != nil || value < 1 || value > MaxPageLimit {
+			c.JSON(http.StatusBadRequest, gin.H{"error": errInvalidLimit.Error()})
+			return listOptions, false
+		}
+		listOptions.Limit = value
+	}
+	if offset := c.Query("offset"); offset != "" {
+		value, err := strconv.Atoi(offset)
+		if err != nil || value < 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": errInvalidOffset.Error()})
+			return listOptions, false
+		}
+		listOptions.Offset = value
+	}
+	sortKey := c.DefaultQuery("sort", defaultSort)
+	if sortKey == relevanceSort {
+		// The hits are read in a fixed order before they are ordered by relevance
+		listOptions.relevance = true
+		sortKey = "oldest"
+	}
+	productSort, err := repositories.ParseProductSort(sortKey)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": errInvalidSort.Error()})
+		return listOptions, false
+	}
+	listOptions.Sort = productSort
+	fields, err := repositories.ParseProductFields(c.Query("fields"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": errInvalidFields.Error()})
+		return listOptions, false
+	}
+	listOptions.Fields = fields
+	return listOptions, true
+}
+
+// searchProducts restricts the filter to the products matching the keyword and returns them as
+// search hits, most relevant first. Without a keyword the filter is left as is and the hits are nil.
+func searchProducts(ctx context.Context, backend search.Backend, keyword string, filter *repositories.ProductFilter) ([]search.Hit, error) {
+	if keyword == "" {
+		return nil, nil
+	}
+	query, err := search.ParseQuery(keyword)
+	if err != nil {
+		return nil, err
+	}
+	hits, err := backend.Search(ctx, query, search.MaxHits)
+	if err != nil {
+		return nil, err
+	}
+	filter.IDs = []primitive.ObjectID{}
+	for _, hit := range hits {
+		filter.IDs = append(filter.IDs, hit.ProductID)
+	}
+	return hits, nil
+}
+
+// listPage reads the requested page of the products of the filter. Pages sorted by relevance hold
+// the search hits that pass the filter, in the order of the hits.
+func listPage(ctx context.Context, products repositories.ProductRepository, filter repositories.ProductFilter, hits []search.Hit, request listRequest) (*repositories.ProductPage, error) {
+	request.Fields = loadedFields(request.Fields)
+	if !request.relevance {
+		return products.List(ctx, filter, request.ProductListOptions)
+	}
+	if hits == nil {
+		return nil, errNoKeyword
+	}
+	start, err := decodeRelevanceCursor(request.Cursor)
+	if err != nil {
+		return nil, err
+	}
+	if len(hits) == 0 {
+		return &repositories.ProductPage{Products: []productModel.Product{}}, nil
+	}
+	// There are at most search.MaxHits products, so all of them are read and ordered here
+	all := request.ProductListOptions
+	all.Cursor, all.Offset, all.Limit = "", 0, len(hits)
+	page, err := products.List(ctx, filter, all)
+	if err != nil {
+		return nil, err
+	}
+	rank := map[primitive.ObjectID]int{}
+	for i, hit := range hits {
+		rank[hit.ProductID] = i
+	}
+	sort.SliceStable(page.Products, func(i, j int) bool {
+		return rank[page.Products[i].ProductID] < rank[page.Products[j].ProductID]
+	})
+	start += request.Offset
+	end := start + request.Limit
+	page.NextCursor = ""
+	if start > len(page.Products) {
+		start = len(page.Products)
+	}
+	if end < len(page.Products) {
+		page.NextCursor = base64.RawURLEncoding.EncodeToString([]byte(relevanceCursorPrefix + strconv.Itoa(end)))
+	} else {
+		end = len(page.Products)
+	}
+	page.Products = page.Products[start:end]
+	return page, nil
+}
+
+// loadedFields returns the fields to read for a projection: the requested fields, plus the prices
+// and price overrides the display prices are computed from.
+func loadedFields(fields []string) []string {
+	if len(fields) == 0 {
+		return fields
+	}
+	loaded := append([]string{}, fields...)
+	for _, field := range fields {
+		if field == "price" || field == "variants" {
+			return append(loaded, "price", "price_overrides")
+		}
+	}
+	return loaded
+}
+
+// decodeRelevanceCursor returns the offset a relevance page starts at.
+func decodeRelevanceCursor(cursor string) (int, error) {
+	if cursor == "" {
+		return 0, nil
+	}
+	data, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil || !strings.HasPrefix(string(data), relevanceCursorPrefix) {
+		return 0, repositories.ErrInvalidCursor
+	}
+	offset, err := strconv.Atoi(strings.TrimPrefix(string(data), relevanceCursorPrefix))
+	if err != nil || offset < 0 {
+		return 0, repositories.ErrInvalidCursor
+	}
+	return offset, nil
+}
+
+// writeListError writes the response for an error returned while searching or listing products.
+func writeListError(c *gin.Context, err error) {
+	switch err {
+	case repositories.ErrInvalidCursor:
+		c.JSON(http.StatusBadRequest, gin.H{"error": errInvalidCursor.Error()})
+	case search.ErrEmptyQuery, errNoKeyword:
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": errFailedFetchProducts.Error()})
+	}
+}
+
+// pageResponse returns the envelope of a page of products: the products under data, the cursor of
+// the next page (null on the last page) under next_cursor and the number of matching products under total.
+// The products hold their display price in the currency of the request. With a projection, every
+// product only holds its ID and the requested fields, with the display price next to the price.
+func pageResponse(c *gin.Context, page *repositories.ProductPage, fields []string) gin.H {
+	localizeProducts(c, page.Products)
+	var nextCursor interface{}
+	if page.NextCursor != "" {
+		nextCursor = page.NextCursor
+	}
+	return gin.H{"data": projectProducts(page.Products, fields), "next_cursor": nextCursor, "total": page.Total}
+}
+
+// projectProducts keeps the product ID and the given fields of every product, by JSON name.
+func projectProducts(products []productModel.Product, fields []string) interface{} {
+	if len(fields) == 0 {
+		return products
+	}
+	documents := []map[string]json.RawMessage{}
+	for _, product := range products {
+		var document map[string]json.RawMessage
+		encoded, _ := json.Marshal(product)
+		_ = json.Unmarshal(encoded, &document)
+		projected := map[string]json.RawMessage{"product_id": document["product_id"]}
+		for _, field := range fields {
+			projected[field] = document[field]
+			if field == "price" {
+				projected["display_price"] = document["display_price"]
+			}
+		}
+		documents = append(documents, projected)
+	}
+	return documents
+}
+
+// localizeProducts sets the display prices of the products in the currency of the request.
+func localizeProducts(c *gin.Context, products []productModel.Product) {
+	for i := range products {
+		localizeProduct(c, &products[i])
+	}
+}
+
+// localizeProduct sets the display prices of the product in the currency of the request.
+func localizeProduct(c *gin.Context, product *productModel.Product) {
+	currency, rates := helpers.RequestCurrency(c)
+	// The currency of a request always has an exchange rate, so the conversion cannot fail
+	_ = product.Localize(rates, currency)
+}
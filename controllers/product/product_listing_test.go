@@ -0,0 +1,136 @@
+package product
+
+import (
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+// listNames follows the cursors of a listing from its first page and returns the names of the
+// products of every page, failing the test on an error or a change of the total.
+func (f *fixture) listNames(path string, query url.Values) [][]string {
+	f.t.Helper()
+	var pages [][]string
+	var total interface{}
+	for {
+		status, response := f.serve(http.MethodGet, path+"?"+query.Encode(), nil)
+		if status != http.StatusOK {
+			f.t.Fatalf("GET %s?%s status = %d: %v", path, query.Encode(), status, response)
+		}
+		if total != nil && response["total"] != total {
+			f.t.Fatalf("total = %v, was %v on the previous page", response["total"], total)
+		}
+		total = response["total"]
+		var names []string
+		for _, product := range response["data"].([]interface{}) {
+			names = append(names, product.(map[string]interface{})["product_name"].(string))
+		}
+		pages = append(pages, names)
+		cursor, _ := response["next_cursor"].(string)
+		if cursor == "" || len(pages) > 10 {
+			return pages
+		}
+		query.Set("cursor", cursor)
+	}
+}
+
+func TestListingCursorPagination(t *testing.T) {
+	tests := []struct {
+		name  string
+		query url.Values
+		pages string
+	}{
+		{name: "cheapest first", query: url.Values{"sort": {"price"}, "limit": {"2"}}, pages: "Apple Cherry|Banana Date|Elderberry"},
+		{name: "most expensive first", query: url.Values{"sort": {"-price"}, "limit": {"2"}}, pages: "Elderberry Date|Cherry Banana|Apple"},
+		{name: "by name on one page", query: url.Values{"sort": {"name"}, "limit": {"5"}}, pages: "Apple Banana Cherry Date Elderberry"},
+		{name: "offset after the cursor", query: url.Values{"sort": {"name"}, "limit": {"2"}, "offset": {"1"}}, pages: "Banana Cherry|Elderberry"},
+		{name: "by relevance", query: url.Values{"keyword": {"apple date"}, "limit": {"1"}}, pages: "Apple|Date"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			f := newFixture(t)
+			f.router.GET("/product/search", f.productController().SearchProducts)
+			// Banana and Cherry share a price, so they are ordered by ID, in the direction of the sort
+			for _, product := range [][2]string{{"Apple", "1.00"}, {"Cherry", "2.00"}, {"Banana", "2.00"}, {"Date", "4.00"}, {"Elderberry", "5.00"}} {
+				f.product(product[0], product[1], 1)
+			}
+
+			var got []string
+			for _, page := range f.listNames("/product/search", test.query) {
+				got = append(got, strings.Join(page, " "))
+			}
+			if pages := strings.Join(got, "|"); pages != test.pages {
+				t.Errorf("pages = %q, want %q", pages, test.pages)
+			}
+		})
+	}
+}
+
+func TestListingCursorSurvivesInserts(t *testing.T) {
+	f := newFixture(t)
+	f.router.GET("/product/search", f.productController().SearchProducts)
+	for _, product := range [][2]string{{"Apple", "1.00"}, {"Banana", "2.00"}, {"Cherry", "3.00"}} {
+		f.product(product[0], product[1], 1)
+	}
+
+	status, first := f.serve(http.MethodGet, "/product/search?sort=price&limit=2", nil)
+	if status != http.StatusOK {
+		t.Fatalf("status = %d: %v", status, first)
+	}
+	// A product inserted before the cursor must neither show up again nor push Cherry off the next page
+	f.product("Acai", "0.50", 1)
+	status, second := f.serve(http.MethodGet, "/product/search?sort=price&limit=2&cursor="+url.QueryEscape(first["next_cursor"].(string)), nil)
+	if status != http.StatusOK {
+		t.Fatalf("status = %d: %v", status, second)
+	}
+	products := second["data"].([]interface{})
+	if len(products) != 1 || products[0].(map[string]interface{})["product_name"] != "Cherry" || second["next_cursor"] != nil {
+		t.Errorf("second page = %v, want only Cherry", second)
+	}
+}
+
+func TestListingFields(t *testing.T) {
+	f := newFixture(t)
+	f.router.GET("/product/search", f.productController().SearchProducts)
+	f.product("Apple", "1.00", 1)
+
+	status, response := f.serve(http.MethodGet, "/product/search?fields=product_name,price", nil)
+	if status != http.StatusOK {
+		t.Fatalf("status = %d: %v", status, response)
+	}
+	product := response["data"].([]interface{})[0].(map[string]interface{})
+	for _, field := range []string{"product_id", "product_name", "price", "display_price"} {
+		if _, found := product[field]; !found {
+			t.Errorf("projected product %v has no %s", product, field)
+		}
+	}
+	if len(product) != 4 {
+		t.Errorf("projected product %v, want only the ID, the name and the prices", product)
+	}
+}
+
+func TestListingInvalidParameters(t *testing.T) {
+	f := newFixture(t)
+	f.router.GET("/product/search", f.productController().SearchProducts)
+	f.product("Apple", "1.00", 1)
+	f.product("Banana", "2.00", 1)
+	_, page := f.serve(http.MethodGet, "/product/search?sort=price&limit=1", nil)
+	priceCursor := page["next_cursor"].(string)
+
+	for _, query := range []string{
+		"limit=0",
+		"limit=101",
+		"limit=many",
+		"offset=-1",
+		"sort=popularity",
+		"sort=relevance",
+		"cursor=garbage",
+		"sort=name&cursor=" + url.QueryEscape(priceCursor),
+		"fields=secret",
+	} {
+		if status, response := f.serve(http.MethodGet, "/product/search?"+query, nil); status != http.StatusBadRequest {
+			t.Errorf("GET ?%s status = %d, want 400: %v", query, status, response)
+		}
+	}
+}
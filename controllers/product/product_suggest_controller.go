@@ -0,0 +1,60 @@
+package product
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	// DefaultSuggestLimit is the number of completions returned when the limit parameter is omitted.
+	DefaultSuggestLimit = 10
+	// MaxSuggestLimit is the largest number of completions a suggestion can hold.
+	MaxSuggestLimit = 20
+)
+
+var (
+	errNoQueryProvided     = errors.New("No query provided")
+	errInvalidSuggestLimit = errors.New("Invalid limit value, it must be between 1 and 20")
+)
+
+/*
+SuggestProducts helps users while they type a search query.
+
+	It returns the products whose name has a word starting with the query q as completions, names
+	starting with the query first, and under did_you_mean the query with its misspelled words
+	corrected from the words of the product names, or null if every word is known.
+
+Possible Errors:
+  - No query provided: If q is empty.
+  - Invalid limit value: If limit is not between 1 and 20.
+*/
+func (pc *ProductController) SuggestProducts(c *gin.Context) {
+	query := c.Query("q")
+	if query == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": errNoQueryProvided.Error()})
+		return
+	}
+	limit := DefaultSuggestLimit
+	if raw := c.Query("limit"); raw != "" {
+		value, err := strconv.Atoi(raw)
+		if err != nil || value < 1 || value > MaxSuggestLimit {
+			c.JSON(http.StatusBadRequest, gin.H{"error": errInvalidSuggestLimit.Error()})
+			return
+		}
+		limit = value
+	}
+
+	var didYouMean interface{}
+	if corrected, changed := pc.suggester.Correct(query); changed {
+		didYouMean = corrected
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"query":        query,
+		"completions":  pc.suggester.Complete(query, limit),
+		"did_you_mean": didYouMean,
+	})
+}
@@ -0,0 +1,99 @@
+package product
+
+import (
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+// completions returns the names of the completions of the response, in order.
+func completions(response map[string]interface{}) string {
+	var names []string
+	for _, completion := range response["completions"].([]interface{}) {
+		names = append(names, completion.(map[string]interface{})["product_name"].(string))
+	}
+	return strings.Join(names, ", ")
+}
+
+func TestSuggestProducts(t *testing.T) {
+	f := newFixture(t)
+	f.router.POST("/admin/products", f.productController().CreateProduct)
+	f.router.GET("/product/suggest", f.productController().SuggestProducts)
+	for _, name := range []string{"Road running shoe", "Running jacket", "Rain jacket"} {
+		if status, response := f.serve(http.MethodPost, "/admin/products", gin.H{"product_name": name, "price": "50.00"}); status != http.StatusCreated {
+			t.Fatalf("creating %s: status = %d: %v", name, status, response)
+		}
+	}
+
+	tests := []struct {
+		query       string
+		status      int
+		completions string
+		didYouMean  interface{}
+	}{
+		{query: "q=run", status: http.StatusOK, completions: "Running jacket, Road running shoe"},
+		{query: "q=RUNNING+Sh", status: http.StatusOK, completions: "Road running shoe"},
+		{query: "q=jack", status: http.StatusOK, completions: "Rain jacket, Running jacket"},
+		{query: "q=run&limit=1", status: http.StatusOK, completions: "Running jacket"},
+		{query: "q=runing+jacket", status: http.StatusOK, didYouMean: "running jacket"},
+		{query: "q=rain+jakcet", status: http.StatusOK, didYouMean: "rain jacket"},
+		{query: "q=sock", status: http.StatusOK},
+		{query: "q=", status: http.StatusBadRequest},
+		{query: "q=run&limit=0", status: http.StatusBadRequest},
+		{query: "q=run&limit=21", status: http.StatusBadRequest},
+	}
+	for _, test := range tests {
+		status, response := f.serve(http.MethodGet, "/product/suggest?"+test.query, nil)
+		if status != test.status {
+			t.Errorf("GET ?%s status = %d, want %d: %v", test.query, status, test.status, response)
+			continue
+		}
+		if status != http.StatusOK {
+			continue
+		}
+		if got := completions(response); got != test.completions {
+			t.Errorf("GET ?%s completions = %q, want %q", test.query, got, test.completions)
+		}
+		if response["did_you_mean"] != test.didYouMean {
+			t.Errorf("GET ?%s did_you_mean = %v, want %v", test.query, response["did_you_mean"], test.didYouMean)
+		}
+	}
+}
+
+func TestSuggestProductsFollowsTheCatalog(t *testing.T) {
+	f := newFixture(t)
+	f.router.PUT("/admin/products/:id", f.productController().UpdateProduct)
+	f.router.DELETE("/admin/products/:id", f.productController().DeleteProduct)
+	f.router.GET("/product/suggest", f.productController().SuggestProducts)
+	product := f.product("Kettle", "30.00", 1)
+	if err := f.suggester.Index(f.context, *product); err != nil {
+		t.Fatal(err)
+	}
+	suggest := func(query string) string {
+		t.Helper()
+		status, response := f.serve(http.MethodGet, "/product/suggest?q="+url.QueryEscape(query), nil)
+		if status != http.StatusOK {
+			t.Fatalf("status = %d: %v", status, response)
+		}
+		return completions(response)
+	}
+
+	path := "/admin/products/" + product.ProductID.Hex()
+	body := gin.H{"product_name": "Electric kettle", "price": "30.00", "stock": 1, "version": product.Version}
+	if status, response := f.serve(http.MethodPut, path, body); status != http.StatusOK {
+		t.Fatalf("renaming: status = %d: %v", status, response)
+	}
+	if got := suggest("elec"); got != "Electric kettle" {
+		t.Errorf("completions of elec after the rename = %q, want the new name", got)
+	}
+
+	if status, response := f.serve(http.MethodDelete, path, nil); status != http.StatusOK {
+		t.Fatalf("deleting: status = %d: %v", status, response)
+	}
+	if got := suggest("kett"); got != "" {
+		t.Errorf("completions of kett after the delete = %q, want none", got)
+	}
+}
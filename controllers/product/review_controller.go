@@ -0,0 +1,411 @@
+package product
+
+import (
+	"context"
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	productModel "github.com/YassinNouh21/GoShopCart-Ecommerce/models/product"
+	userModel "github.com/YassinNouh21/GoShopCart-Ecommerce/models/user"
+	"github.com/YassinNouh21/GoShopCart-Ecommerce/repositories"
+	"github.com/YassinNouh21/GoShopCart-Ecommerce/search"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-playground/validator/v10"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+var (
+	errUnauthorized         = errors.New("Unauthorized")
+	errInvalidProductID     = errors.New("Invalid product ID")
+	errProductNotFound      = errors.New("Product not found")
+	errInvalidReviewID      = errors.New("Invalid review ID")
+	errReviewNotFound       = errors.New("Review not found")
+	errInvalidReviewInput   = errors.New("Invalid request body")
+	errInvalidReviewSort    = errors.New("Invalid sort, use newest or helpful")
+	errReviewNotAllowed     = errors.New("Only customers who received the product can review it")
+	errAlreadyReviewed      = errors.New("You already reviewed this product, edit your review instead")
+	errNotReviewAuthor      = errors.New("Only the author of the review can change it")
+	errOwnReviewHelpful     = errors.New("You cannot mark your own review as helpful")
+	errAlreadyMarkedHelpful = errors.New("You already marked this review as helpful")
+	errFailedSaveReview     = errors.New("Failed to save review")
+	errFailedFetchReviews   = errors.New("Failed to fetch reviews")
+)
+
+// ReviewController serves the review endpoints and keeps the rating of the products up to date.
+type ReviewController struct {
+	reviews    repositories.ReviewRepository
+	products   repositories.ProductRepository
+	orders     repositories.OrderRepository
+	users      repositories.UserRepository
+	transactor repositories.Transactor
+	suggester  *search.Suggester
+}
+
+// NewReviewController creates a ReviewController from the repositories it reads and writes. The
+// suggester ranks completions by rating, so it is updated whenever the rating of a product changes.
+func NewReviewController(reviews repositories.ReviewRepository, products repositories.ProductRepository, orders repositories.OrderRepository, users repositories.UserRepository, transactor repositories.Transactor, suggester *search.Suggester) *ReviewController {
+	return &ReviewController{reviews: reviews, products: products, orders: orders, users: users, transactor: transactor, suggester: suggester}
+}
+
+// ReviewRequest represents the request body for writing or editing a review.
+type ReviewRequest struct {
+	Rating int    `json:"rating" validate:"required,min=1,max=5"`
+	Title  string `json:"title" validate:"max=200"`
+	Body   string `json:"body" validate:"max=5000"`
+}
+
+/*
+GetReviews returns one page of the reviews of a product.
+
+	The reviews are sorted by the sort parameter: newest (the default) or helpful, which lists the
+	reviews marked helpful by the most customers first. The limit and offset parameters select the
+	page, and total holds the number of reviews of the product.
+
+Possible Errors:
+  - Invalid product ID: If the ID in the path is not a valid ObjectID.
+  - Invalid sort: If sort is not newest or helpful.
+  - Invalid limit value / Invalid offset value: If limit is not between 1 and 100 or offset is negative.
+  - Product not found: If no product with the given ID exists.
+*/
+func (rc *ReviewController) GetReviews(c *gin.Context) {
+	productID, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": errInvalidProductID.Error()})
+		return
+	}
+	sort := repositories.ReviewSort(c.DefaultQuery("sort", string(repositories.ReviewSortNewest)))
+	if !sort.Valid() {
+		c.JSON(http.StatusBadRequest, gin.H{"error": errInvalidReviewSort.Error()})
+		return
+	}
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", strconv.Itoa(DefaultPageLimit)))
+	if err != nil || limit < 1 || limit > MaxPageLimit {
+		c.JSON(http.StatusBadRequest, gin.H{"error": errInvalidLimit.Error()})
+		return
+	}
+	offset, err := strconv.Atoi(c.DefaultQuery("offset", "0"))
+	if err != nil || offset < 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": errInvalidOffset.Error()})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	exists, err := rc.products.Exists(ctx, productID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": errFailedFetchReviews.Error()})
+		return
+	}
+	if !exists {
+		c.JSON(http.StatusNotFound, gin.H{"error": errProductNotFound.Error()})
+		return
+	}
+	reviews, total, err := rc.reviews.FindByProduct(ctx, productID, sort, offset, limit)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": errFailedFetchReviews.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": reviews, "total": total})
+}
+
+/*
+CreateReview adds the review of the authenticated user to a product.
+
+	Only customers with a delivered order containing the product can review it, and only once. The
+	review is stored and the rating of the product recomputed in a single transaction.
+
+Possible Errors:
+  - Unauthorized: If the user is not authenticated.
+  - Invalid product ID: If the ID in the path is not a valid ObjectID.
+  - Invalid request body: If the body is not a valid review.
+  - Product not found: If no product with the given ID exists.
+  - Only customers who received the product can review it: If the user has no delivered order containing it.
+  - You already reviewed this product: If the user has a review of the product.
+*/
+func (rc *ReviewController) CreateReview(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": errUnauthorized.Error()})
+		return
+	}
+	productID, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": errInvalidProductID.Error()})
+		return
+	}
+	request, ok := bindReviewRequest(c)
+	if !ok {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
+	defer cancel()
+
+	if _, err := rc.products.FindByID(ctx, productID); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": errProductNotFound.Error()})
+		return
+	}
+	eligible, err := rc.orders.HasDeliveredProduct(ctx, userID, productID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": errFailedSaveReview.Error()})
+		return
+	}
+	if !eligible {
+		c.JSON(http.StatusForbidden, gin.H{"error": errReviewNotAllowed.Error()})
+		return
+	}
+	author, err := rc.users.FindByID(ctx, userID)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": errUnauthorized.Error()})
+		return
+	}
+
+	now := time.Now().UTC()
+	review := productModel.Review{
+		ReviewID:      primitive.NewObjectID(),
+		ProductID:     productID,
+		UserID:        userID,
+		AuthorName:    author.FirstName,
+		Rating:        request.Rating,
+		Title:         request.Title,
+		Body:          request.Body,
+		HelpfulVoters: []primitive.ObjectID{},
+		CreatedAt:     now,
+		UpdatedAt:     now,
+	}
+	product, err := rc.saveReview(ctx, productID, func(ctx context.Context) error {
+		return rc.reviews.Create(ctx, &review)
+	})
+	if err == repositories.ErrDuplicate {
+		c.JSON(http.StatusConflict, gin.H{"error": errAlreadyReviewed.Error()})
+		return
+	}
+	if !rc.writeSaveError(c, err) {
+		return
+	}
+	c.JSON(http.StatusCreated, gin.H{"message": "Review created successfully", "review": review, "rating": product.Rating, "review_count": product.ReviewCount})
+}
+
+/*
+UpdateReview replaces the rating, title and body of a review written by the authenticated user.
+
+Possible Errors:
+  - Unauthorized: If the user is not authenticated.
+  - Invalid product ID / Invalid review ID: If an ID in the path is not a valid ObjectID.
+  - Invalid request body: If the body is not a valid review.
+  - Review not found: If the review does not exist or is not a review of the product.
+  - Only the author of the review can change it: If the review was written by another user.
+*/
+func (rc *ReviewController) UpdateReview(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": errUnauthorized.Error()})
+		return
+	}
+	request, ok := bindReviewRequest(c)
+	if !ok {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
+	defer cancel()
+
+	review, ok := rc.findReview(c, ctx)
+	if !ok {
+		return
+	}
+	if review.UserID != userID {
+		c.JSON(http.StatusForbidden, gin.H{"error": errNotReviewAuthor.Error()})
+		return
+	}
+
+	review.Rating = request.Rating
+	review.Title = request.Title
+	review.Body = request.Body
+	review.UpdatedAt = time.Now().UTC()
+	product, err := rc.saveReview(ctx, review.ProductID, func(ctx context.Context) error {
+		return rc.reviews.Update(ctx, review)
+	})
+	if !rc.writeSaveError(c, err) {
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Review updated successfully", "review": review, "rating": product.Rating, "review_count": product.ReviewCount})
+}
+
+/*
+DeleteReview deletes a review. Customers can delete their own reviews and admins any review.
+
+Possible Errors:
+  - Unauthorized: If the user is not authenticated.
+  - Invalid product ID / Invalid review ID: If an ID in the path is not a valid ObjectID.
+  - Review not found: If the review does not exist or is not a review of the product.
+  - Only the author of the review can change it: If the review was written by another user and the user is not an admin.
+*/
+func (rc *ReviewController) DeleteReview(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": errUnauthorized.Error()})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
+	defer cancel()
+
+	review, ok := rc.findReview(c, ctx)
+	if !ok {
+		return
+	}
+	if review.UserID != userID && c.GetString("user_type") != string(userModel.RoleAdmin) {
+		c.JSON(http.StatusForbidden, gin.H{"error": errNotReviewAuthor.Error()})
+		return
+	}
+
+	product, err := rc.saveReview(ctx, review.ProductID, func(ctx context.Context) error {
+		return rc.reviews.Delete(ctx, review.ReviewID)
+	})
+	if !rc.writeSaveError(c, err) {
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Review deleted successfully", "rating": product.Rating, "review_count": product.ReviewCount})
+}
+
+/*
+MarkReviewHelpful records that the authenticated user found a review helpful.
+
+	Every customer can mark a review once, and never their own.
+
+Possible Errors:
+  - Unauthorized: If the user is not authenticated.
+  - Invalid product ID / Invalid review ID: If an ID in the path is not a valid ObjectID.
+  - Review not found: If the review does not exist or is not a review of the product.
+  - You cannot mark your own review as helpful: If the user wrote the review.
+  - You already marked this review as helpful: If the user marked it before.
+*/
+func (rc *ReviewController) MarkReviewHelpful(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": errUnauthorized.Error()})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	review, ok := rc.findReview(c, ctx)
+	if !ok {
+		return
+	}
+	if review.UserID == userID {
+		c.JSON(http.StatusBadRequest, gin.H{"error": errOwnReviewHelpful.Error()})
+		return
+	}
+
+	err := rc.reviews.AddHelpfulVote(ctx, review.ReviewID, userID)
+	if err == repositories.ErrNotFound {
+		c.JSON(http.StatusNotFound, gin.H{"error": errReviewNotFound.Error()})
+		return
+	}
+	if err == repositories.ErrDuplicate {
+		c.JSON(http.StatusConflict, gin.H{"error": errAlreadyMarkedHelpful.Error()})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": errFailedSaveReview.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Review marked as helpful"})
+}
+
+// saveReview applies the change to the reviews of the product and stores the new rating of the
+// product in the same transaction, then updates the suggester. It returns the updated product.
+func (rc *ReviewController) saveReview(ctx context.Context, productID primitive.ObjectID, change func(ctx context.Context) error) (*productModel.Product, error) {
+	var product *productModel.Product
+	err := rc.transactor.WithTransaction(ctx, func(ctx context.Context) error {
+		if err := change(ctx); err != nil {
+			return err
+		}
+		summary, err := rc.reviews.Summarize(ctx, productID)
+		if err != nil {
+			return err
+		}
+		product, err = rc.products.UpdateRating(ctx, productID, summary)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	// The rating is already stored, so a failure is only logged like in the product controller
+	if err := rc.suggester.Index(ctx, *product); err != nil {
+		log.Printf("search: index product %s: %v", product.ProductID.Hex(), err)
+	}
+	return product, nil
+}
+
+// writeSaveError writes the response for an error returned by saveReview.
+// It returns true if there was no error.
+func (rc *ReviewController) writeSaveError(c *gin.Context, err error) bool {
+	switch err {
+	case nil:
+		return true
+	case repositories.ErrNotFound:
+		c.JSON(http.StatusNotFound, gin.H{"error": errReviewNotFound.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": errFailedSaveReview.Error()})
+	}
+	return false
+}
+
+// findReview returns the review identified by the path, if it is a review of the product of the path.
+// It writes the error response and returns false otherwise.
+func (rc *ReviewController) findReview(c *gin.Context, ctx context.Context) (*productModel.Review, bool) {
+	productID, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": errInvalidProductID.Error()})
+		return nil, false
+	}
+	reviewID, err := primitive.ObjectIDFromHex(c.Param("review_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": errInvalidReviewID.Error()})
+		return nil, false
+	}
+	review, err := rc.reviews.FindByID(ctx, reviewID)
+	if err == repositories.ErrNotFound || (err == nil && review.ProductID != productID) {
+		c.JSON(http.StatusNotFound, gin.H{"error": errReviewNotFound.Error()})
+		return nil, false
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": errFailedFetchReviews.Error()})
+		return nil, false
+	}
+	return review, true
+}
+
+// bindReviewRequest binds and validates the body of a review.
+// It writes the error response and returns false if the body is invalid.
+func bindReviewRequest(c *gin.Context) (ReviewRequest, bool) {
+	var request ReviewRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": errInvalidReviewInput.Error()})
+		return request, false
+	}
+	if err := validator.New().Struct(request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return request, false
+	}
+	return request, true
+}
+
+// currentUserID returns the ID of the authenticated user.
+func currentUserID(c *gin.Context) (primitive.ObjectID, bool) {
+	userID := c.GetString("user_id")
+	if userID == "" {
+		return primitive.NilObjectID, false
+	}
+	objectID, err := primitive.ObjectIDFromHex(userID)
+	return objectID, err == nil
+}
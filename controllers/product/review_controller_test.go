@@ -0,0 +1,178 @@
+package product
+
+import (
+	"net/http"
+	"testing"
+
+	userModel "github.com/YassinNouh21/GoShopCart-Ecommerce/models/user"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func (f *fixture) reviewController() *ReviewController {
+	return NewReviewController(f.repos.Reviews, f.repos.Products, f.repos.Orders, f.repos.Users, f.repos.Transactor, f.suggester)
+}
+
+// signIn makes the router serve every request as the user that the returned pointer is set to, with
+// its role. It must be called before the routes of the test are registered.
+func (f *fixture) signIn() **userModel.User {
+	current := new(*userModel.User)
+	f.router.Use(func(c *gin.Context) {
+		if *current != nil {
+			c.Set("user_id", (*current).ID.Hex())
+			c.Set("user_type", string((*current).Role))
+		}
+		c.Next()
+	})
+	return current
+}
+
+// customer stores a user with the role.
+func (f *fixture) customer(name string, role userModel.Role) *userModel.User {
+	f.t.Helper()
+	user := &userModel.User{ID: primitive.NewObjectID(), FirstName: name, Email: name + "@example.com", Role: role}
+	if err := f.repos.Users.Create(f.context, user); err != nil {
+		f.t.Fatal(err)
+	}
+	return user
+}
+
+// order stores an order of the user in the status holding one unit of the product.
+func (f *fixture) order(user *userModel.User, productID primitive.ObjectID, status userModel.OrderStatus) {
+	f.t.Helper()
+	order := &userModel.Order{
+		OrderID: primitive.NewObjectID(),
+		UserID:  user.ID,
+		Items:   []userModel.OrderItem{{ProductID: productID, ProductName: "Mug", Quantity: 1}},
+		Status:  status,
+	}
+	if err := f.repos.Orders.Create(f.context, order); err != nil {
+		f.t.Fatal(err)
+	}
+}
+
+func TestCreateReview(t *testing.T) {
+	tests := []struct {
+		name    string
+		status  userModel.OrderStatus
+		other   bool
+		body    interface{}
+		code    int
+		reviews int
+	}{
+		{name: "delivered order", status: userModel.OrderStatusDelivered, body: gin.H{"rating": 4, "title": "Nice"}, code: http.StatusCreated, reviews: 1},
+		{name: "shipped order", status: userModel.OrderStatusShipped, body: gin.H{"rating": 4}, code: http.StatusForbidden},
+		{name: "refunded order", status: userModel.OrderStatusRefunded, body: gin.H{"rating": 4}, code: http.StatusForbidden},
+		{name: "delivered order of another product", status: userModel.OrderStatusDelivered, other: true, body: gin.H{"rating": 4}, code: http.StatusForbidden},
+		{name: "rating above 5", status: userModel.OrderStatusDelivered, body: gin.H{"rating": 6}, code: http.StatusBadRequest},
+		{name: "without rating", status: userModel.OrderStatusDelivered, body: gin.H{"title": "Nice"}, code: http.StatusBadRequest},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			f := newFixture(t)
+			current := f.signIn()
+			f.router.POST("/product/:id/reviews", f.reviewController().CreateReview)
+			mug := f.product("Mug", "10.00", 5)
+			*current = f.customer("alice", userModel.RoleCustomer)
+			orderedID := mug.ProductID
+			if test.other {
+				orderedID = f.product("Plate", "12.00", 5).ProductID
+			}
+			f.order(*current, orderedID, test.status)
+
+			status, response := f.serve(http.MethodPost, "/product/"+mug.ProductID.Hex()+"/reviews", test.body)
+			if status != test.code {
+				t.Fatalf("status = %d, want %d: %v", status, test.code, response)
+			}
+			stored, err := f.repos.Products.FindByID(f.context, mug.ProductID)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if stored.ReviewCount != test.reviews {
+				t.Errorf("review count = %d, want %d", stored.ReviewCount, test.reviews)
+			}
+		})
+	}
+}
+
+func TestReviewLifecycle(t *testing.T) {
+	f := newFixture(t)
+	current := f.signIn()
+	controller := f.reviewController()
+	f.router.POST("/product/:id/reviews", controller.CreateReview)
+	f.router.PUT("/product/:id/reviews/:review_id", controller.UpdateReview)
+	f.router.DELETE("/product/:id/reviews/:review_id", controller.DeleteReview)
+	f.router.POST("/product/:id/reviews/:review_id/helpful", controller.MarkReviewHelpful)
+	mug := f.product("Mug", "10.00", 5)
+	alice, bob := f.customer("alice", userModel.RoleCustomer), f.customer("bob", userModel.RoleCustomer)
+	admin := f.customer("admin", userModel.RoleAdmin)
+	f.order(alice, mug.ProductID, userModel.OrderStatusDelivered)
+	f.order(bob, mug.ProductID, userModel.OrderStatusDelivered)
+	reviews := "/product/" + mug.ProductID.Hex() + "/reviews"
+
+	steps := []struct {
+		name   string
+		user   *userModel.User
+		method string
+		path   func(reviewID string) string
+		body   interface{}
+		code   int
+		rating float64
+		count  int
+	}{
+		{name: "alice reviews", user: alice, method: http.MethodPost, body: gin.H{"rating": 5}, code: http.StatusCreated, rating: 5, count: 1},
+		{name: "alice reviews again", user: alice, method: http.MethodPost, body: gin.H{"rating": 1}, code: http.StatusConflict, rating: 5, count: 1},
+		{name: "bob reviews", user: bob, method: http.MethodPost, body: gin.H{"rating": 2}, code: http.StatusCreated, rating: 3.5, count: 2},
+		{
+			name: "bob edits the review of alice", user: bob, method: http.MethodPut, path: func(id string) string { return "/" + id },
+			body: gin.H{"rating": 1}, code: http.StatusForbidden, rating: 3.5, count: 2,
+		},
+		{
+			name: "alice edits her review", user: alice, method: http.MethodPut, path: func(id string) string { return "/" + id },
+			body: gin.H{"rating": 4}, code: http.StatusOK, rating: 3, count: 2,
+		},
+		{
+			name: "alice marks her review helpful", user: alice, method: http.MethodPost, path: func(id string) string { return "/" + id + "/helpful" },
+			code: http.StatusBadRequest, rating: 3, count: 2,
+		},
+		{
+			name: "bob marks it helpful", user: bob, method: http.MethodPost, path: func(id string) string { return "/" + id + "/helpful" },
+			code: http.StatusOK, rating: 3, count: 2,
+		},
+		{
+			name: "bob marks it helpful twice", user: bob, method: http.MethodPost, path: func(id string) string { return "/" + id + "/helpful" },
+			code: http.StatusConflict, rating: 3, count: 2,
+		},
+		{
+			name: "bob deletes it", user: bob, method: http.MethodDelete, path: func(id string) string { return "/" + id },
+			code: http.StatusForbidden, rating: 3, count: 2,
+		},
+		{
+			name: "an admin deletes it", user: admin, method: http.MethodDelete, path: func(id string) string { return "/" + id },
+			code: http.StatusOK, rating: 2, count: 1,
+		},
+	}
+	var aliceReview string
+	for _, step := range steps {
+		*current = step.user
+		path := reviews
+		if step.path != nil {
+			path += step.path(aliceReview)
+		}
+		status, response := f.serve(step.method, path, step.body)
+		if status != step.code {
+			t.Fatalf("%s: status = %d, want %d: %v", step.name, status, step.code, response)
+		}
+		if review, ok := response["review"].(map[string]interface{}); ok && step.user == alice {
+			aliceReview = review["review_id"].(string)
+		}
+		stored, err := f.repos.Products.FindByID(f.context, mug.ProductID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if float64(stored.Rating) != step.rating || stored.ReviewCount != step.count {
+			t.Errorf("%s: rating %v of %d reviews, want %v of %d", step.name, stored.Rating, stored.ReviewCount, step.rating, step.count)
+		}
+	}
+}
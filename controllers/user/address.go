@@ -3,19 +3,28 @@ package user
 import (
 	"context"
 	"fmt"
-	"github.com/YassinNouh21/GoShopCart-Ecommerce/database"
 	"github.com/YassinNouh21/GoShopCart-Ecommerce/models/user"
+	"github.com/YassinNouh21/GoShopCart-Ecommerce/repositories"
 	"net/http"
 	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/go-playground/validator/v10"
-	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
+// AddressController serves the address endpoints on top of a UserRepository.
+type AddressController struct {
+	users repositories.UserRepository
+}
+
+// NewAddressController creates an AddressController that stores addresses in the given repository.
+func NewAddressController(users repositories.UserRepository) *AddressController {
+	return &AddressController{users: users}
+}
+
 /*
-AddAddressController handles the creation of a new address for a user.
+AddAddress handles the creation of a new address for a user.
 
 	It retrieves the user ID from the request context, retrieves the user from the database,
 	validates the request body, generates a new address ID, updates the user with the new address, and returns a success message.
@@ -26,7 +35,7 @@ Possible Errors:
   - Invalid request body: If the request body is not in the expected format or contains invalid data.
   - Failed to create address: If an error occurs while updating the user with the new address.
 */
-func AddAddressController(c *gin.Context) {
+func (ac *AddressController) AddAddress(c *gin.Context) {
 
 	userID, errBool := c.Get("user_id")
 	if !errBool {
@@ -36,11 +45,10 @@ func AddAddressController(c *gin.Context) {
 	}
 
 	// Get the user from the database
-	var existingUser user.User
-	userID, _ = primitive.ObjectIDFromHex(userID.(string))
+	userObjectID, _ := primitive.ObjectIDFromHex(userID.(string))
 	ctx, cancel := context.WithTimeout(context.TODO(), time.Second*10)
 	defer cancel()
-	err := database.DB.UserCollection.FindOne(ctx, primitive.M{"_id": userID}).Decode(&existingUser)
+	_, err := ac.users.FindByID(ctx, userObjectID)
 	if err != nil {
 		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
 		c.Abort()
@@ -65,13 +73,8 @@ func AddAddressController(c *gin.Context) {
 	}
 	addressID := primitive.NewObjectID()
 	address.AddressID = addressID
-	// create slides array
-	update := bson.M{
-		"$push": bson.M{
-			"address_details": address,
-		},
-	} // Update the user in the database
-	_, err = database.DB.UserCollection.UpdateOne(ctx, primitive.M{"_id": userID}, update)
+	// Update the user in the database
+	err = ac.users.AddAddress(ctx, userObjectID, address)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create address"})
 		return
@@ -81,7 +84,7 @@ func AddAddressController(c *gin.Context) {
 }
 
 /*
-DeleteAllAddressController handles the deletion of all addresses for a user.
+DeleteAllAddress handles the deletion of all addresses for a user.
 	It retrieves the user ID from the request context, retrieves the user from the database,
 	removes all addresses from the user's address details field, updates the user in the database, and returns a success message.
 Possible Errors:
@@ -90,7 +93,7 @@ Possible Errors:
 	- Error deleting address: If an error occurs while deleting the addresses.
 */
 
-func DeleteAllAddressController(c *gin.Context) {
+func (ac *AddressController) DeleteAllAddress(c *gin.Context) {
 	userID, errBool := c.Get("user_id")
 	if !errBool {
 		c.JSON(401, gin.H{"error": "Unauthorized"})
@@ -99,34 +102,28 @@ func DeleteAllAddressController(c *gin.Context) {
 	}
 
 	// Get the user from the database
-	var existingUser user.User
-	userID, _ = primitive.ObjectIDFromHex(userID.(string))
+	userObjectID, _ := primitive.ObjectIDFromHex(userID.(string))
 	ctx, cancel := context.WithTimeout(context.TODO(), time.Second*10)
 	defer cancel()
-	err := database.DB.UserCollection.FindOne(ctx, primitive.M{"_id": userID}).Decode(&existingUser)
+	_, err := ac.users.FindByID(ctx, userObjectID)
 	if err != nil {
 		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
 		c.Abort()
 		return
 	}
-	update := bson.M{"$set": bson.M{"address_details": []user.Address{}}}
-	filter := bson.M{"_id": userID}
 
-	_, err = database.DB.UserCollection.UpdateOne(ctx, filter, update)
+	err = ac.users.DeleteAddresses(ctx, userObjectID)
 	if err != nil {
 		c.JSON(http.StatusNotFound, gin.H{"error": "Error deleting address"})
 		c.Abort()
 		return
 	}
-	err = database.DB.UserCollection.FindOne(ctx, primitive.M{"_id": userID}).Decode(&existingUser)
-
-	// create slides array
 
 	c.JSON(http.StatusOK, gin.H{"message": "All Addresses successfully deleted"})
 }
 
 /*
-DeleteAddressWithIdController handles the deletion of a specific address for a user.
+DeleteAddressWithId handles the deletion of a specific address for a user.
 	It retrieves the user ID from the request context, retrieves the address ID from the request parameters,
 	retrieves the user from the database, removes the matching address from the user's address details field,
 	updates the user in the database, and returns a success message.
@@ -138,7 +135,7 @@ Possible Errors:
 	- Address not found: If the user's address details field does not contain an address with the provided ID.
 */
 
-func DeleteAddressWithIdController(c *gin.Context) {
+func (ac *AddressController) DeleteAddressWithId(c *gin.Context) {
 
 	userID, errBool := c.Get("user_id")
 
@@ -154,7 +151,7 @@ func DeleteAddressWithIdController(c *gin.Context) {
 		return
 	}
 	// Get the user from the database
-	userID, _ = primitive.ObjectIDFromHex(userID.(string))
+	userObjectID, _ := primitive.ObjectIDFromHex(userID.(string))
 
 	ctx, cancel := context.WithTimeout(context.TODO(), time.Second*10)
 	defer cancel()
@@ -164,45 +161,25 @@ func DeleteAddressWithIdController(c *gin.Context) {
 		return
 	}
 
-	// Define the filter to match the user and the address ID
-	filter := bson.M{
-		"_id":                 userID,
-		"address_details._id": addressIDObj,
-	}
-
-	// Define the update to remove the matching address
-	update := bson.M{
-		"$pull": bson.M{
-			"address_details": bson.M{"_id": addressIDObj},
-		},
-	}
-
-	updateResult, err := database.DB.UserCollection.UpdateOne(ctx, filter, update)
-
-	if err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+	// Remove the matching address of the user
+	err = ac.users.DeleteAddress(ctx, userObjectID, addressIDObj)
+	if err == repositories.ErrNotFound {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Address not found"})
 		c.Abort()
 		return
 	}
 	if err != nil {
-
 		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
 		c.Abort()
 		return
 	}
-	if updateResult.MatchedCount == 0 {
-		c.JSON(http.StatusNotFound, gin.H{"error": "Address not found"})
-		c.Abort()
-		return
-	}
-	// create slides array
 	message := fmt.Sprintf("Address with ID %s deleted successfully", addressId)
 
 	c.JSON(http.StatusOK, gin.H{"message": message})
 }
 
 /*
-GetAddressController retrieves all addresses for a user.
+GetAddress retrieves all addresses for a user.
 
 	It retrieves the user ID from the request context, retrieves the user from the database,
 	and returns the user's address details field.
@@ -211,7 +188,7 @@ Possible Errors:
   - Unauthorized: If the user ID is not found in the request context.
   - User not found: If the user with the provided ID is not found in the database.
 */
-func GetAddressController(c *gin.Context) {
+func (ac *AddressController) GetAddress(c *gin.Context) {
 
 	userID, errBool := c.Get("user_id")
 	if !errBool {
@@ -221,23 +198,15 @@ func GetAddressController(c *gin.Context) {
 	}
 
 	// Get the user from the database
-	var existingUser user.User
-	userID, _ = primitive.ObjectIDFromHex(userID.(string))
+	userObjectID, _ := primitive.ObjectIDFromHex(userID.(string))
 	ctx, cancel := context.WithTimeout(context.TODO(), time.Second*10)
 	defer cancel()
-	err := database.DB.UserCollection.FindOne(ctx, primitive.M{"_id": userID}).Decode(&existingUser)
+	existingUser, err := ac.users.FindByID(ctx, userObjectID)
 	if err != nil {
 		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
 		c.Abort()
 		return
 	}
-	if err != nil {
-
-		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
-		c.Abort()
-		return
-	}
-	// create slides array
 
 	c.IndentedJSON(http.StatusOK, gin.H{"message": existingUser.AddressDetails})
 }
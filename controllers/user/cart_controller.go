@@ -4,15 +4,18 @@ import (
 	"context"
 	"errors"
 	"fmt"
-	database "github.com/YassinNouh21/GoShopCart-Ecommerce/database"
+	"github.com/YassinNouh21/GoShopCart-Ecommerce/helpers"
+	"github.com/YassinNouh21/GoShopCart-Ecommerce/models/promotion"
+	"github.com/YassinNouh21/GoShopCart-Ecommerce/models/shipping"
 	"github.com/YassinNouh21/GoShopCart-Ecommerce/models/user"
+	"github.com/YassinNouh21/GoShopCart-Ecommerce/pricing"
+	"github.com/YassinNouh21/GoShopCart-Ecommerce/repositories"
 	"log"
 	"net/http"
 	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/go-playground/validator/v10"
-	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
@@ -34,19 +37,62 @@ var (
 
 	// ErrCartIdNotProvided is returned when the cart ID is not provided in the request body.
 	ErrCartIdNotProvided = errors.New("Cannot provide cartID in the request body")
+
+	// ErrFailedFetchCart is returned when the cart cannot be read or priced.
+	ErrFailedFetchCart = errors.New("Failed to fetch cart")
+
+	// ErrAddressRequired is returned when the shipping options are requested by a user without an address.
+	ErrAddressRequired = errors.New("An address is required to compute the shipping")
+
+	// ErrCartNotDeleted is returned when a cart item cannot be removed.
+	ErrCartNotDeleted = errors.New("Failed to delete cart")
+
+	// ErrInvalidCartOperation is returned when an operation of a bulk cart request lacks a field it needs.
+	ErrInvalidCartOperation = errors.New("Invalid cart operation")
+
+	// ErrCouponNotApplied is returned when removing a coupon the cart does not have.
+	ErrCouponNotApplied = errors.New("Coupon is not applied to the cart")
+
+	// ErrCouponAlreadyApplied is returned when applying a coupon the cart already has.
+	ErrCouponAlreadyApplied = errors.New("Coupon is already applied to the cart")
 )
 
+// CartController serves the cart endpoints on top of the user, product and cart repositories.
+type CartController struct {
+	users      repositories.UserRepository
+	products   repositories.ProductRepository
+	carts      repositories.CartRepository
+	transactor repositories.Transactor
+	pricing    *pricing.Engine
+}
+
+// NewCartController creates a CartController from the repositories it reads and writes, the
+// transactor that applies bulk cart operations atomically and the pricing engine that prices the cart.
+func NewCartController(users repositories.UserRepository, products repositories.ProductRepository, carts repositories.CartRepository, transactor repositories.Transactor, engine *pricing.Engine) *CartController {
+	return &CartController{users: users, products: products, carts: carts, transactor: transactor, pricing: engine}
+}
+
 /*
-	GetCartController returns a cart for the authenticated user.
+	GetCart returns the priced cart of the authenticated user, in the currency of the request.
+
+	Every item comes with the details of its product, its unit price and line total, and the cart with
+	its subtotal, discount, tax, shipping estimate and grand total, computed by the pricing engine that
+	checkout uses. Items that cannot be ordered as they are carry an error. The promotions the cart gets
+	are listed with their discount, and the coupons applied to the cart with why they do not apply, if
+	they no longer do.
+
+	The tax and shipping are computed for the address of the address_id query parameter, or else for
+	the first address of the user, with the shipping method of the shipping_method_id query parameter,
+	or else the cheapest one. Users without an address see the cart without tax and shipping.
 
 	Possible errors:
 		- ErrUnauthorized: if the user is not authenticated
 		- ErrUserNotFound: if the user cannot be found in the database
-		- ErrInvalidCartID: if the cart ID in the request body is invalid
-		- ErrCartNotFound: if the cart cannot be found in the database
+		- ErrAddressNotFound: if address_id is not one of the user's addresses
+		- ErrFailedFetchCart: if the cart cannot be read or priced
 */
 
-func GetCartController(c *gin.Context) {
+func (cc *CartController) GetCart(c *gin.Context) {
 
 	userID, errBool := c.Get("user_id")
 	if !errBool {
@@ -55,32 +101,97 @@ func GetCartController(c *gin.Context) {
 		return
 	}
 
-	// Get the user from the database
-	var existingUser user.User
-	userID, _ = primitive.ObjectIDFromHex(userID.(string))
+	// Get the user's cart from the database
+	userObjectID, _ := primitive.ObjectIDFromHex(userID.(string))
 	ctx, cancel := context.WithTimeout(context.TODO(), time.Second*10)
-	err := database.DB.UserCollection.FindOne(ctx, primitive.M{"_id": userID}).Decode(&existingUser)
 	defer cancel()
-
+	existingUser, err := cc.users.FindByID(ctx, userObjectID)
 	if err != nil {
 		c.JSON(http.StatusNotFound, gin.H{"error": ErrUserNotFound.Error()})
 		c.Abort()
 		return
 	}
-	log.Println("posterror", err, "user", existingUser)
+	address, err := cartAddress(c, existingUser)
 	if err != nil {
-
-		c.JSON(http.StatusNotFound, gin.H{"error": ErrUserNotFound.Error()})
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		c.Abort()
+		return
+	}
+	coupons, err := cc.carts.GetCoupons(ctx, userObjectID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": ErrFailedFetchCart.Error()})
+		c.Abort()
+		return
+	}
+	quote, err := cc.priceCart(ctx, c, existingUser, address, coupons)
+	if err != nil {
+		log.Println("price cart", userObjectID.Hex(), ":", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": ErrFailedFetchCart.Error()})
 		c.Abort()
 		return
 	}
-	// create slides array
 
-	c.IndentedJSON(http.StatusOK, gin.H{"message": existingUser.UserCart})
+	c.IndentedJSON(http.StatusOK, quote)
 }
 
-func AddCartController(c *gin.Context) {
+// priceCart prices the cart of the user with the coupons, in the currency of the request and with the
+// tax and shipping of the address, if any, and of the shipping method of the request.
+func (cc *CartController) priceCart(ctx context.Context, c *gin.Context, account *user.User, address *user.Address, coupons []string) (*pricing.Quote, error) {
+	items, err := cc.carts.GetItems(ctx, account.ID)
+	if err != nil {
+		return nil, err
+	}
+	currency, rates := helpers.RequestCurrency(c)
+	return cc.pricing.Quote(ctx, pricing.Request{
+		Items:            items,
+		Currency:         currency,
+		Rates:            rates,
+		UserID:           account.ID,
+		Coupons:          coupons,
+		Address:          address,
+		TaxExempt:        account.TaxExempt,
+		ShippingMethodID: shippingMethodID(c.Query("shipping_method_id")),
+	})
+}
+
+// cartAddress returns the address the cart is taxed and shipped to: the one of the address_id query
+// parameter, or else the first address of the user, or nil if the user has none.
+func cartAddress(c *gin.Context, account *user.User) (*user.Address, error) {
+	if value := c.Query("address_id"); value != "" {
+		addressID, err := primitive.ObjectIDFromHex(value)
+		if err != nil {
+			return nil, ErrAddressNotFound
+		}
+		address, found := findAddress(account.AddressDetails, addressID)
+		if !found {
+			return nil, ErrAddressNotFound
+		}
+		return &address, nil
+	}
+	if len(account.AddressDetails) == 0 {
+		return nil, nil
+	}
+	return &account.AddressDetails[0], nil
+}
+
+/*
+	GetShippingOptions returns the shipping methods available for the cart of the authenticated user
+	at an address, with their cost, cheapest first, in the currency of the request.
+
+	The address is the one of the address_id query parameter, or else the first address of the user.
+	The costs take the subtotal and weight of the cart into account, and the method the cart ships
+	with is the one of the shipping_method_id query parameter, or else the cheapest. When a promotion
+	gives free shipping, the shipping of the cart is zero whatever the method.
+
+	Possible errors:
+		- ErrUnauthorized: if the user is not authenticated
+		- ErrUserNotFound: if the user cannot be found in the database
+		- ErrAddressNotFound: if address_id is not one of the user's addresses
+		- ErrAddressRequired: if the user has no address
+		- ErrFailedFetchCart: if the cart cannot be read or priced
+*/
 
+func (cc *CartController) GetShippingOptions(c *gin.Context) {
 	userID, errBool := c.Get("user_id")
 	if !errBool {
 		c.JSON(401, gin.H{"error": ErrUnauthorized.Error()})
@@ -88,19 +199,201 @@ func AddCartController(c *gin.Context) {
 		return
 	}
 
-	// Get the user from the database
-	var existingUser user.User
-	userID, _ = primitive.ObjectIDFromHex(userID.(string))
+	userObjectID, _ := primitive.ObjectIDFromHex(userID.(string))
 	ctx, cancel := context.WithTimeout(context.TODO(), time.Second*10)
 	defer cancel()
-	err := database.DB.UserCollection.FindOne(ctx, primitive.M{"_id": userID}).Decode(&existingUser)
+	existingUser, err := cc.users.FindByID(ctx, userObjectID)
 	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": ErrUserNotFound.Error()})
+		return
+	}
+	address, err := cartAddress(c, existingUser)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if address == nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": ErrAddressRequired.Error()})
+		return
+	}
+	coupons, err := cc.carts.GetCoupons(ctx, userObjectID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": ErrFailedFetchCart.Error()})
+		return
+	}
+	quote, err := cc.priceCart(ctx, c, existingUser, address, coupons)
+	if err != nil {
+		log.Println("price cart", userObjectID.Hex(), ":", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": ErrFailedFetchCart.Error()})
+		return
+	}
+
+	options := quote.ShippingOptions
+	if options == nil {
+		options = []shipping.Option{}
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"address_id":       address.AddressID,
+		"weight":           quote.Weight,
+		"shipping_options": options,
+		"shipping_method":  quote.ShippingMethod,
+		"shipping":         quote.Shipping,
+		"free_shipping":    quote.FreeShipping,
+		"shipping_error":   quote.ShippingError,
+	})
+}
+
+// CouponRequest represents the request body of the coupon endpoint.
+type CouponRequest struct {
+	Code   string `json:"code" validate:"required"`
+	Remove bool   `json:"remove"`
+}
+
+/*
+	ApplyCoupon applies a coupon code to the cart of the authenticated user, or removes it when the
+	request sets remove, and returns the cart priced with it like GetCart, with the tax of the same
+	address.
+
+	Codes are not case sensitive. A coupon is only added to the cart if it discounts it right away:
+	otherwise the response gives the reason, such as a coupon that expired, reached its usage limit,
+	was already used by the customer, needs a larger subtotal, does not cover any item of the cart or
+	does not combine with the promotions the cart already gets. Coupons stay on the cart until checkout
+	or until they are removed.
+
+	Possible errors:
+		- ErrUnauthorized: if the user is not authenticated
+		- ErrUserNotFound: if the user cannot be found in the database
+		- ErrInvalidRequest: if the request body is invalid
+		- ErrAddressNotFound: if address_id is not one of the user's addresses
+		- ErrCouponNotApplied: if the coupon to remove is not applied to the cart
+		- ErrCouponAlreadyApplied: if the coupon to apply is already applied to the cart
+		- ErrCouponNotFound: if no coupon has the code (404)
+		- The reason the coupon does not apply to the cart (422)
+		- ErrFailedUpdate: if the coupons of the cart cannot be stored
+		- ErrFailedFetchCart: if the cart cannot be read or priced
+*/
+
+func (cc *CartController) ApplyCoupon(c *gin.Context) {
+	userID, errBool := c.Get("user_id")
+	if !errBool {
+		c.JSON(401, gin.H{"error": ErrUnauthorized.Error()})
+		c.Abort()
+		return
+	}
+
+	var request CouponRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": ErrInvalidRequest.Error()})
+		return
+	}
+	if err := validator.New().Struct(request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	code := promotion.NormalizeCode(request.Code)
 
+	userObjectID, _ := primitive.ObjectIDFromHex(userID.(string))
+	ctx, cancel := context.WithTimeout(context.TODO(), time.Second*10)
+	defer cancel()
+	existingUser, err := cc.users.FindByID(ctx, userObjectID)
+	if err != nil {
 		c.JSON(http.StatusNotFound, gin.H{"error": ErrUserNotFound.Error()})
+		return
+	}
+	address, err := cartAddress(c, existingUser)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	coupons, err := cc.carts.GetCoupons(ctx, userObjectID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": ErrFailedFetchCart.Error()})
+		return
+	}
+	applied := false
+	others := []string{}
+	for _, coupon := range coupons {
+		if coupon == code {
+			applied = true
+		} else {
+			others = append(others, coupon)
+		}
+	}
+
+	if request.Remove {
+		if !applied {
+			c.JSON(http.StatusNotFound, gin.H{"error": ErrCouponNotApplied.Error()})
+			return
+		}
+		if err := cc.carts.SetCoupons(ctx, userObjectID, others); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": ErrFailedUpdate.Error()})
+			return
+		}
+		quote, err := cc.priceCart(ctx, c, existingUser, address, others)
+		if err != nil {
+			log.Println("price cart", userObjectID.Hex(), ":", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": ErrFailedFetchCart.Error()})
+			return
+		}
+		c.JSON(http.StatusOK, quote)
+		return
+	}
+
+	if applied {
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": ErrCouponAlreadyApplied.Error()})
+		return
+	}
+	coupons = append(coupons, code)
+	quote, err := cc.priceCart(ctx, c, existingUser, address, coupons)
+	if err != nil {
+		log.Println("price cart", userObjectID.Hex(), ":", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": ErrFailedFetchCart.Error()})
+		return
+	}
+	if problem := quote.Coupon(code).Err(); problem != nil {
+		status := http.StatusUnprocessableEntity
+		if errors.Is(problem, pricing.ErrCouponNotFound) {
+			status = http.StatusNotFound
+		}
+		c.JSON(status, gin.H{"error": problem.Error()})
+		return
+	}
+	if err := cc.carts.SetCoupons(ctx, userObjectID, coupons); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": ErrFailedUpdate.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, quote)
+}
+
+/*
+	AddCart adds a product, or a variant of it, to the cart of the authenticated user.
+	If the same product and variant is already in the cart, its quantity is increased instead.
+	The resulting quantity cannot exceed the stock of the product or variant.
+
+	Possible errors:
+		- ErrUnauthorized: if the user is not authenticated
+		- ErrProductNotFound: if the product does not exist
+		- ErrVariantRequired / ErrVariantNotFound: if the variant is missing for a product with variants or unknown
+		- ErrInsufficientStock: if the product does not have enough stock
+		- ErrFailedUpdate: if the quantity of the existing cart item cannot be increased
+		- ErrCartNotCreate: if the cart item cannot be created
+*/
+
+func (cc *CartController) AddCart(c *gin.Context) {
+
+	userID, errBool := c.Get("user_id")
+	if !errBool {
+		c.JSON(401, gin.H{"error": ErrUnauthorized.Error()})
 		c.Abort()
 		return
 	}
 
+	// The cart is stored apart from the user, which the authentication middleware already loaded
+	userObjectID, _ := primitive.ObjectIDFromHex(userID.(string))
+	ctx, cancel := context.WithTimeout(context.TODO(), time.Second*10)
+	defer cancel()
+
 	var cart user.Cart
 
 	if err := c.ShouldBindJSON(&cart); err != nil {
@@ -108,15 +401,19 @@ func AddCartController(c *gin.Context) {
 		c.Abort()
 		return
 	}
+	// The server assigns the cart ID and timestamps, so set them before validating
+	cart.CartID = primitive.NewObjectID()
+	cart.CreatedAt = time.Now().UTC()
+	cart.UpdatedAt = cart.CreatedAt
 	validator := validator.New()
-	err = validator.Struct(&cart)
+	err := validator.Struct(&cart)
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		c.Abort()
 		return
 	}
-	count, err := database.DB.ProductCollection.CountDocuments(ctx, bson.M{"_id": cart.ProductID})
-	if count == 0 {
+	product, err := cc.products.FindByID(ctx, cart.ProductID)
+	if err == repositories.ErrNotFound {
 		c.JSON(http.StatusBadRequest, gin.H{"error": ErrProductNotFound.Error()})
 		c.Abort()
 		return
@@ -126,59 +423,51 @@ func AddCartController(c *gin.Context) {
 		c.Abort()
 		return
 	}
-	filterSearchProductIdCart := bson.M{
-		"_id":                  userID,
-		"user_cart.product_id": cart.ProductID,
+	variant, err := product.ResolveVariant(cart.VariantID)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		c.Abort()
+		return
 	}
 	// check if the product is already in the cart
-	count, err = database.DB.UserCollection.CountDocuments(ctx, filterSearchProductIdCart)
-	log.Println("number in cart", err, "cart", count)
-	if count > 0 {
-		// increase the quantity and update the cart
-		filterSearchProductIdCart := bson.M{
-			"_id":                  userID,
-			"user_cart.product_id": cart.ProductID,
-		}
-		updateIncrement := bson.M{
-			"$inc": bson.M{
-				"user_cart.$.quantity": cart.Quantity,
-			},
-			"$set": bson.M{
-				"user_cart.$.updated_at": time.Now().UTC(),
-			},
+	items, err := cc.carts.GetItems(ctx, userObjectID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		c.Abort()
+		return
+	}
+	inCart, quantity := false, cart.Quantity
+	for _, item := range items {
+		if item.Holds(cart.ProductID, cart.VariantID) {
+			inCart = true
+			quantity += item.Quantity
 		}
-		updated, err := database.DB.UserCollection.UpdateOne(ctx, filterSearchProductIdCart, updateIncrement)
-
-		if err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+	if quantity > product.StockOf(variant) {
+		c.JSON(http.StatusConflict, gin.H{"error": ErrInsufficientStock.Error()})
+		c.Abort()
+		return
+	}
+	if inCart {
+		// increase the quantity and update the cart
+		err := cc.carts.IncrementQuantity(ctx, userObjectID, cart.ProductID, cart.VariantID, cart.Quantity)
+		if err == repositories.ErrNotFound {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": ErrFailedUpdate.Error()})
 			c.Abort()
 			return
 		}
-
-		if updated.MatchedCount == 0 {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": ErrFailedUpdate.Error()})
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 			c.Abort()
 			return
 		}
 		c.JSON(http.StatusOK, gin.H{"message": "Cart updated successfully"})
 	} else {
-
-		log.Println("error", err, "cart", cart)
-		cartID := primitive.NewObjectID()
-		cart.CartID = cartID
-		cart.CreatedAt = time.Now().UTC()
-		cart.UpdatedAt = time.Now().UTC()
-
-		// create slides array
-		update := bson.M{
-			"$push": bson.M{
-				"user_cart": &cart,
-			},
-		} // Update the user in the database
-		_, err = database.DB.UserCollection.UpdateOne(ctx, primitive.M{"_id": &userID}, update)
+		// Add the item to the user's cart
+		err = cc.carts.AddItem(ctx, userObjectID, cart)
 		if err != nil {
-			log.Println("posterror", err, "address", cart)
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create cart"})
+			log.Println("add cart item", userObjectID.Hex(), ":", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": ErrCartNotCreate.Error()})
 			return
 		}
 		message := fmt.Sprintf("Cart with ID %s created successfully", cart.CartID.Hex())
@@ -186,7 +475,14 @@ func AddCartController(c *gin.Context) {
 	}
 }
 
-func DeleteAllCartController(c *gin.Context) {
+/*
+	DeleteAllCart removes every item from the cart of the authenticated user.
+
+	Possible errors:
+		- ErrUnauthorized: if the user is not authenticated
+*/
+
+func (cc *CartController) DeleteAllCart(c *gin.Context) {
 
 	userID, errBool := c.Get("user_id")
 	if !errBool {
@@ -195,102 +491,87 @@ func DeleteAllCartController(c *gin.Context) {
 		return
 	}
 
-	// Get the user from the database
-	var existingUser user.User
-	userID, _ = primitive.ObjectIDFromHex(userID.(string))
+	userObjectID, _ := primitive.ObjectIDFromHex(userID.(string))
 	ctx, cancel := context.WithTimeout(context.TODO(), time.Second*10)
 	defer cancel()
-	err := database.DB.UserCollection.FindOne(ctx, primitive.M{"_id": userID}).Decode(&existingUser)
+
+	err := cc.carts.Clear(ctx, userObjectID)
 	if err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": ErrUserNotFound.Error()})
+		c.JSON(http.StatusNotFound, gin.H{"error": "Error deleting carts"})
 		c.Abort()
 		return
 	}
-	log.Println("posterror", err, "user", existingUser)
-	update := bson.M{"$set": bson.M{"user_cart": []user.Cart{}}}
-	filter := bson.M{"_id": userID}
 
-	_, err = database.DB.UserCollection.UpdateOne(ctx, filter, update)
+	c.JSON(http.StatusOK, gin.H{"message": "All carts are successfully deleted"})
+}
+
+/*
+	DeleteCartWithId removes a cart item of the authenticated user.
+
+	Possible errors:
+		- ErrInvalidCardId: if the cart ID in the path is invalid
+		- ErrUnauthorized: if the user is not authenticated
+		- ErrCartNotFound: if the cart item cannot be found
+		- ErrCartNotDeleted: if the cart item cannot be removed
+*/
+
+func (cc *CartController) DeleteCartWithId(c *gin.Context) {
+	cartIdObj, err := primitive.ObjectIDFromHex(c.Param("cart_id"))
 	if err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "Error deleting carts"})
+		c.JSON(http.StatusBadRequest, gin.H{"error": ErrInvalidCardId.Error()})
+		return
+	}
+	userID, errBool := c.Get("user_id")
+	if !errBool {
+		c.JSON(401, gin.H{"error": ErrUnauthorized.Error()})
 		c.Abort()
 		return
 	}
 
-	// create slides array
+	userObjectID, _ := primitive.ObjectIDFromHex(userID.(string))
+	ctx, cancel := context.WithTimeout(context.TODO(), time.Second*10)
+	defer cancel()
 
-	c.JSON(http.StatusOK, gin.H{"message": "All carts are successfully deleted"})
+	cc.removeItem(ctx, c, userObjectID, cartIdObj)
+}
+
+// removeItem removes the cart item from the cart of the user and writes the response.
+func (cc *CartController) removeItem(ctx context.Context, c *gin.Context, userID primitive.ObjectID, cartID primitive.ObjectID) {
+	err := cc.carts.RemoveItem(ctx, userID, cartID)
+	if err == repositories.ErrNotFound {
+		c.JSON(http.StatusNotFound, gin.H{"error": ErrCartNotFound.Error()})
+		return
+	}
+	if err != nil {
+		log.Println("remove cart item", cartID.Hex(), ":", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": ErrCartNotDeleted.Error()})
+		return
+	}
+	message := fmt.Sprintf("Cart with ID %s deleted successfully", cartID.Hex())
+	c.JSON(http.StatusOK, gin.H{"message": message})
 }
 
-// func DeleteAddressWithIdController(c *gin.Context) {
-
-// 	userID, errBool := c.Get("user_id")
-
-// 	addressId := c.Param("address_id")
-// 	log.Println("addressId", addressId, c.Params)
-// 	if !errBool {
-// 		c.JSON(401, gin.H{"error": "Unauthorized"})
-// 		c.Abort()
-// 		return
-// 	}
-// 	if addressId == "" {
-// 		c.JSON(401, gin.H{"error": "There is no address id provided"})
-// 		c.Abort()
-// 		return
-// 	}
-// 	// Get the user from the database
-// 	var existingUser user.User
-// 	userID, _ = primitive.ObjectIDFromHex(userID.(string))
-
-// 	ctx, cancel := context.WithTimeout(context.TODO(), time.Second*10)
-// 	defer cancel()
-// 	addressIDObj, err := primitive.ObjectIDFromHex(addressId)
-// 	if err != nil {
-// 		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid address ID"})
-// 		return
-// 	}
-
-// 	// Define the filter to match the user and the address ID
-// 	filter := bson.M{
-// 		"_id":                userID,
-// 		"addressdetails._id": addressIDObj,
-// 	}
-
-// 	// Define the update to remove the matching address
-// 	update := bson.M{
-// 		"$pull": bson.M{
-// 			"addressdetails": bson.M{"_id": addressIDObj},
-// 		},
-// 	}
-
-// 	updateResult, err := database.DB.UserCollection.UpdateOne(ctx, filter, update)
-
-// 	if err != nil {
-// 		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
-// 		c.Abort()
-// 		return
-// 	}
-// 	log.Println("posterror", err, "user", existingUser)
-// 	if err != nil {
-
-// 		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
-// 		c.Abort()
-// 		return
-// 	}
-// 	if updateResult.MatchedCount == 0 {
-// 		c.JSON(http.StatusNotFound, gin.H{"error": "Address not found"})
-// 		c.Abort()
-// 		return
-// 	}
-// 	// create slides array
-// 	message := fmt.Sprintf("Address with ID %s deleted successfully", addressId)
-
-//		c.JSON(http.StatusOK, gin.H{"message": message})
-//	}
-func UpdateCartController(c *gin.Context) {
+/*
+	UpdateCart replaces a cart item of the authenticated user, or removes it when the quantity is zero.
+	An item switched to a product and variant another item holds is merged into that item, whose ID the
+	response gives, like BulkUpdateCart. The resulting quantity cannot exceed the stock of the product or
+	variant.
+
+	Possible errors:
+		- ErrInvalidCardId: if the cart ID in the path is invalid
+		- ErrUnauthorized: if the user is not authenticated
+		- ErrCartIdNotProvided: if the request body contains a cart ID
+		- ErrCartNotFound: if the cart item cannot be found
+		- ErrProductNotFound: if the product does not exist
+		- ErrVariantRequired / ErrVariantNotFound: if the variant is missing for a product with variants or unknown
+		- ErrInsufficientStock: if the product does not have enough stock
+		- ErrCartNotDeleted: if the cart item cannot be removed
+		- ErrCartNotUpdated: if the cart item cannot be stored
+*/
+
+func (cc *CartController) UpdateCart(c *gin.Context) {
 	userID, errBool := c.Get("user_id")
 	cartId := c.Param("cart_id")
-	log.Println("cartId", cartId, c.Params)
 	cartIdObj, err := primitive.ObjectIDFromHex(cartId)
 
 	if err != nil {
@@ -303,17 +584,9 @@ func UpdateCartController(c *gin.Context) {
 		return
 	}
 
-	// Get the user from the database
-	var existingUser user.User
-	userID, _ = primitive.ObjectIDFromHex(userID.(string))
+	userObjectID, _ := primitive.ObjectIDFromHex(userID.(string))
 	ctx, cancel := context.WithTimeout(context.TODO(), time.Second*10)
 	defer cancel()
-	err = database.DB.UserCollection.FindOne(ctx, primitive.M{"_id": userID}).Decode(&existingUser)
-	if err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": ErrUserNotFound.Error()})
-		c.Abort()
-		return
-	}
 
 	var cart user.CartWithoutId
 
@@ -326,6 +599,28 @@ func UpdateCartController(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, gin.H{"error": ErrCartIdNotProvided.Error()})
 		return
 	}
+	// check if cart exist
+	items, err := cc.carts.GetItems(ctx, userObjectID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": ErrCartNotUpdated.Error()})
+		c.Abort()
+		return
+	}
+	found := false
+	for _, item := range items {
+		if item.CartID == cartIdObj {
+			found = true
+			cart.CreatedAt = item.CreatedAt
+		}
+	}
+	if !found {
+		c.JSON(http.StatusBadRequest, gin.H{"error": ErrCartNotFound.Error()})
+		c.Abort()
+		return
+	}
+	// The server keeps the creation time of the item and sets its update time
+	cart.CartID = cartIdObj
+	cart.UpdatedAt = time.Now().UTC()
 	validator := validator.New()
 	err = validator.Struct(&cart)
 	if err != nil {
@@ -333,43 +628,298 @@ func UpdateCartController(c *gin.Context) {
 		c.Abort()
 		return
 	}
-	// check if cart exist
-
-	filterIdCart := bson.M{
-		"_id":           &userID,
-		"user_cart._id": &cartIdObj,
+	if cart.Quantity == 0 {
+		cc.removeItem(ctx, c, userObjectID, cartIdObj)
+		return
 	}
-	log.Println("filterIdCart", filterIdCart, "updateCartWithID", filterIdCart)
+	product, err := cc.products.FindByID(ctx, cart.ProductID)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": ErrProductNotFound.Error()})
+		c.Abort()
+		return
+	}
+	variant, err := product.ResolveVariant(cart.VariantID)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		c.Abort()
+		return
+	}
+	stock := product.StockOf(variant)
 
-	count, err := database.DB.UserCollection.CountDocuments(ctx, &filterIdCart)
-	if count == 0 {
+	// The item holding the new quantity, which is another item when the update is merged into it
+	var updated user.Cart
+	err = cc.transactor.WithTransaction(ctx, func(ctx context.Context) error {
+		items, err := cc.carts.GetItems(ctx, userObjectID)
+		if err != nil {
+			return err
+		}
+		j := -1
+		for i, item := range items {
+			if item.CartID == cartIdObj {
+				j = i
+			}
+		}
+		if j < 0 {
+			return ErrCartNotFound
+		}
+		items, j = updateItem(items, j, cart.ProductID, cart.VariantID, cart.Quantity, cart.UpdatedAt)
+		if items[j].Quantity > stock {
+			return ErrInsufficientStock
+		}
+		updated = items[j]
+		return cc.carts.SetItems(ctx, userObjectID, items)
+	})
+	if err == ErrCartNotFound {
 		c.JSON(http.StatusBadRequest, gin.H{"error": ErrCartNotFound.Error()})
+		return
+	}
+	if err == ErrInsufficientStock {
+		c.JSON(http.StatusConflict, gin.H{"error": ErrInsufficientStock.Error()})
+		return
+	}
+	if err != nil {
+		log.Println("update cart item", userObjectID.Hex(), ":", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": ErrCartNotUpdated.Error()})
+		return
+	}
+	message := fmt.Sprintf("Cart with ID %s updated successfully", updated.CartID.Hex())
+	c.JSON(http.StatusOK, gin.H{"message": message})
+}
+
+/*
+	CartOperation is an operation of a bulk cart request.
+
+	Fields:
+	- Op: The operation: add, update or remove.
+	- CartID: The cart item to update or remove.
+	- ProductID: The product to add, or the product an updated item switches to, with VariantID.
+	- VariantID: The selected variant, for products with variants.
+	- Quantity: The quantity to add, or the new quantity of an updated item. Updating an item to zero
+	  removes it.
+*/
+
+type CartOperation struct {
+	Op        string              `json:"op" validate:"required,oneof=add update remove"`
+	CartID    *primitive.ObjectID `json:"cart_id"`
+	ProductID *primitive.ObjectID `json:"product_id"`
+	VariantID *primitive.ObjectID `json:"variant_id"`
+	Quantity  int                 `json:"quantity" validate:"gte=0"`
+}
+
+// BulkCartRequest represents the request body of the bulk cart endpoint. It holds at most 100
+// operations.
+type BulkCartRequest struct {
+	Operations []CartOperation `json:"operations" validate:"required,min=1,max=100,dive"`
+}
+
+// cartOperationError is the error of the operation of a bulk cart request that cannot be applied,
+// with the status of the response.
+type cartOperationError struct {
+	index  int
+	status int
+	err    error
+}
+
+func (e *cartOperationError) Error() string {
+	return fmt.Sprintf("operation %d: %v", e.index, e.err)
+}
+
+/*
+	BulkUpdateCart applies a list of add, update and remove operations to the cart of the authenticated
+	user in one request, and returns the cart priced like GetCart.
+
+	The operations apply in order and atomically: if one of them cannot be applied, the cart is left as
+	it was and the response gives the error with the index of the operation. An add increases the
+	quantity of the item already holding the product and variant, like AddCart, and so does an update
+	switching an item to it, which removes the switched item. An update to a quantity of zero removes
+	the item. The resulting quantities cannot exceed the stock of their product or variant.
+
+	Possible errors:
+		- ErrUnauthorized: if the user is not authenticated
+		- ErrUserNotFound: if the user cannot be found in the database
+		- ErrInvalidRequest: if the request body is invalid
+		- ErrAddressNotFound: if address_id is not one of the user's addresses
+		- ErrInvalidCartOperation: if an operation lacks its cart_id, product_id or quantity
+		- ErrCartNotFound: if an operation names a cart item that is not in the cart
+		- ErrProductNotFound: if a product does not exist
+		- ErrVariantRequired / ErrVariantNotFound: if a variant is missing for a product with variants or unknown
+		- ErrInsufficientStock: if a product does not have enough stock
+		- ErrFailedUpdate: if the cart cannot be stored
+		- ErrFailedFetchCart: if the cart cannot be priced
+*/
+
+func (cc *CartController) BulkUpdateCart(c *gin.Context) {
+	userID, errBool := c.Get("user_id")
+	if !errBool {
+		c.JSON(401, gin.H{"error": ErrUnauthorized.Error()})
 		c.Abort()
 		return
 	}
-	// chekc if the product is already in the cart
 
-	log.Println("error", err, "cart", cart)
-	cart.UpdatedAt = time.Now().UTC()
+	var request BulkCartRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": ErrInvalidRequest.Error()})
+		return
+	}
+	if err := validator.New().Struct(request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
 
-	// create slides array except the _id
-	cart.CartID = cartIdObj
-	updateCartWithID := bson.M{
-		"$set": bson.M{
-			"user_cart.$": &cart,
-		},
+	userObjectID, _ := primitive.ObjectIDFromHex(userID.(string))
+	ctx, cancel := context.WithTimeout(context.TODO(), time.Second*10)
+	defer cancel()
+	existingUser, err := cc.users.FindByID(ctx, userObjectID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": ErrUserNotFound.Error()})
+		return
 	}
-	updated, err := database.DB.UserCollection.UpdateOne(ctx, filterIdCart, updateCartWithID)
-	if updated.MatchedCount == 0 {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": ErrCartNotUpdated.Error()})
-		c.Abort()
+	address, err := cartAddress(c, existingUser)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	now := time.Now().UTC()
+	err = cc.transactor.WithTransaction(ctx, func(ctx context.Context) error {
+		items, err := cc.carts.GetItems(ctx, userObjectID)
+		if err != nil {
+			return err
+		}
+		items, err = cc.applyOperations(ctx, items, request.Operations, now)
+		if err != nil {
+			return err
+		}
+		return cc.carts.SetItems(ctx, userObjectID, items)
+	})
+	var operationErr *cartOperationError
+	if errors.As(err, &operationErr) {
+		c.JSON(operationErr.status, gin.H{"error": operationErr.err.Error(), "operation": operationErr.index})
 		return
 	}
 	if err != nil {
-		log.Println("posterror", err, "address", cart)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": ErrCartNotCreate.Error()})
+		log.Println("bulk cart", userObjectID.Hex(), ":", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": ErrFailedUpdate.Error()})
 		return
 	}
-	message := fmt.Sprintf("Cart with ID %s updated successfully", cart.CartID.Hex())
-	c.JSON(http.StatusOK, gin.H{"message": message})
+
+	coupons, err := cc.carts.GetCoupons(ctx, userObjectID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": ErrFailedFetchCart.Error()})
+		return
+	}
+	quote, err := cc.priceCart(ctx, c, existingUser, address, coupons)
+	if err != nil {
+		log.Println("price cart", userObjectID.Hex(), ":", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": ErrFailedFetchCart.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, quote)
+}
+
+// updateItem sets the product, variant and quantity of the item at index j of the cart items. An item
+// switched to a product and variant another item holds is merged into that item, so the cart keeps one
+// item per product and variant like AddCart. It returns the resulting items and the index of the item
+// holding the quantity, whose stock the caller checks.
+func updateItem(items []user.Cart, j int, productID primitive.ObjectID, variantID *primitive.ObjectID, quantity int, now time.Time) ([]user.Cart, int) {
+	result := append([]user.Cart{}, items...)
+	for k, item := range result {
+		if k == j || !item.Holds(productID, variantID) {
+			continue
+		}
+		result[k].Quantity += quantity
+		result[k].UpdatedAt = now
+		result = append(result[:j:j], result[j+1:]...)
+		if k > j {
+			k--
+		}
+		return result, k
+	}
+	result[j].ProductID = productID
+	result[j].VariantID = variantID
+	result[j].Quantity = quantity
+	result[j].UpdatedAt = now
+	return result, j
+}
+
+// applyOperations applies the operations to the cart items in order and checks the stock of the items
+// they leave changed. It returns the resulting items, or a *cartOperationError naming the first
+// operation that cannot be applied.
+func (cc *CartController) applyOperations(ctx context.Context, items []user.Cart, operations []CartOperation, now time.Time) ([]user.Cart, error) {
+	result := append([]user.Cart{}, items...)
+	// changed maps the cart ID of every item left changed to the last operation changing it
+	changed := map[primitive.ObjectID]int{}
+	find := func(cartID primitive.ObjectID) int {
+		for i, item := range result {
+			if item.CartID == cartID {
+				return i
+			}
+		}
+		return -1
+	}
+	for i, operation := range operations {
+		invalid := func(reason string) error {
+			return &cartOperationError{index: i, status: http.StatusBadRequest, err: fmt.Errorf("%w: %s", ErrInvalidCartOperation, reason)}
+		}
+		if operation.Op == "add" {
+			if operation.ProductID == nil || operation.Quantity == 0 {
+				return nil, invalid("add needs a product_id and a positive quantity")
+			}
+			j := -1
+			for k, item := range result {
+				if item.Holds(*operation.ProductID, operation.VariantID) {
+					j = k
+				}
+			}
+			if j < 0 {
+				result = append(result, user.Cart{CartID: primitive.NewObjectID(), ProductID: *operation.ProductID, VariantID: operation.VariantID, CreatedAt: now})
+				j = len(result) - 1
+			}
+			result[j].Quantity += operation.Quantity
+			result[j].UpdatedAt = now
+			changed[result[j].CartID] = i
+			continue
+		}
+		if operation.CartID == nil {
+			return nil, invalid(operation.Op + " needs a cart_id")
+		}
+		j := find(*operation.CartID)
+		if j < 0 {
+			return nil, &cartOperationError{index: i, status: http.StatusBadRequest, err: ErrCartNotFound}
+		}
+		if operation.Op == "remove" || operation.Quantity == 0 {
+			result = append(result[:j:j], result[j+1:]...)
+			delete(changed, *operation.CartID)
+			continue
+		}
+		productID, variantID := result[j].ProductID, result[j].VariantID
+		if operation.ProductID != nil {
+			productID, variantID = *operation.ProductID, operation.VariantID
+		}
+		result, j = updateItem(result, j, productID, variantID, operation.Quantity, now)
+		delete(changed, *operation.CartID)
+		changed[result[j].CartID] = i
+	}
+
+	for _, item := range result {
+		i, found := changed[item.CartID]
+		if !found {
+			continue
+		}
+		product, err := cc.products.FindByID(ctx, item.ProductID)
+		if err == repositories.ErrNotFound {
+			return nil, &cartOperationError{index: i, status: http.StatusBadRequest, err: ErrProductNotFound}
+		}
+		if err != nil {
+			return nil, err
+		}
+		variant, err := product.ResolveVariant(item.VariantID)
+		if err != nil {
+			return nil, &cartOperationError{index: i, status: http.StatusBadRequest, err: err}
+		}
+		if item.Quantity > product.StockOf(variant) {
+			return nil, &cartOperationError{index: i, status: http.StatusConflict, err: ErrInsufficientStock}
+		}
+	}
+	return result, nil
 }
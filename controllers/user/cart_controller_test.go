@@ -0,0 +1,429 @@
+package user
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+
+	productModel "github.com/YassinNouh21/GoShopCart-Ecommerce/models/product"
+	"github.com/YassinNouh21/GoShopCart-Ecommerce/models/user"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func (f *fixture) cartController() *CartController {
+	return NewCartController(f.repos.Users, f.repos.Products, f.repos.Carts, f.repos.Transactor, f.engine)
+}
+
+func TestAddCart(t *testing.T) {
+	tests := []struct {
+		name     string
+		inCart   int
+		stock    int
+		body     func(product *productModel.Product) interface{}
+		status   int
+		quantity int
+	}{
+		{
+			name: "new item", stock: 5,
+			body:   func(p *productModel.Product) interface{} { return gin.H{"product_id": p.ProductID, "quantity": 2} },
+			status: http.StatusOK, quantity: 2,
+		},
+		{
+			name: "increases the item holding the product", inCart: 1, stock: 5,
+			body:   func(p *productModel.Product) interface{} { return gin.H{"product_id": p.ProductID, "quantity": 3} },
+			status: http.StatusOK, quantity: 4,
+		},
+		{
+			name: "above the stock", stock: 5,
+			body:   func(p *productModel.Product) interface{} { return gin.H{"product_id": p.ProductID, "quantity": 6} },
+			status: http.StatusConflict,
+		},
+		{
+			name: "with the cart above the stock", inCart: 4, stock: 5,
+			body:   func(p *productModel.Product) interface{} { return gin.H{"product_id": p.ProductID, "quantity": 2} },
+			status: http.StatusConflict, quantity: 4,
+		},
+		{
+			name: "unknown product", stock: 5,
+			body: func(p *productModel.Product) interface{} {
+				return gin.H{"product_id": primitive.NewObjectID(), "quantity": 1}
+			},
+			status: http.StatusBadRequest,
+		},
+		{
+			name: "zero quantity", stock: 5,
+			body:   func(p *productModel.Product) interface{} { return gin.H{"product_id": p.ProductID, "quantity": 0} },
+			status: http.StatusBadRequest,
+		},
+		{
+			name: "malformed body", stock: 5,
+			body:   func(p *productModel.Product) interface{} { return "{" },
+			status: http.StatusBadRequest,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			f := newFixture(t)
+			f.router.POST("/cart", f.cartController().AddCart)
+			product := f.product("10.00", test.stock)
+			if test.inCart > 0 {
+				f.item(product, test.inCart)
+			}
+
+			status, response := f.serve(http.MethodPost, "/cart", test.body(product))
+			if status != test.status {
+				t.Fatalf("status = %d, want %d: %v", status, test.status, response)
+			}
+			if got := f.quantities()[product.ProductID]; got != test.quantity {
+				t.Errorf("quantity in cart = %d, want %d", got, test.quantity)
+			}
+		})
+	}
+}
+
+func TestUpdateCart(t *testing.T) {
+	tests := []struct {
+		name     string
+		body     func(item user.Cart) interface{}
+		unknown  bool
+		status   int
+		quantity int
+	}{
+		{
+			name:   "new quantity",
+			body:   func(item user.Cart) interface{} { return gin.H{"product_id": item.ProductID, "quantity": 3} },
+			status: http.StatusOK, quantity: 3,
+		},
+		{
+			name:   "zero quantity removes the item without timestamps",
+			body:   func(item user.Cart) interface{} { return gin.H{"product_id": item.ProductID, "quantity": 0} },
+			status: http.StatusOK, quantity: 0,
+		},
+		{
+			name:   "above the stock",
+			body:   func(item user.Cart) interface{} { return gin.H{"product_id": item.ProductID, "quantity": 6} },
+			status: http.StatusConflict, quantity: 1,
+		},
+		{
+			name: "cart ID in the body",
+			body: func(item user.Cart) interface{} {
+				return gin.H{"cart_id": item.CartID, "product_id": item.ProductID, "quantity": 2}
+			},
+			status: http.StatusBadRequest, quantity: 1,
+		},
+		{
+			name: "negative quantity",
+			body: func(item user.Cart) interface{} {
+				return gin.H{"product_id": item.ProductID, "quantity": -1}
+			},
+			status: http.StatusBadRequest, quantity: 1,
+		},
+		{
+			name:    "unknown cart item",
+			body:    func(item user.Cart) interface{} { return gin.H{"product_id": item.ProductID, "quantity": 2} },
+			unknown: true,
+			status:  http.StatusBadRequest, quantity: 1,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			f := newFixture(t)
+			f.router.PUT("/cart/:cart_id", f.cartController().UpdateCart)
+			product := f.product("10.00", 5)
+			item := f.item(product, 1)
+			cartID := item.CartID
+			if test.unknown {
+				cartID = primitive.NewObjectID()
+			}
+
+			status, response := f.serve(http.MethodPut, "/cart/"+cartID.Hex(), test.body(item))
+			if status != test.status {
+				t.Fatalf("status = %d, want %d: %v", status, test.status, response)
+			}
+			if got := f.quantities()[product.ProductID]; got != test.quantity {
+				t.Errorf("quantity in cart = %d, want %d", got, test.quantity)
+			}
+		})
+	}
+}
+
+func TestUpdateCartKeepsCreationTime(t *testing.T) {
+	f := newFixture(t)
+	f.router.PUT("/cart/:cart_id", f.cartController().UpdateCart)
+	product := f.product("10.00", 5)
+	item := f.item(product, 1)
+
+	body := gin.H{"product_id": product.ProductID, "quantity": 2, "created_at": "2030-01-01T00:00:00Z"}
+	if status, response := f.serve(http.MethodPut, "/cart/"+item.CartID.Hex(), body); status != http.StatusOK {
+		t.Fatalf("status = %d: %v", status, response)
+	}
+	items, err := f.repos.Carts.GetItems(f.context, f.user.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(items) != 1 || !items[0].CreatedAt.Equal(item.CreatedAt) || !items[0].UpdatedAt.After(item.UpdatedAt) {
+		t.Errorf("got items %+v, want the creation time %s kept and the update time set", items, item.CreatedAt)
+	}
+}
+
+func TestUpdateCartMergesIntoOneItem(t *testing.T) {
+	f := newFixture(t)
+	f.router.PUT("/cart/:cart_id", f.cartController().UpdateCart)
+	mug, plate := f.product("8.00", 5), f.product("12.00", 5)
+	mugItem := f.item(mug, 1)
+	plateItem := f.item(plate, 2)
+
+	body := gin.H{"product_id": mug.ProductID, "quantity": 5}
+	if status, response := f.serve(http.MethodPut, "/cart/"+plateItem.CartID.Hex(), body); status != http.StatusConflict {
+		t.Fatalf("status = %d, want the merged quantity 6 rejected above the stock: %v", status, response)
+	}
+
+	body["quantity"] = 2
+	status, response := f.serve(http.MethodPut, "/cart/"+plateItem.CartID.Hex(), body)
+	if status != http.StatusOK {
+		t.Fatalf("status = %d: %v", status, response)
+	}
+	items, err := f.repos.Carts.GetItems(f.context, f.user.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(items) != 1 || items[0].CartID != mugItem.CartID || items[0].Quantity != 3 {
+		t.Errorf("got items %+v, want the item %s of 3 mugs", items, mugItem.CartID.Hex())
+	}
+	if message := response["message"].(string); !strings.Contains(message, mugItem.CartID.Hex()) {
+		t.Errorf("message %q, want the ID of the merged item", message)
+	}
+}
+
+func TestBulkUpdateCart(t *testing.T) {
+	// Every test starts with 1 mug and 2 plates in the cart; cups are not in the cart
+	type products struct {
+		mug, plate, cup *productModel.Product
+		mugItem         user.Cart
+		plateItem       user.Cart
+	}
+	tests := []struct {
+		name       string
+		operations func(p products) interface{}
+		status     int
+		operation  float64
+		quantities func(p products) map[primitive.ObjectID]int
+	}{
+		{
+			name: "add, update and remove",
+			operations: func(p products) interface{} {
+				return []gin.H{
+					{"op": "add", "product_id": p.cup.ProductID, "quantity": 2},
+					{"op": "update", "cart_id": p.mugItem.CartID, "quantity": 4},
+					{"op": "remove", "cart_id": p.plateItem.CartID},
+				}
+			},
+			status: http.StatusOK,
+			quantities: func(p products) map[primitive.ObjectID]int {
+				return map[primitive.ObjectID]int{p.mug.ProductID: 4, p.cup.ProductID: 2}
+			},
+		},
+		{
+			name: "add to the item holding the product",
+			operations: func(p products) interface{} {
+				return []gin.H{{"op": "add", "product_id": p.mug.ProductID, "quantity": 2}}
+			},
+			status: http.StatusOK,
+			quantities: func(p products) map[primitive.ObjectID]int {
+				return map[primitive.ObjectID]int{p.mug.ProductID: 3, p.plate.ProductID: 2}
+			},
+		},
+		{
+			name: "update to zero removes the item",
+			operations: func(p products) interface{} {
+				return []gin.H{{"op": "update", "cart_id": p.plateItem.CartID, "quantity": 0}}
+			},
+			status: http.StatusOK,
+			quantities: func(p products) map[primitive.ObjectID]int {
+				return map[primitive.ObjectID]int{p.mug.ProductID: 1}
+			},
+		},
+		{
+			name: "update switching to a product in the cart merges the items",
+			operations: func(p products) interface{} {
+				return []gin.H{{"op": "update", "cart_id": p.plateItem.CartID, "product_id": p.mug.ProductID, "quantity": 2}}
+			},
+			status: http.StatusOK,
+			quantities: func(p products) map[primitive.ObjectID]int {
+				return map[primitive.ObjectID]int{p.mug.ProductID: 3}
+			},
+		},
+		{
+			name: "failing operation leaves the cart unchanged",
+			operations: func(p products) interface{} {
+				return []gin.H{
+					{"op": "remove", "cart_id": p.plateItem.CartID},
+					{"op": "add", "product_id": p.cup.ProductID, "quantity": 6},
+				}
+			},
+			status: http.StatusConflict, operation: 1,
+		},
+		{
+			name: "unknown cart item",
+			operations: func(p products) interface{} {
+				return []gin.H{
+					{"op": "add", "product_id": p.cup.ProductID, "quantity": 1},
+					{"op": "update", "cart_id": primitive.NewObjectID(), "quantity": 1},
+				}
+			},
+			status: http.StatusBadRequest, operation: 1,
+		},
+		{
+			name: "update without a cart ID",
+			operations: func(p products) interface{} {
+				return []gin.H{{"op": "update", "quantity": 1}}
+			},
+			status: http.StatusBadRequest, operation: 0,
+		},
+		{
+			name: "unknown product",
+			operations: func(p products) interface{} {
+				return []gin.H{{"op": "add", "product_id": primitive.NewObjectID(), "quantity": 1}}
+			},
+			status: http.StatusBadRequest, operation: 0,
+		},
+		{
+			name: "unknown operation",
+			operations: func(p products) interface{} {
+				return []gin.H{{"op": "replace", "cart_id": p.mugItem.CartID}}
+			},
+			status: http.StatusBadRequest, operation: -1,
+		},
+		{
+			name: "more than 100 operations",
+			operations: func(p products) interface{} {
+				operations := make([]gin.H, 101)
+				for i := range operations {
+					operations[i] = gin.H{"op": "add", "product_id": p.cup.ProductID, "quantity": 1}
+				}
+				return operations
+			},
+			status: http.StatusBadRequest, operation: -1,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			f := newFixture(t)
+			f.router.POST("/cart/bulk", f.cartController().BulkUpdateCart)
+			p := products{mug: f.product("8.00", 5), plate: f.product("12.00", 5), cup: f.product("4.00", 5)}
+			p.mugItem = f.item(p.mug, 1)
+			p.plateItem = f.item(p.plate, 2)
+
+			status, response := f.serve(http.MethodPost, "/cart/bulk", gin.H{"operations": test.operations(p)})
+			if status != test.status {
+				t.Fatalf("status = %d, want %d: %v", status, test.status, response)
+			}
+			if test.status != http.StatusOK {
+				operation, found := response["operation"]
+				if test.operation >= 0 && operation != test.operation {
+					t.Errorf("failing operation = %v, want %v", operation, test.operation)
+				}
+				if test.operation < 0 && found {
+					t.Errorf("failing operation = %v, want none", operation)
+				}
+			}
+			want := map[primitive.ObjectID]int{p.mug.ProductID: 1, p.plate.ProductID: 2}
+			if test.quantities != nil {
+				want = test.quantities(p)
+			}
+			got := f.quantities()
+			if len(got) != len(want) {
+				t.Fatalf("cart = %v, want %v", got, want)
+			}
+			for productID, quantity := range want {
+				if got[productID] != quantity {
+					t.Errorf("quantity of %s = %d, want %d", productID.Hex(), got[productID], quantity)
+				}
+			}
+		})
+	}
+}
+
+func TestBulkUpdateCartMergesIntoOneItem(t *testing.T) {
+	f := newFixture(t)
+	f.router.POST("/cart/bulk", f.cartController().BulkUpdateCart)
+	mug, plate := f.product("8.00", 5), f.product("12.00", 5)
+	f.item(mug, 1)
+	plateItem := f.item(plate, 2)
+
+	operations := []gin.H{{"op": "update", "cart_id": plateItem.CartID, "product_id": mug.ProductID, "quantity": 5}}
+	if status, response := f.serve(http.MethodPost, "/cart/bulk", gin.H{"operations": operations}); status != http.StatusConflict {
+		t.Fatalf("status = %d, want the merged quantity 6 rejected above the stock: %v", status, response)
+	}
+
+	operations[0]["quantity"] = 2
+	if status, response := f.serve(http.MethodPost, "/cart/bulk", gin.H{"operations": operations}); status != http.StatusOK {
+		t.Fatalf("status = %d: %v", status, response)
+	}
+	items, err := f.repos.Carts.GetItems(f.context, f.user.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(items) != 1 || !items[0].Holds(mug.ProductID, nil) || items[0].Quantity != 3 {
+		t.Errorf("got items %+v, want one item of 3 mugs", items)
+	}
+}
+
+func TestAddCartVariants(t *testing.T) {
+	// Every test starts with 1 small shirt in the cart; 2 small and 5 medium shirts are in stock
+	small, medium := primitive.NewObjectID(), primitive.NewObjectID()
+	tests := []struct {
+		name   string
+		body   gin.H
+		status int
+		small  int
+		medium int
+	}{
+		{name: "another variant is a new item", body: gin.H{"variant_id": medium, "quantity": 3}, status: http.StatusOK, small: 1, medium: 3},
+		{name: "same variant increases its item", body: gin.H{"variant_id": small, "quantity": 1}, status: http.StatusOK, small: 2},
+		{name: "above the stock of the variant", body: gin.H{"variant_id": small, "quantity": 2}, status: http.StatusConflict, small: 1},
+		{name: "without a variant", body: gin.H{"quantity": 1}, status: http.StatusBadRequest, small: 1},
+		{name: "unknown variant", body: gin.H{"variant_id": primitive.NewObjectID(), "quantity": 1}, status: http.StatusBadRequest, small: 1},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			f := newFixture(t)
+			f.router.POST("/cart", f.cartController().AddCart)
+			shirt := f.product("20.00", 0)
+			shirt.Options = []productModel.ProductOption{{Name: "size", Values: []string{"S", "M"}}}
+			shirt.Variants = []productModel.Variant{
+				{VariantID: small, SKU: "SHIRT-S", Options: map[string]string{"size": "S"}, Stock: 2},
+				{VariantID: medium, SKU: "SHIRT-M", Options: map[string]string{"size": "M"}, Stock: 5},
+			}
+			if err := f.repos.Products.Replace(f.context, shirt, shirt.Version); err != nil {
+				t.Fatal(err)
+			}
+			item := user.Cart{CartID: primitive.NewObjectID(), ProductID: shirt.ProductID, VariantID: &small, Quantity: 1}
+			if err := f.repos.Carts.AddItem(f.context, f.user.ID, item); err != nil {
+				t.Fatal(err)
+			}
+
+			test.body["product_id"] = shirt.ProductID
+			status, response := f.serve(http.MethodPost, "/cart", test.body)
+			if status != test.status {
+				t.Fatalf("status = %d, want %d: %v", status, test.status, response)
+			}
+			items, err := f.repos.Carts.GetItems(f.context, f.user.ID)
+			if err != nil {
+				t.Fatal(err)
+			}
+			quantities := map[primitive.ObjectID]int{}
+			for _, item := range items {
+				if item.VariantID == nil {
+					t.Fatalf("got item %+v without a variant", item)
+				}
+				quantities[*item.VariantID] += item.Quantity
+			}
+			if quantities[small] != test.small || quantities[medium] != test.medium {
+				t.Errorf("got %d small and %d medium shirts, want %d and %d", quantities[small], quantities[medium], test.small, test.medium)
+			}
+		})
+	}
+}
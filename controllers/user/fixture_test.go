@@ -0,0 +1,122 @@
+package user
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	productModel "github.com/YassinNouh21/GoShopCart-Ecommerce/models/product"
+	"github.com/YassinNouh21/GoShopCart-Ecommerce/models/user"
+	"github.com/YassinNouh21/GoShopCart-Ecommerce/money"
+	"github.com/YassinNouh21/GoShopCart-Ecommerce/pricing"
+	"github.com/YassinNouh21/GoShopCart-Ecommerce/repositories"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func init() {
+	gin.SetMode(gin.TestMode)
+}
+
+// fixture is a signed in user with an address, on top of the in-memory repositories. Its router
+// serves the handlers registered by the test as that user.
+type fixture struct {
+	t       *testing.T
+	repos   repositories.Repositories
+	engine  *pricing.Engine
+	user    *user.User
+	router  *gin.Engine
+	context context.Context
+}
+
+func newFixture(t *testing.T) *fixture {
+	repos := repositories.NewMemoryRepositories()
+	signedIn := &user.User{
+		ID:        primitive.NewObjectID(),
+		FirstName: "Alice",
+		Email:     "alice@example.com",
+		Role:      user.RoleCustomer,
+		AddressDetails: []user.Address{
+			{AddressID: primitive.NewObjectID(), Street: "1 Main St", City: "Austin", State: "TX", PostalCode: "73301", CountryCode: "US"},
+		},
+	}
+	if err := repos.Users.Create(context.Background(), signedIn); err != nil {
+		t.Fatal(err)
+	}
+	router := gin.New()
+	router.Use(func(c *gin.Context) {
+		c.Set("user_id", signedIn.ID.Hex())
+		c.Next()
+	})
+	return &fixture{
+		t:       t,
+		repos:   repos,
+		engine:  pricing.NewEngine(repos.Products, repos.Promotions, repos.ShippingZones, pricing.NewTableTaxCalculator(repos.TaxRates, repos.Categories)),
+		user:    signedIn,
+		router:  router,
+		context: context.Background(),
+	}
+}
+
+// product stores a product of the price in USD with the stock.
+func (f *fixture) product(price string, stock int) *productModel.Product {
+	f.t.Helper()
+	amount, err := money.Parse(price, money.USD)
+	if err != nil {
+		f.t.Fatal(err)
+	}
+	product := &productModel.Product{ProductID: primitive.NewObjectID(), ProductName: "Product " + price, Price: amount, Stock: stock}
+	if err := f.repos.Products.Create(f.context, product); err != nil {
+		f.t.Fatal(err)
+	}
+	return product
+}
+
+// item puts the quantity of the product in the cart of the user and returns the cart item.
+func (f *fixture) item(product *productModel.Product, quantity int) user.Cart {
+	f.t.Helper()
+	created := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	item := user.Cart{CartID: primitive.NewObjectID(), ProductID: product.ProductID, Quantity: quantity, CreatedAt: created, UpdatedAt: created}
+	if err := f.repos.Carts.AddItem(f.context, f.user.ID, item); err != nil {
+		f.t.Fatal(err)
+	}
+	return item
+}
+
+// quantities returns the quantity of every product in the cart of the user.
+func (f *fixture) quantities() map[primitive.ObjectID]int {
+	f.t.Helper()
+	items, err := f.repos.Carts.GetItems(f.context, f.user.ID)
+	if err != nil {
+		f.t.Fatal(err)
+	}
+	quantities := map[primitive.ObjectID]int{}
+	for _, item := range items {
+		quantities[item.ProductID] += item.Quantity
+	}
+	return quantities
+}
+
+// serve sends the request with the JSON body to the router and decodes the JSON response.
+func (f *fixture) serve(method, path string, body interface{}) (int, map[string]interface{}) {
+	f.t.Helper()
+	var payload bytes.Buffer
+	if text, ok := body.(string); ok {
+		payload.WriteString(text)
+	} else if body != nil {
+		if err := json.NewEncoder(&payload).Encode(body); err != nil {
+			f.t.Fatal(err)
+		}
+	}
+	request := httptest.NewRequest(method, path, &payload)
+	request.Header.Set("Content-Type", "application/json")
+	recorder := httptest.NewRecorder()
+	f.router.ServeHTTP(recorder, request)
+	response := map[string]interface{}{}
+	json.Unmarshal(recorder.Body.Bytes(), &response)
+	return recorder.Code, response
+}
@@ -0,0 +1,349 @@
+package user
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/YassinNouh21/GoShopCart-Ecommerce/helpers"
+	"github.com/YassinNouh21/GoShopCart-Ecommerce/models/user"
+	"github.com/YassinNouh21/GoShopCart-Ecommerce/pricing"
+	"github.com/YassinNouh21/GoShopCart-Ecommerce/repositories"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-playground/validator/v10"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+var (
+	// ErrCartTokenRequired is returned when a guest cart route is called without a cart token.
+	ErrCartTokenRequired = errors.New("A cart token is required")
+
+	// ErrGuestCartNotFound is returned when the guest cart of the cart token does not exist or expired.
+	ErrGuestCartNotFound = errors.New("Guest cart not found or expired")
+)
+
+// GuestCartController serves the cart endpoints of the visitors who have not signed in, whose carts
+// are identified by the cart token of the X-Cart-Token header instead of a user.
+type GuestCartController struct {
+	products   repositories.ProductRepository
+	guestCarts repositories.GuestCartRepository
+	pricing    *pricing.Engine
+	ttl        time.Duration
+}
+
+// NewGuestCartController creates a GuestCartController from the repositories it reads and writes, the
+// pricing engine that prices the carts and how long a guest cart is kept after its last change.
+func NewGuestCartController(products repositories.ProductRepository, guestCarts repositories.GuestCartRepository, engine *pricing.Engine, ttl time.Duration) *GuestCartController {
+	return &GuestCartController{products: products, guestCarts: guestCarts, pricing: engine, ttl: ttl}
+}
+
+// guestCartID returns the ID of the guest cart of the cart token of the request.
+func guestCartID(c *gin.Context) (primitive.ObjectID, error) {
+	token := c.GetHeader(helpers.CartTokenHeader)
+	if token == "" {
+		return primitive.NilObjectID, ErrCartTokenRequired
+	}
+	return helpers.ValidateCartToken(token)
+}
+
+// stockOf returns the stock of the product and variant of the cart item, or the status and error of
+// the response if they cannot be put in a cart.
+func (gc *GuestCartController) stockOf(ctx context.Context, productID primitive.ObjectID, variantID *primitive.ObjectID) (int, int, error) {
+	product, err := gc.products.FindByID(ctx, productID)
+	if err == repositories.ErrNotFound {
+		return 0, http.StatusBadRequest, ErrProductNotFound
+	}
+	if err != nil {
+		return 0, http.StatusInternalServerError, err
+	}
+	variant, err := product.ResolveVariant(variantID)
+	if err != nil {
+		return 0, http.StatusBadRequest, err
+	}
+	return product.StockOf(variant), http.StatusOK, nil
+}
+
+/*
+	GetGuestCart returns the priced guest cart of the cart token, in the currency of the request.
+
+	The cart is priced like the cart of a user by the pricing engine, with the automatic promotions it
+	gets, but without tax and shipping, which need an address: they are added once the visitor signs in
+	and the cart is merged into theirs.
+
+	Possible errors:
+		- ErrCartTokenRequired / ErrInvalidCartToken: if the cart token is missing or invalid
+		- ErrGuestCartNotFound: if the guest cart does not exist or expired
+		- ErrFailedFetchCart: if the cart cannot be read or priced
+*/
+
+func (gc *GuestCartController) GetGuestCart(c *gin.Context) {
+	cartID, err := guestCartID(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.TODO(), time.Second*10)
+	defer cancel()
+	cart, err := gc.guestCarts.FindByID(ctx, cartID)
+	if err == repositories.ErrNotFound {
+		c.JSON(http.StatusNotFound, gin.H{"error": ErrGuestCartNotFound.Error()})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": ErrFailedFetchCart.Error()})
+		return
+	}
+	currency, rates := helpers.RequestCurrency(c)
+	quote, err := gc.pricing.Quote(ctx, pricing.Request{Items: cart.Items, Currency: currency, Rates: rates})
+	if err != nil {
+		log.Println("price guest cart", cartID.Hex(), ":", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": ErrFailedFetchCart.Error()})
+		return
+	}
+
+	c.IndentedJSON(http.StatusOK, quote)
+}
+
+/*
+	AddGuestCart adds a product, or a variant of it, to the guest cart of the cart token. Without a cart
+	token, or when its cart expired, a new guest cart is created and its cart token returned, in the
+	cart_token field and the X-Cart-Token header, for the next requests.
+
+	If the same product and variant is already in the cart, its quantity is increased instead. The
+	resulting quantity cannot exceed the stock of the product or variant.
+
+	Possible errors:
+		- ErrInvalidCartToken: if the cart token is invalid
+		- ErrProductNotFound: if the product does not exist
+		- ErrVariantRequired / ErrVariantNotFound: if the variant is missing for a product with variants or unknown
+		- ErrInsufficientStock: if the product does not have enough stock
+		- ErrFailedUpdate: if the quantity of the existing cart item cannot be increased
+		- ErrCartNotCreate: if the cart item or the guest cart cannot be created
+*/
+
+func (gc *GuestCartController) AddGuestCart(c *gin.Context) {
+	var item user.Cart
+	if err := c.ShouldBindJSON(&item); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	// The server assigns the cart ID and timestamps, so set them before validating
+	now := time.Now().UTC()
+	item.CartID = primitive.NewObjectID()
+	item.CreatedAt = now
+	item.UpdatedAt = now
+	if err := validator.New().Struct(&item); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.TODO(), time.Second*10)
+	defer cancel()
+
+	var cart *user.GuestCart
+	if c.GetHeader(helpers.CartTokenHeader) != "" {
+		cartID, err := guestCartID(c)
+		if err != nil {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+			return
+		}
+		cart, err = gc.guestCarts.FindByID(ctx, cartID)
+		if err != nil && err != repositories.ErrNotFound {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": ErrCartNotCreate.Error()})
+			return
+		}
+	}
+
+	stock, status, err := gc.stockOf(ctx, item.ProductID, item.VariantID)
+	if err != nil {
+		c.JSON(status, gin.H{"error": err.Error()})
+		return
+	}
+	inCart, quantity := false, item.Quantity
+	if cart != nil {
+		for _, existing := range cart.Items {
+			if existing.Holds(item.ProductID, item.VariantID) {
+				inCart = true
+				quantity += existing.Quantity
+			}
+		}
+	}
+	if quantity > stock {
+		c.JSON(http.StatusConflict, gin.H{"error": ErrInsufficientStock.Error()})
+		return
+	}
+
+	expiresAt := now.Add(gc.ttl)
+	message := fmt.Sprintf("Cart with ID %s created successfully", item.CartID.Hex())
+	failure := ErrCartNotCreate
+	switch {
+	case cart == nil:
+		cart = &user.GuestCart{GuestCartID: primitive.NewObjectID(), Items: []user.Cart{item}, CreatedAt: now, UpdatedAt: now, ExpiresAt: expiresAt}
+		err = gc.guestCarts.Create(ctx, cart)
+	case inCart:
+		// increase the quantity of the existing item
+		message, failure = "Cart updated successfully", ErrFailedUpdate
+		err = gc.guestCarts.IncrementQuantity(ctx, cart.GuestCartID, item.ProductID, item.VariantID, item.Quantity, expiresAt)
+	default:
+		err = gc.guestCarts.AddItem(ctx, cart.GuestCartID, item, expiresAt)
+	}
+	if err != nil {
+		log.Println("add guest cart", cart.GuestCartID.Hex(), ":", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": failure.Error()})
+		return
+	}
+	token, err := helpers.GenerateCartToken(cart.GuestCartID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": ErrCartNotCreate.Error()})
+		return
+	}
+
+	c.Header(helpers.CartTokenHeader, token)
+	c.JSON(http.StatusOK, gin.H{"message": message, "cart_token": token})
+}
+
+/*
+	UpdateGuestCart replaces an item of the guest cart of the cart token, or removes it when the
+	quantity is zero. An item switched to a product and variant another item holds is merged into that
+	item, like UpdateCart. The resulting quantity cannot exceed the stock of the product or variant.
+
+	Possible errors:
+		- ErrInvalidCardId: if the cart ID in the path is invalid
+		- ErrCartTokenRequired / ErrInvalidCartToken: if the cart token is missing or invalid
+		- ErrCartIdNotProvided: if the request body contains a cart ID
+		- ErrGuestCartNotFound: if the guest cart does not exist or expired
+		- ErrCartNotFound: if the cart item cannot be found
+		- ErrProductNotFound: if the product does not exist
+		- ErrVariantRequired / ErrVariantNotFound: if the variant is missing for a product with variants or unknown
+		- ErrInsufficientStock: if the product does not have enough stock
+		- ErrCartNotDeleted: if the cart item cannot be removed
+		- ErrCartNotUpdated: if the cart item cannot be stored
+*/
+
+func (gc *GuestCartController) UpdateGuestCart(c *gin.Context) {
+	itemID, err := primitive.ObjectIDFromHex(c.Param("cart_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": ErrInvalidCardId.Error()})
+		return
+	}
+	cartID, err := guestCartID(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		return
+	}
+
+	var item user.CartWithoutId
+	if err := c.ShouldBindJSON(&item); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if item.CartID != primitive.NilObjectID {
+		c.JSON(http.StatusBadRequest, gin.H{"error": ErrCartIdNotProvided.Error()})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.TODO(), time.Second*10)
+	defer cancel()
+	cart, err := gc.guestCarts.FindByID(ctx, cartID)
+	if err == repositories.ErrNotFound {
+		c.JSON(http.StatusNotFound, gin.H{"error": ErrGuestCartNotFound.Error()})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": ErrCartNotUpdated.Error()})
+		return
+	}
+	j := -1
+	for i, existing := range cart.Items {
+		if existing.CartID == itemID {
+			j = i
+			item.CreatedAt = existing.CreatedAt
+		}
+	}
+	if j < 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": ErrCartNotFound.Error()})
+		return
+	}
+	// The server keeps the creation time of the item and sets its update time
+	now := time.Now().UTC()
+	item.CartID = itemID
+	item.UpdatedAt = now
+	if err := validator.New().Struct(&item); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if item.Quantity == 0 {
+		err = gc.guestCarts.RemoveItem(ctx, cartID, itemID, now.Add(gc.ttl))
+		if err == repositories.ErrNotFound {
+			c.JSON(http.StatusNotFound, gin.H{"error": ErrCartNotFound.Error()})
+			return
+		}
+		if err != nil {
+			log.Println("remove guest cart item", cartID.Hex(), ":", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": ErrCartNotDeleted.Error()})
+			return
+		}
+		message := fmt.Sprintf("Cart with ID %s deleted successfully", itemID.Hex())
+		c.JSON(http.StatusOK, gin.H{"message": message})
+		return
+	}
+	stock, status, err := gc.stockOf(ctx, item.ProductID, item.VariantID)
+	if err != nil {
+		c.JSON(status, gin.H{"error": err.Error()})
+		return
+	}
+	items, j := updateItem(cart.Items, j, item.ProductID, item.VariantID, item.Quantity, now)
+	if items[j].Quantity > stock {
+		c.JSON(http.StatusConflict, gin.H{"error": ErrInsufficientStock.Error()})
+		return
+	}
+
+	err = gc.guestCarts.SetItems(ctx, cartID, items, now.Add(gc.ttl))
+	if err == repositories.ErrNotFound {
+		c.JSON(http.StatusNotFound, gin.H{"error": ErrGuestCartNotFound.Error()})
+		return
+	}
+	if err != nil {
+		log.Println("update guest cart", cartID.Hex(), ":", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": ErrCartNotUpdated.Error()})
+		return
+	}
+	message := fmt.Sprintf("Cart with ID %s updated successfully", items[j].CartID.Hex())
+	c.JSON(http.StatusOK, gin.H{"message": message})
+}
+
+/*
+	DeleteGuestCart removes the guest cart of the cart token with all its items. The cart token no
+	longer finds a cart afterwards.
+
+	Possible errors:
+		- ErrCartTokenRequired / ErrInvalidCartToken: if the cart token is missing or invalid
+		- ErrGuestCartNotFound: if the guest cart does not exist or expired
+*/
+
+func (gc *GuestCartController) DeleteGuestCart(c *gin.Context) {
+	cartID, err := guestCartID(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.TODO(), time.Second*10)
+	defer cancel()
+	err = gc.guestCarts.Delete(ctx, cartID)
+	if err == repositories.ErrNotFound {
+		c.JSON(http.StatusNotFound, gin.H{"error": ErrGuestCartNotFound.Error()})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error deleting carts"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "All carts are successfully deleted"})
+}
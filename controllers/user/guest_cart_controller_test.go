@@ -0,0 +1,56 @@
+package user
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/YassinNouh21/GoShopCart-Ecommerce/helpers"
+	"github.com/YassinNouh21/GoShopCart-Ecommerce/models/user"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestUpdateGuestCartMergesIntoOneItem(t *testing.T) {
+	f := newFixture(t)
+	f.router.PUT("/guest/cart/:cart_id", NewGuestCartController(f.repos.Products, f.repos.GuestCarts, f.engine, time.Hour).UpdateGuestCart)
+	mug, plate := f.product("8.00", 5), f.product("12.00", 5)
+	created := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	mugItem := user.Cart{CartID: primitive.NewObjectID(), ProductID: mug.ProductID, Quantity: 1, CreatedAt: created, UpdatedAt: created}
+	plateItem := user.Cart{CartID: primitive.NewObjectID(), ProductID: plate.ProductID, Quantity: 2, CreatedAt: created, UpdatedAt: created}
+	cart := &user.GuestCart{GuestCartID: primitive.NewObjectID(), Items: []user.Cart{mugItem, plateItem}, ExpiresAt: time.Now().Add(time.Hour)}
+	if err := f.repos.GuestCarts.Create(f.context, cart); err != nil {
+		t.Fatal(err)
+	}
+	token, err := helpers.GenerateCartToken(cart.GuestCartID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	update := func(quantity int) int {
+		t.Helper()
+		body := `{"product_id":"` + mug.ProductID.Hex() + `","quantity":` + strconv.Itoa(quantity) + `}`
+		request := httptest.NewRequest(http.MethodPut, "/guest/cart/"+plateItem.CartID.Hex(), strings.NewReader(body))
+		request.Header.Set("Content-Type", "application/json")
+		request.Header.Set(helpers.CartTokenHeader, token)
+		recorder := httptest.NewRecorder()
+		f.router.ServeHTTP(recorder, request)
+		return recorder.Code
+	}
+
+	if status := update(5); status != http.StatusConflict {
+		t.Fatalf("status = %d, want the merged quantity 6 rejected above the stock", status)
+	}
+	if status := update(2); status != http.StatusOK {
+		t.Fatalf("status = %d", status)
+	}
+	stored, err := f.repos.GuestCarts.FindByID(f.context, cart.GuestCartID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(stored.Items) != 1 || stored.Items[0].CartID != mugItem.CartID || stored.Items[0].Quantity != 3 {
+		t.Errorf("got items %+v, want the item %s of 3 mugs", stored.Items, mugItem.CartID.Hex())
+	}
+}
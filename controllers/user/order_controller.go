@@ -0,0 +1,528 @@
+package user
+
+import (
+	"context"
+	"errors"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/YassinNouh21/GoShopCart-Ecommerce/helpers"
+	"github.com/YassinNouh21/GoShopCart-Ecommerce/inventory"
+	productModel "github.com/YassinNouh21/GoShopCart-Ecommerce/models/product"
+	"github.com/YassinNouh21/GoShopCart-Ecommerce/models/user"
+	"github.com/YassinNouh21/GoShopCart-Ecommerce/payments"
+	"github.com/YassinNouh21/GoShopCart-Ecommerce/pricing"
+	"github.com/YassinNouh21/GoShopCart-Ecommerce/repositories"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-playground/validator/v10"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+var (
+	// ErrCartEmpty is returned when checking out an empty cart.
+	ErrCartEmpty = errors.New("Cart is empty")
+
+	// ErrAddressNotFound is returned when the shipping address is not one of the user's addresses.
+	ErrAddressNotFound = errors.New("Address not found")
+
+	// ErrCheckoutFailed is returned when the order cannot be created.
+	ErrCheckoutFailed = errors.New("Failed to create order")
+
+	// ErrOrderNotFound is returned when the order does not exist or belongs to another user.
+	ErrOrderNotFound = errors.New("Order not found")
+
+	// ErrFailedFetchOrders is returned when the orders cannot be read.
+	ErrFailedFetchOrders = errors.New("Failed to fetch orders")
+
+	// ErrOrderNotCancellable is returned when the order is no longer awaiting payment.
+	ErrOrderNotCancellable = errors.New("Only orders awaiting payment can be cancelled")
+
+	// ErrOrderNotUpdated is returned when the order status cannot be changed.
+	ErrOrderNotUpdated = errors.New("Failed to update order")
+
+	// ErrPaymentDeclined is returned when the payment provider declines the payment of the order.
+	ErrPaymentDeclined = errors.New("Payment declined")
+
+	// ErrPaymentTimeout is returned when the payment provider does not answer in time.
+	ErrPaymentTimeout = errors.New("Payment provider timed out")
+
+	// ErrPaymentFailed is returned when the payment cannot be authorized.
+	ErrPaymentFailed = errors.New("Payment failed")
+
+	// ErrPaymentNotVoided is returned when an order was cancelled but its payment cannot be voided.
+	ErrPaymentNotVoided = errors.New("Order cancelled but the payment could not be voided")
+
+	// ErrCartChanged is returned when the cart changed while the payment was being authorized.
+	ErrCartChanged = errors.New("Cart changed during checkout, please try again")
+
+	// ErrInsufficientStock is returned when a product does not have enough stock for the requested quantity.
+	ErrInsufficientStock = errors.New("Not enough stock")
+
+	// ErrPromotionUsedUp is returned when a promotion of the cart reached its usage limit during checkout.
+	ErrPromotionUsedUp = errors.New("A promotion of the cart has reached its usage limit, please review your cart")
+)
+
+// OrderController serves the checkout and order endpoints.
+type OrderController struct {
+	users      repositories.UserRepository
+	carts      repositories.CartRepository
+	orders     repositories.OrderRepository
+	transactor repositories.Transactor
+	payments   payments.PaymentProvider
+	inventory  *inventory.Reservations
+	pricing    *pricing.Engine
+}
+
+// NewOrderController creates an OrderController from the repositories it reads and writes,
+// the payment provider that charges the orders, the reservations that hold their stock and the
+// pricing engine that computes their totals.
+func NewOrderController(users repositories.UserRepository, carts repositories.CartRepository, orders repositories.OrderRepository, transactor repositories.Transactor, provider payments.PaymentProvider, reservations *inventory.Reservations, engine *pricing.Engine) *OrderController {
+	return &OrderController{users: users, carts: carts, orders: orders, transactor: transactor, payments: provider, inventory: reservations, pricing: engine}
+}
+
+// CheckoutRequest represents the request body of the checkout.
+// Without a shipping method the cheapest one available for the address is used.
+type CheckoutRequest struct {
+	AddressID        string `json:"address_id" validate:"required"`
+	PaymentMethod    string `json:"payment_method" validate:"required"`
+	ShippingMethodID string `json:"shipping_method_id"`
+}
+
+/*
+Checkout turns the cart of the authenticated user into an order and charges it.
+
+	It prices the cart with the pricing engine, like the cart endpoint, and snapshots the lines with the
+	product names and unit prices at purchase time, the promotions applied, the tax of every line and
+	the totals of the quote. Coupons of the cart that no longer apply simply give no discount. One of
+	the user's addresses is the shipping address, whose country and state set the tax and whose
+	shipping zone the shipping method. The order is placed in the currency of the request, with price overrides or
+	prices converted at the current exchange rate, which the order records. The total is authorized at
+	the payment provider before anything is stored, so a declined payment leaves the cart untouched.
+	The stock of the items and the promotions are then reserved, the order is created and the cart is
+	cleared in a single transaction; if that fails, the authorization is voided. Finally the payment is
+	captured and the order moves to paid. If the capture fails, the order stays pending_payment until
+	the provider reports the payment through its webhook.
+
+Possible Errors:
+  - ErrUnauthorized: If the user is not authenticated.
+  - ErrInvalidRequest: If the request body is invalid.
+  - ErrUserNotFound: If the user cannot be found in the database.
+  - ErrAddressNotFound: If the address is not one of the user's addresses.
+  - ErrCartEmpty: If the cart has no items.
+  - ErrProductNotFound: If a product in the cart no longer exists.
+  - ErrVariantRequired / ErrVariantNotFound: If a cart item no longer points at a variant of its product.
+  - ErrInsufficientStock: If a product does not have enough stock for the ordered quantity.
+  - ErrShippingMethodNotFound: If the shipping method is not available for the address.
+  - ErrNoShipping: If no shipping method is available for the address and the cart.
+  - ErrPromotionUsedUp: If a promotion of the cart reached its usage limit during checkout.
+  - ErrPaymentDeclined: If the payment provider declines the payment.
+  - ErrPaymentTimeout: If the payment provider does not answer in time.
+  - ErrPaymentFailed: If the payment cannot be authorized for another reason.
+  - ErrCartChanged: If the cart changed while the payment was being authorized.
+  - ErrCheckoutFailed: If the order cannot be stored.
+*/
+func (oc *OrderController) Checkout(c *gin.Context) {
+	userID, errBool := c.Get("user_id")
+	if !errBool {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": ErrUnauthorized.Error()})
+		c.Abort()
+		return
+	}
+	userObjectID, _ := primitive.ObjectIDFromHex(userID.(string))
+
+	var request CheckoutRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": ErrInvalidRequest.Error()})
+		return
+	}
+	if err := validator.New().Struct(request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	addressID, err := primitive.ObjectIDFromHex(request.AddressID)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": ErrAddressNotFound.Error()})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	existingUser, err := oc.users.FindByID(ctx, userObjectID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": ErrUserNotFound.Error()})
+		return
+	}
+	address, found := findAddress(existingUser.AddressDetails, addressID)
+	if !found {
+		c.JSON(http.StatusBadRequest, gin.H{"error": ErrAddressNotFound.Error()})
+		return
+	}
+
+	items, err := oc.carts.GetItems(ctx, userObjectID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": ErrCheckoutFailed.Error()})
+		return
+	}
+	coupons, err := oc.carts.GetCoupons(ctx, userObjectID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": ErrCheckoutFailed.Error()})
+		return
+	}
+	currency, rates := helpers.RequestCurrency(c)
+	quote, err := oc.pricing.Quote(ctx, pricing.Request{
+		Items:            items,
+		Currency:         currency,
+		Rates:            rates,
+		UserID:           userObjectID,
+		Coupons:          coupons,
+		Address:          &address,
+		TaxExempt:        existingUser.TaxExempt,
+		ShippingMethodID: shippingMethodID(request.ShippingMethodID),
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": ErrCheckoutFailed.Error()})
+		return
+	}
+	order, err := oc.buildOrder(userObjectID, quote)
+	if errors.Is(err, ErrCartEmpty) || errors.Is(err, pricing.ErrProductNotFound) ||
+		errors.Is(err, productModel.ErrVariantRequired) || errors.Is(err, productModel.ErrVariantNotFound) ||
+		errors.Is(err, pricing.ErrShippingMethodNotFound) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if errors.Is(err, pricing.ErrNoShipping) {
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": err.Error()})
+		return
+	}
+	if errors.Is(err, pricing.ErrInsufficientStock) {
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": ErrCheckoutFailed.Error()})
+		return
+	}
+	order.ShippingAddress = address
+	order.PaymentMethod = request.PaymentMethod
+
+	transaction, err := oc.payments.Authorize(ctx, payments.AuthorizationRequest{
+		OrderID:       order.OrderID.Hex(),
+		Amount:        order.Total,
+		PaymentMethod: request.PaymentMethod,
+	})
+	if errors.Is(err, payments.ErrPaymentDeclined) {
+		c.JSON(http.StatusPaymentRequired, gin.H{"error": ErrPaymentDeclined.Error()})
+		return
+	}
+	if errors.Is(err, payments.ErrPaymentTimeout) {
+		c.JSON(http.StatusGatewayTimeout, gin.H{"error": ErrPaymentTimeout.Error()})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusBadGateway, gin.H{"error": ErrPaymentFailed.Error()})
+		return
+	}
+	order.Payment = &user.OrderPayment{
+		Provider:      oc.payments.Name(),
+		TransactionID: transaction.ID,
+		Status:        user.PaymentStatusAuthorized,
+		Amount:        transaction.Amount,
+		UpdatedAt:     time.Now().UTC(),
+	}
+
+	err = oc.transactor.WithTransaction(ctx, func(ctx context.Context) error {
+		current, err := oc.carts.GetItems(ctx, userObjectID)
+		if err != nil {
+			return err
+		}
+		if !sameCartItems(items, current) {
+			return ErrCartChanged
+		}
+		if err := oc.inventory.Reserve(ctx, order); err != nil {
+			return err
+		}
+		err = oc.orders.Create(ctx, order)
+		if err == nil {
+			err = oc.carts.Clear(ctx, userObjectID)
+		}
+		if err != nil {
+			// Give the stock back explicitly, not every Transactor rolls back
+			oc.inventory.Release(ctx, *order)
+		}
+		return err
+	})
+	if err != nil {
+		if _, voidErr := oc.payments.Void(ctx, transaction.ID); voidErr != nil {
+			log.Println("void authorization", transaction.ID, ":", voidErr)
+		}
+		if err == ErrCartChanged {
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+			return
+		}
+		if errors.Is(err, repositories.ErrInsufficientStock) {
+			c.JSON(http.StatusConflict, gin.H{"error": ErrInsufficientStock.Error()})
+			return
+		}
+		if errors.Is(err, repositories.ErrLimitReached) {
+			c.JSON(http.StatusConflict, gin.H{"error": ErrPromotionUsedUp.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": ErrCheckoutFailed.Error()})
+		return
+	}
+
+	oc.capturePayment(ctx, order)
+
+	c.JSON(http.StatusCreated, gin.H{"message": "Order created successfully", "order": order})
+}
+
+// capturePayment captures the authorized payment of a stored order and moves the order to paid.
+// Failures are logged and leave the order pending_payment, the webhook of the provider settles it later.
+func (oc *OrderController) capturePayment(ctx context.Context, order *user.Order) {
+	if _, err := oc.payments.Capture(ctx, order.Payment.TransactionID); err != nil {
+		log.Println("capture payment of order", order.OrderID.Hex(), ":", err)
+		return
+	}
+	payment := *order.Payment
+	payment.Status = user.PaymentStatusCaptured
+	payment.UpdatedAt = time.Now().UTC()
+	if err := oc.orders.UpdatePayment(ctx, order.OrderID, payment); err != nil {
+		log.Println("record payment of order", order.OrderID.Hex(), ":", err)
+		return
+	}
+	order.Payment = &payment
+
+	change, err := order.Transition(user.OrderStatusPaid, user.SystemActor, "Payment captured")
+	if err == nil {
+		err = oc.orders.UpdateStatus(ctx, order.OrderID, change)
+	}
+	if err != nil {
+		log.Println("mark order", order.OrderID.Hex(), "paid:", err)
+		return
+	}
+	order.Status = change.To
+	order.UpdatedAt = change.ChangedAt
+	order.StatusHistory = append(order.StatusHistory, change)
+}
+
+// sameCartItems reports whether two reads of a cart hold the same products and variants in the same quantities.
+func sameCartItems(before []user.Cart, after []user.Cart) bool {
+	if len(before) != len(after) {
+		return false
+	}
+	for i := range before {
+		if !before[i].Holds(after[i].ProductID, after[i].VariantID) || before[i].Quantity != after[i].Quantity {
+			return false
+		}
+	}
+	return true
+}
+
+// buildOrder snapshots the priced cart into a new order with the totals of the quote, so the order
+// charges exactly what the cart showed. It returns the problem of the first line that cannot be ordered.
+func (oc *OrderController) buildOrder(userID primitive.ObjectID, quote *pricing.Quote) (*user.Order, error) {
+	if len(quote.Lines) == 0 {
+		return nil, ErrCartEmpty
+	}
+	if err := quote.Err(); err != nil {
+		return nil, err
+	}
+	now := time.Now().UTC()
+	order := &user.Order{
+		OrderID: primitive.NewObjectID(),
+		UserID:  userID,
+		Items:   []user.OrderItem{},
+		Status:  user.OrderStatusPendingPayment,
+		StatusHistory: []user.OrderStatusChange{{
+			To:        user.OrderStatusPendingPayment,
+			ChangedBy: userID.Hex(),
+			ChangedAt: now,
+		}},
+		ExchangeRate:   quote.ExchangeRate,
+		Subtotal:       quote.Subtotal,
+		Discount:       quote.Discount,
+		Promotions:     quote.Promotions,
+		Tax:            quote.Tax,
+		TaxIncluded:    quote.TaxIncluded,
+		Shipping:       quote.Shipping,
+		ShippingMethod: quote.ShippingMethod,
+		Total:          quote.Total,
+		Quantity:       quote.Quantity,
+		CreatedAt:      now,
+		UpdatedAt:      now,
+	}
+	for _, line := range quote.Lines {
+		order.Items = append(order.Items, user.OrderItem{
+			ProductID:   line.ProductID,
+			VariantID:   line.VariantID,
+			ProductName: line.ProductName,
+			SKU:         line.SKU,
+			Options:     line.Options,
+			UnitPrice:   line.UnitPrice,
+			Quantity:    line.Quantity,
+			LineTotal:   line.LineTotal,
+			Discount:    line.Discount,
+			TaxName:     line.TaxName,
+			TaxRate:     line.TaxRate,
+			TaxIncluded: line.TaxIncluded,
+			Tax:         line.Tax,
+		})
+	}
+	return order, nil
+}
+
+/*
+GetOrders returns the orders of the authenticated user, newest first.
+
+Possible Errors:
+  - ErrUnauthorized: If the user is not authenticated.
+  - ErrFailedFetchOrders: If the orders cannot be read.
+*/
+func (oc *OrderController) GetOrders(c *gin.Context) {
+	userID, errBool := c.Get("user_id")
+	if !errBool {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": ErrUnauthorized.Error()})
+		c.Abort()
+		return
+	}
+	userObjectID, _ := primitive.ObjectIDFromHex(userID.(string))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	orders, err := oc.orders.FindByUser(ctx, userObjectID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": ErrFailedFetchOrders.Error()})
+		return
+	}
+
+	c.IndentedJSON(http.StatusOK, gin.H{"message": orders})
+}
+
+/*
+GetOrder returns one order of the authenticated user.
+
+Possible Errors:
+  - ErrUnauthorized: If the user is not authenticated.
+  - ErrInvalidID: If the order ID is not a valid ObjectID.
+  - ErrOrderNotFound: If the order does not exist or belongs to another user.
+*/
+func (oc *OrderController) GetOrder(c *gin.Context) {
+	userID, errBool := c.Get("user_id")
+	if !errBool {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": ErrUnauthorized.Error()})
+		c.Abort()
+		return
+	}
+	orderID, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": ErrInvalidID.Error()})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	order, err := oc.orders.FindByID(ctx, orderID)
+	if err != nil || order.UserID.Hex() != userID.(string) {
+		c.JSON(http.StatusNotFound, gin.H{"error": ErrOrderNotFound.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, order)
+}
+
+/*
+CancelOrder cancels an order of the authenticated user that is still awaiting payment.
+Once the order is cancelled its reserved stock is given back and its authorized payment, if any, is voided.
+
+Possible Errors:
+  - ErrUnauthorized: If the user is not authenticated.
+  - ErrInvalidID: If the order ID is not a valid ObjectID.
+  - ErrOrderNotFound: If the order does not exist or belongs to another user.
+  - ErrOrderNotCancellable: If the order is no longer awaiting payment.
+  - ErrOrderNotUpdated: If the order status cannot be changed.
+  - ErrPaymentNotVoided: If the order was cancelled but its payment cannot be voided.
+*/
+func (oc *OrderController) CancelOrder(c *gin.Context) {
+	userID, errBool := c.Get("user_id")
+	if !errBool {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": ErrUnauthorized.Error()})
+		c.Abort()
+		return
+	}
+	orderID, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": ErrInvalidID.Error()})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	order, err := oc.orders.FindByID(ctx, orderID)
+	if err != nil || order.UserID.Hex() != userID.(string) {
+		c.JSON(http.StatusNotFound, gin.H{"error": ErrOrderNotFound.Error()})
+		return
+	}
+	if order.Status != user.OrderStatusPendingPayment {
+		c.JSON(http.StatusConflict, gin.H{"error": ErrOrderNotCancellable.Error()})
+		return
+	}
+	change, err := order.Transition(user.OrderStatusCancelled, userID.(string), "Cancelled by customer")
+	if err != nil {
+		c.JSON(http.StatusConflict, gin.H{"error": ErrOrderNotCancellable.Error()})
+		return
+	}
+	// The conditional status update comes first, so an order paid in the meantime keeps its payment
+	err = oc.orders.UpdateStatus(ctx, orderID, change)
+	if err == repositories.ErrVersionConflict {
+		c.JSON(http.StatusConflict, gin.H{"error": ErrOrderNotCancellable.Error()})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": ErrOrderNotUpdated.Error()})
+		return
+	}
+	oc.inventory.Release(ctx, *order)
+
+	released, err := helpers.ReleasePayment(ctx, oc.payments, order.Payment)
+	if err != nil {
+		log.Println("void payment of cancelled order", orderID.Hex(), ":", err)
+		c.JSON(http.StatusBadGateway, gin.H{"error": ErrPaymentNotVoided.Error()})
+		return
+	}
+	if released != nil {
+		if err := oc.orders.UpdatePayment(ctx, orderID, *released); err != nil {
+			log.Println("record payment of cancelled order", orderID.Hex(), ":", err)
+		}
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Order cancelled successfully"})
+}
+
+// shippingMethodID returns the shipping method chosen by its ID, or nil if none is chosen. An invalid ID
+// matches no method.
+func shippingMethodID(value string) *primitive.ObjectID {
+	if value == "" {
+		return nil
+	}
+	id, err := primitive.ObjectIDFromHex(value)
+	if err != nil {
+		id = primitive.NilObjectID
+	}
+	return &id
+}
+
+// findAddress returns the address with the given ID.
+func findAddress(addresses []user.Address, addressID primitive.ObjectID) (user.Address, bool) {
+	for _, address := range addresses {
+		if address.AddressID == addressID {
+			return address, true
+		}
+	}
+	return user.Address{}, false
+}
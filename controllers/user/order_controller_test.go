@@ -0,0 +1,109 @@
+package user
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/YassinNouh21/GoShopCart-Ecommerce/inventory"
+	"github.com/YassinNouh21/GoShopCart-Ecommerce/models/user"
+	"github.com/YassinNouh21/GoShopCart-Ecommerce/payments"
+	"github.com/YassinNouh21/GoShopCart-Ecommerce/repositories"
+
+	"github.com/gin-gonic/gin"
+)
+
+// failingOrders is an OrderRepository whose Create fails, to roll back a checkout after the stock was reserved.
+type failingOrders struct {
+	repositories.OrderRepository
+}
+
+func (failingOrders) Create(ctx context.Context, order *user.Order) error {
+	return errors.New("orders unavailable")
+}
+
+func TestCheckout(t *testing.T) {
+	tests := []struct {
+		name        string
+		mode        payments.FakeMode
+		failCreate  bool
+		status      int
+		orderStatus user.OrderStatus
+		cart        int
+		stock       int
+		transaction payments.TransactionStatus
+	}{
+		{
+			name: "order paid", mode: payments.FakeSucceed,
+			status: http.StatusCreated, orderStatus: user.OrderStatusPaid, cart: 0, stock: 3,
+			transaction: payments.TransactionCaptured,
+		},
+		{
+			name: "payment declined", mode: payments.FakeDecline,
+			status: http.StatusPaymentRequired, cart: 2, stock: 5,
+		},
+		{
+			name: "payment timed out", mode: payments.FakeTimeout,
+			status: http.StatusGatewayTimeout, cart: 2, stock: 5,
+		},
+		{
+			name: "order not stored rolls back", mode: payments.FakeSucceed, failCreate: true,
+			status: http.StatusInternalServerError, cart: 2, stock: 5,
+			transaction: payments.TransactionVoided,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			f := newFixture(t)
+			provider := payments.NewFakeProvider(test.mode, "whsec")
+			var orders repositories.OrderRepository = f.repos.Orders
+			if test.failCreate {
+				orders = failingOrders{f.repos.Orders}
+			}
+			reservations := inventory.NewReservations(f.repos.Products, orders, f.repos.Promotions, provider, 15*time.Minute)
+			controller := NewOrderController(f.repos.Users, f.repos.Carts, orders, f.repos.Transactor, provider, reservations, f.engine)
+			f.router.POST("/checkout", controller.Checkout)
+			product := f.product("10.00", 5)
+			f.item(product, 2)
+
+			body := gin.H{"address_id": f.user.AddressDetails[0].AddressID.Hex(), "payment_method": "card"}
+			status, response := f.serve(http.MethodPost, "/checkout", body)
+			if status != test.status {
+				t.Fatalf("status = %d, want %d: %v", status, test.status, response)
+			}
+			if got := f.quantities()[product.ProductID]; got != test.cart {
+				t.Errorf("quantity in cart = %d, want %d", got, test.cart)
+			}
+			stored, err := f.repos.Products.FindByID(f.context, product.ProductID)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if stored.Stock != test.stock {
+				t.Errorf("stock = %d, want %d", stored.Stock, test.stock)
+			}
+
+			placed, err := f.repos.Orders.FindByUser(f.context, f.user.ID)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if test.orderStatus == "" {
+				if len(placed) != 0 {
+					t.Errorf("got orders %+v, want none", placed)
+				}
+			} else if len(placed) != 1 || placed[0].Status != test.orderStatus || placed[0].Total.Decimal() != "20.00" {
+				t.Errorf("got orders %+v, want one %s order of 20.00", placed, test.orderStatus)
+			}
+
+			if test.transaction != "" {
+				// The fake provider numbers its transactions, the checkout authorized the first one
+				_, err := provider.Void(f.context, "fake_txn_000001")
+				if !errors.Is(err, payments.ErrInvalidTransactionState) || !strings.HasSuffix(err.Error(), string(test.transaction)) {
+					t.Errorf("voiding the authorization again = %v, want it already %s", err, test.transaction)
+				}
+			}
+		})
+	}
+}
@@ -6,36 +6,51 @@ import (
 	"net/http"
 	"time"
 
-	"github.com/YassinNouh21/GoShopCart-Ecommerce/database"
+	"github.com/YassinNouh21/GoShopCart-Ecommerce/helpers"
 	userModels "github.com/YassinNouh21/GoShopCart-Ecommerce/models/user"
+	"github.com/YassinNouh21/GoShopCart-Ecommerce/money"
+	"github.com/YassinNouh21/GoShopCart-Ecommerce/repositories"
 
 	"github.com/gin-gonic/gin"
-	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
 var (
-	ErrUnauthorized    = errors.New("Unauthorized")
-	ErrInvalidID       = errors.New("Invalid id")
-	ErrUserNotFound    = errors.New("User not found")
-	ErrInvalidRequest  = errors.New("Invalid request body")
-	ErrUpdateFailed    = errors.New("Failed to update user")
-	ErrProductNotFound = errors.New("Product not found")
+	ErrUnauthorized        = errors.New("Unauthorized")
+	ErrInvalidID           = errors.New("Invalid id")
+	ErrUserNotFound        = errors.New("User not found")
+	ErrInvalidRequest      = errors.New("Invalid request body")
+	ErrUpdateFailed        = errors.New("Failed to update user")
+	ErrProductNotFound     = errors.New("Product not found")
+	ErrUnsupportedCurrency = errors.New("Unsupported currency, it needs an exchange rate")
 )
 
+// ProfileController serves the profile endpoints on top of a UserRepository.
+type ProfileController struct {
+	users repositories.UserRepository
+}
+
+// NewProfileController creates a ProfileController that reads users from the given repository.
+func NewProfileController(users repositories.UserRepository) *ProfileController {
+	return &ProfileController{users: users}
+}
+
 // profileRespose represents the response structure for the profile request.
 type profileRespose struct {
 	UserID         string               `json:"userid"`
 	FirstName      string               `json:"first_name"`
 	LastName       string               `json:"last_name"`
 	Email          string               `json:"email"`
+	Role           userModels.Role      `json:"role"`
+	Currency       money.Currency       `json:"currency"`
+	TaxExempt      bool                 `json:"tax_exempt"`
 	CreatedAt      time.Time            `json:"created_at"`
 	UpdatedAt      time.Time            `json:"updated_at"`
 	AddressDetails []userModels.Address `json:"address"`
 }
 
 /*
-GetProfileController handles the retrieval of user profile information.
+GetProfile handles the retrieval of user profile information.
 
 	It retrieves the user ID from the request context, queries the database for the user with the corresponding ID,
 	and returns the user's profile information.
@@ -45,7 +60,7 @@ Possible Errors:
   - ErrInvalidID: If the user ID in the request context is not a valid ObjectID.
   - ErrUserNotFound: If no user with the provided ID exists in the database.
 */
-func GetProfileController(c *gin.Context) {
+func (pc *ProfileController) GetProfile(c *gin.Context) {
 	// Create a context with a timeout of 10 seconds
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancel()
@@ -67,9 +82,7 @@ func GetProfileController(c *gin.Context) {
 	}
 
 	// Query the database for the user with the corresponding ID
-	filter := bson.M{"_id": objectID}
-	var user userModels.User
-	err = database.DB.UserCollection.FindOne(ctx, filter).Decode(&user)
+	user, err := pc.users.FindByID(ctx, objectID)
 	if err != nil {
 		c.JSON(http.StatusNotFound, gin.H{"error": ErrUserNotFound.Error()})
 		c.Abort()
@@ -82,6 +95,9 @@ func GetProfileController(c *gin.Context) {
 		FirstName:      user.FirstName,
 		LastName:       user.LastName,
 		Email:          user.Email,
+		Role:           userModels.RoleOf(*user),
+		Currency:       user.Currency,
+		TaxExempt:      user.TaxExempt,
 		CreatedAt:      user.CreatedAt,
 		UpdatedAt:      user.UpdatedAt,
 		AddressDetails: user.AddressDetails,
@@ -98,7 +114,7 @@ type UpdateProfile struct {
 }
 
 /*
-UpdateProfileController handles the updating of user profile information.
+UpdateProfile handles the updating of user profile information.
 
 It retrieves the user ID from the request context, parses the JSON request body containing the updated user information,
 validates the request body, updates the user's profile information in the database, and returns a success message.
@@ -109,7 +125,7 @@ Possible Errors:
   - ErrUpdateFailed: If an error occurs while updating the user's profile information in the database.
   - ErrUserNotFound: If no user with the provided ID exists in the database.
 */
-func UpdateProfileController(c *gin.Context) {
+func (pc *ProfileController) UpdateProfile(c *gin.Context) {
 	// Create a context with a timeout of 10 seconds
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancel()
@@ -131,22 +147,25 @@ func UpdateProfileController(c *gin.Context) {
 		return
 	}
 
-	// Convert the user ID to an ObjectID and create a filter for the update query
-	objectID, err := primitive.ObjectIDFromHex(userID.(string))
-	filter := bson.M{"_id": objectID}
+	// Convert the user ID to an ObjectID
+	objectID, _ := primitive.ObjectIDFromHex(userID.(string))
 
 	// Update the user's profile information in the database
-	update := bson.M{"$set": updatedUser}
-	result, err := database.DB.UserCollection.UpdateOne(ctx, filter, update)
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": ErrUpdateFailed.Error()})
+	err = pc.users.UpdateProfile(ctx, objectID, repositories.ProfileUpdate{
+		FirstName:      updatedUser.FirstName,
+		LastName:       updatedUser.LastName,
+		Email:          updatedUser.Email,
+		AddressDetails: updatedUser.AddressDetails,
+	})
+
+	// Check if the update matched the user
+	if err == repositories.ErrNotFound {
+		c.JSON(http.StatusNotFound, gin.H{"error": ErrUserNotFound.Error()})
 		c.Abort()
 		return
 	}
-
-	// Check if the update query matched any documents in the database
-	if result.MatchedCount == 0 {
-		c.JSON(http.StatusNotFound, gin.H{"error": ErrUserNotFound.Error()})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": ErrUpdateFailed.Error()})
 		c.Abort()
 		return
 	}
@@ -154,3 +173,57 @@ func UpdateProfileController(c *gin.Context) {
 	// Return a success message
 	c.JSON(http.StatusOK, gin.H{"message": "User updated successfully"})
 }
+
+// UpdateCurrency represents the request structure for setting the preferred currency of the user.
+type UpdateCurrency struct {
+	Currency string `json:"currency"`
+}
+
+/*
+UpdateCurrency sets the currency the user prefers prices in.
+
+	The preference applies to the requests without an X-Currency header. The currency must have an
+	exchange rate; an empty currency clears the preference, so prices are in the store currency.
+
+Possible Errors:
+  - ErrUnauthorized: If the user ID is not found in the request context.
+  - ErrInvalidRequest: If the request body is not in the expected format.
+  - ErrUnsupportedCurrency: If the currency is unknown or has no exchange rate.
+  - ErrUserNotFound: If no user with the provided ID exists in the database.
+  - ErrUpdateFailed: If an error occurs while updating the user in the database.
+*/
+func (pc *ProfileController) UpdateCurrency(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	objectID, err := primitive.ObjectIDFromHex(c.GetString("user_id"))
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": ErrUnauthorized.Error()})
+		return
+	}
+	var request UpdateCurrency
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": ErrInvalidRequest.Error()})
+		return
+	}
+	var currency money.Currency
+	if request.Currency != "" {
+		_, rates := helpers.RequestCurrency(c)
+		currency, err = money.ParseCurrency(request.Currency)
+		if err != nil || !rates.Supports(currency) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": ErrUnsupportedCurrency.Error()})
+			return
+		}
+	}
+
+	err = pc.users.UpdateCurrency(ctx, objectID, currency)
+	if err == repositories.ErrNotFound {
+		c.JSON(http.StatusNotFound, gin.H{"error": ErrUserNotFound.Error()})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": ErrUpdateFailed.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Currency updated successfully", "currency": currency})
+}
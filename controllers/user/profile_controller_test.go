@@ -0,0 +1,55 @@
+package user
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/YassinNouh21/GoShopCart-Ecommerce/middlewares"
+	"github.com/YassinNouh21/GoShopCart-Ecommerce/money"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestUpdateCurrency(t *testing.T) {
+	// The user prefers EUR; EUR and JPY have an exchange rate, GBP does not
+	tests := []struct {
+		name     string
+		body     interface{}
+		status   int
+		currency money.Currency
+	}{
+		{name: "currency with a rate", body: gin.H{"currency": "jpy"}, status: http.StatusOK, currency: money.JPY},
+		{name: "store currency", body: gin.H{"currency": "USD"}, status: http.StatusOK, currency: money.USD},
+		{name: "clear the preference", body: gin.H{"currency": ""}, status: http.StatusOK, currency: ""},
+		{name: "currency without a rate", body: gin.H{"currency": "GBP"}, status: http.StatusBadRequest, currency: money.EUR},
+		{name: "unknown currency", body: gin.H{"currency": "euro"}, status: http.StatusBadRequest, currency: money.EUR},
+		{name: "malformed body", body: "{", status: http.StatusBadRequest, currency: money.EUR},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			f := newFixture(t)
+			for _, rate := range []money.ExchangeRate{{Currency: money.EUR, Rate: "0.92"}, {Currency: money.JPY, Rate: "150"}} {
+				if err := f.repos.ExchangeRates.Save(f.context, &rate); err != nil {
+					t.Fatal(err)
+				}
+			}
+			if err := f.repos.Users.UpdateCurrency(f.context, f.user.ID, money.EUR); err != nil {
+				t.Fatal(err)
+			}
+			currency := middlewares.Currency(f.repos.Users, f.repos.ExchangeRates)
+			f.router.PUT("/user/profile/currency", currency, NewProfileController(f.repos.Users).UpdateCurrency)
+
+			status, response := f.serve(http.MethodPut, "/user/profile/currency", test.body)
+			if status != test.status {
+				t.Fatalf("status = %d, want %d: %v", status, test.status, response)
+			}
+			stored, err := f.repos.Users.FindByID(f.context, f.user.ID)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if stored.Currency != test.currency {
+				t.Errorf("preferred currency = %q, want %q", stored.Currency, test.currency)
+			}
+		})
+	}
+}
@@ -10,6 +10,11 @@ import (
 	"go.mongodb.org/mongo-driver/mongo/options"
 )
 
+// DatabaseName is the name of the MongoDB database used by the application.
+const DatabaseName = "e-commerce"
+
+// MongoInstance connects to the MongoDB server configured by MONGO_URI and pings it.
+// It panics if the server cannot be reached.
 func MongoInstance() *mongo.Client {
 	godotenv.Load(".env")
 	uri := os.Getenv("MONGO_URI")
@@ -22,22 +27,28 @@ func MongoInstance() *mongo.Client {
 		panic(err)
 	}
 	// Send a ping to confirm a successful connection
-	if err := client.Database("e-commerce").RunCommand(context.TODO(), bson.D{{Key: "ping", Value: 1}}).Err(); err != nil {
+	if err := client.Database(DatabaseName).RunCommand(context.TODO(), bson.D{{Key: "ping", Value: 1}}).Err(); err != nil {
 		panic(err)
 	}
 	return client
 }
 
-var MongoDBInstance *mongo.Client = MongoInstance()
-
-func GetCollectionMongoDB(collectionName string) *mongo.Collection {
-	collection := MongoDBInstance.Database("e-commerce").Collection(collectionName)
-	return collection
-}
-
-func InitializeMongoDBCollections() {
-	mongoDBCollectionProducts := MongoDBInstance.Database("e-commerce").Collection("products")
-	mongoDBCollectionUsers := MongoDBInstance.Database("e-commerce").Collection("users")
-
-	InitializeDatabase(mongoDBCollectionUsers, mongoDBCollectionProducts)
+// InitializeMongoDBCollections returns the collections of the application database.
+func InitializeMongoDBCollections(client *mongo.Client) *DatabaseCollection {
+	db := client.Database(DatabaseName)
+	return &DatabaseCollection{
+		Client:                 client,
+		UserCollection:         db.Collection("users"),
+		ProductCollection:      db.Collection("products"),
+		CategoryCollection:     db.Collection("categories"),
+		OrderCollection:        db.Collection("orders"),
+		ReviewCollection:       db.Collection("reviews"),
+		ExchangeRateCollection: db.Collection("exchange_rates"),
+		PromotionCollection:    db.Collection("promotions"),
+		RedemptionCollection:   db.Collection("promotion_redemptions"),
+		TaxRateCollection:      db.Collection("tax_rates"),
+		ShippingZoneCollection: db.Collection("shipping_zones"),
+		CartCollection:         db.Collection("carts"),
+		GuestCartCollection:    db.Collection("guest_carts"),
+	}
 }
@@ -2,19 +2,19 @@ package database
 
 import "go.mongodb.org/mongo-driver/mongo"
 
-// DatabaseCollection holds the database collections.
+// DatabaseCollection holds the database client and collections.
 type DatabaseCollection struct {
-	UserCollection    *mongo.Collection
-	ProductCollection *mongo.Collection
-}
-
-// DB holds the instance of the DatabaseCollection used in the project.
-var DB *DatabaseCollection
-
-// InitializeDatabase initializes the database collections with the provided user and product collections.
-func InitializeDatabase(userCollection, productCollection *mongo.Collection) {
-	DB = &DatabaseCollection{
-		UserCollection:    userCollection,
-		ProductCollection: productCollection,
-	}
+	Client                 *mongo.Client
+	UserCollection         *mongo.Collection
+	ProductCollection      *mongo.Collection
+	CategoryCollection     *mongo.Collection
+	OrderCollection        *mongo.Collection
+	ReviewCollection       *mongo.Collection
+	ExchangeRateCollection *mongo.Collection
+	PromotionCollection    *mongo.Collection
+	RedemptionCollection   *mongo.Collection
+	TaxRateCollection      *mongo.Collection
+	ShippingZoneCollection *mongo.Collection
+	CartCollection         *mongo.Collection
+	GuestCartCollection    *mongo.Collection
 }
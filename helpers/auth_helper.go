@@ -14,7 +14,7 @@ import (
 
 	- HashPassword: Hashes the provided password using bcrypt. It returns the hashed password as a string and an error if any.
 
-	- CheckUserType: Checks if the user type in the context matches the provided user role. It returns an error if the user is not authorized to access the resource.
+	- CheckUserType: Checks if the user type in the context matches one of the provided user roles. It returns an error if the user is not authorized to access the resource.
 
 	- VerifyPassword: Compares the hashed password with the input password. It returns true if the passwords match, false otherwise.
 */
@@ -29,14 +29,16 @@ func HashPassword(password string) (string, error) {
 	return string(hash), nil
 }
 
-// CheckUserType checks if the user type in the context matches the provided user role.
+// CheckUserType checks if the user type in the context matches one of the provided user roles.
 // It returns an error if the user is not authorized to access the resource.
-func CheckUserType(c *gin.Context, userRole string) error {
+func CheckUserType(c *gin.Context, userRoles ...string) error {
 	userType := c.GetString("user_type")
-	if userType != userRole {
-		return fmt.Errorf("user is not authorized to access this resource")
+	for _, userRole := range userRoles {
+		if userType == userRole {
+			return nil
+		}
 	}
-	return nil
+	return fmt.Errorf("user is not authorized to access this resource")
 }
 
 // VerifyPassword compares the hashed password with the input password.
@@ -0,0 +1,78 @@
+package helpers
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/golang-jwt/jwt"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+/*
+	Guest carts are identified by a cart token: a JWT signed with the same secret as the user tokens,
+	naming the guest cart it was issued for. The token itself does not expire; the guest cart does, so
+	a token outlives its cart and then simply finds no cart.
+*/
+
+// CartTokenHeader is the request and response header carrying the cart token of a guest cart.
+const CartTokenHeader = "X-Cart-Token"
+
+// DefaultGuestCartTTL is how long a guest cart is kept after its last change.
+const DefaultGuestCartTTL = 30 * 24 * time.Hour
+
+// cartTokenAudience tells cart tokens apart from the user tokens signed with the same secret.
+const cartTokenAudience = "guest_cart"
+
+// ErrInvalidCartToken is returned when a cart token is not one issued by GenerateCartToken.
+var ErrInvalidCartToken = errors.New("Invalid cart token")
+
+// CartClaims represents the claims of a cart token.
+type CartClaims struct {
+	CartID string
+	jwt.StandardClaims
+}
+
+// GenerateCartToken returns the signed cart token of the guest cart with the given ID.
+func GenerateCartToken(cartID primitive.ObjectID) (string, error) {
+	claims := CartClaims{
+		CartID:         cartID.Hex(),
+		StandardClaims: jwt.StandardClaims{Audience: cartTokenAudience, IssuedAt: time.Now().Unix()},
+	}
+	return jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString(apiKey())
+}
+
+// ValidateCartToken checks the signature of the cart token and returns the ID of its guest cart.
+// It returns ErrInvalidCartToken if the token is malformed, signed with another key or not a cart token.
+func ValidateCartToken(cartToken string) (primitive.ObjectID, error) {
+	claims := &CartClaims{}
+	token, err := jwt.ParseWithClaims(cartToken, claims, func(token *jwt.Token) (interface{}, error) {
+		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
+			return nil, ErrInvalidCartToken
+		}
+		return apiKey(), nil
+	})
+	if err != nil || !token.Valid || !claims.VerifyAudience(cartTokenAudience, true) {
+		return primitive.NilObjectID, ErrInvalidCartToken
+	}
+	cartID, err := primitive.ObjectIDFromHex(claims.CartID)
+	if err != nil {
+		return primitive.NilObjectID, ErrInvalidCartToken
+	}
+	return cartID, nil
+}
+
+// GuestCartTTLFromEnv reads how long guest carts are kept from the GUEST_CART_TTL environment variable,
+// a Go duration such as "720h". It returns DefaultGuestCartTTL if the variable is not set.
+func GuestCartTTLFromEnv() (time.Duration, error) {
+	value := os.Getenv("GUEST_CART_TTL")
+	if value == "" {
+		return DefaultGuestCartTTL, nil
+	}
+	ttl, err := time.ParseDuration(value)
+	if err != nil || ttl <= 0 {
+		return 0, fmt.Errorf("invalid GUEST_CART_TTL %q", value)
+	}
+	return ttl, nil
+}
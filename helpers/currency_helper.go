@@ -0,0 +1,49 @@
+package helpers
+
+import (
+	"context"
+
+	"github.com/YassinNouh21/GoShopCart-Ecommerce/money"
+	"github.com/YassinNouh21/GoShopCart-Ecommerce/repositories"
+
+	"github.com/gin-gonic/gin"
+)
+
+/*
+	This file implements the currency of a request, shared by the product, cart and order endpoints.
+
+	- LoadExchangeRates: Reads the exchange rates from the store currency managed by the admins.
+	- RequestCurrency: Returns the currency selected for the request by the Currency middleware, with
+	  the exchange rates to convert prices to it.
+*/
+
+const (
+	// CurrencyKey is the context key of the currency selected for the request.
+	CurrencyKey = "currency"
+	// ExchangeRatesKey is the context key of the exchange rates loaded for the request.
+	ExchangeRatesKey = "exchange_rates"
+)
+
+// LoadExchangeRates reads the exchange rates from the store currency to the other currencies.
+func LoadExchangeRates(ctx context.Context, exchangeRates repositories.ExchangeRateRepository) (*money.Rates, error) {
+	rates, err := exchangeRates.FindAll(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return money.NewRates(money.StoreCurrency(), rates)
+}
+
+// RequestCurrency returns the currency prices are shown and charged in for the request, with the
+// exchange rates to convert them. Requests that did not go through the Currency middleware are in the
+// store currency.
+func RequestCurrency(c *gin.Context) (money.Currency, *money.Rates) {
+	currency, _ := c.Get(CurrencyKey)
+	rates, _ := c.Get(ExchangeRatesKey)
+	selected, ok := currency.(money.Currency)
+	converter, loaded := rates.(*money.Rates)
+	if !ok || !loaded {
+		converter, _ = money.NewRates(money.StoreCurrency(), nil)
+		return money.StoreCurrency(), converter
+	}
+	return selected, converter
+}
@@ -0,0 +1,23 @@
+package helpers
+
+// MergePatch applies a JSON Merge Patch (RFC 7386) to a decoded JSON object.
+// Keys set to null in the patch are removed from the target, nested objects are merged
+// recursively, and any other value replaces the one in the target. It returns the merged target.
+func MergePatch(target map[string]interface{}, patch map[string]interface{}) map[string]interface{} {
+	if target == nil {
+		target = map[string]interface{}{}
+	}
+	for key, value := range patch {
+		if value == nil {
+			delete(target, key)
+			continue
+		}
+		if patchObject, ok := value.(map[string]interface{}); ok {
+			targetObject, _ := target[key].(map[string]interface{})
+			target[key] = MergePatch(targetObject, patchObject)
+			continue
+		}
+		target[key] = value
+	}
+	return target
+}
@@ -0,0 +1,41 @@
+package helpers
+
+import (
+	"context"
+	"time"
+
+	userModel "github.com/YassinNouh21/GoShopCart-Ecommerce/models/user"
+	"github.com/YassinNouh21/GoShopCart-Ecommerce/payments"
+)
+
+/*
+	This file implements the payment operations shared by the customer and admin order endpoints.
+
+	- ReleasePayment: Gives the money of a cancelled or refunded order back to the customer. An authorized
+	  payment is voided and a captured payment is refunded at the provider.
+*/
+
+// ReleasePayment voids the payment if it is only authorized and refunds it if it was captured.
+// It returns the updated payment, or nil if there is nothing to release.
+func ReleasePayment(ctx context.Context, provider payments.PaymentProvider, payment *userModel.OrderPayment) (*userModel.OrderPayment, error) {
+	if payment == nil {
+		return nil, nil
+	}
+	var err error
+	released := *payment
+	switch payment.Status {
+	case userModel.PaymentStatusAuthorized:
+		_, err = provider.Void(ctx, payment.TransactionID)
+		released.Status = userModel.PaymentStatusVoided
+	case userModel.PaymentStatusCaptured:
+		_, err = provider.Refund(ctx, payment.TransactionID)
+		released.Status = userModel.PaymentStatusRefunded
+	default:
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	released.UpdatedAt = time.Now().UTC()
+	return &released, nil
+}
@@ -4,17 +4,15 @@ import (
 	"context"
 	"errors"
 	"fmt"
-	"github.com/YassinNouh21/GoShopCart-Ecommerce/database"
 	userModel "github.com/YassinNouh21/GoShopCart-Ecommerce/models/user"
+	"github.com/YassinNouh21/GoShopCart-Ecommerce/repositories"
 	"log"
 	"os"
 	"strings"
 	"time"
 
 	"github.com/golang-jwt/jwt"
-	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
-	//"go.mongodb.org/mongo-driver/mongo"
 )
 
 /*
@@ -34,33 +32,35 @@ import (
 
 */
 
-// mongoDBCollectionUser represents the MongoDB collection for user data.
-//var mongoDBCollectionUser mongo.Collection = *database.DB.UserCollection
-
 // UserClaims represents the custom claims for a JWT token.
 type UserClaims struct {
 	Email     string
 	FirstName string
 	ID        string
+	Role      string
 	jwt.StandardClaims
 }
 
-// CreateUserClaims creates a new UserClaims instance with the provided email, first name, and ID.
-func CreateUserClaims(email string, firstName string, id string) *UserClaims {
+// CreateUserClaims creates a new UserClaims instance with the provided email, first name, ID, and role.
+func CreateUserClaims(email string, firstName string, id string, role userModel.Role) *UserClaims {
 	return &UserClaims{
 		Email:     email,
 		FirstName: firstName,
 		ID:        id,
+		Role:      string(role),
 	}
 }
 
-// API_KEY is the secret key used for JWT token generation and verification.
-var API_KEY = []byte(os.Getenv("SECRET_JWT"))
+// apiKey returns the secret key used for JWT token generation and verification.
+// It is read on every call so the key loaded from the .env file at startup is used.
+func apiKey() []byte {
+	return []byte(os.Getenv("SECRET_JWT"))
+}
 
 // GenerateToken generates a new JWT token and refresh token based on the provided user claims.
 // It returns the signed token, signed refresh token, and any error encountered.
 func GenerateToken(userclaim UserClaims) (signedToken string, signedRefreshToken string, err error) {
-	secretKey := API_KEY
+	secretKey := apiKey()
 
 	// Set expiration time for the token
 	userclaim.StandardClaims = jwt.StandardClaims{
@@ -96,27 +96,19 @@ func GenerateToken(userclaim UserClaims) (signedToken string, signedRefreshToken
 }
 
 // UpdateToken updates the user's access token and refresh token in the database.
-// It takes the signed access token, signed refresh token, and user ID as parameters.
+// It takes the user repository, signed access token, signed refresh token, and user ID as parameters.
 // It returns an error if the token update operation fails.
-func UpdateToken(signedToken string, signedRefreshToken string, userId primitive.ObjectID) error {
+func UpdateToken(users repositories.UserRepository, signedToken string, signedRefreshToken string, userId primitive.ObjectID) error {
 	var ctx, cancel = context.WithTimeout(context.Background(), 10*time.Second)
-
-	// Create the update operation with the new tokens and update time
-	update := bson.D{{Key: "$set", Value: bson.D{{Key: "token", Value: signedToken}, {Key: "refreshtoken", Value: signedRefreshToken}, {Key: "updateat", Value: time.Now()}}}}
-
-	// Create the filter for the user ID
-	filter := bson.D{{Key: "_id", Value: userId}}
-
-	// Perform the update operation on the user collection
-	updatedUser, err := database.DB.UserCollection.UpdateOne(ctx, filter, update)
 	defer cancel()
 
-	if updatedUser.MatchedCount == 0 {
+	err := users.UpdateTokens(ctx, userId, signedToken, signedRefreshToken)
+	if err == repositories.ErrNotFound {
 		return errors.New("User token is not updated")
 	}
 
 	if err != nil {
-		log.Panic(err)
+		log.Println(err)
 		return err
 	}
 
@@ -126,9 +118,9 @@ func UpdateToken(signedToken string, signedRefreshToken string, userId primitive
 // ValidateToken validates the provided JWT token and returns the claims if valid.
 // It also checks the token against the user's token in the database for additional validation.
 // It returns the claims and an error message if any issue occurs during validation.
-func ValidateToken(verifyToken string) (claim *UserClaims, errorMessage string) {
+func ValidateToken(users repositories.UserRepository, verifyToken string) (claim *UserClaims, errorMessage string) {
 	token, err := jwt.ParseWithClaims(verifyToken, &UserClaims{}, func(token *jwt.Token) (interface{}, error) {
-		return []byte(API_KEY), nil
+		return apiKey(), nil
 	})
 	fmt.Println("token", err, verifyToken)
 
@@ -144,8 +136,7 @@ func ValidateToken(verifyToken string) (claim *UserClaims, errorMessage string)
 	}
 
 	// Check if the user exists in the database
-	var user userModel.User
-	err = database.DB.UserCollection.FindOne(context.Background(), bson.M{"_id": &userIdPrimitive}).Decode(&user)
+	user, err := users.FindByID(context.Background(), userIdPrimitive)
 	if err != nil {
 		return nil, "user not found"
 	}
@@ -155,6 +146,9 @@ func ValidateToken(verifyToken string) (claim *UserClaims, errorMessage string)
 		return nil, "token is not valid"
 	}
 
+	// The stored role is authoritative, so role changes apply to tokens issued before them
+	claim.Role = string(userModel.RoleOf(*user))
+
 	if !ok {
 		return nil, "error while parsing claims"
 	}
@@ -169,9 +163,9 @@ func ValidateToken(verifyToken string) (claim *UserClaims, errorMessage string)
 // ValidateRefreshToken validates the provided refresh token and returns the claims if valid.
 // It also checks the token against the user's refresh token in the database for additional validation.
 // It returns the claims and an error message if any issue occurs during validation.
-func ValidateRefreshToken(verifyToken string) (claim *UserClaims, errorMessage string) {
+func ValidateRefreshToken(users repositories.UserRepository, verifyToken string) (claim *UserClaims, errorMessage string) {
 	token, err := jwt.ParseWithClaims(verifyToken, &UserClaims{}, func(token *jwt.Token) (interface{}, error) {
-		return []byte(API_KEY), nil
+		return apiKey(), nil
 	})
 	fmt.Println("refresh token", err, verifyToken)
 
@@ -187,8 +181,7 @@ func ValidateRefreshToken(verifyToken string) (claim *UserClaims, errorMessage s
 	}
 
 	// Check if the user exists in the database
-	var user userModel.User
-	err = database.DB.UserCollection.FindOne(context.Background(), bson.M{"_id": &userIdPrimitive}).Decode(&user)
+	user, err := users.FindByID(context.Background(), userIdPrimitive)
 	if err != nil {
 		return nil, "user not found"
 	}
@@ -198,6 +191,9 @@ func ValidateRefreshToken(verifyToken string) (claim *UserClaims, errorMessage s
 		return nil, "token is not valid"
 	}
 
+	// The stored role is authoritative, so refreshed tokens carry the current role
+	claim.Role = string(userModel.RoleOf(*user))
+
 	if !ok {
 		return nil, "error while parsing claims"
 	}
@@ -212,8 +208,8 @@ func ValidateRefreshToken(verifyToken string) (claim *UserClaims, errorMessage s
 // GenerateNewAccessToken generates a new access token based on the provided refresh token.
 // It validates the refresh token and checks the user's existence in the database.
 // It returns the signed access token and any error encountered.
-func GenerateNewAccessToken(refreshToken string) (signedToken string, err error) {
-	claim, errString := ValidateRefreshToken(refreshToken)
+func GenerateNewAccessToken(users repositories.UserRepository, refreshToken string) (signedToken string, err error) {
+	claim, errString := ValidateRefreshToken(users, refreshToken)
 	if errString != "" {
 		return "", errors.New(errString)
 	}
@@ -221,13 +217,12 @@ func GenerateNewAccessToken(refreshToken string) (signedToken string, err error)
 	var ctx, cancel = context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancel()
 
-	var user userModel.User
 	userIdPrimitive, err := primitive.ObjectIDFromHex(claim.ID)
 	if err != nil {
 		return "", errors.New("invalid user id")
 	}
 
-	err = database.DB.UserCollection.FindOne(ctx, bson.M{"_id": &userIdPrimitive}).Decode(&user)
+	_, err = users.FindByID(ctx, userIdPrimitive)
 	if err != nil {
 		return "", errors.New("user not found")
 	}
@@ -237,7 +232,7 @@ func GenerateNewAccessToken(refreshToken string) (signedToken string, err error)
 		return "", errors.New("error while generating new token")
 	}
 
-	_, err = database.DB.UserCollection.UpdateOne(ctx, bson.M{"_id": &userIdPrimitive}, bson.M{"$set": bson.M{"token": signedToken}})
+	err = users.UpdateAccessToken(ctx, userIdPrimitive, signedToken)
 	if err != nil {
 		return "", errors.New("error while updating token")
 	}
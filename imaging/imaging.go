@@ -0,0 +1,176 @@
+package imaging
+
+import (
+	"bytes"
+	"errors"
+	"image"
+	"image/draw"
+	_ "image/gif"
+	"image/jpeg"
+	"image/png"
+	"net/http"
+)
+
+/*
+	Package imaging validates uploaded images and generates their thumbnails.
+
+	Only JPEG, PNG and GIF images are accepted, recognized by their content rather than by the name
+	or type the client sends. Thumbnails are scaled down to fit the square of every ThumbnailSize,
+	keeping the aspect ratio, and are encoded as JPEG for JPEG images and as PNG otherwise, so
+	transparency is kept. GIF animations are reduced to their first frame.
+*/
+
+const (
+	// MaxImageSize is the largest image, in bytes, that is accepted.
+	MaxImageSize = 5 << 20
+	// MaxImageDimension is the largest width or height, in pixels, of an accepted image. It keeps
+	// small but highly compressed files from expanding into huge images when decoded.
+	MaxImageDimension = 6000
+	// jpegQuality is the quality the JPEG thumbnails are encoded with.
+	jpegQuality = 85
+)
+
+var (
+	// ErrImageTooLarge is returned when an image exceeds MaxImageSize or MaxImageDimension.
+	ErrImageTooLarge = errors.New("image too large")
+
+	// ErrUnsupportedImageType is returned when the content is not a JPEG, PNG or GIF image.
+	ErrUnsupportedImageType = errors.New("unsupported image type")
+
+	// ErrInvalidImage is returned when the image cannot be decoded.
+	ErrInvalidImage = errors.New("invalid image")
+)
+
+// ThumbnailSize names a square the thumbnails of that size fit in.
+type ThumbnailSize struct {
+	Name         string
+	MaxDimension int
+}
+
+// ThumbnailSizes are the thumbnails generated for every image, largest first.
+var ThumbnailSizes = []ThumbnailSize{
+	{Name: "large", MaxDimension: 800},
+	{Name: "medium", MaxDimension: 400},
+	{Name: "small", MaxDimension: 150},
+}
+
+// extensions maps the accepted content types to the extension of their files.
+var extensions = map[string]string{
+	"image/jpeg": ".jpg",
+	"image/png":  ".png",
+	"image/gif":  ".gif",
+}
+
+// Encoded is an image in one of the accepted formats.
+type Encoded struct {
+	Data        []byte
+	ContentType string
+	// Extension is the file extension of the format, with its dot.
+	Extension string
+	Width     int
+	Height    int
+}
+
+// Thumbnail is the thumbnail of an image in one of the ThumbnailSizes.
+type Thumbnail struct {
+	Size string
+	Encoded
+}
+
+// Processed is a validated image with its thumbnails.
+type Processed struct {
+	// Original is the uploaded image, unchanged.
+	Original   Encoded
+	Thumbnails []Thumbnail
+}
+
+// Process validates the image and generates a thumbnail in every ThumbnailSize. Images smaller than a
+// thumbnail size are never scaled up; their thumbnail of that size has the size of the image.
+func Process(data []byte) (*Processed, error) {
+	if len(data) > MaxImageSize {
+		return nil, ErrImageTooLarge
+	}
+	contentType := http.DetectContentType(data)
+	extension, supported := extensions[contentType]
+	if !supported {
+		return nil, ErrUnsupportedImageType
+	}
+	config, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return nil, ErrInvalidImage
+	}
+	if config.Width > MaxImageDimension || config.Height > MaxImageDimension {
+		return nil, ErrImageTooLarge
+	}
+	decoded, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, ErrInvalidImage
+	}
+
+	processed := &Processed{Original: Encoded{
+		Data:        data,
+		ContentType: contentType,
+		Extension:   extension,
+		Width:       config.Width,
+		Height:      config.Height,
+	}}
+	// Every thumbnail is scaled from the previous, larger one, which is much faster than scaling
+	// every size from the original and makes no visible difference with a box filter
+	source := toRGBA(decoded)
+	for _, size := range ThumbnailSizes {
+		width, height := fit(source.Bounds().Dx(), source.Bounds().Dy(), size.MaxDimension)
+		source = resize(source, width, height)
+		encoded, err := encode(source, contentType == "image/jpeg")
+		if err != nil {
+			return nil, err
+		}
+		processed.Thumbnails = append(processed.Thumbnails, Thumbnail{Size: size.Name, Encoded: encoded})
+	}
+	return processed, nil
+}
+
+// fit returns the size of an image of the given size scaled down to fit a square of maxDimension
+// pixels, keeping its aspect ratio. The image is left alone if it already fits.
+func fit(width, height, maxDimension int) (int, int) {
+	if width <= maxDimension && height <= maxDimension {
+		return width, height
+	}
+	if width >= height {
+		return maxDimension, maxInt(1, height*maxDimension/width)
+	}
+	return maxInt(1, width*maxDimension/height), maxDimension
+}
+
+// encode encodes the thumbnail as JPEG or PNG.
+func encode(img *image.RGBA, asJPEG bool) (Encoded, error) {
+	var buffer bytes.Buffer
+	encoded := Encoded{Width: img.Bounds().Dx(), Height: img.Bounds().Dy()}
+	if asJPEG {
+		if err := jpeg.Encode(&buffer, img, &jpeg.Options{Quality: jpegQuality}); err != nil {
+			return Encoded{}, err
+		}
+		encoded.ContentType, encoded.Extension = "image/jpeg", ".jpg"
+	} else {
+		if err := png.Encode(&buffer, img); err != nil {
+			return Encoded{}, err
+		}
+		encoded.ContentType, encoded.Extension = "image/png", ".png"
+	}
+	encoded.Data = buffer.Bytes()
+	return encoded, nil
+}
+
+// toRGBA converts the image to RGBA with its origin at (0, 0), so its pixels can be read directly.
+func toRGBA(img image.Image) *image.RGBA {
+	bounds := img.Bounds()
+	converted := image.NewRGBA(image.Rect(0, 0, bounds.Dx(), bounds.Dy()))
+	draw.Draw(converted, converted.Bounds(), img, bounds.Min, draw.Src)
+	return converted
+}
+
+func maxInt(a, b int) int {
+	if a > b {
+		return a
+	}
+	return b
+}
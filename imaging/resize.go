@@ -0,0 +1,47 @@
+package imaging
+
+import "image"
+
+// resize scales the image to the given size with a box filter: every pixel of the result is the
+// average of the pixels of the source it covers. The colors of RGBA images are premultiplied by
+// their alpha, so averaging them keeps the edges of transparent areas clean.
+func resize(src *image.RGBA, width, height int) *image.RGBA {
+	srcWidth, srcHeight := src.Bounds().Dx(), src.Bounds().Dy()
+	if width == srcWidth && height == srcHeight {
+		return src
+	}
+	dst := image.NewRGBA(image.Rect(0, 0, width, height))
+	for y := 0; y < height; y++ {
+		y0, y1 := span(y, height, srcHeight)
+		for x := 0; x < width; x++ {
+			x0, x1 := span(x, width, srcWidth)
+			var sums [4]uint64
+			for sy := y0; sy < y1; sy++ {
+				row := src.Pix[sy*src.Stride:]
+				for sx := x0; sx < x1; sx++ {
+					pixel := row[sx*4 : sx*4+4]
+					for i := range sums {
+						sums[i] += uint64(pixel[i])
+					}
+				}
+			}
+			count := uint64((y1 - y0) * (x1 - x0))
+			offset := y*dst.Stride + x*4
+			for i := range sums {
+				dst.Pix[offset+i] = uint8((sums[i] + count/2) / count)
+			}
+		}
+	}
+	return dst
+}
+
+// span returns the range of source pixels covered by the pixel i of a line of n pixels scaled from
+// srcN pixels. Every pixel covers at least one source pixel.
+func span(i, n, srcN int) (int, int) {
+	start := i * srcN / n
+	end := (i + 1) * srcN / n
+	if end <= start {
+		end = start + 1
+	}
+	return start, end
+}
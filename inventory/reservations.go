@@ -0,0 +1,192 @@
+package inventory
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"time"
+
+	"github.com/YassinNouh21/GoShopCart-Ecommerce/helpers"
+	"github.com/YassinNouh21/GoShopCart-Ecommerce/models/promotion"
+	userModel "github.com/YassinNouh21/GoShopCart-Ecommerce/models/user"
+	"github.com/YassinNouh21/GoShopCart-Ecommerce/payments"
+	"github.com/YassinNouh21/GoShopCart-Ecommerce/repositories"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+/*
+	Package inventory keeps the stock of the products in line with the orders.
+
+	Checkout reserves the stock of the ordered items by decrementing it with conditional updates, so two
+	checkouts can never sell the same unit. A reservation lasts until the order is paid; orders still
+	awaiting payment when it expires are cancelled by the sweeper, which voids their payment and gives
+	the stock back. Cancelling an order in any other way gives the stock back as well.
+
+	The promotions of an order are redeemed with its stock, so a coupon at its usage limit fails the
+	checkout like a product out of stock, and a cancelled order gives their uses back.
+*/
+
+// DefaultReservationTTL is how long the stock of an order awaiting payment stays reserved.
+const DefaultReservationTTL = 15 * time.Minute
+
+// Reservations reserves and releases the stock of orders.
+type Reservations struct {
+	products   repositories.ProductRepository
+	orders     repositories.OrderRepository
+	promotions repositories.PromotionRepository
+	payments   payments.PaymentProvider
+	ttl        time.Duration
+}
+
+// NewReservations creates Reservations that hold the stock and the promotions of an order for the
+// given duration.
+func NewReservations(products repositories.ProductRepository, orders repositories.OrderRepository, promotions repositories.PromotionRepository, provider payments.PaymentProvider, ttl time.Duration) *Reservations {
+	return &Reservations{products: products, orders: orders, promotions: promotions, payments: provider, ttl: ttl}
+}
+
+// ReservationTTLFromEnv reads the reservation duration from the STOCK_RESERVATION_TTL environment variable,
+// a Go duration such as "15m". It returns DefaultReservationTTL if the variable is not set.
+func ReservationTTLFromEnv() (time.Duration, error) {
+	value := os.Getenv("STOCK_RESERVATION_TTL")
+	if value == "" {
+		return DefaultReservationTTL, nil
+	}
+	ttl, err := time.ParseDuration(value)
+	if err != nil || ttl <= 0 {
+		return 0, fmt.Errorf("invalid STOCK_RESERVATION_TTL %q", value)
+	}
+	return ttl, nil
+}
+
+// Reserve decrements the stock of every item of the order, redeems its promotions and sets its
+// reservation expiry. Either everything is reserved or nothing: if one item is out of stock, the items
+// reserved so far are given back and an error wrapping repositories.ErrInsufficientStock is returned,
+// and if a promotion reached its usage limit, the stock is given back and an error wrapping
+// repositories.ErrLimitReached is returned.
+func (r *Reservations) Reserve(ctx context.Context, order *userModel.Order) error {
+	for i, item := range order.Items {
+		_, err := r.products.AdjustStock(ctx, item.ProductID, item.VariantID, -item.Quantity)
+		if err == nil {
+			continue
+		}
+		r.restock(ctx, order.Items[:i])
+		if err == repositories.ErrInsufficientStock {
+			return fmt.Errorf("%w: %s", err, item.ProductName)
+		}
+		return err
+	}
+	if err := r.redeem(ctx, order); err != nil {
+		r.restock(ctx, order.Items)
+		return err
+	}
+	reservedUntil := time.Now().UTC().Add(r.ttl)
+	order.ReservedUntil = &reservedUntil
+	return nil
+}
+
+// Release gives back the stock of a cancelled order.
+// Orders placed before stock reservations never took stock and are left alone.
+func (r *Reservations) Release(ctx context.Context, order userModel.Order) {
+	if order.ReservedUntil == nil {
+		return
+	}
+	r.restock(ctx, order.Items)
+	if len(order.Promotions) > 0 {
+		if err := r.promotions.ReleaseRedemptions(ctx, order.OrderID); err != nil {
+			log.Println("release promotions of order", order.OrderID.Hex(), ":", err)
+		}
+	}
+}
+
+// redeem records the use of every promotion of the order. If one cannot be redeemed, the uses
+// recorded so far are given back.
+func (r *Reservations) redeem(ctx context.Context, order *userModel.Order) error {
+	for _, applied := range order.Promotions {
+		err := r.promotions.Redeem(ctx, promotion.Redemption{
+			RedemptionID: primitive.NewObjectID(),
+			PromotionID:  applied.PromotionID,
+			UserID:       order.UserID,
+			OrderID:      order.OrderID,
+			CreatedAt:    order.CreatedAt,
+		})
+		if err == nil {
+			continue
+		}
+		if releaseErr := r.promotions.ReleaseRedemptions(ctx, order.OrderID); releaseErr != nil {
+			log.Println("release promotions of order", order.OrderID.Hex(), ":", releaseErr)
+		}
+		if err == repositories.ErrLimitReached || err == repositories.ErrNotFound {
+			return fmt.Errorf("%w: %s", repositories.ErrLimitReached, applied.Name)
+		}
+		return err
+	}
+	return nil
+}
+
+// restock increments the stock of the products of the items.
+// Products deleted in the meantime are skipped.
+func (r *Reservations) restock(ctx context.Context, items []userModel.OrderItem) {
+	for _, item := range items {
+		_, err := r.products.AdjustStock(ctx, item.ProductID, item.VariantID, item.Quantity)
+		if err != nil && err != repositories.ErrNotFound {
+			log.Println("restock product", item.ProductID.Hex(), ":", err)
+		}
+	}
+}
+
+// ExpireOverdue cancels the orders whose reservation expired before now while they were still awaiting
+// payment, voids their payment and gives their stock back. It returns the number of cancelled orders.
+func (r *Reservations) ExpireOverdue(ctx context.Context, now time.Time) (int, error) {
+	orders, err := r.orders.FindExpiredReservations(ctx, now)
+	if err != nil {
+		return 0, err
+	}
+	cancelled := 0
+	for _, order := range orders {
+		change, err := order.Transition(userModel.OrderStatusCancelled, userModel.SystemActor, "Payment not completed before the stock reservation expired")
+		if err != nil {
+			continue
+		}
+		// The conditional status update comes first, so an order paid in the meantime is left alone
+		err = r.orders.UpdateStatus(ctx, order.OrderID, change)
+		if err == repositories.ErrVersionConflict || err == repositories.ErrNotFound {
+			continue
+		}
+		if err != nil {
+			return cancelled, err
+		}
+		cancelled++
+		r.Release(ctx, order)
+
+		released, err := helpers.ReleasePayment(ctx, r.payments, order.Payment)
+		if err != nil {
+			log.Println("void payment of expired order", order.OrderID.Hex(), ":", err)
+			continue
+		}
+		if released != nil {
+			if err := r.orders.UpdatePayment(ctx, order.OrderID, *released); err != nil {
+				log.Println("record payment of expired order", order.OrderID.Hex(), ":", err)
+			}
+		}
+	}
+	return cancelled, nil
+}
+
+// RunSweeper calls ExpireOverdue at every interval until the context is done.
+func (r *Reservations) RunSweeper(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			sweepCtx, cancel := context.WithTimeout(ctx, interval)
+			if _, err := r.ExpireOverdue(sweepCtx, now.UTC()); err != nil {
+				log.Println("expire stock reservations:", err)
+			}
+			cancel()
+		}
+	}
+}
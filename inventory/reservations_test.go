@@ -0,0 +1,187 @@
+package inventory
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	productModel "github.com/YassinNouh21/GoShopCart-Ecommerce/models/product"
+	"github.com/YassinNouh21/GoShopCart-Ecommerce/models/promotion"
+	userModel "github.com/YassinNouh21/GoShopCart-Ecommerce/models/user"
+	"github.com/YassinNouh21/GoShopCart-Ecommerce/money"
+	"github.com/YassinNouh21/GoShopCart-Ecommerce/payments"
+	"github.com/YassinNouh21/GoShopCart-Ecommerce/repositories"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// storeProduct stores a product with the stock and returns its ID.
+func storeProduct(t *testing.T, repos repositories.Repositories, stock int) primitive.ObjectID {
+	t.Helper()
+	product := &productModel.Product{ProductID: primitive.NewObjectID(), ProductName: "Mug", Price: money.New(1000, money.USD), Stock: stock}
+	if err := repos.Products.Create(context.Background(), product); err != nil {
+		t.Fatal(err)
+	}
+	return product.ProductID
+}
+
+// stockOf returns the stock of the product.
+func stockOf(t *testing.T, repos repositories.Repositories, productID primitive.ObjectID) int {
+	t.Helper()
+	product, err := repos.Products.FindByID(context.Background(), productID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return product.Stock
+}
+
+func TestReserve(t *testing.T) {
+	tests := []struct {
+		name      string
+		quantity  int
+		usedUp    bool
+		err       error
+		mugs      int
+		plates    int
+		usageLeft int
+	}{
+		{name: "enough stock", quantity: 2, mugs: 3, plates: 2, usageLeft: 0},
+		{name: "all the stock left", quantity: 4, mugs: 1, plates: 0, usageLeft: 0},
+		{name: "second item out of stock gives the first back", quantity: 5, err: repositories.ErrInsufficientStock, mugs: 5, plates: 4, usageLeft: 1},
+		{name: "promotion used up gives the stock back", quantity: 2, usedUp: true, err: repositories.ErrLimitReached, mugs: 5, plates: 4},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			ctx := context.Background()
+			repos := repositories.NewMemoryRepositories()
+			reservations := NewReservations(repos.Products, repos.Orders, repos.Promotions, payments.NewFakeProvider(payments.FakeSucceed, "whsec"), 15*time.Minute)
+			mug, plate := storeProduct(t, repos, 5), storeProduct(t, repos, 4)
+			coupon := &promotion.Promotion{PromotionID: primitive.NewObjectID(), Code: "ONCE", Name: "Once", Kind: promotion.KindPercentage, Percent: 10, UsageLimit: 1, Active: true}
+			if test.usedUp {
+				coupon.UsageCount = 1
+			}
+			if err := repos.Promotions.Create(ctx, coupon); err != nil {
+				t.Fatal(err)
+			}
+			order := &userModel.Order{
+				OrderID: primitive.NewObjectID(),
+				UserID:  primitive.NewObjectID(),
+				Items: []userModel.OrderItem{
+					{ProductID: mug, ProductName: "Mug", Quantity: test.quantity},
+					{ProductID: plate, ProductName: "Plate", Quantity: test.quantity},
+				},
+				Promotions: []promotion.Applied{{PromotionID: coupon.PromotionID, Code: coupon.Code, Name: coupon.Name}},
+			}
+
+			before := time.Now().UTC()
+			err := reservations.Reserve(ctx, order)
+			if !errors.Is(err, test.err) {
+				t.Fatalf("Reserve() error = %v, want %v", err, test.err)
+			}
+			if got := stockOf(t, repos, mug); got != test.mugs {
+				t.Errorf("mug stock = %d, want %d", got, test.mugs)
+			}
+			if got := stockOf(t, repos, plate); got != test.plates {
+				t.Errorf("plate stock = %d, want %d", got, test.plates)
+			}
+			stored, err := repos.Promotions.FindByID(ctx, coupon.PromotionID)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if left := stored.UsageLimit - stored.UsageCount; left != test.usageLeft {
+				t.Errorf("coupon uses left = %d, want %d", left, test.usageLeft)
+			}
+			reserved := order.ReservedUntil != nil && !order.ReservedUntil.Before(before.Add(15*time.Minute))
+			if reserved != (test.err == nil) {
+				t.Errorf("reserved until %v after Reserve() error %v", order.ReservedUntil, test.err)
+			}
+		})
+	}
+}
+
+func TestRelease(t *testing.T) {
+	ctx := context.Background()
+	repos := repositories.NewMemoryRepositories()
+	reservations := NewReservations(repos.Products, repos.Orders, repos.Promotions, payments.NewFakeProvider(payments.FakeSucceed, "whsec"), 15*time.Minute)
+	mug := storeProduct(t, repos, 5)
+	order := userModel.Order{OrderID: primitive.NewObjectID(), Items: []userModel.OrderItem{{ProductID: mug, Quantity: 2}}}
+
+	reservations.Release(ctx, order)
+	if got := stockOf(t, repos, mug); got != 5 {
+		t.Errorf("stock after releasing an order placed before reservations = %d, want 5", got)
+	}
+
+	if err := reservations.Reserve(ctx, &order); err != nil {
+		t.Fatal(err)
+	}
+	reservations.Release(ctx, order)
+	if got := stockOf(t, repos, mug); got != 5 {
+		t.Errorf("stock after releasing a reserved order = %d, want 5", got)
+	}
+
+	if err := repos.Products.Delete(ctx, mug); err != nil {
+		t.Fatal(err)
+	}
+	reservations.Release(ctx, order)
+}
+
+func TestExpireOverdue(t *testing.T) {
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name          string
+		status        userModel.OrderStatus
+		reservedUntil time.Time
+		cancelled     bool
+	}{
+		{name: "expired while awaiting payment", status: userModel.OrderStatusPendingPayment, reservedUntil: now.Add(-time.Minute), cancelled: true},
+		{name: "still reserved", status: userModel.OrderStatusPendingPayment, reservedUntil: now.Add(time.Minute)},
+		{name: "paid before the expiry", status: userModel.OrderStatusPaid, reservedUntil: now.Add(-time.Minute)},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			ctx := context.Background()
+			repos := repositories.NewMemoryRepositories()
+			provider := payments.NewFakeProvider(payments.FakeSucceed, "whsec")
+			reservations := NewReservations(repos.Products, repos.Orders, repos.Promotions, provider, 15*time.Minute)
+			mug := storeProduct(t, repos, 3)
+			transaction, err := provider.Authorize(ctx, payments.AuthorizationRequest{Amount: money.New(2000, money.USD), PaymentMethod: "card"})
+			if err != nil {
+				t.Fatal(err)
+			}
+			order := &userModel.Order{
+				OrderID:       primitive.NewObjectID(),
+				UserID:        primitive.NewObjectID(),
+				Items:         []userModel.OrderItem{{ProductID: mug, Quantity: 2}},
+				PaymentMethod: "card",
+				Payment:       &userModel.OrderPayment{Provider: provider.Name(), TransactionID: transaction.ID, Status: userModel.PaymentStatusAuthorized},
+				Status:        test.status,
+				ReservedUntil: &test.reservedUntil,
+			}
+			if err := repos.Orders.Create(ctx, order); err != nil {
+				t.Fatal(err)
+			}
+
+			cancelled, err := reservations.ExpireOverdue(ctx, now)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if (cancelled == 1) != test.cancelled {
+				t.Errorf("cancelled %d orders, want cancelled %t", cancelled, test.cancelled)
+			}
+			stored, err := repos.Orders.FindByID(ctx, order.OrderID)
+			if err != nil {
+				t.Fatal(err)
+			}
+			wantStatus, wantPayment, wantStock := test.status, userModel.PaymentStatusAuthorized, 3
+			if test.cancelled {
+				wantStatus, wantPayment, wantStock = userModel.OrderStatusCancelled, userModel.PaymentStatusVoided, 5
+			}
+			if stored.Status != wantStatus || stored.Payment.Status != wantPayment {
+				t.Errorf("order %s with payment %s, want %s with payment %s", stored.Status, stored.Payment.Status, wantStatus, wantPayment)
+			}
+			if got := stockOf(t, repos, mug); got != wantStock {
+				t.Errorf("stock = %d, want %d", got, wantStock)
+			}
+		})
+	}
+}
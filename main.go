@@ -1,13 +1,22 @@
 package main
 
 import (
+	"context"
+	"github.com/YassinNouh21/GoShopCart-Ecommerce/controllers/admin"
 	"github.com/YassinNouh21/GoShopCart-Ecommerce/database"
-	"github.com/YassinNouh21/GoShopCart-Ecommerce/middlewares"
+	"github.com/YassinNouh21/GoShopCart-Ecommerce/helpers"
+	"github.com/YassinNouh21/GoShopCart-Ecommerce/inventory"
+	"github.com/YassinNouh21/GoShopCart-Ecommerce/migrations"
+	"github.com/YassinNouh21/GoShopCart-Ecommerce/money"
+	"github.com/YassinNouh21/GoShopCart-Ecommerce/payments"
+	"github.com/YassinNouh21/GoShopCart-Ecommerce/repositories"
 	routers "github.com/YassinNouh21/GoShopCart-Ecommerce/routes"
-	"net/http"
+	"github.com/YassinNouh21/GoShopCart-Ecommerce/search"
+	"github.com/YassinNouh21/GoShopCart-Ecommerce/storage"
+	"log"
 	"os"
+	"time"
 
-	"github.com/gin-gonic/gin"
 	"github.com/joho/godotenv"
 )
 
@@ -20,13 +29,13 @@ func envPortOr(port string) string {
 	return ":" + port
 }
 
-// initializeDB initializes the database client and collections.
-func initializeDB() {
-	database.InitializeMongoDBCollections()
+// initializeDB connects to MongoDB and returns its collections and the repositories on top of them.
+func initializeDB() (*database.DatabaseCollection, repositories.Repositories) {
+	collections := database.InitializeMongoDBCollections(database.MongoInstance())
+	return collections, repositories.NewMongoRepositories(collections)
 }
 
 func main() {
-	initializeDB()
 	// Load environment variables from .env file
 	err := godotenv.Load(".env")
 	if err != nil {
@@ -34,36 +43,64 @@ func main() {
 		return
 	}
 
-	// Get the port from the environment variable
-	port := os.Getenv("PORT")
+	// The catalog is priced in the currency named by STORE_CURRENCY
+	if currency := money.StoreCurrency(); !currency.Valid() {
+		log.Fatalf("unknown STORE_CURRENCY %q", currency)
+	}
+
+	collections, repos := initializeDB()
+
+	// Bring the documents stored by earlier versions up to date before serving them
+	if _, err := migrations.Run(context.Background(), collections.Client.Database(database.DatabaseName), migrations.All); err != nil {
+		log.Fatal(err)
+	}
+
+	// Grant the admin role to the user named by BOOTSTRAP_ADMIN_EMAIL, if any
+	if err := admin.BootstrapAdmin(repos.Users, os.Getenv("BOOTSTRAP_ADMIN_EMAIL")); err != nil {
+		log.Println("bootstrap admin:", err)
+	}
+
+	// Create the payment provider selected by PAYMENT_PROVIDER
+	provider, err := payments.NewProviderFromEnv()
+	if err != nil {
+		log.Fatal(err)
+	}
 
-	// Create a new Gin router with default middleware
-	router := gin.Default()
-	router.Use(gin.Logger())
+	// Reserve stock at checkout and cancel orders whose reservation expires before they are paid
+	ttl, err := inventory.ReservationTTLFromEnv()
+	if err != nil {
+		log.Fatal(err)
+	}
+	reservations := inventory.NewReservations(repos.Products, repos.Orders, repos.Promotions, provider, ttl)
+	go reservations.RunSweeper(context.Background(), time.Minute)
 
-	// Initialize database client and collections
-	authRoutes := router.Group("/auth")
-	routers.GetAuthRoutes(authRoutes)
+	// Create the search backend selected by SEARCH_BACKEND and the suggester, and load the catalog into them
+	searchBackend, err := search.NewBackendFromEnv(collections.ProductCollection)
+	if err != nil {
+		log.Fatal(err)
+	}
+	suggester := search.NewSuggester()
+	for _, indexer := range []search.Indexer{searchBackend, suggester} {
+		if err := indexer.Load(context.Background(), repos.Products); err != nil {
+			log.Fatal(err)
+		}
+	}
 
-	// Use Authentication middleware
-	router.Use(middlewares.Authentication())
+	// Create the blob store selected by BLOB_STORE for the uploaded images
+	blobs, err := storage.NewBlobStoreFromEnv()
+	if err != nil {
+		log.Fatal(err)
+	}
 
-	// Set up user-related routes under /user and product-related routes under /product
-	userRoutes := router.Group("/user")
-	routers.ProfileRoutes(userRoutes)
-	routers.AddressRoutes(userRoutes)
-	routers.CartRoutes(userRoutes)
-	// Set up product-related routes under /product
-	productRoutes := router.Group("/product")
-	routers.ProductRoutes(productRoutes)
-	routers.ProductFilterRoutes(productRoutes)
+	// Keep the guest carts for GUEST_CART_TTL after their last change
+	guestCartTTL, err := helpers.GuestCartTTLFromEnv()
+	if err != nil {
+		log.Fatal(err)
+	}
 
-	router.NoRoute(func(c *gin.Context) {
-		c.JSON(http.StatusNotFound, gin.H{
-			"error": "Route not defined",
-		})
-	})
+	// Create the router with every route of the application
+	router := routers.SetupRouter(repos, routers.Services{Payments: provider, Inventory: reservations, Search: searchBackend, Suggester: suggester, Blobs: blobs, GuestCartTTL: guestCartTTL})
 
 	// Run the server on the specified port
-	router.Run(":" + port)
+	router.Run(envPortOr("8080"))
 }
@@ -10,13 +10,15 @@ Functions:
 
 import (
 	"github.com/YassinNouh21/GoShopCart-Ecommerce/helpers"
+	"github.com/YassinNouh21/GoShopCart-Ecommerce/repositories"
 	"strings"
 
 	"github.com/gin-gonic/gin"
 )
 
-// Authentication is a middleware function that validates the user's authentication token.
-func Authentication() gin.HandlerFunc {
+// Authentication is a middleware function that validates the user's authentication token
+// against the tokens stored in the user repository.
+func Authentication(users repositories.UserRepository) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		clientToken := c.Request.Header.Get("token")
 		authHeader := c.GetHeader("Authorization")
@@ -29,7 +31,7 @@ func Authentication() gin.HandlerFunc {
 			return
 		}
 
-		userClaim, err := helpers.ValidateToken(clientToken)
+		userClaim, err := helpers.ValidateToken(users, clientToken)
 
 		if err != "" {
 			c.JSON(401, gin.H{
@@ -39,6 +41,7 @@ func Authentication() gin.HandlerFunc {
 			return
 		}
 		c.Set("user_id", userClaim.ID)
+		c.Set("user_type", userClaim.Role)
 		c.Next()
 	}
 }
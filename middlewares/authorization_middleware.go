@@ -0,0 +1,36 @@
+package middlewares
+
+/*
+
+The Authorization middleware function, which restricts a route to users with specific roles.
+It must run after the Authentication middleware, which sets the role of the user on the context.
+
+Functions:
+- Authorization: Rejects users whose role is not one of the allowed roles.
+*/
+
+import (
+	"github.com/YassinNouh21/GoShopCart-Ecommerce/helpers"
+	userModel "github.com/YassinNouh21/GoShopCart-Ecommerce/models/user"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Authorization is a middleware function that only lets users with one of the given roles through.
+func Authorization(roles ...userModel.Role) gin.HandlerFunc {
+	allowedRoles := make([]string, len(roles))
+	for i, role := range roles {
+		allowedRoles[i] = string(role)
+	}
+	return func(c *gin.Context) {
+		if err := helpers.CheckUserType(c, allowedRoles...); err != nil {
+			c.JSON(http.StatusForbidden, gin.H{
+				"error": err.Error(),
+			})
+			c.Abort()
+			return
+		}
+		c.Next()
+	}
+}
@@ -0,0 +1,73 @@
+package middlewares
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/YassinNouh21/GoShopCart-Ecommerce/helpers"
+	userModel "github.com/YassinNouh21/GoShopCart-Ecommerce/models/user"
+	"github.com/YassinNouh21/GoShopCart-Ecommerce/repositories"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestAuthorization(t *testing.T) {
+	tests := []struct {
+		name    string
+		role    userModel.Role
+		stored  userModel.Role
+		allowed []userModel.Role
+		noToken bool
+		status  int
+	}{
+		{name: "admin on an admin route", role: userModel.RoleAdmin, allowed: []userModel.Role{userModel.RoleAdmin}, status: http.StatusOK},
+		{name: "staff on an admin route", role: userModel.RoleStaff, allowed: []userModel.Role{userModel.RoleAdmin}, status: http.StatusForbidden},
+		{name: "staff on a staff route", role: userModel.RoleStaff, allowed: []userModel.Role{userModel.RoleAdmin, userModel.RoleStaff}, status: http.StatusOK},
+		{name: "customer on a staff route", role: userModel.RoleCustomer, allowed: []userModel.Role{userModel.RoleAdmin, userModel.RoleStaff}, status: http.StatusForbidden},
+		{name: "user without a stored role is a customer", role: "", allowed: []userModel.Role{userModel.RoleAdmin, userModel.RoleStaff}, status: http.StatusForbidden},
+		{name: "demoted admin with an admin token", role: userModel.RoleAdmin, stored: userModel.RoleCustomer, allowed: []userModel.Role{userModel.RoleAdmin}, status: http.StatusForbidden},
+		{name: "promoted customer with a customer token", role: userModel.RoleCustomer, stored: userModel.RoleAdmin, allowed: []userModel.Role{userModel.RoleAdmin}, status: http.StatusOK},
+		{name: "no token", noToken: true, allowed: []userModel.Role{userModel.RoleAdmin}, status: http.StatusUnauthorized},
+	}
+	gin.SetMode(gin.TestMode)
+	t.Setenv("SECRET_JWT", "test-secret")
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			ctx := context.Background()
+			users := repositories.NewMemoryRepositories().Users
+			user := &userModel.User{ID: primitive.NewObjectID(), FirstName: "Alice", Email: "alice@example.com", Role: test.role}
+			if err := users.Create(ctx, user); err != nil {
+				t.Fatal(err)
+			}
+			token, refreshToken, err := helpers.GenerateToken(*helpers.CreateUserClaims(user.Email, user.FirstName, user.ID.Hex(), test.role))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := users.UpdateTokens(ctx, user.ID, token, refreshToken); err != nil {
+				t.Fatal(err)
+			}
+			if test.stored != "" {
+				if err := users.UpdateRole(ctx, user.ID, test.stored); err != nil {
+					t.Fatal(err)
+				}
+			}
+			router := gin.New()
+			router.GET("/admin", Authentication(users), Authorization(test.allowed...), func(c *gin.Context) {
+				c.Status(http.StatusOK)
+			})
+
+			request := httptest.NewRequest(http.MethodGet, "/admin", nil)
+			if !test.noToken {
+				request.Header.Set("Authorization", "Bearer "+token)
+			}
+			recorder := httptest.NewRecorder()
+			router.ServeHTTP(recorder, request)
+			if recorder.Code != test.status {
+				t.Errorf("status = %d, want %d: %s", recorder.Code, test.status, recorder.Body)
+			}
+		})
+	}
+}
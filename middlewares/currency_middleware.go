@@ -0,0 +1,73 @@
+package middlewares
+
+/*
+
+The Currency middleware function, which selects the currency of the prices of a request.
+It must run after the Authentication middleware, which sets the ID of the user on the context.
+
+Functions:
+- Currency: Selects the currency from the X-Currency header, else from the preference of the user,
+  else the store currency.
+*/
+
+import (
+	"context"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/YassinNouh21/GoShopCart-Ecommerce/helpers"
+	"github.com/YassinNouh21/GoShopCart-Ecommerce/money"
+	"github.com/YassinNouh21/GoShopCart-Ecommerce/repositories"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// CurrencyHeader is the request header selecting the currency of the prices.
+const CurrencyHeader = "X-Currency"
+
+// Currency is a middleware function that selects the currency prices are shown and charged in and
+// loads the exchange rates to convert them. A currency requested in the header must have an exchange
+// rate; a preferred currency that lost its exchange rate falls back to the store currency.
+func Currency(users repositories.UserRepository, exchangeRates repositories.ExchangeRateRepository) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+
+		rates, err := helpers.LoadExchangeRates(ctx, exchangeRates)
+		if err != nil {
+			log.Printf("currency: loading the exchange rates: %v", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load the exchange rates"})
+			c.Abort()
+			return
+		}
+		currency := rates.Base()
+		if requested := strings.TrimSpace(c.GetHeader(CurrencyHeader)); requested != "" {
+			selected, err := money.ParseCurrency(requested)
+			if err != nil || !rates.Supports(selected) {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "Unsupported currency " + strings.ToUpper(requested) + ", the currencies are " + joinCurrencies(rates.Currencies())})
+				c.Abort()
+				return
+			}
+			currency = selected
+		} else if userID, err := primitive.ObjectIDFromHex(c.GetString("user_id")); err == nil {
+			if user, err := users.FindByID(ctx, userID); err == nil && rates.Supports(user.Currency) {
+				currency = user.Currency
+			}
+		}
+		c.Set(helpers.CurrencyKey, currency)
+		c.Set(helpers.ExchangeRatesKey, rates)
+		c.Header(CurrencyHeader, string(currency))
+		c.Next()
+	}
+}
+
+func joinCurrencies(currencies []money.Currency) string {
+	codes := make([]string, len(currencies))
+	for i, currency := range currencies {
+		codes[i] = string(currency)
+	}
+	return strings.Join(codes, ", ")
+}
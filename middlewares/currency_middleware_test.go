@@ -0,0 +1,77 @@
+package middlewares
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/YassinNouh21/GoShopCart-Ecommerce/helpers"
+	userModel "github.com/YassinNouh21/GoShopCart-Ecommerce/models/user"
+	"github.com/YassinNouh21/GoShopCart-Ecommerce/money"
+	"github.com/YassinNouh21/GoShopCart-Ecommerce/repositories"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestCurrency(t *testing.T) {
+	// EUR has an exchange rate, GBP does not
+	tests := []struct {
+		name      string
+		header    string
+		preferred money.Currency
+		signedIn  bool
+		status    int
+		currency  money.Currency
+	}{
+		{name: "store currency by default", status: http.StatusOK, currency: money.USD},
+		{name: "header", header: "eur", status: http.StatusOK, currency: money.EUR},
+		{name: "preference of the user", preferred: money.EUR, signedIn: true, status: http.StatusOK, currency: money.EUR},
+		{name: "header wins over the preference", header: "USD", preferred: money.EUR, signedIn: true, status: http.StatusOK, currency: money.USD},
+		{name: "preference without a rate falls back", preferred: money.GBP, signedIn: true, status: http.StatusOK, currency: money.USD},
+		{name: "header without a rate", header: "GBP", status: http.StatusBadRequest},
+		{name: "unknown header", header: "Euro", status: http.StatusBadRequest},
+	}
+	gin.SetMode(gin.TestMode)
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			ctx := context.Background()
+			repos := repositories.NewMemoryRepositories()
+			if err := repos.ExchangeRates.Save(ctx, &money.ExchangeRate{Currency: money.EUR, Rate: "0.92"}); err != nil {
+				t.Fatal(err)
+			}
+			user := &userModel.User{ID: primitive.NewObjectID(), FirstName: "Alice", Email: "alice@example.com", Currency: test.preferred}
+			if err := repos.Users.Create(ctx, user); err != nil {
+				t.Fatal(err)
+			}
+			var selected money.Currency
+			router := gin.New()
+			router.GET("/product", func(c *gin.Context) {
+				if test.signedIn {
+					c.Set("user_id", user.ID.Hex())
+				}
+				c.Next()
+			}, Currency(repos.Users, repos.ExchangeRates), func(c *gin.Context) {
+				selected, _ = helpers.RequestCurrency(c)
+				c.Status(http.StatusOK)
+			})
+
+			request := httptest.NewRequest(http.MethodGet, "/product", nil)
+			if test.header != "" {
+				request.Header.Set(CurrencyHeader, test.header)
+			}
+			recorder := httptest.NewRecorder()
+			router.ServeHTTP(recorder, request)
+			if recorder.Code != test.status {
+				t.Fatalf("status = %d, want %d: %s", recorder.Code, test.status, recorder.Body)
+			}
+			if selected != test.currency {
+				t.Errorf("currency = %q, want %q", selected, test.currency)
+			}
+			if got := recorder.Header().Get(CurrencyHeader); test.status == http.StatusOK && got != string(test.currency) {
+				t.Errorf("%s response header = %q, want %q", CurrencyHeader, got, test.currency)
+			}
+		})
+	}
+}
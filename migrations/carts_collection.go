@@ -0,0 +1,55 @@
+package migrations
+
+import (
+	"context"
+	"errors"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// cartsCollectionMigration moves the cart items and coupons embedded in the users to the carts
+// collection, one document per user keyed by the ID of the user, and removes them from the users.
+// The users created at sign up also held an empty usercart array, which is removed with them.
+var cartsCollectionMigration = Migration{
+	ID:          "0005_carts_collection",
+	Description: "move the carts embedded in the users to the carts collection",
+	Up: func(ctx context.Context, db *mongo.Database) error {
+		// Transactions cannot create collections on every MongoDB version, so checkout needs it to exist
+		var commandErr mongo.CommandError
+		if err := db.CreateCollection(ctx, "carts"); err != nil && !(errors.As(err, &commandErr) && commandErr.Name == "NamespaceExists") {
+			return err
+		}
+
+		users := db.Collection("users")
+		// A cart moved before the migration was interrupted is replaced with the same items and coupons
+		cursor, err := users.Aggregate(ctx, mongo.Pipeline{
+			{{Key: "$match", Value: bson.M{"$or": bson.A{
+				bson.M{"user_cart.0": bson.M{"$exists": true}},
+				bson.M{"cart_coupons.0": bson.M{"$exists": true}},
+			}}}},
+			{{Key: "$project", Value: bson.M{
+				"items":      bson.M{"$ifNull": bson.A{"$user_cart", bson.A{}}},
+				"coupons":    "$cart_coupons",
+				"updated_at": "$$NOW",
+			}}},
+			{{Key: "$merge", Value: bson.M{"into": "carts", "on": "_id", "whenMatched": "replace", "whenNotMatched": "insert"}}},
+		})
+		if err != nil {
+			return err
+		}
+		if err := cursor.Close(ctx); err != nil {
+			return err
+		}
+
+		_, err = users.UpdateMany(ctx,
+			bson.M{"$or": bson.A{
+				bson.M{"user_cart": bson.M{"$exists": true}},
+				bson.M{"cart_coupons": bson.M{"$exists": true}},
+				bson.M{"usercart": bson.M{"$exists": true}},
+			}},
+			bson.M{"$unset": bson.M{"user_cart": "", "cart_coupons": "", "usercart": ""}},
+		)
+		return err
+	},
+}
@@ -0,0 +1,23 @@
+package migrations
+
+import (
+	"context"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// guestCartExpiryMigration creates the TTL index that makes MongoDB remove the guest carts once their
+// expires_at has passed.
+var guestCartExpiryMigration = Migration{
+	ID:          "0004_guest_cart_expiry",
+	Description: "remove the guest carts once they expire",
+	Up: func(ctx context.Context, db *mongo.Database) error {
+		_, err := db.Collection("guest_carts").Indexes().CreateOne(ctx, mongo.IndexModel{
+			Keys:    bson.D{{Key: "expires_at", Value: 1}},
+			Options: options.Index().SetName("expires_at_ttl").SetExpireAfterSeconds(0),
+		})
+		return err
+	},
+}
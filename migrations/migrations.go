@@ -0,0 +1,90 @@
+package migrations
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sort"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+/*
+	Package migrations changes the documents stored by earlier versions of the application to the
+	shape the current models expect.
+
+	Every migration runs once per database: the applied migrations are recorded in the migrations
+	collection and Run skips them. Migrations are written to be safe to run again, so a migration
+	interrupted halfway, or run by two servers starting together, is completed the next time.
+*/
+
+// CollectionName is the collection recording the applied migrations.
+const CollectionName = "migrations"
+
+// Migration is a one-time change to the stored documents.
+type Migration struct {
+	// ID orders the migrations and records them once applied. It starts with a sequence number.
+	ID string
+	// Description says what the migration changes.
+	Description string
+	// Up applies the migration to the database.
+	Up func(ctx context.Context, db *mongo.Database) error
+}
+
+// All lists every migration, in the order they are applied.
+var All = []Migration{
+	moneyMigration,
+	orderChargesMigration,
+	orderLineTaxMigration,
+	guestCartExpiryMigration,
+	cartsCollectionMigration,
+	productStockMigration,
+}
+
+// record is the document recording an applied migration.
+type record struct {
+	ID          string    `bson:"_id"`
+	Description string    `bson:"description"`
+	AppliedAt   time.Time `bson:"applied_at"`
+}
+
+// Run applies the migrations that were not applied to the database yet, in order of ID, and
+// returns the IDs of those it applied. It stops at the first migration that fails.
+func Run(ctx context.Context, db *mongo.Database, migrations []Migration) ([]string, error) {
+	collection := db.Collection(CollectionName)
+	cursor, err := collection.Find(ctx, bson.M{})
+	if err != nil {
+		return nil, err
+	}
+	var records []record
+	if err := cursor.All(ctx, &records); err != nil {
+		return nil, err
+	}
+	applied := map[string]bool{}
+	for _, record := range records {
+		applied[record.ID] = true
+	}
+
+	pending := []Migration{}
+	for _, migration := range migrations {
+		if !applied[migration.ID] {
+			pending = append(pending, migration)
+		}
+	}
+	sort.Slice(pending, func(i, j int) bool { return pending[i].ID < pending[j].ID })
+	done := []string{}
+	for _, migration := range pending {
+		log.Printf("migrations: applying %s: %s", migration.ID, migration.Description)
+		if err := migration.Up(ctx, db); err != nil {
+			return done, fmt.Errorf("migration %s: %w", migration.ID, err)
+		}
+		_, err := collection.InsertOne(ctx, record{ID: migration.ID, Description: migration.Description, AppliedAt: time.Now().UTC()})
+		if err != nil && !mongo.IsDuplicateKeyError(err) {
+			return done, fmt.Errorf("migration %s: %w", migration.ID, err)
+		}
+		done = append(done, migration.ID)
+	}
+	return done, nil
+}
@@ -0,0 +1,140 @@
+package migrations
+
+import (
+	"context"
+	"fmt"
+	"math"
+
+	"github.com/YassinNouh21/GoShopCart-Ecommerce/money"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/bsontype"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// moneyMigration converts the prices and amounts stored as plain numbers, which were float32 in the
+// models, to money documents with an integer amount of minor units in the store currency.
+var moneyMigration = Migration{
+	ID:          "0001_money_amounts",
+	Description: "store prices and order amounts as minor units with a currency",
+	Up: func(ctx context.Context, db *mongo.Database) error {
+		currency := money.StoreCurrency()
+		if !currency.Valid() {
+			return fmt.Errorf("%w %q in STORE_CURRENCY", money.ErrUnknownCurrency, currency)
+		}
+		if err := migrateProductPrices(ctx, db.Collection("products"), currency); err != nil {
+			return err
+		}
+		return migrateOrderAmounts(ctx, db.Collection("orders"), currency)
+	},
+}
+
+// numberTypes matches the fields holding a plain number.
+var numberTypes = bson.M{"$type": "number"}
+
+func migrateProductPrices(ctx context.Context, collection *mongo.Collection, currency money.Currency) error {
+	query := bson.M{"$or": bson.A{
+		bson.M{"price": numberTypes},
+		bson.M{"variants.price": numberTypes},
+	}}
+	cursor, err := collection.Find(ctx, query)
+	if err != nil {
+		return err
+	}
+	defer cursor.Close(ctx)
+	for cursor.Next(ctx) {
+		var product struct {
+			ID       primitive.ObjectID `bson:"_id"`
+			Price    bson.RawValue      `bson:"price"`
+			Variants []struct {
+				Price bson.RawValue `bson:"price"`
+			} `bson:"variants"`
+		}
+		if err := cursor.Decode(&product); err != nil {
+			return err
+		}
+		set := bson.M{}
+		convertAmount(set, "price", product.Price, currency)
+		for i, variant := range product.Variants {
+			convertAmount(set, fmt.Sprintf("variants.%d.price", i), variant.Price, currency)
+		}
+		if err := setFields(ctx, collection, product.ID, set); err != nil {
+			return err
+		}
+	}
+	return cursor.Err()
+}
+
+func migrateOrderAmounts(ctx context.Context, collection *mongo.Collection, currency money.Currency) error {
+	conditions := bson.A{}
+	for _, field := range []string{"subtotal", "discount", "total", "items.unit_price", "items.line_total", "payment.amount"} {
+		conditions = append(conditions, bson.M{field: numberTypes})
+	}
+	cursor, err := collection.Find(ctx, bson.M{"$or": conditions})
+	if err != nil {
+		return err
+	}
+	defer cursor.Close(ctx)
+	for cursor.Next(ctx) {
+		var order struct {
+			ID       primitive.ObjectID `bson:"_id"`
+			Subtotal bson.RawValue      `bson:"subtotal"`
+			Discount bson.RawValue      `bson:"discount"`
+			Total    bson.RawValue      `bson:"total"`
+			Items    []struct {
+				UnitPrice bson.RawValue `bson:"unit_price"`
+				LineTotal bson.RawValue `bson:"line_total"`
+			} `bson:"items"`
+			Payment *struct {
+				Amount bson.RawValue `bson:"amount"`
+			} `bson:"payment"`
+		}
+		if err := cursor.Decode(&order); err != nil {
+			return err
+		}
+		set := bson.M{}
+		convertAmount(set, "subtotal", order.Subtotal, currency)
+		convertAmount(set, "discount", order.Discount, currency)
+		convertAmount(set, "total", order.Total, currency)
+		for i, item := range order.Items {
+			convertAmount(set, fmt.Sprintf("items.%d.unit_price", i), item.UnitPrice, currency)
+			convertAmount(set, fmt.Sprintf("items.%d.line_total", i), item.LineTotal, currency)
+		}
+		if order.Payment != nil {
+			convertAmount(set, "payment.amount", order.Payment.Amount, currency)
+		}
+		if err := setFields(ctx, collection, order.ID, set); err != nil {
+			return err
+		}
+	}
+	return cursor.Err()
+}
+
+// convertAmount adds the money form of the value to the fields to set, if it is a plain number.
+// Values already converted, or missing, are left as they are.
+func convertAmount(set bson.M, field string, value bson.RawValue, currency money.Currency) {
+	var number float64
+	switch value.Type {
+	case bsontype.Double:
+		number = value.Double()
+	case bsontype.Int32:
+		number = float64(value.Int32())
+	case bsontype.Int64:
+		number = float64(value.Int64())
+	default:
+		return
+	}
+	// The float32 prices were stored as the nearest double, such as 19.989999771118164 for 19.99,
+	// so rounding to the nearest minor unit recovers the intended amount
+	minorUnits := math.Round(number * math.Pow10(currency.Digits()))
+	set[field] = money.New(int64(minorUnits), currency)
+}
+
+// setFields sets the fields of the document with the ID, if there are any.
+func setFields(ctx context.Context, collection *mongo.Collection, id primitive.ObjectID, set bson.M) error {
+	if len(set) == 0 {
+		return nil
+	}
+	_, err := collection.UpdateOne(ctx, bson.M{"_id": id}, bson.M{"$set": set})
+	return err
+}
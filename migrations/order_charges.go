@@ -0,0 +1,29 @@
+package migrations
+
+import (
+	"context"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// orderChargesMigration gives the orders placed before the pricing engine a zero tax and shipping in
+// the currency of their total, so every order holds the same amounts.
+var orderChargesMigration = Migration{
+	ID:          "0002_order_tax_shipping",
+	Description: "add a zero tax and shipping to the orders without them",
+	Up: func(ctx context.Context, db *mongo.Database) error {
+		orders := db.Collection("orders")
+		for _, field := range []string{"tax", "shipping"} {
+			zero := bson.M{"amount": int64(0), "currency": "$total.currency"}
+			_, err := orders.UpdateMany(ctx,
+				bson.M{field: bson.M{"$exists": false}},
+				bson.A{bson.M{"$set": bson.M{field: zero}}},
+			)
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	},
+}
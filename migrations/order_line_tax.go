@@ -0,0 +1,36 @@
+package migrations
+
+import (
+	"context"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// orderLineTaxMigration gives the orders placed before taxes a zero included tax, and their items a
+// zero discount and tax, in the currency of their total, so every order breaks its tax out per item.
+var orderLineTaxMigration = Migration{
+	ID:          "0003_order_line_tax",
+	Description: "add a zero included tax to the orders and a zero discount and tax to their items",
+	Up: func(ctx context.Context, db *mongo.Database) error {
+		orders := db.Collection("orders")
+		zero := bson.M{"amount": int64(0), "currency": "$total.currency"}
+		_, err := orders.UpdateMany(ctx,
+			bson.M{"tax_included": bson.M{"$exists": false}},
+			bson.A{bson.M{"$set": bson.M{"tax_included": zero}}},
+		)
+		if err != nil {
+			return err
+		}
+		// The fields of the item come last, so the amounts it already has are kept
+		items := bson.M{"$map": bson.M{
+			"input": "$items",
+			"in":    bson.M{"$mergeObjects": bson.A{bson.M{"discount": zero, "tax": zero}, "$$this"}},
+		}}
+		_, err = orders.UpdateMany(ctx,
+			bson.M{"items": bson.M{"$elemMatch": bson.M{"tax": bson.M{"$exists": false}}}},
+			bson.A{bson.M{"$set": bson.M{"items": items}}},
+		)
+		return err
+	},
+}
@@ -0,0 +1,49 @@
+package migrations
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strconv"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// DefaultInitialStock is the stock given to the products created before stock was tracked when
+// INITIAL_PRODUCT_STOCK is not set. Their real quantities are unknown, so they start out of stock
+// until an admin adjusts them.
+const DefaultInitialStock = 0
+
+// productStockMigration writes a starting stock on the products created before stock was tracked,
+// INITIAL_PRODUCT_STOCK if it is set and none otherwise, so inventory reports and stock adjustments
+// see every product with an explicit stock.
+var productStockMigration = Migration{
+	ID:          "0006_product_initial_stock",
+	Description: "give the products without a stock an explicit starting stock",
+	Up: func(ctx context.Context, db *mongo.Database) error {
+		stock, err := initialStockFromEnv()
+		if err != nil {
+			return err
+		}
+		_, err = db.Collection("products").UpdateMany(ctx,
+			bson.M{"stock": bson.M{"$exists": false}},
+			bson.M{"$set": bson.M{"stock": stock}},
+		)
+		return err
+	},
+}
+
+// initialStockFromEnv returns the starting stock set by the INITIAL_PRODUCT_STOCK environment
+// variable, or DefaultInitialStock if it is not set.
+func initialStockFromEnv() (int, error) {
+	value := os.Getenv("INITIAL_PRODUCT_STOCK")
+	if value == "" {
+		return DefaultInitialStock, nil
+	}
+	stock, err := strconv.Atoi(value)
+	if err != nil || stock < 0 {
+		return 0, fmt.Errorf("invalid INITIAL_PRODUCT_STOCK %q", value)
+	}
+	return stock, nil
+}
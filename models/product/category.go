@@ -0,0 +1,83 @@
+package product
+
+import (
+	"regexp"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+/*
+	Category is a node of the product taxonomy, stored in the categories collection.
+
+	Categories form a tree through their parent. Every category also keeps the chain of its ancestors,
+	root first, so breadcrumbs are read without walking the tree and the descendants of a category are
+	found with a single query on the ancestor IDs.
+
+	Fields:
+	- CategoryID: The unique identifier for the category.
+	- Name: The display name of the category. It is a required field.
+	- Slug: The URL identifier of the category, unique across the taxonomy. It is derived from the name when omitted.
+	- ParentID: The identifier of the parent category. It is missing for root categories.
+	- Ancestors: The ancestors of the category, root first.
+	- TaxClass: The tax class of the products of the category and of its subcategories without one,
+	  such as "books". When empty, the class of the parent applies, and at the root the standard rates.
+	- CreatedAt: The timestamp indicating when the category was created.
+	- UpdatedAt: The timestamp indicating when the category was last updated.
+*/
+
+type Category struct {
+	CategoryID primitive.ObjectID  `json:"category_id" bson:"_id"`
+	Name       string              `json:"name" bson:"name" validate:"required"`
+	Slug       string              `json:"slug" bson:"slug" validate:"required"`
+	ParentID   *primitive.ObjectID `json:"parent_id,omitempty" bson:"parent_id,omitempty"`
+	Ancestors  []CategoryRef       `json:"ancestors" bson:"ancestors"`
+	TaxClass   string              `json:"tax_class,omitempty" bson:"tax_class,omitempty"`
+	CreatedAt  time.Time           `json:"created_at" bson:"created_at"`
+	UpdatedAt  time.Time           `json:"updated_at" bson:"updated_at"`
+}
+
+// CategoryRef is the short form of a category kept in the ancestors of its descendants.
+type CategoryRef struct {
+	CategoryID primitive.ObjectID `json:"category_id" bson:"_id"`
+	Name       string             `json:"name" bson:"name"`
+	Slug       string             `json:"slug" bson:"slug"`
+}
+
+// Ref returns the short form of the category.
+func (c Category) Ref() CategoryRef {
+	return CategoryRef{CategoryID: c.CategoryID, Name: c.Name, Slug: c.Slug}
+}
+
+// Breadcrumbs returns the path from the root of the taxonomy to the category, the category included.
+func (c Category) Breadcrumbs() []CategoryRef {
+	breadcrumbs := make([]CategoryRef, 0, len(c.Ancestors)+1)
+	breadcrumbs = append(breadcrumbs, c.Ancestors...)
+	return append(breadcrumbs, c.Ref())
+}
+
+// HasAncestor reports whether the category with the given ID is an ancestor of the category.
+func (c Category) HasAncestor(id primitive.ObjectID) bool {
+	for _, ancestor := range c.Ancestors {
+		if ancestor.CategoryID == id {
+			return true
+		}
+	}
+	return false
+}
+
+var (
+	slugPattern   = regexp.MustCompile(`^[a-z0-9]+(?:-[a-z0-9]+)*$`)
+	slugSeparator = regexp.MustCompile(`[^a-z0-9]+`)
+)
+
+// ValidSlug reports whether the slug only holds lowercase letters and digits separated by single dashes.
+func ValidSlug(slug string) bool {
+	return slugPattern.MatchString(slug)
+}
+
+// Slugify derives a slug from a category name, for example "Men's Shoes" becomes "men-s-shoes".
+func Slugify(name string) string {
+	return strings.Trim(slugSeparator.ReplaceAllString(strings.ToLower(name), "-"), "-")
+}
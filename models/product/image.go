@@ -0,0 +1,64 @@
+package product
+
+import "go.mongodb.org/mongo-driver/bson/primitive"
+
+/*
+	ProductImage is an image uploaded for a product, stored in the blob store with its thumbnails.
+
+	Fields:
+	- ImageID: The unique identifier of the image within its product. It is assigned by the server.
+	- URL: The URL the image is served from.
+	- Key: The key of the image in the blob store. It is not exposed by the API.
+	- ContentType: The MIME type of the image: image/jpeg, image/png or image/gif.
+	- Width: The width of the image in pixels.
+	- Height: The height of the image in pixels.
+	- Thumbnails: The scaled down versions of the image, largest first.
+*/
+
+type ProductImage struct {
+	ImageID     primitive.ObjectID `json:"image_id" bson:"_id"`
+	URL         string             `json:"url" bson:"url"`
+	Key         string             `json:"-" bson:"key"`
+	ContentType string             `json:"content_type" bson:"content_type"`
+	Width       int                `json:"width" bson:"width"`
+	Height      int                `json:"height" bson:"height"`
+	Thumbnails  []ImageThumbnail   `json:"thumbnails" bson:"thumbnails"`
+}
+
+/*
+	ImageThumbnail is a scaled down version of a product image.
+
+	Fields:
+	- Size: The name of the size: large, medium or small.
+	- URL: The URL the thumbnail is served from.
+	- Key: The key of the thumbnail in the blob store. It is not exposed by the API.
+	- Width: The width of the thumbnail in pixels.
+	- Height: The height of the thumbnail in pixels.
+*/
+
+type ImageThumbnail struct {
+	Size   string `json:"size" bson:"size"`
+	URL    string `json:"url" bson:"url"`
+	Key    string `json:"-" bson:"key"`
+	Width  int    `json:"width" bson:"width"`
+	Height int    `json:"height" bson:"height"`
+}
+
+// FindImage returns the image of the product with the given ID.
+func (p Product) FindImage(imageID primitive.ObjectID) (*ProductImage, bool) {
+	for i := range p.Images {
+		if p.Images[i].ImageID == imageID {
+			return &p.Images[i], true
+		}
+	}
+	return nil, false
+}
+
+// BlobKeys returns the keys of the image and of its thumbnails in the blob store.
+func (image ProductImage) BlobKeys() []string {
+	keys := []string{image.Key}
+	for _, thumbnail := range image.Thumbnails {
+		keys = append(keys, thumbnail.Key)
+	}
+	return keys
+}
@@ -1,6 +1,12 @@
 package product
 
-import "go.mongodb.org/mongo-driver/bson/primitive"
+import (
+	"errors"
+	"time"
+
+	"github.com/YassinNouh21/GoShopCart-Ecommerce/money"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
 
 /*
 	Package product defines the Product model used in the ecommerce application.
@@ -10,17 +16,103 @@ import "go.mongodb.org/mongo-driver/bson/primitive"
 	Fields:
 	- ProductID: The unique identifier for the product. It is represented as a primitive.ObjectID.
 	- ProductName: The name of the product. It is a required field.
-	- Price: The price of the product. It is a required field and represented as a float32.
-	- Rating: The rating of the product. It is a required field and represented as a float32.
-	- ImageUrl: The URL of the product's image. It is a required field.
+	- Description: The description of the product. It is searched together with the name.
+	- Price: The price of the product, a positive amount in the store currency. It is a required field.
+	- PriceOverrides: Prices of the product set by hand in other currencies, at most one per currency.
+	  They are shown and charged instead of the price converted with the exchange rates.
+	- DisplayPrice: The price in the currency of the request, set on the responses and never stored.
+	- Rating: The average rating of the reviews of the product, from 0 without reviews to 5. It is
+	  maintained by the server whenever a review is written, edited or deleted.
+	- ReviewCount: The number of reviews of the product, maintained together with the rating.
+	- ImageUrl: The URL of the main image of the product. Once images are uploaded it is the URL of the
+	  first of them.
+	- Images: The images uploaded for the product, in display order.
+	- CategoryIDs: The identifiers of the categories the product is listed in.
+	- Options: The options along which the variants of the product differ, such as size or color.
+	- Variants: The purchasable versions of the product. When present, customers buy a variant and the
+	  price and stock of the variant apply.
+	- Stock: The number of units available for sale, for products without variants. Checkouts reserve
+	  units by decrementing it, and cancelled orders give them back.
+	- Weight: The shipping weight of one unit, in grams. Weight-based shipping rates charge by the weight
+	  of the cart.
+	- Dimensions: The size of the package of one unit, in millimetres.
+	- CreatedAt: The timestamp indicating when the product was created.
+	- UpdatedAt: The timestamp indicating when the product was last updated.
+	- Version: The revision of the product, incremented on every update, stock changes included. Clients send it back
+	  (in the body or the If-Match header) so concurrent edits are detected.
 
 	This Product model is used to represent individual products in the ecommerce application.
 */
 
 type Product struct {
-	ProductID   primitive.ObjectID `json:"product_id" bson:"_id" validate:"required"`
-	ProductName string             `json:"product_name" bson:"product_name" validate:"required"`
-	Price       float32            `json:"price" bson:"price" validate:"required"`
-	Rating      float32            `json:"rating" bson:"rating" validate:"required"`
-	ImageUrl    string             `json:"image" bson:"image_url" validate:"required"`
+	ProductID      primitive.ObjectID   `json:"product_id" bson:"_id" validate:"required"`
+	ProductName    string               `json:"product_name" bson:"product_name" validate:"required"`
+	Description    string               `json:"description" bson:"description"`
+	Price          money.Money          `json:"price" bson:"price"`
+	PriceOverrides []money.Money        `json:"price_overrides" bson:"price_overrides"`
+	DisplayPrice   *money.Money         `json:"display_price,omitempty" bson:"-"`
+	Rating         float32              `json:"rating" bson:"rating" validate:"gte=0,max=5"`
+	ReviewCount    int                  `json:"review_count" bson:"review_count"`
+	ImageUrl       string               `json:"image" bson:"image_url"`
+	Images         []ProductImage       `json:"images" bson:"images"`
+	CategoryIDs    []primitive.ObjectID `json:"category_ids" bson:"category_ids"`
+	Options        []ProductOption      `json:"options" bson:"options" validate:"dive"`
+	Variants       []Variant            `json:"variants" bson:"variants" validate:"dive"`
+	Stock          int                  `json:"stock" bson:"stock" validate:"gte=0"`
+	Weight         int                  `json:"weight" bson:"weight" validate:"gte=0"`
+	Dimensions     *Dimensions          `json:"dimensions,omitempty" bson:"dimensions,omitempty"`
+	CreatedAt      time.Time            `json:"created_at" bson:"created_at"`
+	UpdatedAt      time.Time            `json:"updated_at" bson:"updated_at"`
+	Version        int                  `json:"version" bson:"version"`
+}
+
+// Dimensions is the size of a package, in millimetres.
+type Dimensions struct {
+	Length int `json:"length" bson:"length" validate:"gt=0"`
+	Width  int `json:"width" bson:"width" validate:"gt=0"`
+	Height int `json:"height" bson:"height" validate:"gt=0"`
+}
+
+var (
+	// ErrInvalidPrice is returned when the price of a product or of a variant is not a positive amount
+	// in the store currency.
+	ErrInvalidPrice = errors.New("The price must be a positive amount in the store currency")
+
+	// ErrInvalidPriceOverride is returned when a price override is not a positive amount, is in the
+	// store currency or repeats the currency of another override.
+	ErrInvalidPriceOverride = errors.New("Price overrides must be positive amounts in other currencies than the store currency, one per currency")
+)
+
+// ValidatePrices checks that the price of the product and the prices of its variants are positive
+// amounts in the store currency, and that their price overrides are valid.
+func (p Product) ValidatePrices() error {
+	currency := money.StoreCurrency()
+	if !p.Price.IsPositive() || p.Price.Currency != currency {
+		return ErrInvalidPrice
+	}
+	if err := validateOverrides(p.PriceOverrides, currency); err != nil {
+		return err
+	}
+	for _, variant := range p.Variants {
+		if variant.Price != nil && (!variant.Price.IsPositive() || variant.Price.Currency != currency) {
+			return ErrInvalidPrice
+		}
+		if err := validateOverrides(variant.PriceOverrides, currency); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// validateOverrides checks that the price overrides are positive amounts in distinct currencies
+// other than the store currency.
+func validateOverrides(overrides []money.Money, storeCurrency money.Currency) error {
+	currencies := map[money.Currency]bool{}
+	for _, override := range overrides {
+		if !override.IsPositive() || !override.Currency.Valid() || override.Currency == storeCurrency || currencies[override.Currency] {
+			return ErrInvalidPriceOverride
+		}
+		currencies[override.Currency] = true
+	}
+	return nil
 }
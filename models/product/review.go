@@ -0,0 +1,50 @@
+package product
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+/*
+	Review is the rating and opinion of a customer about a product, stored in the reviews collection.
+
+	Only customers with a delivered order containing the product can review it, once. The average
+	rating and the number of reviews of a product are kept on the product and recomputed whenever
+	one of its reviews is created, edited or deleted.
+
+	Fields:
+	- ReviewID: The unique identifier for the review.
+	- ProductID: The identifier of the reviewed product.
+	- UserID: The identifier of the customer who wrote the review.
+	- AuthorName: The first name of the customer at the time of the review.
+	- Rating: The rating given to the product, from 1 to 5. It is a required field.
+	- Title: The optional headline of the review.
+	- Body: The text of the review, at most 5000 characters.
+	- HelpfulCount: The number of other customers who marked the review as helpful.
+	- HelpfulVoters: The customers who marked the review as helpful. It is not exposed by the API.
+	- CreatedAt: The timestamp indicating when the review was written.
+	- UpdatedAt: The timestamp indicating when the review was last edited.
+*/
+
+type Review struct {
+	ReviewID      primitive.ObjectID   `json:"review_id" bson:"_id"`
+	ProductID     primitive.ObjectID   `json:"product_id" bson:"product_id"`
+	UserID        primitive.ObjectID   `json:"user_id" bson:"user_id"`
+	AuthorName    string               `json:"author_name" bson:"author_name"`
+	Rating        int                  `json:"rating" bson:"rating" validate:"required,min=1,max=5"`
+	Title         string               `json:"title" bson:"title" validate:"max=200"`
+	Body          string               `json:"body" bson:"body" validate:"max=5000"`
+	HelpfulCount  int                  `json:"helpful_count" bson:"helpful_count"`
+	HelpfulVoters []primitive.ObjectID `json:"-" bson:"helpful_voters"`
+	CreatedAt     time.Time            `json:"created_at" bson:"created_at"`
+	UpdatedAt     time.Time            `json:"updated_at" bson:"updated_at"`
+}
+
+// RatingSummary is the aggregate of the reviews of a product.
+type RatingSummary struct {
+	// Average is the mean rating of the reviews, rounded to two decimals, or 0 without reviews.
+	Average float32
+	// Count is the number of reviews.
+	Count int
+}
@@ -0,0 +1,224 @@
+package product
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/YassinNouh21/GoShopCart-Ecommerce/money"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+/*
+	ProductOption is a dimension along which the variants of a product differ, for example
+	a "size" option with the values "S", "M" and "L".
+
+	Fields:
+	- Name: The name of the option. It is a required field.
+	- Values: The values the variants can take for the option. At least one is required.
+*/
+
+type ProductOption struct {
+	Name   string   `json:"name" bson:"name" validate:"required"`
+	Values []string `json:"values" bson:"values" validate:"required,min=1,dive,required"`
+}
+
+/*
+	Variant is a purchasable version of a product, such as the red shirt in size M.
+
+	Fields:
+	- VariantID: The unique identifier of the variant within its product. It is assigned by the server.
+	- SKU: The stock keeping unit of the variant, unique across the catalog. It is a required field.
+	- Options: The value of every option of the product for this variant, keyed by option name.
+	- Price: The price of the variant. When omitted the price of the product applies.
+	- PriceOverrides: Prices of the variant set by hand in other currencies, at most one per currency.
+	- DisplayPrice: The price in the currency of the request, set on the responses and never stored.
+	- Stock: The number of units of the variant available for sale.
+	- ImageUrl: The URL of the image of the variant. When omitted the image of the product applies.
+*/
+
+type Variant struct {
+	VariantID      primitive.ObjectID `json:"variant_id" bson:"_id"`
+	SKU            string             `json:"sku" bson:"sku" validate:"required"`
+	Options        map[string]string  `json:"options" bson:"options"`
+	Price          *money.Money       `json:"price,omitempty" bson:"price,omitempty"`
+	PriceOverrides []money.Money      `json:"price_overrides,omitempty" bson:"price_overrides,omitempty"`
+	DisplayPrice   *money.Money       `json:"display_price,omitempty" bson:"-"`
+	Stock          int                `json:"stock" bson:"stock" validate:"gte=0"`
+	ImageUrl       string             `json:"image,omitempty" bson:"image_url,omitempty"`
+}
+
+var (
+	// ErrVariantRequired is returned when a product with variants is referenced without a variant.
+	ErrVariantRequired = errors.New("A variant of the product must be selected")
+
+	// ErrVariantNotFound is returned when the variant does not belong to the product.
+	ErrVariantNotFound = errors.New("Variant not found")
+)
+
+// HasVariants reports whether the product is sold through its variants.
+func (p Product) HasVariants() bool {
+	return len(p.Variants) > 0
+}
+
+// FindVariant returns the variant of the product with the given ID.
+func (p Product) FindVariant(variantID primitive.ObjectID) (*Variant, bool) {
+	for i := range p.Variants {
+		if p.Variants[i].VariantID == variantID {
+			return &p.Variants[i], true
+		}
+	}
+	return nil, false
+}
+
+// ResolveVariant returns the variant a cart or order item refers to. A product with variants requires
+// a variant ID, and a product without variants is referenced without one; nil is returned for it.
+func (p Product) ResolveVariant(variantID *primitive.ObjectID) (*Variant, error) {
+	if variantID == nil {
+		if p.HasVariants() {
+			return nil, ErrVariantRequired
+		}
+		return nil, nil
+	}
+	variant, found := p.FindVariant(*variantID)
+	if !found {
+		return nil, ErrVariantNotFound
+	}
+	return variant, nil
+}
+
+// PriceOf returns the unit price of the variant, or of the product if the variant is nil or has no price.
+func (p Product) PriceOf(variant *Variant) money.Money {
+	if variant != nil && variant.Price != nil {
+		return *variant.Price
+	}
+	return p.Price
+}
+
+/*
+PriceIn returns the unit price of the variant, or of the product if the variant is nil, in a currency.
+
+	A price override in the currency wins: the one of the variant, then, for a variant without a price
+	of its own, the one of the product. Otherwise the price in the store currency is converted with the
+	exchange rates.
+*/
+func (p Product) PriceIn(variant *Variant, rates *money.Rates, currency money.Currency) (money.Money, error) {
+	if variant != nil {
+		if override, found := findOverride(variant.PriceOverrides, currency); found {
+			return override, nil
+		}
+		if variant.Price != nil {
+			return rates.Convert(*variant.Price, currency)
+		}
+	}
+	if override, found := findOverride(p.PriceOverrides, currency); found {
+		return override, nil
+	}
+	return rates.Convert(p.Price, currency)
+}
+
+// Localize sets the display price of the product and of its variants in a currency. Products read
+// without their price, through a projection, are left as they are.
+func (p *Product) Localize(rates *money.Rates, currency money.Currency) error {
+	if p.Price.Currency == "" {
+		return nil
+	}
+	price, err := p.PriceIn(nil, rates, currency)
+	if err != nil {
+		return err
+	}
+	p.DisplayPrice = &price
+	for i := range p.Variants {
+		price, err := p.PriceIn(&p.Variants[i], rates, currency)
+		if err != nil {
+			return err
+		}
+		p.Variants[i].DisplayPrice = &price
+	}
+	return nil
+}
+
+// findOverride returns the price override in the currency.
+func findOverride(overrides []money.Money, currency money.Currency) (money.Money, bool) {
+	for _, override := range overrides {
+		if override.Currency == currency {
+			return override, true
+		}
+	}
+	return money.Money{}, false
+}
+
+// StockOf returns the stock of the variant, or of the product if the variant is nil.
+func (p Product) StockOf(variant *Variant) int {
+	if variant != nil {
+		return variant.Stock
+	}
+	return p.Stock
+}
+
+// ValidateVariants checks that the variants match the options of the product: every variant has one
+// allowed value for each option and no other, no two variants share the same combination of values,
+// and no two variants share a SKU or an ID.
+func (p Product) ValidateVariants() error {
+	if len(p.Options) > 0 && len(p.Variants) == 0 {
+		return errors.New("A product with options needs at least one variant")
+	}
+	if len(p.Variants) > 0 && len(p.Options) == 0 {
+		return errors.New("A product with variants needs options")
+	}
+	allowed := map[string]map[string]bool{}
+	for _, option := range p.Options {
+		if allowed[option.Name] != nil {
+			return fmt.Errorf("Duplicate option %q", option.Name)
+		}
+		allowed[option.Name] = map[string]bool{}
+		for _, value := range option.Values {
+			allowed[option.Name][value] = true
+		}
+	}
+	skus := map[string]bool{}
+	ids := map[primitive.ObjectID]bool{}
+	combinations := map[string]bool{}
+	for _, variant := range p.Variants {
+		if variant.SKU == "" {
+			return errors.New("Every variant needs a SKU")
+		}
+		if skus[variant.SKU] {
+			return fmt.Errorf("Duplicate SKU %q", variant.SKU)
+		}
+		skus[variant.SKU] = true
+		if ids[variant.VariantID] {
+			return fmt.Errorf("Duplicate variant ID %s", variant.VariantID.Hex())
+		}
+		ids[variant.VariantID] = true
+		if len(variant.Options) != len(p.Options) {
+			return fmt.Errorf("Variant %q must have exactly one value for each option", variant.SKU)
+		}
+		for name, value := range variant.Options {
+			values, found := allowed[name]
+			if !found {
+				return fmt.Errorf("Variant %q has unknown option %q", variant.SKU, name)
+			}
+			if !values[value] {
+				return fmt.Errorf("Variant %q has invalid value %q for option %q", variant.SKU, value, name)
+			}
+		}
+		combination := variantCombination(variant.Options)
+		if combinations[combination] {
+			return fmt.Errorf("Variant %q repeats the options of another variant", variant.SKU)
+		}
+		combinations[combination] = true
+	}
+	return nil
+}
+
+// variantCombination returns a key identifying the option values of a variant.
+func variantCombination(options map[string]string) string {
+	pairs := make([]string, 0, len(options))
+	for name, value := range options {
+		pairs = append(pairs, name+"="+value)
+	}
+	sort.Strings(pairs)
+	return strings.Join(pairs, "\x00")
+}
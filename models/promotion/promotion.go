@@ -0,0 +1,210 @@
+package promotion
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	productModel "github.com/YassinNouh21/GoShopCart-Ecommerce/models/product"
+	"github.com/YassinNouh21/GoShopCart-Ecommerce/money"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Kind is the benefit a promotion gives.
+type Kind string
+
+const (
+	// KindPercentage takes a percentage off the items in scope.
+	KindPercentage Kind = "percentage"
+	// KindFixedAmount takes a fixed amount off the items in scope, spread over them.
+	KindFixedAmount Kind = "fixed_amount"
+	// KindFreeShipping waives the shipping of the order.
+	KindFreeShipping Kind = "free_shipping"
+	// KindBuyXGetY gives GetQuantity units free for every BuyQuantity units bought of the items in
+	// scope; the cheapest units are the free ones.
+	KindBuyXGetY Kind = "buy_x_get_y"
+)
+
+// MaxCodeLength is the longest coupon code.
+const MaxCodeLength = 32
+
+/*
+	Promotion is a discount defined by the admins, stored in the promotions collection.
+
+	A promotion with a code is a coupon, which customers apply to their cart; a promotion without a
+	code applies to every cart it is eligible for.
+
+	Fields:
+	- PromotionID: The unique identifier of the promotion.
+	- Code: The coupon code, unique and in upper case. When empty the promotion applies automatically.
+	- Name: The name of the promotion shown to the customers. It is a required field.
+	- Kind: The benefit of the promotion: percentage, fixed_amount, free_shipping or buy_x_get_y.
+	- Percent: The percentage taken off, from 1 to 100, for percentage promotions.
+	- Amount: The amount taken off, in the store currency, for fixed_amount promotions.
+	- BuyQuantity: The number of units to buy, for buy_x_get_y promotions.
+	- GetQuantity: The number of units given free for every BuyQuantity units, for buy_x_get_y promotions.
+	- MinimumSpend: The subtotal, in the store currency, the cart must reach for the promotion to apply.
+	- ProductIDs: The products the promotion applies to.
+	- CategoryIDs: The categories whose products the promotion applies to. Without products or
+	  categories, the promotion applies to every product.
+	- UsageLimit: How many orders can use the promotion in total. Zero means no limit.
+	- UsageLimitPerUser: How many orders of one customer can use the promotion. Zero means no limit.
+	- UsageCount: How many orders use the promotion, maintained by the server. Cancelled orders give
+	  their use back.
+	- StartsAt: The time the promotion starts. When missing it starts right away.
+	- EndsAt: The time the promotion ends. When missing it never ends.
+	- Stackable: Whether the promotion combines with other promotions. A promotion that does not
+	  stack applies alone; when promotions compete, the cart gets the largest discount.
+	- Active: Whether the promotion can be used. Admins deactivate a promotion to pause it.
+	- CreatedAt: The timestamp when the promotion was created.
+	- UpdatedAt: The timestamp when the promotion was last updated.
+*/
+
+type Promotion struct {
+	PromotionID       primitive.ObjectID   `json:"promotion_id" bson:"_id"`
+	Code              string               `json:"code,omitempty" bson:"code,omitempty"`
+	Name              string               `json:"name" bson:"name" validate:"required,max=100"`
+	Kind              Kind                 `json:"kind" bson:"kind"`
+	Percent           int                  `json:"percent,omitempty" bson:"percent,omitempty"`
+	Amount            *money.Money         `json:"amount,omitempty" bson:"amount,omitempty"`
+	BuyQuantity       int                  `json:"buy_quantity,omitempty" bson:"buy_quantity,omitempty"`
+	GetQuantity       int                  `json:"get_quantity,omitempty" bson:"get_quantity,omitempty"`
+	MinimumSpend      *money.Money         `json:"minimum_spend,omitempty" bson:"minimum_spend,omitempty"`
+	ProductIDs        []primitive.ObjectID `json:"product_ids" bson:"product_ids"`
+	CategoryIDs       []primitive.ObjectID `json:"category_ids" bson:"category_ids"`
+	UsageLimit        int                  `json:"usage_limit" bson:"usage_limit" validate:"gte=0"`
+	UsageLimitPerUser int                  `json:"usage_limit_per_user" bson:"usage_limit_per_user" validate:"gte=0"`
+	UsageCount        int                  `json:"usage_count" bson:"usage_count"`
+	StartsAt          *time.Time           `json:"starts_at,omitempty" bson:"starts_at,omitempty"`
+	EndsAt            *time.Time           `json:"ends_at,omitempty" bson:"ends_at,omitempty"`
+	Stackable         bool                 `json:"stackable" bson:"stackable"`
+	Active            bool                 `json:"active" bson:"active"`
+	CreatedAt         time.Time            `json:"created_at" bson:"created_at"`
+	UpdatedAt         time.Time            `json:"updated_at" bson:"updated_at"`
+}
+
+/*
+	Applied is a promotion applied to an order, with the discount it gave.
+
+	Fields:
+	- PromotionID: The identifier of the promotion.
+	- Code: The coupon code, for coupons.
+	- Name: The name of the promotion.
+	- Kind: The benefit of the promotion.
+	- Discount: The amount the promotion took off, in the currency of the order. For free shipping it
+	  is the shipping waived.
+*/
+
+type Applied struct {
+	PromotionID primitive.ObjectID `json:"promotion_id" bson:"promotion_id"`
+	Code        string             `json:"code,omitempty" bson:"code,omitempty"`
+	Name        string             `json:"name" bson:"name"`
+	Kind        Kind               `json:"kind" bson:"kind"`
+	Discount    money.Money        `json:"discount" bson:"discount"`
+}
+
+/*
+	Redemption is the use of a promotion by an order, stored in the promotion_redemptions collection.
+	It counts towards the usage limit per user and is removed when the order is cancelled.
+
+	Fields:
+	- RedemptionID: The unique identifier of the redemption.
+	- PromotionID: The identifier of the promotion used.
+	- UserID: The identifier of the customer who placed the order.
+	- OrderID: The identifier of the order.
+	- CreatedAt: The timestamp when the order was placed.
+*/
+
+type Redemption struct {
+	RedemptionID primitive.ObjectID `json:"redemption_id" bson:"_id"`
+	PromotionID  primitive.ObjectID `json:"promotion_id" bson:"promotion_id"`
+	UserID       primitive.ObjectID `json:"user_id" bson:"user_id"`
+	OrderID      primitive.ObjectID `json:"order_id" bson:"order_id"`
+	CreatedAt    time.Time          `json:"created_at" bson:"created_at"`
+}
+
+var (
+	// ErrNotActive is returned for a promotion that was deactivated.
+	ErrNotActive = errors.New("is not active")
+	// ErrNotStarted is returned for a promotion that has not started yet.
+	ErrNotStarted = errors.New("has not started yet")
+	// ErrExpired is returned for a promotion that has ended.
+	ErrExpired = errors.New("has expired")
+	// ErrUsedUp is returned for a promotion that reached its usage limit.
+	ErrUsedUp = errors.New("has reached its usage limit")
+)
+
+// NormalizeCode returns the coupon code in the form it is stored in: trimmed and in upper case.
+func NormalizeCode(code string) string {
+	return strings.ToUpper(strings.TrimSpace(code))
+}
+
+// Validate checks that the promotion has the fields its kind needs, in the store currency, and a
+// valid code and period.
+func (p Promotion) Validate() error {
+	if len(p.Code) > MaxCodeLength || strings.ContainsAny(p.Code, " \t\n") {
+		return fmt.Errorf("The code must have at most %d characters and no spaces", MaxCodeLength)
+	}
+	currency := money.StoreCurrency()
+	switch p.Kind {
+	case KindPercentage:
+		if p.Percent < 1 || p.Percent > 100 {
+			return errors.New("A percentage promotion needs a percent from 1 to 100")
+		}
+	case KindFixedAmount:
+		if p.Amount == nil || !p.Amount.IsPositive() || p.Amount.Currency != currency {
+			return errors.New("A fixed_amount promotion needs a positive amount in the store currency")
+		}
+	case KindFreeShipping:
+	case KindBuyXGetY:
+		if p.BuyQuantity < 1 || p.GetQuantity < 1 {
+			return errors.New("A buy_x_get_y promotion needs a buy_quantity and a get_quantity of at least 1")
+		}
+	default:
+		return errors.New("The kind must be percentage, fixed_amount, free_shipping or buy_x_get_y")
+	}
+	if p.MinimumSpend != nil && (p.MinimumSpend.IsNegative() || p.MinimumSpend.Currency != currency) {
+		return errors.New("The minimum spend must be an amount in the store currency")
+	}
+	if p.StartsAt != nil && p.EndsAt != nil && !p.EndsAt.After(*p.StartsAt) {
+		return errors.New("The promotion must end after it starts")
+	}
+	return nil
+}
+
+// Available returns why the promotion cannot be used at the given time, or nil if it can.
+func (p Promotion) Available(now time.Time) error {
+	switch {
+	case !p.Active:
+		return ErrNotActive
+	case p.StartsAt != nil && now.Before(*p.StartsAt):
+		return ErrNotStarted
+	case p.EndsAt != nil && !now.Before(*p.EndsAt):
+		return ErrExpired
+	case p.UsageLimit > 0 && p.UsageCount >= p.UsageLimit:
+		return ErrUsedUp
+	}
+	return nil
+}
+
+// Covers reports whether the promotion applies to the product: it is one of its products, or in one
+// of its categories, or the promotion has no scope.
+func (p Promotion) Covers(product productModel.Product) bool {
+	if len(p.ProductIDs) == 0 && len(p.CategoryIDs) == 0 {
+		return true
+	}
+	for _, productID := range p.ProductIDs {
+		if productID == product.ProductID {
+			return true
+		}
+	}
+	for _, categoryID := range p.CategoryIDs {
+		for _, productCategoryID := range product.CategoryIDs {
+			if categoryID == productCategoryID {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,292 @@
+package shipping
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/YassinNouh21/GoShopCart-Ecommerce/money"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// RateType is how a shipping method computes its cost.
+type RateType string
+
+const (
+	// RateFlat charges the same rate for every cart.
+	RateFlat RateType = "flat"
+	// RateWeight charges the rate of the lightest weight tier the cart fits in.
+	RateWeight RateType = "weight"
+	// RatePrice charges the rate of the highest price tier the subtotal reaches.
+	RatePrice RateType = "price"
+)
+
+/*
+	Region is a part of the world a shipping zone covers: a country, a state of it, or the postal codes
+	of the country starting with a prefix.
+
+	Fields:
+	- CountryCode: The ISO 3166 alpha-2 code of the country, such as "US". It is a required field.
+	- State: The state or province, as written in the addresses. When empty every state matches.
+	- PostalPrefix: The start of the postal codes, such as "SW1". When empty every postal code matches.
+*/
+
+type Region struct {
+	CountryCode  string `json:"country_code" bson:"country_code"`
+	State        string `json:"state,omitempty" bson:"state,omitempty"`
+	PostalPrefix string `json:"postal_prefix,omitempty" bson:"postal_prefix,omitempty"`
+}
+
+/*
+	WeightTier is a rate of a weight-based method.
+
+	Fields:
+	- MaxWeight: The heaviest cart, in grams, the tier applies to.
+	- Rate: The cost of shipping, in the store currency.
+*/
+
+type WeightTier struct {
+	MaxWeight int         `json:"max_weight" bson:"max_weight"`
+	Rate      money.Money `json:"rate" bson:"rate"`
+}
+
+/*
+	PriceTier is a rate of a price-tiered method.
+
+	Fields:
+	- MinSubtotal: The subtotal, in the store currency, from which the tier applies.
+	- Rate: The cost of shipping, in the store currency.
+*/
+
+type PriceTier struct {
+	MinSubtotal money.Money `json:"min_subtotal" bson:"min_subtotal"`
+	Rate        money.Money `json:"rate" bson:"rate"`
+}
+
+/*
+	Method is a way of shipping the orders of a zone, such as standard or express delivery.
+
+	Fields:
+	- MethodID: The unique identifier of the method. It is assigned by the server when missing.
+	- Name: The name of the method shown to the customers. It is a required field.
+	- RateType: How the cost is computed: flat, weight or price.
+	- Rate: The cost of shipping, in the store currency, for flat methods.
+	- WeightTiers: The rates by weight, lightest first, for weight methods. Carts heavier than the
+	  last tier cannot use the method.
+	- PriceTiers: The rates by subtotal, lowest first, for price methods. The first starts at zero.
+	- FreeAbove: The subtotal, in the store currency, from which shipping is free. When missing the
+	  method is never free.
+	- Active: Whether customers can choose the method.
+*/
+
+type Method struct {
+	MethodID    primitive.ObjectID `json:"method_id" bson:"_id"`
+	Name        string             `json:"name" bson:"name" validate:"required,max=50"`
+	RateType    RateType           `json:"rate_type" bson:"rate_type"`
+	Rate        *money.Money       `json:"rate,omitempty" bson:"rate,omitempty"`
+	WeightTiers []WeightTier       `json:"weight_tiers,omitempty" bson:"weight_tiers,omitempty"`
+	PriceTiers  []PriceTier        `json:"price_tiers,omitempty" bson:"price_tiers,omitempty"`
+	FreeAbove   *money.Money       `json:"free_above,omitempty" bson:"free_above,omitempty"`
+	Active      bool               `json:"active" bson:"active"`
+}
+
+/*
+	Zone is a set of regions shipped to with the same methods, managed by the admins and stored in the
+	shipping_zones collection.
+
+	An address is in the zone of its most specific matching region: a postal prefix wins over a state,
+	a longer prefix over a shorter one, and a state over the whole country.
+
+	Fields:
+	- ZoneID: The unique identifier of the zone.
+	- Name: The name of the zone, such as "Domestic". It is a required field.
+	- Regions: The regions the zone covers. At least one is required.
+	- Methods: The shipping methods of the zone.
+	- CreatedAt: The timestamp when the zone was created.
+	- UpdatedAt: The timestamp when the zone was last updated.
+*/
+
+type Zone struct {
+	ZoneID    primitive.ObjectID `json:"zone_id" bson:"_id"`
+	Name      string             `json:"name" bson:"name" validate:"required,max=100"`
+	Regions   []Region           `json:"regions" bson:"regions" validate:"required,min=1"`
+	Methods   []Method           `json:"methods" bson:"methods" validate:"dive"`
+	CreatedAt time.Time          `json:"created_at" bson:"created_at"`
+	UpdatedAt time.Time          `json:"updated_at" bson:"updated_at"`
+}
+
+/*
+	Option is a shipping method available for a cart, with its cost. Orders keep the option they were
+	shipped with.
+
+	Fields:
+	- ZoneID: The identifier of the zone of the shipping address.
+	- MethodID: The identifier of the method.
+	- Name: The name of the method.
+	- Cost: The cost of shipping the cart with the method, in the currency of the cart.
+*/
+
+type Option struct {
+	ZoneID   primitive.ObjectID `json:"zone_id" bson:"zone_id"`
+	MethodID primitive.ObjectID `json:"method_id" bson:"method_id"`
+	Name     string             `json:"name" bson:"name"`
+	Cost     money.Money        `json:"cost" bson:"cost"`
+}
+
+var countryCodePattern = regexp.MustCompile(`^[A-Z]{2}$`)
+
+// NormalizeRegion returns the region in the form it is matched in: the country and state trimmed and
+// in upper case, and the postal prefix in upper case without spaces.
+func NormalizeRegion(region Region) Region {
+	return Region{
+		CountryCode:  strings.ToUpper(strings.TrimSpace(region.CountryCode)),
+		State:        strings.ToUpper(strings.TrimSpace(region.State)),
+		PostalPrefix: strings.ToUpper(strings.Join(strings.Fields(region.PostalPrefix), "")),
+	}
+}
+
+// specificity returns how precisely the region matches an address in the normalized region, or zero
+// if it does not match it.
+func (r Region) specificity(address Region) int {
+	if r.CountryCode != address.CountryCode {
+		return 0
+	}
+	if r.State != "" && r.State != address.State {
+		return 0
+	}
+	if !strings.HasPrefix(address.PostalPrefix, r.PostalPrefix) {
+		return 0
+	}
+	score := 1 + 2*len(r.PostalPrefix)
+	if r.State != "" {
+		score++
+	}
+	return score
+}
+
+// Match returns the zone of the address among the zones, or nil if none covers it. Of zones with
+// equally specific regions the first wins.
+func Match(zones []Zone, countryCode, state, postalCode string) *Zone {
+	address := NormalizeRegion(Region{CountryCode: countryCode, State: state, PostalPrefix: postalCode})
+	var best *Zone
+	bestScore := 0
+	for i := range zones {
+		for _, region := range zones[i].Regions {
+			if score := region.specificity(address); score > bestScore {
+				best, bestScore = &zones[i], score
+			}
+		}
+	}
+	return best
+}
+
+// Validate checks the regions of the zone and the rates of its methods, whose amounts are in the
+// store currency.
+func (z Zone) Validate() error {
+	for _, region := range z.Regions {
+		if !countryCodePattern.MatchString(region.CountryCode) {
+			return errors.New("Every region needs a country_code, an ISO 3166 alpha-2 code such as US")
+		}
+	}
+	ids := map[primitive.ObjectID]bool{}
+	for _, method := range z.Methods {
+		if ids[method.MethodID] {
+			return fmt.Errorf("Duplicate method ID %s", method.MethodID.Hex())
+		}
+		ids[method.MethodID] = true
+		if err := method.validate(); err != nil {
+			return fmt.Errorf("Method %q: %w", method.Name, err)
+		}
+	}
+	return nil
+}
+
+// validate checks that the method has the rates its type needs.
+func (m Method) validate() error {
+	currency := money.StoreCurrency()
+	valid := func(amount money.Money) bool {
+		return !amount.IsNegative() && amount.Currency == currency
+	}
+	switch m.RateType {
+	case RateFlat:
+		if m.Rate == nil || !valid(*m.Rate) {
+			return errors.New("a flat method needs a rate in the store currency")
+		}
+	case RateWeight:
+		if len(m.WeightTiers) == 0 {
+			return errors.New("a weight method needs weight_tiers")
+		}
+		for i, tier := range m.WeightTiers {
+			if tier.MaxWeight <= 0 || (i > 0 && tier.MaxWeight <= m.WeightTiers[i-1].MaxWeight) || !valid(tier.Rate) {
+				return errors.New("the weight tiers need increasing positive max_weight and rates in the store currency")
+			}
+		}
+	case RatePrice:
+		if len(m.PriceTiers) == 0 || !m.PriceTiers[0].MinSubtotal.IsZero() {
+			return errors.New("a price method needs price_tiers, the first with a min_subtotal of zero")
+		}
+		for i, tier := range m.PriceTiers {
+			if !valid(tier.MinSubtotal) || !valid(tier.Rate) || (i > 0 && tier.MinSubtotal.Cmp(m.PriceTiers[i-1].MinSubtotal) <= 0) {
+				return errors.New("the price tiers need increasing min_subtotal and rates in the store currency")
+			}
+		}
+	default:
+		return errors.New("the rate_type must be flat, weight or price")
+	}
+	if m.FreeAbove != nil && !valid(*m.FreeAbove) {
+		return errors.New("free_above must be an amount in the store currency")
+	}
+	return nil
+}
+
+// Cost returns the cost of shipping a cart of the subtotal and weight, in grams, with the method, in
+// the currency of the subtotal. The amounts of the method are converted with the exchange rates. It
+// returns false if the cart is too heavy for the method.
+func (m Method) Cost(subtotal money.Money, weight int, rates *money.Rates) (money.Money, bool, error) {
+	currency := subtotal.Currency
+	if m.FreeAbove != nil {
+		threshold, err := rates.Convert(*m.FreeAbove, currency)
+		if err != nil {
+			return money.Money{}, false, err
+		}
+		if subtotal.Cmp(threshold) >= 0 {
+			return money.Zero(currency), true, nil
+		}
+	}
+	var rate money.Money
+	switch m.RateType {
+	case RateFlat:
+		rate = *m.Rate
+	case RateWeight:
+		found := false
+		for _, tier := range m.WeightTiers {
+			if weight <= tier.MaxWeight {
+				rate, found = tier.Rate, true
+				break
+			}
+		}
+		if !found {
+			return money.Money{}, false, nil
+		}
+	case RatePrice:
+		for _, tier := range m.PriceTiers {
+			minimum, err := rates.Convert(tier.MinSubtotal, currency)
+			if err != nil {
+				return money.Money{}, false, err
+			}
+			if subtotal.Cmp(minimum) < 0 {
+				break
+			}
+			rate = tier.Rate
+		}
+	default:
+		return money.Money{}, false, nil
+	}
+	cost, err := rates.Convert(rate, currency)
+	if err != nil {
+		return money.Money{}, false, err
+	}
+	return cost, true, nil
+}
@@ -0,0 +1,141 @@
+package tax
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/YassinNouh21/GoShopCart-Ecommerce/money"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// StandardClass is the tax class of the products whose categories have none.
+const StandardClass = ""
+
+/*
+	Rate is a tax on the products shipped to a country, or to a state of it, managed by the admins and
+	stored in the tax_rates collection.
+
+	A cart is taxed with the rates of the country of its shipping address. Every product takes the most
+	specific rate of its tax class: the one of the state, then the one of the whole country, and, when
+	its class has none, the standard rate of the state and then of the country. Products without a rate
+	are not taxed.
+
+	Fields:
+	- RateID: The unique identifier of the rate.
+	- CountryCode: The ISO 3166 alpha-2 code of the country, such as "DE". It is a required field.
+	- State: The state or province the rate is limited to, as written in the addresses. When empty the
+	  rate applies to the whole country.
+	- TaxClass: The tax class the rate applies to, such as "books". When empty it is the standard rate.
+	- Name: The name of the tax shown to the customers, such as "VAT". It is a required field.
+	- Percent: The rate as a decimal percentage with at most 4 decimals, such as "19" or "8.875".
+	- Inclusive: Whether the prices of the catalog include the tax, as for VAT, or the tax is added to
+	  them, as for sales tax.
+	- UpdatedAt: The timestamp when the rate was last set.
+*/
+
+type Rate struct {
+	RateID      primitive.ObjectID `json:"rate_id" bson:"_id"`
+	CountryCode string             `json:"country_code" bson:"country_code"`
+	State       string             `json:"state,omitempty" bson:"state"`
+	TaxClass    string             `json:"tax_class,omitempty" bson:"tax_class"`
+	Name        string             `json:"name" bson:"name" validate:"required,max=50"`
+	Percent     string             `json:"percent" bson:"percent"`
+	Inclusive   bool               `json:"inclusive" bson:"inclusive"`
+	UpdatedAt   time.Time          `json:"updated_at" bson:"updated_at"`
+}
+
+var (
+	// ErrInvalidPercent is returned for a percentage that is not a decimal number from 0 to 100 with at
+	// most 4 decimals.
+	ErrInvalidPercent = errors.New("invalid tax percentage")
+
+	percentPattern     = regexp.MustCompile(`^[0-9]{1,3}(\.[0-9]{1,4})?$`)
+	countryCodePattern = regexp.MustCompile(`^[A-Z]{2}$`)
+	taxClassPattern    = regexp.MustCompile(`^[a-z0-9]+(?:[_-][a-z0-9]+)*$`)
+)
+
+// NormalizeRegion returns a country code or state in the form rates are matched in: trimmed and in
+// upper case.
+func NormalizeRegion(region string) string {
+	return strings.ToUpper(strings.TrimSpace(region))
+}
+
+// NormalizeClass returns a tax class in the form it is stored in: trimmed and in lower case.
+func NormalizeClass(class string) string {
+	return strings.ToLower(strings.TrimSpace(class))
+}
+
+// ValidClass reports whether the tax class is empty, the standard class, or holds lowercase letters
+// and digits separated by single dashes or underscores.
+func ValidClass(class string) bool {
+	return class == StandardClass || taxClassPattern.MatchString(class)
+}
+
+// ParsePercent reads a percentage from 0 to 100 with at most 4 decimals, such as "8.875".
+func ParsePercent(value string) (*big.Rat, error) {
+	if !percentPattern.MatchString(value) {
+		return nil, fmt.Errorf("%w %q", ErrInvalidPercent, value)
+	}
+	percent, ok := new(big.Rat).SetString(value)
+	if !ok || percent.Cmp(big.NewRat(100, 1)) > 0 {
+		return nil, fmt.Errorf("%w %q", ErrInvalidPercent, value)
+	}
+	return percent, nil
+}
+
+// Validate checks the country code, tax class and percentage of the rate.
+func (r Rate) Validate() error {
+	if !countryCodePattern.MatchString(r.CountryCode) {
+		return errors.New("The country_code must be an ISO 3166 alpha-2 code such as DE")
+	}
+	if !ValidClass(r.TaxClass) {
+		return errors.New("The tax_class must only contain lowercase letters and digits separated by dashes or underscores")
+	}
+	if _, err := ParsePercent(r.Percent); err != nil {
+		return errors.New("The percent must be a decimal number from 0 to 100 with at most 4 decimals")
+	}
+	return nil
+}
+
+/*
+Tax returns the tax of the rate on an amount, rounded half away from zero to the minor unit.
+
+	For an exclusive rate the amount is before tax and the tax is added to it; for an inclusive rate
+	the amount already holds the tax, which is the part percent / (100 + percent) of it.
+*/
+func (r Rate) Tax(amount money.Money) (money.Money, error) {
+	percent, err := ParsePercent(r.Percent)
+	if err != nil {
+		return money.Money{}, err
+	}
+	base := big.NewRat(100, 1)
+	if r.Inclusive {
+		base.Add(base, percent)
+	}
+	return amount.Scale(new(big.Rat).Quo(percent, base)), nil
+}
+
+// Select returns the rate of the tax class for the state among the rates of a country, or nil if none
+// applies. The rate of the class for the state wins over the one for the whole country, and both over
+// the standard rates, of the state and then of the country.
+func Select(rates []Rate, state string, class string) *Rate {
+	state = NormalizeRegion(state)
+	classes := []string{class}
+	if class != StandardClass {
+		classes = append(classes, StandardClass)
+	}
+	for _, taxClass := range classes {
+		for _, region := range []string{state, ""} {
+			for i := range rates {
+				if rates[i].TaxClass == taxClass && rates[i].State == region {
+					return &rates[i]
+				}
+			}
+		}
+	}
+	return nil
+}
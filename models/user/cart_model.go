@@ -10,10 +10,12 @@ import (
 	Cart represents a user's cart item, which includes the cart ID, product ID, quantity, and timestamps for creation and updates.
 
 	CartWithoutId is a variant of the Cart model without the CartID field, used when the cart item doesn't 	require an explicit ID.
+	It is the body of a cart item update, where a quantity of zero removes the item.
 
 	Fields:
 	- CartID: The unique identifier of the cart item.
 	- ProductID: The identifier of the associated product.
+	- VariantID: The identifier of the selected variant, for products with variants.
 	- Quantity: The quantity of the product in the cart.
 	- CreatedAt: The timestamp indicating when the cart item was created.
 	- UpdatedAt: The timestamp indicating when the cart item was last updated.
@@ -21,17 +23,32 @@ import (
 */
 
 type Cart struct {
-	CartID    primitive.ObjectID `json:"cart_id" bson:"_id" validate:"required"`
-	ProductID primitive.ObjectID `json:"product_id" bson:"product_id" validate:"required"`
-	Quantity  int                `json:"quantity" bson:"quantity" validate:"required"`
-	CreatedAt time.Time          `json:"created_at" bson:"created_at" validate:"required"`
-	UpdatedAt time.Time          `json:"updated_at" bson:"updated_at" validate:"required"`
+	CartID    primitive.ObjectID  `json:"cart_id" bson:"_id" validate:"required"`
+	ProductID primitive.ObjectID  `json:"product_id" bson:"product_id" validate:"required"`
+	VariantID *primitive.ObjectID `json:"variant_id,omitempty" bson:"variant_id,omitempty"`
+	Quantity  int                 `json:"quantity" bson:"quantity" validate:"required,gt=0"`
+	CreatedAt time.Time           `json:"created_at" bson:"created_at" validate:"required"`
+	UpdatedAt time.Time           `json:"updated_at" bson:"updated_at" validate:"required"`
 }
 
 type CartWithoutId struct {
-	CartID    primitive.ObjectID `json:"cart_id" bson:"_id"`
-	ProductID primitive.ObjectID `json:"product_id" bson:"product_id" validate:"required"`
-	Quantity  int                `json:"quantity" bson:"quantity" validate:"required"`
-	CreatedAt time.Time          `json:"created_at" bson:"created_at" validate:"required"`
-	UpdatedAt time.Time          `json:"updated_at" bson:"updated_at" validate:"required"`
+	CartID    primitive.ObjectID  `json:"cart_id" bson:"_id"`
+	ProductID primitive.ObjectID  `json:"product_id" bson:"product_id" validate:"required"`
+	VariantID *primitive.ObjectID `json:"variant_id,omitempty" bson:"variant_id,omitempty"`
+	Quantity  int                 `json:"quantity" bson:"quantity" validate:"gte=0"`
+	CreatedAt time.Time           `json:"created_at" bson:"created_at" validate:"required"`
+	UpdatedAt time.Time           `json:"updated_at" bson:"updated_at" validate:"required"`
+}
+
+// Holds reports whether the cart item holds the given product and variant.
+func (c Cart) Holds(productID primitive.ObjectID, variantID *primitive.ObjectID) bool {
+	return c.ProductID == productID && SameVariant(c.VariantID, variantID)
+}
+
+// SameVariant reports whether two optional variant IDs refer to the same variant.
+func SameVariant(a, b *primitive.ObjectID) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return *a == *b
 }
@@ -0,0 +1,28 @@
+package user
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+/*
+	GuestCart is the cart of a visitor who has not signed in, stored in the guest_carts collection and
+	identified by a signed cart token. MongoDB removes it once it expires, and it is merged into the
+	cart of the user the visitor signs in as.
+
+	Fields:
+	- GuestCartID: The unique identifier of the guest cart, carried by its cart token.
+	- Items: The items of the cart, in the same shape as the items of a user's cart.
+	- CreatedAt: The timestamp when the cart was created.
+	- UpdatedAt: The timestamp when the cart was last changed.
+	- ExpiresAt: The timestamp from which the cart is removed. Every change of the cart extends it.
+*/
+
+type GuestCart struct {
+	GuestCartID primitive.ObjectID `json:"guest_cart_id" bson:"_id"`
+	Items       []Cart             `json:"items" bson:"items"`
+	CreatedAt   time.Time          `json:"created_at" bson:"created_at"`
+	UpdatedAt   time.Time          `json:"updated_at" bson:"updated_at"`
+	ExpiresAt   time.Time          `json:"expires_at" bson:"expires_at"`
+}
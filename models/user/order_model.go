@@ -3,26 +3,98 @@ package user
 import (
 	"time"
 
+	"github.com/YassinNouh21/GoShopCart-Ecommerce/models/promotion"
+	"github.com/YassinNouh21/GoShopCart-Ecommerce/models/shipping"
+	"github.com/YassinNouh21/GoShopCart-Ecommerce/money"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
 /*
 Package user defines the data model for the Order entity.
-The Order struct represents an order placed by a user. It contains information such as the order ID, discount, payment method, creation and update timestamps, and the quantity of items in the order.
+The Order struct represents an order placed by a user at checkout. It is stored in its own orders collection
+and keeps a snapshot of the purchased items and the shipping address, so later changes to products or
+addresses do not alter past orders.
 
 Fields:
-- OrderID: The unique identifier for the order.
-- Discount: The discount applied to the order.
-- PaymentMethod: The payment method used for the order.
-- CreatedAt: The timestamp when the order was created.
-- UpdatedAt: The timestamp when the order was last updated.
-- Quantity: The quantity of items in the order.
+  - OrderID: The unique identifier for the order.
+  - UserID: The identifier of the user who placed the order.
+  - Items: The purchased items with the product names and unit prices at purchase time.
+  - ShippingAddress: The address the order is shipped to.
+  - ExchangeRate: The rate from the store currency to the currency of the order used at checkout. It is
+    missing for orders in the store currency.
+  - Subtotal: The sum of the line totals of the items, in the currency selected at checkout.
+  - Discount: The discount of the promotions applied to the order.
+  - Promotions: The promotions applied to the order, with the discount of each.
+  - Tax: The tax of the order, the sum of the taxes of the items.
+  - TaxIncluded: The part of the tax already included in the prices of the items.
+  - Shipping: The cost of shipping the order.
+  - ShippingMethod: The shipping method of the order with its cost before promotions. It is missing
+    for orders placed without shipping zones.
+  - Total: The amount to pay, the subtotal minus the discount plus the tax not included in the prices
+    and the shipping.
+  - PaymentMethod: The payment method used for the order.
+  - Payment: The payment of the order at the payment provider. It is missing for orders placed before payments.
+  - Status: The current state of the order in its lifecycle.
+  - StatusHistory: Every status change of the order with its time and author, oldest first.
+  - ReservedUntil: The time until which the stock of the items is held while the order awaits payment. It is missing for orders placed before stock reservations.
+  - CreatedAt: The timestamp when the order was created.
+  - UpdatedAt: The timestamp when the order was last updated.
+  - Quantity: The quantity of items in the order.
 */
 type Order struct {
-	OrderID       primitive.ObjectID `json:"order_id" validate:"required" bson:"product_id"`
-	Discount      float32            `json:"discount" validate:"required" bson:"discount"`
-	PaymentMethod string             `json:"payment_method" validate:"required" bson:"payment_method"`
-	CreatedAt     time.Time          `json:"created_at" validate:"required" bson:"created_at"`
-	UpdatedAt     time.Time          `json:"updated_at" validate:"required" bson:"updated_at"`
-	Quantity      int                `json:"quantity" validate:"required" bson:"quantity"`
+	OrderID         primitive.ObjectID  `json:"order_id" bson:"_id"`
+	UserID          primitive.ObjectID  `json:"user_id" bson:"user_id"`
+	Items           []OrderItem         `json:"items" bson:"items"`
+	ShippingAddress Address             `json:"shipping_address" bson:"shipping_address"`
+	ExchangeRate    string              `json:"exchange_rate,omitempty" bson:"exchange_rate,omitempty"`
+	Subtotal        money.Money         `json:"subtotal" bson:"subtotal"`
+	Discount        money.Money         `json:"discount" bson:"discount"`
+	Promotions      []promotion.Applied `json:"promotions,omitempty" bson:"promotions,omitempty"`
+	Tax             money.Money         `json:"tax" bson:"tax"`
+	TaxIncluded     money.Money         `json:"tax_included" bson:"tax_included"`
+	Shipping        money.Money         `json:"shipping" bson:"shipping"`
+	ShippingMethod  *shipping.Option    `json:"shipping_method,omitempty" bson:"shipping_method,omitempty"`
+	Total           money.Money         `json:"total" bson:"total"`
+	PaymentMethod   string              `json:"payment_method" validate:"required" bson:"payment_method"`
+	Payment         *OrderPayment       `json:"payment,omitempty" bson:"payment,omitempty"`
+	Status          OrderStatus         `json:"status" bson:"status"`
+	StatusHistory   []OrderStatusChange `json:"status_history" bson:"status_history"`
+	ReservedUntil   *time.Time          `json:"reserved_until,omitempty" bson:"reserved_until,omitempty"`
+	CreatedAt       time.Time           `json:"created_at" bson:"created_at"`
+	UpdatedAt       time.Time           `json:"updated_at" bson:"updated_at"`
+	Quantity        int                 `json:"quantity" bson:"quantity"`
+}
+
+/*
+OrderItem is a line of an order, a snapshot of a cart item taken at checkout.
+
+Fields:
+- ProductID: The identifier of the purchased product.
+- VariantID: The identifier of the purchased variant, for products with variants.
+- ProductName: The name of the product at purchase time.
+- SKU: The SKU of the variant at purchase time.
+- Options: The option values of the variant at purchase time.
+- UnitPrice: The price of one unit at purchase time.
+- Quantity: The number of units purchased.
+- LineTotal: The unit price multiplied by the quantity.
+- Discount: The part of the discount of the promotions taken off the item.
+- TaxName: The name of the tax of the item, such as "VAT".
+- TaxRate: The percentage of the tax of the item. It is missing for items without tax.
+- TaxIncluded: Whether the tax is included in the unit price rather than added to it.
+- Tax: The tax on the line total minus the discount.
+*/
+type OrderItem struct {
+	ProductID   primitive.ObjectID  `json:"product_id" bson:"product_id"`
+	VariantID   *primitive.ObjectID `json:"variant_id,omitempty" bson:"variant_id,omitempty"`
+	ProductName string              `json:"product_name" bson:"product_name"`
+	SKU         string              `json:"sku,omitempty" bson:"sku,omitempty"`
+	Options     map[string]string   `json:"options,omitempty" bson:"options,omitempty"`
+	UnitPrice   money.Money         `json:"unit_price" bson:"unit_price"`
+	Quantity    int                 `json:"quantity" bson:"quantity"`
+	LineTotal   money.Money         `json:"line_total" bson:"line_total"`
+	Discount    money.Money         `json:"discount" bson:"discount"`
+	TaxName     string              `json:"tax_name,omitempty" bson:"tax_name,omitempty"`
+	TaxRate     string              `json:"tax_rate,omitempty" bson:"tax_rate,omitempty"`
+	TaxIncluded bool                `json:"tax_included,omitempty" bson:"tax_included,omitempty"`
+	Tax         money.Money         `json:"tax" bson:"tax"`
 }
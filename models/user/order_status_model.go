@@ -0,0 +1,99 @@
+package user
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+/*
+	OrderStatus is the state of an order in its lifecycle.
+
+	An order starts as pending_payment and moves through the states allowed by the transition table:
+
+	- pending_payment: paid, cancelled
+	- paid: fulfilling, cancelled
+	- fulfilling: shipped, cancelled
+	- shipped: delivered, refunded
+	- delivered: refunded
+	- cancelled and refunded are final.
+*/
+
+type OrderStatus string
+
+const (
+	OrderStatusPendingPayment OrderStatus = "pending_payment"
+	OrderStatusPaid           OrderStatus = "paid"
+	OrderStatusFulfilling     OrderStatus = "fulfilling"
+	OrderStatusShipped        OrderStatus = "shipped"
+	OrderStatusDelivered      OrderStatus = "delivered"
+	OrderStatusCancelled      OrderStatus = "cancelled"
+	OrderStatusRefunded       OrderStatus = "refunded"
+)
+
+// ErrInvalidStatusTransition is returned when an order cannot move to the requested status.
+var ErrInvalidStatusTransition = errors.New("invalid order status transition")
+
+// orderStatusTransitions lists the statuses an order can move to from each status.
+var orderStatusTransitions = map[OrderStatus][]OrderStatus{
+	OrderStatusPendingPayment: {OrderStatusPaid, OrderStatusCancelled},
+	OrderStatusPaid:           {OrderStatusFulfilling, OrderStatusCancelled},
+	OrderStatusFulfilling:     {OrderStatusShipped, OrderStatusCancelled},
+	OrderStatusShipped:        {OrderStatusDelivered, OrderStatusRefunded},
+	OrderStatusDelivered:      {OrderStatusRefunded},
+	OrderStatusCancelled:      {},
+	OrderStatusRefunded:       {},
+}
+
+// Valid reports whether the status is one of the known order statuses.
+func (s OrderStatus) Valid() bool {
+	_, found := orderStatusTransitions[s]
+	return found
+}
+
+// CanTransitionTo reports whether an order in this status can move to the given status.
+func (s OrderStatus) CanTransitionTo(to OrderStatus) bool {
+	for _, next := range orderStatusTransitions[s] {
+		if next == to {
+			return true
+		}
+	}
+	return false
+}
+
+/*
+	OrderStatusChange is an entry of the status history of an order.
+
+	Fields:
+	- From: The status before the change. It is empty for the entry created at checkout.
+	- To: The status after the change.
+	- ChangedBy: The ID of the user who made the change, or "system" for automatic changes.
+	- Note: An optional explanation of the change.
+	- ChangedAt: The timestamp of the change.
+*/
+
+type OrderStatusChange struct {
+	From      OrderStatus `json:"from,omitempty" bson:"from,omitempty"`
+	To        OrderStatus `json:"to" bson:"to"`
+	ChangedBy string      `json:"changed_by" bson:"changed_by"`
+	Note      string      `json:"note,omitempty" bson:"note,omitempty"`
+	ChangedAt time.Time   `json:"changed_at" bson:"changed_at"`
+}
+
+// SystemActor is the ChangedBy value of status changes made by the application itself.
+const SystemActor = "system"
+
+// Transition returns the history entry that moves the order to the given status.
+// It returns ErrInvalidStatusTransition if the transition table does not allow the move.
+func (o Order) Transition(to OrderStatus, changedBy string, note string) (OrderStatusChange, error) {
+	if !o.Status.CanTransitionTo(to) {
+		return OrderStatusChange{}, fmt.Errorf("%w: %s to %s", ErrInvalidStatusTransition, o.Status, to)
+	}
+	return OrderStatusChange{
+		From:      o.Status,
+		To:        to,
+		ChangedBy: changedBy,
+		Note:      note,
+		ChangedAt: time.Now().UTC(),
+	}, nil
+}
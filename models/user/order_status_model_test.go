@@ -0,0 +1,54 @@
+package user
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestOrderStatusTransitions(t *testing.T) {
+	statuses := []OrderStatus{
+		OrderStatusPendingPayment, OrderStatusPaid, OrderStatusFulfilling, OrderStatusShipped,
+		OrderStatusDelivered, OrderStatusCancelled, OrderStatusRefunded,
+	}
+	allowed := map[OrderStatus][]OrderStatus{
+		OrderStatusPendingPayment: {OrderStatusPaid, OrderStatusCancelled},
+		OrderStatusPaid:           {OrderStatusFulfilling, OrderStatusCancelled},
+		OrderStatusFulfilling:     {OrderStatusShipped, OrderStatusCancelled},
+		OrderStatusShipped:        {OrderStatusDelivered, OrderStatusRefunded},
+		OrderStatusDelivered:      {OrderStatusRefunded},
+	}
+	for _, from := range statuses {
+		if !from.Valid() {
+			t.Errorf("%s is not a valid status", from)
+		}
+		for _, to := range statuses {
+			want := false
+			for _, next := range allowed[from] {
+				want = want || next == to
+			}
+			if got := from.CanTransitionTo(to); got != want {
+				t.Errorf("%s can move to %s = %t, want %t", from, to, got, want)
+			}
+		}
+	}
+	for _, status := range []OrderStatus{"", "lost", "PAID"} {
+		if status.Valid() || OrderStatusPendingPayment.CanTransitionTo(status) {
+			t.Errorf("unknown status %q is accepted", status)
+		}
+	}
+}
+
+func TestOrderTransition(t *testing.T) {
+	order := Order{Status: OrderStatusPaid}
+	change, err := order.Transition(OrderStatusFulfilling, "admin", "Packing")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if change.From != OrderStatusPaid || change.To != OrderStatusFulfilling || change.ChangedBy != "admin" || change.Note != "Packing" || change.ChangedAt.IsZero() {
+		t.Errorf("got change %+v", change)
+	}
+
+	if _, err := order.Transition(OrderStatusDelivered, "admin", ""); !errors.Is(err, ErrInvalidStatusTransition) {
+		t.Errorf("skipping to delivered = %v, want ErrInvalidStatusTransition", err)
+	}
+}
@@ -0,0 +1,65 @@
+package user
+
+import (
+	"time"
+
+	"github.com/YassinNouh21/GoShopCart-Ecommerce/money"
+)
+
+/*
+	PaymentStatus is the state of the payment of an order.
+
+	A payment starts as authorized at checkout and moves through the states allowed by the transition table:
+
+	- authorized: captured, voided, failed
+	- captured: refunded
+	- voided, refunded and failed are final.
+*/
+
+type PaymentStatus string
+
+const (
+	PaymentStatusAuthorized PaymentStatus = "authorized"
+	PaymentStatusCaptured   PaymentStatus = "captured"
+	PaymentStatusVoided     PaymentStatus = "voided"
+	PaymentStatusRefunded   PaymentStatus = "refunded"
+	PaymentStatusFailed     PaymentStatus = "failed"
+)
+
+// paymentStatusTransitions lists the statuses a payment can move to from each status.
+var paymentStatusTransitions = map[PaymentStatus][]PaymentStatus{
+	PaymentStatusAuthorized: {PaymentStatusCaptured, PaymentStatusVoided, PaymentStatusFailed},
+	PaymentStatusCaptured:   {PaymentStatusRefunded},
+	PaymentStatusVoided:     {},
+	PaymentStatusRefunded:   {},
+	PaymentStatusFailed:     {},
+}
+
+// CanTransitionTo reports whether a payment in this status can move to the given status.
+func (s PaymentStatus) CanTransitionTo(to PaymentStatus) bool {
+	for _, next := range paymentStatusTransitions[s] {
+		if next == to {
+			return true
+		}
+	}
+	return false
+}
+
+/*
+	OrderPayment is the payment of an order at the payment provider.
+
+	Fields:
+	- Provider: The name of the payment provider holding the payment.
+	- TransactionID: The identifier of the payment transaction at the provider.
+	- Status: The current state of the payment.
+	- Amount: The authorized amount, the total of the order.
+	- UpdatedAt: The timestamp when the payment status last changed.
+*/
+
+type OrderPayment struct {
+	Provider      string        `json:"provider" bson:"provider"`
+	TransactionID string        `json:"transaction_id" bson:"transaction_id"`
+	Status        PaymentStatus `json:"status" bson:"status"`
+	Amount        money.Money   `json:"amount" bson:"amount"`
+	UpdatedAt     time.Time     `json:"updated_at" bson:"updated_at"`
+}
@@ -0,0 +1,34 @@
+package user
+
+/*
+	Role is the access level of a user.
+
+	Every user signs up as a customer. Staff and admin roles are granted by an admin, or by the
+	startup bootstrap for the first admin. Users stored before roles existed have no role and are
+	treated as customers.
+*/
+
+type Role string
+
+const (
+	RoleCustomer Role = "customer"
+	RoleStaff    Role = "staff"
+	RoleAdmin    Role = "admin"
+)
+
+// Valid reports whether the role is one of the known roles.
+func (r Role) Valid() bool {
+	switch r {
+	case RoleCustomer, RoleStaff, RoleAdmin:
+		return true
+	}
+	return false
+}
+
+// RoleOf returns the role of the user, defaulting to customer when none is stored.
+func RoleOf(user User) Role {
+	if user.Role == "" {
+		return RoleCustomer
+	}
+	return user.Role
+}
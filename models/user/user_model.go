@@ -1,6 +1,7 @@
 package user
 
 import (
+	"github.com/YassinNouh21/GoShopCart-Ecommerce/money"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 	"time"
 )
@@ -8,7 +9,8 @@ import (
 /* User Package user provides the User model for representing user data.
 
  The User struct represents a user entity in the application, including their personal information,
-authentication credentials, access tokens, and related data such as addresses, orders, and cart items.
+authentication credentials, access tokens, and related data such as addresses and orders. The cart of
+the user is stored in the carts collection, apart from the user.
  Fields:
 - ID: The unique identifier of the user.
 - FirstName: The first name of the user. Must be between 3 and 20 characters.
@@ -17,12 +19,14 @@ authentication credentials, access tokens, and related data such as addresses, o
 - Password: The password of the user. Must be at least 6 characters.
 - Token: The access token associated with the user.
 - RefreshToken: The refresh token associated with the user.
+- Role: The access level of the user (customer, staff or admin).
+- Currency: The currency the user prefers prices in. When empty, prices are in the store currency.
+- TaxExempt: Whether the user pays no tax, set by the admins.
 - CreatedAt: The timestamp indicating the creation time of the user.
 - UpdatedAt: The timestamp indicating the last update time of the user.
 - UserID: The user ID associated with the user.
 - AddressDetails: The list of addresses associated with the user.
 - OrderStatus: The list of order statuses associated with the user.
-- UserCart: The list of cart items associated with the user.
 */
 
 type User struct {
@@ -33,9 +37,11 @@ type User struct {
 	Password       string             `json:"password" bson:"password" validate:"required,min=6"`
 	Token          string             `json:"token" bson:"token"`
 	RefreshToken   string             `json:"refresh_token" bson:"refresh_token"`
+	Role           Role               `json:"role" bson:"role"`
+	Currency       money.Currency     `json:"currency" bson:"currency,omitempty"`
+	TaxExempt      bool               `json:"tax_exempt" bson:"tax_exempt,omitempty"`
 	CreatedAt      time.Time          `json:"created_at" bson:"created_at"`
 	UpdatedAt      time.Time          `json:"updated_at" bson:"updated_at"`
 	AddressDetails []Address          `json:"address" bson:"address_details"`
 	OrderStatus    []Order            `json:"order_status"`
-	UserCart       []Cart             `json:"user_cart"`
 }
@@ -0,0 +1,66 @@
+package money
+
+import (
+	"os"
+	"strings"
+)
+
+// Currency is an ISO 4217 currency code, such as USD.
+type Currency string
+
+const (
+	USD Currency = "USD"
+	EUR Currency = "EUR"
+	GBP Currency = "GBP"
+	JPY Currency = "JPY"
+)
+
+// currencyDigits lists the supported currencies with the number of digits of their minor unit.
+var currencyDigits = map[Currency]int{
+	"AED": 2, "AUD": 2, "BGN": 2, "BRL": 2, "CAD": 2, "CHF": 2, "CNY": 2, "CZK": 2, "DKK": 2,
+	"EGP": 2, "EUR": 2, "GBP": 2, "HKD": 2, "HUF": 2, "IDR": 2, "ILS": 2, "INR": 2, "MAD": 2,
+	"MXN": 2, "MYR": 2, "NOK": 2, "NZD": 2, "PHP": 2, "PLN": 2, "RON": 2, "SAR": 2, "SEK": 2,
+	"SGD": 2, "THB": 2, "TRY": 2, "TWD": 2, "UAH": 2, "USD": 2, "ZAR": 2,
+	"CLP": 0, "ISK": 0, "JPY": 0, "KRW": 0, "VND": 0,
+	"BHD": 3, "JOD": 3, "KWD": 3, "OMR": 3, "TND": 3,
+}
+
+// ParseCurrency returns the currency with the given code, in any case.
+// It returns ErrUnknownCurrency if the currency is not supported.
+func ParseCurrency(code string) (Currency, error) {
+	currency := Currency(strings.ToUpper(strings.TrimSpace(code)))
+	if !currency.Valid() {
+		return "", ErrUnknownCurrency
+	}
+	return currency, nil
+}
+
+// Valid reports whether the currency is supported.
+func (c Currency) Valid() bool {
+	_, found := currencyDigits[c]
+	return found
+}
+
+// Digits returns the number of digits of the minor unit of the currency: 2 for the cents of USD,
+// 0 for JPY, which has no minor unit.
+func (c Currency) Digits() int {
+	return currencyDigits[c]
+}
+
+// scale returns the number of minor units in one unit of the currency.
+func (c Currency) scale() int64 {
+	scale := int64(1)
+	for i := 0; i < c.Digits(); i++ {
+		scale *= 10
+	}
+	return scale
+}
+
+// StoreCurrency returns the currency the catalog is priced in, set by the STORE_CURRENCY environment
+// variable and USD by default. Amounts sent without a currency are in the store currency.
+func StoreCurrency() Currency {
+	if code := os.Getenv("STORE_CURRENCY"); code != "" {
+		return Currency(strings.ToUpper(code))
+	}
+	return USD
+}
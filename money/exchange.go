@@ -0,0 +1,181 @@
+package money
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+	"sort"
+	"strings"
+	"time"
+)
+
+// MaxRateDecimals is the largest number of decimals of an exchange rate.
+const MaxRateDecimals = 10
+
+var (
+	// ErrInvalidRate is returned for an exchange rate that is not a positive decimal number.
+	ErrInvalidRate = errors.New("invalid exchange rate")
+
+	// ErrNoExchangeRate is returned when converting to a currency without an exchange rate.
+	ErrNoExchangeRate = errors.New("no exchange rate")
+)
+
+/*
+	ExchangeRate is the price of one unit of the store currency in another currency, managed by the
+	admins and stored in the exchange_rates collection.
+
+	Fields:
+	- Currency: The currency the rate converts to. It identifies the rate.
+	- Rate: The amount of the currency one unit of the store currency is worth, as a decimal string
+	  such as "0.92", so it is kept exactly.
+	- Rounding: The step converted prices are rounded to, such as "0.05", as a decimal amount of the
+	  currency. When empty, prices are rounded to the minor unit of the currency.
+	- UpdatedAt: The timestamp when the rate was last set.
+*/
+
+type ExchangeRate struct {
+	Currency  Currency  `json:"currency" bson:"_id"`
+	Rate      string    `json:"rate" bson:"rate"`
+	Rounding  string    `json:"rounding,omitempty" bson:"rounding,omitempty"`
+	UpdatedAt time.Time `json:"updated_at" bson:"updated_at"`
+}
+
+// ParseRate reads an exchange rate, a positive decimal number with at most MaxRateDecimals decimals.
+func ParseRate(value string) (*big.Rat, error) {
+	value = strings.TrimSpace(value)
+	whole, fraction, hasPoint := strings.Cut(value, ".")
+	if whole == "" || (hasPoint && fraction == "") || len(fraction) > MaxRateDecimals || !isDigits(whole) || !isDigits(fraction) {
+		return nil, fmt.Errorf("%w %q", ErrInvalidRate, value)
+	}
+	rate, ok := new(big.Rat).SetString(value)
+	if !ok || rate.Sign() <= 0 {
+		return nil, fmt.Errorf("%w %q", ErrInvalidRate, value)
+	}
+	return rate, nil
+}
+
+// conversion is an exchange rate ready to convert amounts.
+type conversion struct {
+	rate *big.Rat
+	// text is the rate as it was set.
+	text string
+	// step is the number of minor units converted amounts are rounded to.
+	step int64
+}
+
+// Rates converts amounts between the store currency and the currencies with an exchange rate.
+type Rates struct {
+	base        Currency
+	conversions map[Currency]conversion
+}
+
+// NewRates creates the converter for the exchange rates from the base currency. It returns an error
+// if a rate or its rounding is invalid.
+func NewRates(base Currency, rates []ExchangeRate) (*Rates, error) {
+	converter := &Rates{base: base, conversions: map[Currency]conversion{base: {rate: big.NewRat(1, 1), text: "1", step: 1}}}
+	for _, exchangeRate := range rates {
+		rate, err := ParseRate(exchangeRate.Rate)
+		if err != nil {
+			return nil, err
+		}
+		step, err := RoundingStep(exchangeRate.Rounding, exchangeRate.Currency)
+		if err != nil {
+			return nil, err
+		}
+		converter.conversions[exchangeRate.Currency] = conversion{rate: rate, text: strings.TrimSpace(exchangeRate.Rate), step: step}
+	}
+	return converter, nil
+}
+
+// RoundingStep returns the number of minor units the rounding of a currency stands for: 1 when the
+// rounding is empty, or the positive amount it holds, such as 5 for "0.05" in CHF.
+func RoundingStep(rounding string, currency Currency) (int64, error) {
+	if rounding == "" {
+		return 1, nil
+	}
+	step, err := Parse(rounding, currency)
+	if err != nil {
+		return 0, err
+	}
+	if !step.IsPositive() {
+		return 0, fmt.Errorf("%w %q for %s", ErrInvalidAmount, rounding, currency)
+	}
+	return step.Amount, nil
+}
+
+// Base returns the currency the rates convert from.
+func (r *Rates) Base() Currency {
+	return r.base
+}
+
+// Supports reports whether amounts can be converted to the currency: it is the base currency or has
+// an exchange rate.
+func (r *Rates) Supports(currency Currency) bool {
+	_, found := r.conversions[currency]
+	return found
+}
+
+// Currencies returns the base currency followed by the currencies with an exchange rate, sorted.
+func (r *Rates) Currencies() []Currency {
+	currencies := []Currency{}
+	for currency := range r.conversions {
+		if currency != r.base {
+			currencies = append(currencies, currency)
+		}
+	}
+	sort.Slice(currencies, func(i, j int) bool { return currencies[i] < currencies[j] })
+	return append([]Currency{r.base}, currencies...)
+}
+
+// Rate returns the exchange rate from the base currency to the currency, as a decimal string.
+func (r *Rates) Rate(currency Currency) (string, error) {
+	conversion, found := r.conversions[currency]
+	if !found {
+		return "", fmt.Errorf("%w to %s", ErrNoExchangeRate, currency)
+	}
+	return conversion.text, nil
+}
+
+/*
+Convert returns the amount in another currency.
+
+	The amount is converted through the base currency with the exchange rates of both currencies,
+	then rounded half away from zero to the rounding step of the target currency, which is its minor
+	unit unless the rate sets a coarser rounding. Amounts already in the currency are returned as
+	they are.
+*/
+func (r *Rates) Convert(amount Money, to Currency) (Money, error) {
+	if amount.Currency == to {
+		return amount, nil
+	}
+	from, found := r.conversions[amount.Currency]
+	if !found {
+		return Money{}, fmt.Errorf("%w from %s", ErrNoExchangeRate, amount.Currency)
+	}
+	target, found := r.conversions[to]
+	if !found {
+		return Money{}, fmt.Errorf("%w to %s", ErrNoExchangeRate, to)
+	}
+	// minor units of to = amount / 10^digits(from) / rate(from) * rate(to) * 10^digits(to)
+	value := new(big.Rat).SetInt64(amount.Amount)
+	value.Quo(value, from.rate)
+	value.Mul(value, target.rate)
+	value.Mul(value, new(big.Rat).SetFrac(big.NewInt(to.scale()), big.NewInt(amount.Currency.scale())))
+	// Round to a multiple of the step
+	value.Quo(value, new(big.Rat).SetInt64(target.step))
+	units := roundHalfAwayFromZero(value)
+	return Money{Amount: units * target.step, Currency: to}, nil
+}
+
+// roundHalfAwayFromZero rounds the number to the nearest integer, halves away from zero.
+func roundHalfAwayFromZero(value *big.Rat) int64 {
+	numerator := new(big.Int).Abs(value.Num())
+	denominator := value.Denom()
+	// floor((2n + d) / 2d) is n/d rounded half up
+	twice := new(big.Int).Mul(numerator, big.NewInt(2))
+	rounded := new(big.Int).Quo(twice.Add(twice, denominator), new(big.Int).Mul(denominator, big.NewInt(2)))
+	if value.Sign() < 0 {
+		rounded.Neg(rounded)
+	}
+	return rounded.Int64()
+}
@@ -0,0 +1,224 @@
+package money
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"strconv"
+	"strings"
+)
+
+/*
+	Package money represents amounts of money exactly, as an integer number of minor units of a
+	currency, such as 1999 cents for 19.99 USD.
+
+	Amounts are stored in MongoDB as a document with the integer amount in minor units and the
+	currency code, so they can be compared, sorted and summed without rounding errors. In JSON an
+	amount is written as a decimal string with its currency, {"amount": "19.99", "currency": "USD"},
+	and can also be read from a bare decimal string or number, which is then in the store currency.
+*/
+
+var (
+	// ErrInvalidAmount is returned for an amount that is not a decimal number with at most as many
+	// decimals as its currency has digits.
+	ErrInvalidAmount = errors.New("invalid amount")
+
+	// ErrUnknownCurrency is returned for a currency code that is not supported.
+	ErrUnknownCurrency = errors.New("unknown currency")
+)
+
+// Money is an amount of money in a currency.
+type Money struct {
+	// Amount is the number of minor units of the currency, such as cents. It can be negative.
+	Amount int64 `bson:"amount"`
+	// Currency is the currency of the amount.
+	Currency Currency `bson:"currency"`
+}
+
+// New returns the amount of minor units in the currency.
+func New(amount int64, currency Currency) Money {
+	return Money{Amount: amount, Currency: currency}
+}
+
+// Zero returns no money in the currency.
+func Zero(currency Currency) Money {
+	return Money{Currency: currency}
+}
+
+// FromMajor returns the amount of whole units of the currency, such as 25 dollars.
+func FromMajor(units int64, currency Currency) Money {
+	return Money{Amount: units * currency.scale(), Currency: currency}
+}
+
+// Parse reads a decimal amount such as "19.99" or "-5" in the currency. It returns ErrInvalidAmount
+// if the value has more decimals than the currency has digits, and ErrUnknownCurrency for an
+// unsupported currency.
+func Parse(value string, currency Currency) (Money, error) {
+	if !currency.Valid() {
+		return Money{}, ErrUnknownCurrency
+	}
+	value = strings.TrimSpace(value)
+	sign := ""
+	if strings.HasPrefix(value, "-") || strings.HasPrefix(value, "+") {
+		sign, value = value[:1], value[1:]
+	}
+	whole, fraction, hasPoint := strings.Cut(value, ".")
+	if (whole == "" && fraction == "") || (hasPoint && fraction == "") || len(fraction) > currency.Digits() ||
+		!isDigits(whole) || !isDigits(fraction) {
+		return Money{}, fmt.Errorf("%w %q for %s", ErrInvalidAmount, sign+value, currency)
+	}
+	digits := whole + fraction + strings.Repeat("0", currency.Digits()-len(fraction))
+	amount, err := strconv.ParseInt(sign+digits, 10, 64)
+	if err != nil {
+		return Money{}, fmt.Errorf("%w %q for %s", ErrInvalidAmount, sign+value, currency)
+	}
+	return Money{Amount: amount, Currency: currency}, nil
+}
+
+func isDigits(value string) bool {
+	for _, r := range value {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+// Decimal returns the amount as a decimal number with the digits of its currency, such as "19.90".
+func (m Money) Decimal() string {
+	digits := m.Currency.Digits()
+	amount := m.Amount
+	sign := ""
+	if amount < 0 {
+		sign, amount = "-", -amount
+	}
+	text := strconv.FormatInt(amount, 10)
+	if digits == 0 {
+		return sign + text
+	}
+	if len(text) <= digits {
+		text = strings.Repeat("0", digits-len(text)+1) + text
+	}
+	return sign + text[:len(text)-digits] + "." + text[len(text)-digits:]
+}
+
+// String returns the amount with its currency, such as "19.90 USD".
+func (m Money) String() string {
+	return m.Decimal() + " " + string(m.Currency)
+}
+
+// IsZero reports whether the amount is zero.
+func (m Money) IsZero() bool {
+	return m.Amount == 0
+}
+
+// IsPositive reports whether the amount is greater than zero.
+func (m Money) IsPositive() bool {
+	return m.Amount > 0
+}
+
+// IsNegative reports whether the amount is less than zero.
+func (m Money) IsNegative() bool {
+	return m.Amount < 0
+}
+
+// Add returns the sum of the amounts. It panics if they are in different currencies; the zero
+// Money takes the currency of the other amount, so sums can start from it.
+func (m Money) Add(other Money) Money {
+	currency := m.sameCurrency(other)
+	return Money{Amount: m.Amount + other.Amount, Currency: currency}
+}
+
+// Sub returns the amount minus the other amount. It panics if they are in different currencies.
+func (m Money) Sub(other Money) Money {
+	currency := m.sameCurrency(other)
+	return Money{Amount: m.Amount - other.Amount, Currency: currency}
+}
+
+// Mul returns the amount multiplied by a quantity.
+func (m Money) Mul(quantity int) Money {
+	return Money{Amount: m.Amount * int64(quantity), Currency: m.Currency}
+}
+
+// Scale returns the amount multiplied by the factor, rounded half away from zero to the minor unit.
+func (m Money) Scale(factor *big.Rat) Money {
+	value := new(big.Rat).Mul(new(big.Rat).SetInt64(m.Amount), factor)
+	return Money{Amount: roundHalfAwayFromZero(value), Currency: m.Currency}
+}
+
+// Cmp compares the amounts, returning -1, 0 or 1 if the amount is less than, equal to or greater than
+// the other. It panics if they are in different currencies.
+func (m Money) Cmp(other Money) int {
+	m.sameCurrency(other)
+	switch {
+	case m.Amount < other.Amount:
+		return -1
+	case m.Amount > other.Amount:
+		return 1
+	}
+	return 0
+}
+
+// sameCurrency returns the currency of both amounts, treating the zero Money as having any currency.
+func (m Money) sameCurrency(other Money) Currency {
+	switch {
+	case m.Currency == other.Currency:
+		return m.Currency
+	case m == Money{}:
+		return other.Currency
+	case other == Money{}:
+		return m.Currency
+	}
+	panic(fmt.Sprintf("money: mixing %s and %s", m.Currency, other.Currency))
+}
+
+// jsonMoney is the JSON form of an amount.
+type jsonMoney struct {
+	Amount   json.RawMessage `json:"amount"`
+	Currency string          `json:"currency"`
+}
+
+func (m Money) MarshalJSON() ([]byte, error) {
+	amount, _ := json.Marshal(m.Decimal())
+	return json.Marshal(jsonMoney{Amount: amount, Currency: string(m.Currency)})
+}
+
+// UnmarshalJSON reads an amount written as {"amount": "19.99", "currency": "USD"}, where the amount
+// can also be a number and the currency defaults to the store currency, or as a bare decimal string
+// or number in the store currency. Numbers are read from their decimal text, never through a float.
+func (m *Money) UnmarshalJSON(data []byte) error {
+	data = bytes.TrimSpace(data)
+	if bytes.Equal(data, []byte("null")) {
+		return nil
+	}
+	currency := StoreCurrency()
+	amount := data
+	if len(data) > 0 && data[0] == '{' {
+		var decoded jsonMoney
+		if err := json.Unmarshal(data, &decoded); err != nil {
+			return err
+		}
+		if decoded.Currency != "" {
+			parsed, err := ParseCurrency(decoded.Currency)
+			if err != nil {
+				return fmt.Errorf("%w %q", ErrUnknownCurrency, decoded.Currency)
+			}
+			currency = parsed
+		}
+		amount = bytes.TrimSpace(decoded.Amount)
+	}
+	text := string(amount)
+	if len(amount) > 0 && amount[0] == '"' {
+		if err := json.Unmarshal(amount, &text); err != nil {
+			return err
+		}
+	}
+	parsed, err := Parse(text, currency)
+	if err != nil {
+		return err
+	}
+	*m = parsed
+	return nil
+}
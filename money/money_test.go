@@ -0,0 +1,64 @@
+package money
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name     string
+		value    string
+		currency Currency
+		want     Money
+		err      error
+	}{
+		{name: "decimal", value: "19.99", currency: USD, want: New(1999, USD)},
+		{name: "whole", value: "25", currency: USD, want: New(2500, USD)},
+		{name: "one decimal", value: "0.5", currency: EUR, want: New(50, EUR)},
+		{name: "leading point", value: ".75", currency: USD, want: New(75, USD)},
+		{name: "negative", value: "-5", currency: USD, want: New(-500, USD)},
+		{name: "plus sign", value: "+1.10", currency: GBP, want: New(110, GBP)},
+		{name: "surrounding spaces", value: " 3.20 ", currency: USD, want: New(320, USD)},
+		{name: "no minor unit", value: "1500", currency: JPY, want: New(1500, JPY)},
+		{name: "three digits", value: "1.234", currency: "KWD", want: New(1234, "KWD")},
+		{name: "too many decimals", value: "1.999", currency: USD, err: ErrInvalidAmount},
+		{name: "decimals without minor unit", value: "1.5", currency: JPY, err: ErrInvalidAmount},
+		{name: "trailing point", value: "12.", currency: USD, err: ErrInvalidAmount},
+		{name: "empty", value: "", currency: USD, err: ErrInvalidAmount},
+		{name: "sign only", value: "-", currency: USD, err: ErrInvalidAmount},
+		{name: "letters", value: "12a", currency: USD, err: ErrInvalidAmount},
+		{name: "grouping", value: "1,000", currency: USD, err: ErrInvalidAmount},
+		{name: "overflow", value: "99999999999999999999", currency: USD, err: ErrInvalidAmount},
+		{name: "unknown currency", value: "1", currency: "XXX", err: ErrUnknownCurrency},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got, err := Parse(test.value, test.currency)
+			if !errors.Is(err, test.err) {
+				t.Fatalf("Parse(%q, %s) error = %v, want %v", test.value, test.currency, err, test.err)
+			}
+			if got != test.want {
+				t.Errorf("Parse(%q, %s) = %v, want %v", test.value, test.currency, got, test.want)
+			}
+		})
+	}
+}
+
+func TestMoneyDecimal(t *testing.T) {
+	tests := []struct {
+		money Money
+		want  string
+	}{
+		{New(1990, USD), "19.90"},
+		{New(5, USD), "0.05"},
+		{New(-5, USD), "-0.05"},
+		{New(1500, JPY), "1500"},
+		{New(1234, "KWD"), "1.234"},
+	}
+	for _, test := range tests {
+		if got := test.money.Decimal(); got != test.want {
+			t.Errorf("%#v.Decimal() = %q, want %q", test.money, got, test.want)
+		}
+	}
+}
@@ -0,0 +1,138 @@
+package payments
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"sync"
+)
+
+// FakeMode selects the outcome of the authorizations made with the FakeProvider.
+type FakeMode string
+
+const (
+	// FakeSucceed approves every authorization.
+	FakeSucceed FakeMode = "succeed"
+	// FakeDecline declines every authorization.
+	FakeDecline FakeMode = "decline"
+	// FakeTimeout fails every authorization with ErrPaymentTimeout.
+	FakeTimeout FakeMode = "timeout"
+)
+
+// Valid reports whether the mode is one of the known fake modes.
+func (m FakeMode) Valid() bool {
+	return m == FakeSucceed || m == FakeDecline || m == FakeTimeout
+}
+
+/*
+	FakeProvider is an in-process PaymentProvider for development and tests.
+
+	It keeps its transactions in memory and numbers them in order, so the same sequence of calls
+	always yields the same transaction IDs. The mode decides whether authorizations succeed, are
+	declined or time out; captures, voids and refunds only check the state of the transaction.
+	Webhook payloads are signed with HMAC-SHA256 using the webhook secret, and Sign produces the
+	signature a real gateway would send.
+*/
+
+type FakeProvider struct {
+	mu           sync.Mutex
+	mode         FakeMode
+	secret       []byte
+	sequence     int
+	transactions map[string]*Transaction
+}
+
+// NewFakeProvider creates a FakeProvider with the given mode and webhook secret.
+func NewFakeProvider(mode FakeMode, webhookSecret string) *FakeProvider {
+	return &FakeProvider{
+		mode:         mode,
+		secret:       []byte(webhookSecret),
+		transactions: map[string]*Transaction{},
+	}
+}
+
+// SetMode changes the outcome of the next authorizations.
+func (p *FakeProvider) SetMode(mode FakeMode) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.mode = mode
+}
+
+func (p *FakeProvider) Name() string {
+	return "fake"
+}
+
+func (p *FakeProvider) Authorize(ctx context.Context, request AuthorizationRequest) (*Transaction, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	switch p.mode {
+	case FakeDecline:
+		return nil, ErrPaymentDeclined
+	case FakeTimeout:
+		return nil, ErrPaymentTimeout
+	}
+	p.sequence++
+	transaction := &Transaction{
+		ID:      fmt.Sprintf("fake_txn_%06d", p.sequence),
+		OrderID: request.OrderID,
+		Status:  TransactionAuthorized,
+		Amount:  request.Amount,
+	}
+	p.transactions[transaction.ID] = transaction
+	copied := *transaction
+	return &copied, nil
+}
+
+func (p *FakeProvider) Capture(ctx context.Context, transactionID string) (*Transaction, error) {
+	return p.move(transactionID, TransactionAuthorized, TransactionCaptured)
+}
+
+func (p *FakeProvider) Void(ctx context.Context, transactionID string) (*Transaction, error) {
+	return p.move(transactionID, TransactionAuthorized, TransactionVoided)
+}
+
+func (p *FakeProvider) Refund(ctx context.Context, transactionID string) (*Transaction, error) {
+	return p.move(transactionID, TransactionCaptured, TransactionRefunded)
+}
+
+// move changes the status of the transaction if it is in the expected status.
+func (p *FakeProvider) move(transactionID string, from TransactionStatus, to TransactionStatus) (*Transaction, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	transaction, found := p.transactions[transactionID]
+	if !found {
+		return nil, ErrUnknownTransaction
+	}
+	if transaction.Status != from {
+		return nil, fmt.Errorf("%w: %s is %s", ErrInvalidTransactionState, transactionID, transaction.Status)
+	}
+	transaction.Status = to
+	copied := *transaction
+	return &copied, nil
+}
+
+// Sign returns the hex encoded HMAC-SHA256 signature of the payload.
+func (p *FakeProvider) Sign(payload []byte) string {
+	return hex.EncodeToString(hmacSum(p.secret, payload))
+}
+
+func (p *FakeProvider) VerifyWebhook(payload []byte, signature string) (*WebhookEvent, error) {
+	expected, err := hex.DecodeString(signature)
+	if err != nil || !hmac.Equal(expected, hmacSum(p.secret, payload)) {
+		return nil, ErrInvalidSignature
+	}
+	var event WebhookEvent
+	if err := json.Unmarshal(payload, &event); err != nil {
+		return nil, err
+	}
+	return &event, nil
+}
+
+func hmacSum(secret []byte, payload []byte) []byte {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write(payload)
+	return mac.Sum(nil)
+}
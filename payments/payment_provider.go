@@ -0,0 +1,119 @@
+package payments
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/YassinNouh21/GoShopCart-Ecommerce/money"
+)
+
+/*
+	Package payments defines the PaymentProvider interface the checkout uses to charge orders,
+	and a fake in-process provider for development and tests.
+
+	An order is paid in two steps: the amount is authorized at checkout and captured right after the
+	order is stored. Authorizations of orders that are not stored are voided, and captured payments
+	are refunded when an order is cancelled or refunded. Providers report asynchronous payment
+	updates through signed webhooks.
+*/
+
+var (
+	// ErrPaymentDeclined is returned when the provider refuses the payment.
+	ErrPaymentDeclined = errors.New("payment declined")
+
+	// ErrPaymentTimeout is returned when the provider does not answer in time.
+	ErrPaymentTimeout = errors.New("payment provider timed out")
+
+	// ErrUnknownTransaction is returned when the provider has no transaction with the given ID.
+	ErrUnknownTransaction = errors.New("unknown payment transaction")
+
+	// ErrInvalidTransactionState is returned when an operation does not apply to the transaction in its current state.
+	ErrInvalidTransactionState = errors.New("operation not allowed in the transaction state")
+
+	// ErrInvalidSignature is returned when a webhook payload does not match its signature.
+	ErrInvalidSignature = errors.New("invalid webhook signature")
+)
+
+// TransactionStatus is the state of a payment transaction at the provider.
+type TransactionStatus string
+
+const (
+	TransactionAuthorized TransactionStatus = "authorized"
+	TransactionCaptured   TransactionStatus = "captured"
+	TransactionVoided     TransactionStatus = "voided"
+	TransactionRefunded   TransactionStatus = "refunded"
+)
+
+// Transaction is a payment held by the provider.
+type Transaction struct {
+	ID      string
+	OrderID string
+	Status  TransactionStatus
+	Amount  money.Money
+}
+
+// AuthorizationRequest describes the payment to authorize for an order.
+type AuthorizationRequest struct {
+	OrderID       string
+	Amount        money.Money
+	PaymentMethod string
+}
+
+// EventType is the kind of payment update reported by a webhook.
+type EventType string
+
+const (
+	EventPaymentCaptured EventType = "payment.captured"
+	EventPaymentFailed   EventType = "payment.failed"
+	EventPaymentRefunded EventType = "payment.refunded"
+)
+
+// WebhookEvent is a verified payment update sent by the provider.
+type WebhookEvent struct {
+	Type          EventType   `json:"type"`
+	TransactionID string      `json:"transaction_id"`
+	OrderID       string      `json:"order_id"`
+	Amount        money.Money `json:"amount"`
+}
+
+// PaymentProvider charges orders through a payment gateway.
+type PaymentProvider interface {
+	// Name identifies the provider in the payment details of orders.
+	Name() string
+	// Authorize reserves the amount of the order without charging it.
+	Authorize(ctx context.Context, request AuthorizationRequest) (*Transaction, error)
+	// Capture charges an authorized transaction.
+	Capture(ctx context.Context, transactionID string) (*Transaction, error)
+	// Void releases an authorized transaction that was not captured.
+	Void(ctx context.Context, transactionID string) (*Transaction, error)
+	// Refund returns the amount of a captured transaction.
+	Refund(ctx context.Context, transactionID string) (*Transaction, error)
+	// VerifyWebhook checks the signature of a webhook payload and decodes its event.
+	VerifyWebhook(payload []byte, signature string) (*WebhookEvent, error)
+}
+
+// NewProviderFromEnv creates the provider selected by the PAYMENT_PROVIDER environment variable.
+// The fake provider, the default, is configured by FAKE_PAYMENT_MODE and PAYMENT_WEBHOOK_SECRET,
+// which must not be empty.
+func NewProviderFromEnv() (PaymentProvider, error) {
+	switch provider := os.Getenv("PAYMENT_PROVIDER"); provider {
+	case "", "fake":
+		mode := FakeMode(os.Getenv("FAKE_PAYMENT_MODE"))
+		if mode == "" {
+			mode = FakeSucceed
+		}
+		if !mode.Valid() {
+			return nil, fmt.Errorf("unknown FAKE_PAYMENT_MODE %q", mode)
+		}
+		// Without a secret anyone could sign webhook events and mark orders paid or refunded
+		secret := os.Getenv("PAYMENT_WEBHOOK_SECRET")
+		if secret == "" {
+			return nil, errors.New("PAYMENT_WEBHOOK_SECRET is required")
+		}
+		return NewFakeProvider(mode, secret), nil
+	default:
+		return nil, fmt.Errorf("unknown PAYMENT_PROVIDER %q", provider)
+	}
+}
@@ -0,0 +1,311 @@
+package pricing
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	productModel "github.com/YassinNouh21/GoShopCart-Ecommerce/models/product"
+	"github.com/YassinNouh21/GoShopCart-Ecommerce/models/promotion"
+	shippingModel "github.com/YassinNouh21/GoShopCart-Ecommerce/models/shipping"
+	taxModel "github.com/YassinNouh21/GoShopCart-Ecommerce/models/tax"
+	userModel "github.com/YassinNouh21/GoShopCart-Ecommerce/models/user"
+	"github.com/YassinNouh21/GoShopCart-Ecommerce/money"
+	"github.com/YassinNouh21/GoShopCart-Ecommerce/repositories"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+/*
+	Package pricing computes what a cart costs.
+
+	The Engine turns cart items into a Quote: every item becomes a line with the product details, the
+	unit price in the currency of the request and the line total, and the quote adds up the subtotal,
+	the discounts of the promotions, the tax and the shipping into the grand total. The cart endpoint
+	shows the quote and checkout turns it into the order, so the customer is charged exactly the
+	amounts shown.
+
+	Unit prices are converted and rounded to the currency first and every other amount is computed
+	from them in minor units, so the totals are exact in any currency.
+*/
+
+var (
+	// ErrProductNotFound is the problem of a line whose product no longer exists.
+	ErrProductNotFound = errors.New("Product not found")
+
+	// ErrInsufficientStock is the problem of a line whose quantity exceeds the stock.
+	ErrInsufficientStock = errors.New("Not enough stock")
+)
+
+// Request is what a quote is computed for.
+type Request struct {
+	// Items are the cart items to price.
+	Items []userModel.Cart
+	// Currency is the currency of the quote.
+	Currency money.Currency
+	// Rates converts the prices of the catalog to the currency.
+	Rates *money.Rates
+	// UserID is the customer the cart belongs to, whose redemptions count towards the usage limits
+	// per user.
+	UserID primitive.ObjectID
+	// Coupons are the coupon codes applied to the cart.
+	Coupons []string
+	// Address is the shipping address the tax is computed for. Without one the quote has no tax.
+	Address *userModel.Address
+	// TaxExempt is whether the customer pays no tax.
+	TaxExempt bool
+	// ShippingMethodID is the shipping method chosen for the cart. Without one the cheapest applies.
+	ShippingMethodID *primitive.ObjectID
+}
+
+/*
+	Line is a priced cart item.
+
+	Fields:
+	- CartID: The identifier of the cart item.
+	- ProductID: The identifier of the product.
+	- VariantID: The identifier of the selected variant, for products with variants.
+	- ProductName: The name of the product.
+	- SKU: The SKU of the variant.
+	- Options: The option values of the variant.
+	- Image: The image of the variant, or else of the product.
+	- UnitPrice: The price of one unit in the currency of the quote.
+	- Quantity: The number of units in the cart.
+	- LineTotal: The unit price multiplied by the quantity.
+	- Discount: The part of the discount of the promotions taken off the line.
+	- TaxName: The name of the tax of the line, such as "VAT".
+	- TaxRate: The percentage of the tax of the line, such as "19". It is missing for lines without tax.
+	- TaxIncluded: Whether the tax is included in the unit price rather than added to it.
+	- Tax: The tax on the line total minus the discount.
+	- Available: The stock of the product or variant.
+	- Error: Why the line cannot be ordered as it is, such as a deleted product or missing stock.
+	  Lines without a product or variant have no price and are left out of the totals.
+*/
+
+type Line struct {
+	CartID      primitive.ObjectID    `json:"cart_id"`
+	ProductID   primitive.ObjectID    `json:"product_id"`
+	VariantID   *primitive.ObjectID   `json:"variant_id,omitempty"`
+	ProductName string                `json:"product_name,omitempty"`
+	SKU         string                `json:"sku,omitempty"`
+	Options     map[string]string     `json:"options,omitempty"`
+	Image       string                `json:"image,omitempty"`
+	UnitPrice   money.Money           `json:"unit_price"`
+	Quantity    int                   `json:"quantity"`
+	LineTotal   money.Money           `json:"line_total"`
+	Discount    money.Money           `json:"discount"`
+	TaxName     string                `json:"tax_name,omitempty"`
+	TaxRate     string                `json:"tax_rate,omitempty"`
+	TaxIncluded bool                  `json:"tax_included,omitempty"`
+	Tax         money.Money           `json:"tax"`
+	Available   int                   `json:"available"`
+	Error       string                `json:"error,omitempty"`
+	Product     *productModel.Product `json:"-"`
+	Variant     *productModel.Variant `json:"-"`
+	err         error
+	rate        *taxModel.Rate
+}
+
+// Err returns the problem of the line, or nil if it can be ordered.
+func (l Line) Err() error {
+	return l.err
+}
+
+// priced reports whether the line has a price and counts in the totals.
+func (l Line) priced() bool {
+	return l.Product != nil && (l.err == nil || errors.Is(l.err, ErrInsufficientStock))
+}
+
+/*
+	Quote is the price of a cart.
+
+	Fields:
+	- Currency: The currency of every amount of the quote.
+	- ExchangeRate: The rate from the store currency to the currency. It is missing for the store currency.
+	- Lines: The priced cart items, in cart order.
+	- Quantity: The number of units of the priced lines.
+	- Weight: The shipping weight of the priced lines, in grams.
+	- Subtotal: The sum of the line totals.
+	- Discount: The discount of the promotions on the subtotal, the sum of the discounts of the lines.
+	- Tax: The tax of the cart, the sum of the taxes of the lines.
+	- TaxIncluded: The part of the tax already included in the prices.
+	- TaxExempt: Whether the customer pays no tax.
+	- Shipping: The cost of shipping with the shipping method. It is zero when a promotion gives free
+	  shipping.
+	- ShippingMethod: The shipping method of the cart with its cost before promotions. It is missing
+	  without an address or shipping zones.
+	- ShippingOptions: The shipping methods available for the address, cheapest first.
+	- ShippingError: Why the cart cannot be shipped to the address, if it cannot.
+	- FreeShipping: Whether a promotion waives the shipping.
+	- Promotions: The promotions applied to the cart, with the discount of each.
+	- Coupons: The coupon codes applied to the cart, with why they do not apply if they do not.
+	- Total: The amount to pay, the subtotal minus the discount plus the tax not included in the prices
+	  and the shipping.
+*/
+
+type Quote struct {
+	Currency        money.Currency         `json:"currency"`
+	ExchangeRate    string                 `json:"exchange_rate,omitempty"`
+	Lines           []Line                 `json:"items"`
+	Quantity        int                    `json:"quantity"`
+	Weight          int                    `json:"weight"`
+	Subtotal        money.Money            `json:"subtotal"`
+	Discount        money.Money            `json:"discount"`
+	Tax             money.Money            `json:"tax"`
+	TaxIncluded     money.Money            `json:"tax_included"`
+	TaxExempt       bool                   `json:"tax_exempt,omitempty"`
+	Shipping        money.Money            `json:"shipping"`
+	ShippingMethod  *shippingModel.Option  `json:"shipping_method,omitempty"`
+	ShippingOptions []shippingModel.Option `json:"-"`
+	ShippingError   string                 `json:"shipping_error,omitempty"`
+	FreeShipping    bool                   `json:"free_shipping"`
+	Promotions      []promotion.Applied    `json:"promotions"`
+	Coupons         []Coupon               `json:"coupons"`
+	Total           money.Money            `json:"total"`
+	shippingErr     error
+}
+
+// Err returns the problem of the first line that cannot be ordered, with the name or ID of its
+// product, or else the shipping problem, or nil if the whole cart can be ordered.
+func (q *Quote) Err() error {
+	for _, line := range q.Lines {
+		if line.err == nil {
+			continue
+		}
+		if line.Product == nil {
+			return fmt.Errorf("%w: %s", line.err, line.ProductID.Hex())
+		}
+		return fmt.Errorf("%w: %s", line.err, line.ProductName)
+	}
+	return q.shippingErr
+}
+
+// Engine prices carts from the products of the catalog, the promotions, the shipping zones and the
+// tax rates.
+type Engine struct {
+	products   repositories.ProductRepository
+	promotions repositories.PromotionRepository
+	zones      repositories.ShippingZoneRepository
+	taxes      TaxCalculator
+}
+
+// NewEngine creates an Engine that reads the prices and stock from the product repository, the
+// discounts from the promotion repository, the shipping rates from the shipping zone repository and
+// the tax rates from the tax calculator.
+func NewEngine(products repositories.ProductRepository, promotions repositories.PromotionRepository, zones repositories.ShippingZoneRepository, taxes TaxCalculator) *Engine {
+	return &Engine{products: products, promotions: promotions, zones: zones, taxes: taxes}
+}
+
+// Quote prices the items of the request, computes the shipping, applies the promotions and computes
+// the tax. The problems of single lines and of the shipping are reported on the quote and by
+// Quote.Err, and those of the coupons on the coupons; an error is only returned if the products,
+// promotions, shipping zones or tax rates cannot be read or an amount cannot be converted to the
+// currency.
+func (e *Engine) Quote(ctx context.Context, request Request) (*Quote, error) {
+	currency := request.Currency
+	quote := &Quote{
+		Currency:    currency,
+		Lines:       []Line{},
+		Subtotal:    money.Zero(currency),
+		Discount:    money.Zero(currency),
+		Tax:         money.Zero(currency),
+		TaxIncluded: money.Zero(currency),
+		TaxExempt:   request.TaxExempt,
+		Shipping:    money.Zero(currency),
+		Promotions:  []promotion.Applied{},
+		Coupons:     []Coupon{},
+	}
+	if currency != request.Rates.Base() {
+		rate, err := request.Rates.Rate(currency)
+		if err != nil {
+			return nil, err
+		}
+		quote.ExchangeRate = rate
+	}
+
+	products := map[primitive.ObjectID]*productModel.Product{}
+	for _, item := range request.Items {
+		product, found := products[item.ProductID]
+		if !found {
+			var err error
+			product, err = e.products.FindByID(ctx, item.ProductID)
+			if err != nil && err != repositories.ErrNotFound {
+				return nil, err
+			}
+			products[item.ProductID] = product
+		}
+		line, err := priceLine(item, product, request)
+		if err != nil {
+			return nil, err
+		}
+		quote.Lines = append(quote.Lines, line)
+	}
+	if err := e.resolveTaxes(ctx, request, quote); err != nil {
+		return nil, err
+	}
+	for _, line := range quote.Lines {
+		if line.priced() {
+			quote.Subtotal = quote.Subtotal.Add(line.LineTotal)
+			quote.Quantity += line.Quantity
+			quote.Weight += line.Product.Weight * line.Quantity
+		}
+	}
+	if err := e.applyShipping(ctx, request, quote); err != nil {
+		return nil, err
+	}
+	if err := e.applyPromotions(ctx, request, quote); err != nil {
+		return nil, err
+	}
+	if err := applyTaxes(quote); err != nil {
+		return nil, err
+	}
+	quote.Total = quote.Subtotal.Sub(quote.Discount).Add(quote.Tax).Sub(quote.TaxIncluded).Add(quote.Shipping)
+	return quote, nil
+}
+
+// priceLine prices a cart item from its product, which is nil if it no longer exists.
+func priceLine(item userModel.Cart, product *productModel.Product, request Request) (Line, error) {
+	line := Line{
+		CartID:    item.CartID,
+		ProductID: item.ProductID,
+		VariantID: item.VariantID,
+		Quantity:  item.Quantity,
+		UnitPrice: money.Zero(request.Currency),
+		LineTotal: money.Zero(request.Currency),
+		Discount:  money.Zero(request.Currency),
+		Tax:       money.Zero(request.Currency),
+	}
+	if product == nil {
+		line.err = ErrProductNotFound
+		line.Error = line.err.Error()
+		return line, nil
+	}
+	line.Product = product
+	line.ProductName = product.ProductName
+	line.Image = product.ImageUrl
+	variant, err := product.ResolveVariant(item.VariantID)
+	if err != nil {
+		line.err = err
+		line.Error = err.Error()
+		return line, nil
+	}
+	if variant != nil {
+		line.Variant = variant
+		line.SKU = variant.SKU
+		line.Options = variant.Options
+		if variant.ImageUrl != "" {
+			line.Image = variant.ImageUrl
+		}
+	}
+	unitPrice, err := product.PriceIn(variant, request.Rates, request.Currency)
+	if err != nil {
+		return line, err
+	}
+	line.UnitPrice = unitPrice
+	line.LineTotal = unitPrice.Mul(item.Quantity)
+	line.Available = product.StockOf(variant)
+	if line.Available < item.Quantity {
+		line.err = ErrInsufficientStock
+		line.Error = fmt.Sprintf("%s, %d available", ErrInsufficientStock, line.Available)
+	}
+	return line, nil
+}
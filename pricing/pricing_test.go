@@ -0,0 +1,362 @@
+package pricing
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	productModel "github.com/YassinNouh21/GoShopCart-Ecommerce/models/product"
+	"github.com/YassinNouh21/GoShopCart-Ecommerce/models/promotion"
+	shippingModel "github.com/YassinNouh21/GoShopCart-Ecommerce/models/shipping"
+	taxModel "github.com/YassinNouh21/GoShopCart-Ecommerce/models/tax"
+	userModel "github.com/YassinNouh21/GoShopCart-Ecommerce/models/user"
+	"github.com/YassinNouh21/GoShopCart-Ecommerce/money"
+	"github.com/YassinNouh21/GoShopCart-Ecommerce/repositories"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// usd returns the amount of the decimal in USD, for the expectations of the tests.
+func usd(t *testing.T, value string) money.Money {
+	t.Helper()
+	amount, err := money.Parse(value, money.USD)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return amount
+}
+
+// quoteTest is a cart of one product of 10.00 USD weighing 500 g, priced with the promotions, tax
+// rates and shipping zones of the test.
+type quoteTest struct {
+	name       string
+	quantity   int
+	stock      int
+	promotions []promotion.Promotion
+	coupons    []string
+	taxRates   []taxModel.Rate
+	zones      []shippingModel.Zone
+	address    *userModel.Address
+	taxExempt  bool
+	method     *primitive.ObjectID
+
+	subtotal string
+	discount string
+	tax      string
+	included string
+	shipping string
+	total    string
+	err      error
+	coupon   error
+}
+
+func (test quoteTest) run(t *testing.T) {
+	ctx := context.Background()
+	repos := repositories.NewMemoryRepositories()
+	stock := test.stock
+	if stock == 0 {
+		stock = 100
+	}
+	product := &productModel.Product{ProductID: primitive.NewObjectID(), ProductName: "Mug", Price: usd(t, "10.00"), Stock: stock, Weight: 500}
+	if err := repos.Products.Create(ctx, product); err != nil {
+		t.Fatal(err)
+	}
+	for i := range test.promotions {
+		test.promotions[i].PromotionID = primitive.NewObjectID()
+		test.promotions[i].Active = true
+		if err := repos.Promotions.Create(ctx, &test.promotions[i]); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for i := range test.taxRates {
+		test.taxRates[i].RateID = primitive.NewObjectID()
+		if err := repos.TaxRates.Save(ctx, &test.taxRates[i]); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for i := range test.zones {
+		if err := repos.ShippingZones.Create(ctx, &test.zones[i]); err != nil {
+			t.Fatal(err)
+		}
+	}
+	rates, err := money.NewRates(money.USD, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	engine := NewEngine(repos.Products, repos.Promotions, repos.ShippingZones, NewTableTaxCalculator(repos.TaxRates, repos.Categories))
+
+	quote, err := engine.Quote(ctx, Request{
+		Items:            []userModel.Cart{{CartID: primitive.NewObjectID(), ProductID: product.ProductID, Quantity: test.quantity}},
+		Currency:         money.USD,
+		Rates:            rates,
+		UserID:           primitive.NewObjectID(),
+		Coupons:          test.coupons,
+		Address:          test.address,
+		TaxExempt:        test.taxExempt,
+		ShippingMethodID: test.method,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !errors.Is(quote.Err(), test.err) {
+		t.Errorf("quote error = %v, want %v", quote.Err(), test.err)
+	}
+	for _, amount := range []struct {
+		name string
+		got  money.Money
+		want string
+	}{
+		{"subtotal", quote.Subtotal, test.subtotal},
+		{"discount", quote.Discount, test.discount},
+		{"tax", quote.Tax, test.tax},
+		{"tax included", quote.TaxIncluded, test.included},
+		{"shipping", quote.Shipping, test.shipping},
+		{"total", quote.Total, test.total},
+	} {
+		want := amount.want
+		if want == "" {
+			want = "0.00"
+		}
+		if amount.got != usd(t, want) {
+			t.Errorf("%s = %s, want %s USD", amount.name, amount.got, want)
+		}
+	}
+	if len(test.coupons) > 0 {
+		if len(quote.Coupons) != len(test.coupons) {
+			t.Fatalf("got %d coupons, want %d", len(quote.Coupons), len(test.coupons))
+		}
+		coupon := quote.Coupons[0]
+		if !errors.Is(coupon.Err(), test.coupon) || coupon.Applied != (test.coupon == nil) {
+			t.Errorf("coupon applied = %v with error %v, want error %v", coupon.Applied, coupon.Err(), test.coupon)
+		}
+	}
+}
+
+func TestQuoteLines(t *testing.T) {
+	tests := []quoteTest{
+		{
+			name: "single unit", quantity: 1,
+			subtotal: "10.00", total: "10.00",
+		},
+		{
+			name: "line total of the quantity", quantity: 3,
+			subtotal: "30.00", total: "30.00",
+		},
+		{
+			name: "quantity above the stock is priced but cannot be ordered", quantity: 3, stock: 2,
+			subtotal: "30.00", total: "30.00", err: ErrInsufficientStock,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, test.run)
+	}
+}
+
+func TestQuotePromotions(t *testing.T) {
+	fiveOff := usd(t, "5.00")
+	fifty := usd(t, "50.00")
+	tests := []quoteTest{
+		{
+			name: "automatic percentage", quantity: 3,
+			promotions: []promotion.Promotion{{Name: "Spring", Kind: promotion.KindPercentage, Percent: 10}},
+			subtotal:   "30.00", discount: "3.00", total: "27.00",
+		},
+		{
+			name: "fixed amount coupon", quantity: 3,
+			promotions: []promotion.Promotion{{Code: "FIVE", Name: "Five off", Kind: promotion.KindFixedAmount, Amount: &fiveOff}},
+			coupons:    []string{"FIVE"},
+			subtotal:   "30.00", discount: "5.00", total: "25.00",
+		},
+		{
+			name: "buy two get one free", quantity: 3,
+			promotions: []promotion.Promotion{{Name: "3 for 2", Kind: promotion.KindBuyXGetY, BuyQuantity: 2, GetQuantity: 1}},
+			subtotal:   "30.00", discount: "10.00", total: "20.00",
+		},
+		{
+			name: "buy two get one needs three units", quantity: 2,
+			promotions: []promotion.Promotion{{Name: "3 for 2", Kind: promotion.KindBuyXGetY, BuyQuantity: 2, GetQuantity: 1}},
+			subtotal:   "20.00", total: "20.00",
+		},
+		{
+			name: "largest of promotions that do not stack", quantity: 3,
+			promotions: []promotion.Promotion{
+				{Name: "Spring", Kind: promotion.KindPercentage, Percent: 10},
+				{Code: "FIVE", Name: "Five off", Kind: promotion.KindFixedAmount, Amount: &fiveOff},
+			},
+			coupons:  []string{"FIVE"},
+			subtotal: "30.00", discount: "5.00", total: "25.00",
+		},
+		{
+			name: "coupon below its minimum spend", quantity: 3,
+			promotions: []promotion.Promotion{{Code: "BIG", Name: "Big spender", Kind: promotion.KindFixedAmount, Amount: &fiveOff, MinimumSpend: &fifty}},
+			coupons:    []string{"BIG"},
+			subtotal:   "30.00", total: "30.00", coupon: ErrMinimumSpend,
+		},
+		{
+			name: "unknown coupon", quantity: 1,
+			coupons:  []string{"NOPE"},
+			subtotal: "10.00", total: "10.00", coupon: ErrCouponNotFound,
+		},
+		{
+			name: "used up coupon", quantity: 1,
+			promotions: []promotion.Promotion{{Code: "ONCE", Name: "Once", Kind: promotion.KindPercentage, Percent: 50, UsageLimit: 1, UsageCount: 1}},
+			coupons:    []string{"ONCE"},
+			subtotal:   "10.00", total: "10.00", coupon: promotion.ErrUsedUp,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, test.run)
+	}
+}
+
+func TestQuoteTax(t *testing.T) {
+	fiveOff := usd(t, "5.00")
+	california := &userModel.Address{Street: "1 Main St", City: "Los Angeles", State: "CA", PostalCode: "90001", CountryCode: "US"}
+	berlin := &userModel.Address{Street: "Unter den Linden 1", City: "Berlin", State: "BE", PostalCode: "10117", CountryCode: "DE"}
+	salesTax := []taxModel.Rate{
+		{CountryCode: "US", Name: "Sales tax", Percent: "5"},
+		{CountryCode: "US", State: "CA", Name: "Sales tax", Percent: "10"},
+	}
+	vat := []taxModel.Rate{{CountryCode: "DE", Name: "VAT", Percent: "25", Inclusive: true}}
+	tests := []quoteTest{
+		{
+			name: "no address", quantity: 3, taxRates: salesTax,
+			subtotal: "30.00", total: "30.00",
+		},
+		{
+			name: "state rate wins over the country rate", quantity: 3, taxRates: salesTax, address: california,
+			subtotal: "30.00", tax: "3.00", total: "33.00",
+		},
+		{
+			name: "tax on the discounted line", quantity: 3, taxRates: salesTax, address: california,
+			promotions: []promotion.Promotion{{Code: "FIVE", Name: "Five off", Kind: promotion.KindFixedAmount, Amount: &fiveOff}},
+			coupons:    []string{"FIVE"},
+			subtotal:   "30.00", discount: "5.00", tax: "2.50", total: "27.50",
+		},
+		{
+			name: "inclusive tax does not add to the total", quantity: 3, taxRates: vat, address: berlin,
+			subtotal: "30.00", tax: "6.00", included: "6.00", total: "30.00",
+		},
+		{
+			name: "exempt customer pays no sales tax", quantity: 3, taxRates: salesTax, address: california, taxExempt: true,
+			subtotal: "30.00", total: "30.00",
+		},
+		{
+			name: "exempt customer gets the inclusive tax off", quantity: 3, taxRates: vat, address: berlin, taxExempt: true,
+			subtotal: "24.00", total: "24.00",
+		},
+		{
+			name: "country without rates", quantity: 3, taxRates: vat, address: california,
+			subtotal: "30.00", total: "30.00",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, test.run)
+	}
+}
+
+func TestQuoteShipping(t *testing.T) {
+	rate := func(value string) *money.Money {
+		amount := usd(t, value)
+		return &amount
+	}
+	flat := shippingModel.Method{MethodID: primitive.NewObjectID(), Name: "Flat", RateType: shippingModel.RateFlat, Rate: rate("7.00"), Active: true}
+	byWeight := shippingModel.Method{MethodID: primitive.NewObjectID(), Name: "By weight", RateType: shippingModel.RateWeight, Active: true, WeightTiers: []shippingModel.WeightTier{
+		{MaxWeight: 1000, Rate: usd(t, "4.00")},
+		{MaxWeight: 2000, Rate: usd(t, "6.00")},
+	}}
+	byPrice := shippingModel.Method{MethodID: primitive.NewObjectID(), Name: "By price", RateType: shippingModel.RatePrice, Active: true, PriceTiers: []shippingModel.PriceTier{
+		{MinSubtotal: usd(t, "0"), Rate: usd(t, "9.00")},
+		{MinSubtotal: usd(t, "25.00"), Rate: usd(t, "3.00")},
+		{MinSubtotal: usd(t, "100.00"), Rate: usd(t, "0")},
+	}}
+	freeAbove := flat
+	freeAbove.MethodID = primitive.NewObjectID()
+	freeAbove.FreeAbove = rate("25.00")
+	inactive := flat
+	inactive.MethodID = primitive.NewObjectID()
+	inactive.Rate = rate("1.00")
+	inactive.Active = false
+	zone := func(methods ...shippingModel.Method) []shippingModel.Zone {
+		return []shippingModel.Zone{{ZoneID: primitive.NewObjectID(), Name: "Domestic", Regions: []shippingModel.Region{{CountryCode: "US"}}, Methods: methods}}
+	}
+	home := &userModel.Address{Street: "1 Main St", City: "Austin", State: "TX", PostalCode: "73301", CountryCode: "US"}
+	abroad := &userModel.Address{Street: "1 Rue de Rivoli", City: "Paris", State: "IDF", PostalCode: "75001", CountryCode: "FR"}
+	tests := []quoteTest{
+		{
+			name: "no zones ship for free", quantity: 1, address: home,
+			subtotal: "10.00", total: "10.00",
+		},
+		{
+			name: "flat rate", quantity: 1, address: home, zones: zone(flat),
+			subtotal: "10.00", shipping: "7.00", total: "17.00",
+		},
+		{
+			name: "lightest weight tier the cart fits in", quantity: 3, address: home, zones: zone(byWeight),
+			subtotal: "30.00", shipping: "6.00", total: "36.00",
+		},
+		{
+			name: "cart heavier than the last weight tier", quantity: 5, address: home, zones: zone(byWeight),
+			subtotal: "50.00", total: "50.00", err: ErrNoShipping,
+		},
+		{
+			name: "highest price tier the subtotal reaches", quantity: 3, address: home, zones: zone(byPrice),
+			subtotal: "30.00", shipping: "3.00", total: "33.00",
+		},
+		{
+			name: "lowest price tier", quantity: 1, address: home, zones: zone(byPrice),
+			subtotal: "10.00", shipping: "9.00", total: "19.00",
+		},
+		{
+			name: "free above the threshold", quantity: 3, address: home, zones: zone(freeAbove),
+			subtotal: "30.00", total: "30.00",
+		},
+		{
+			name: "cheapest active method by default", quantity: 1, address: home, zones: zone(flat, byWeight, inactive),
+			subtotal: "10.00", shipping: "4.00", total: "14.00",
+		},
+		{
+			name: "chosen method", quantity: 1, address: home, zones: zone(flat, byWeight), method: &flat.MethodID,
+			subtotal: "10.00", shipping: "7.00", total: "17.00",
+		},
+		{
+			name: "chosen method not available", quantity: 1, address: home, zones: zone(flat), method: &inactive.MethodID,
+			subtotal: "10.00", total: "10.00", err: ErrShippingMethodNotFound,
+		},
+		{
+			name: "address outside every zone", quantity: 1, address: abroad, zones: zone(flat),
+			subtotal: "10.00", total: "10.00", err: ErrNoShipping,
+		},
+		{
+			name: "free shipping promotion", quantity: 1, address: home, zones: zone(flat),
+			promotions: []promotion.Promotion{{Name: "Free shipping", Kind: promotion.KindFreeShipping}},
+			subtotal:   "10.00", total: "10.00",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, test.run)
+	}
+}
+
+func TestQuoteDeletedProduct(t *testing.T) {
+	repos := repositories.NewMemoryRepositories()
+	rates, _ := money.NewRates(money.USD, nil)
+	engine := NewEngine(repos.Products, repos.Promotions, repos.ShippingZones, NewTableTaxCalculator(repos.TaxRates, repos.Categories))
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	quote, err := engine.Quote(ctx, Request{
+		Items:    []userModel.Cart{{CartID: primitive.NewObjectID(), ProductID: primitive.NewObjectID(), Quantity: 2}},
+		Currency: money.USD,
+		Rates:    rates,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !errors.Is(quote.Err(), ErrProductNotFound) {
+		t.Errorf("quote error = %v, want ErrProductNotFound", quote.Err())
+	}
+	if !quote.Total.IsZero() || quote.Quantity != 0 {
+		t.Errorf("deleted product counted in the totals: total %s, quantity %d", quote.Total, quote.Quantity)
+	}
+}
@@ -0,0 +1,381 @@
+package pricing
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/YassinNouh21/GoShopCart-Ecommerce/models/promotion"
+	"github.com/YassinNouh21/GoShopCart-Ecommerce/money"
+	"github.com/YassinNouh21/GoShopCart-Ecommerce/repositories"
+)
+
+/*
+	Promotions are applied after the lines are priced.
+
+	The candidates are the active promotions without a code and the coupons of the request. A candidate
+	applies if it is available at the time of the quote, the customer has not used it up, the subtotal
+	reaches its minimum spend and the cart holds items in its scope. The stackable candidates apply
+	together, every other one applies alone, and the cart gets the option with the largest discount.
+
+	Within an option the promotions take their discount from what is left of the lines, buy X get Y
+	first, then percentages, then fixed amounts, so a percentage never applies to units already given
+	free. Every discount is kept on the line it comes from, in minor units of the quote currency.
+*/
+
+var (
+	// ErrCouponNotFound is the problem of a coupon code no promotion has.
+	ErrCouponNotFound = errors.New("does not exist")
+
+	// ErrCouponUsed is the problem of a coupon the customer used as many times as allowed.
+	ErrCouponUsed = errors.New("has already been used by you")
+
+	// ErrMinimumSpend is the problem of a promotion whose minimum spend the subtotal does not reach.
+	ErrMinimumSpend = errors.New("requires a minimum spend")
+
+	// ErrNoEligibleItems is the problem of a promotion that applies to none of the items in the cart.
+	ErrNoEligibleItems = errors.New("does not apply to the items in the cart")
+
+	// ErrNotCombinable is the problem of a coupon that lost to a larger discount it does not stack with.
+	ErrNotCombinable = errors.New("cannot be combined with the other promotions of the cart")
+)
+
+/*
+	Coupon is the state of a coupon code of the request.
+
+	Fields:
+	- Code: The coupon code.
+	- Applied: Whether the coupon discounts the cart.
+	- Error: Why the coupon does not discount the cart, such as an expired coupon or a minimum spend the
+	  cart does not reach.
+*/
+
+type Coupon struct {
+	Code    string `json:"code"`
+	Applied bool   `json:"applied"`
+	Error   string `json:"error,omitempty"`
+	err     error
+}
+
+// Err returns why the coupon does not discount the cart, or nil if it does.
+func (c Coupon) Err() error {
+	return c.err
+}
+
+// Coupon returns the state of the coupon with the code, or nil if the request did not have it.
+func (q *Quote) Coupon(code string) *Coupon {
+	for i := range q.Coupons {
+		if q.Coupons[i].Code == code {
+			return &q.Coupons[i]
+		}
+	}
+	return nil
+}
+
+// candidate is a promotion that can apply to the cart.
+type candidate struct {
+	promotion promotion.Promotion
+	// coupon is the index of the coupon of the quote the promotion comes from, or -1.
+	coupon int
+}
+
+// outcome is what an option of promotions takes off the cart.
+type outcome struct {
+	candidates   []candidate
+	discounts    []int64
+	applied      []promotion.Applied
+	freeShipping bool
+	total        int64
+}
+
+// kindOrder is the order in which the kinds of promotions apply within an option.
+var kindOrder = map[promotion.Kind]int{
+	promotion.KindBuyXGetY:     0,
+	promotion.KindPercentage:   1,
+	promotion.KindFixedAmount:  2,
+	promotion.KindFreeShipping: 3,
+}
+
+// applyPromotions finds the promotions the priced cart is eligible for, applies the best option of
+// them and reports the state of every coupon of the request.
+func (e *Engine) applyPromotions(ctx context.Context, request Request, quote *Quote) error {
+	now := time.Now().UTC()
+	candidates := []candidate{}
+	automatic, err := e.promotions.FindAutomatic(ctx)
+	if err != nil {
+		return err
+	}
+	for _, p := range automatic {
+		problem, err := e.check(ctx, p, request, quote, now)
+		if err != nil {
+			return err
+		}
+		if problem == nil {
+			candidates = append(candidates, candidate{promotion: p, coupon: -1})
+		}
+	}
+	for _, code := range request.Coupons {
+		p, err := e.promotions.FindByCode(ctx, code)
+		var problem error
+		switch {
+		case err == repositories.ErrNotFound:
+			problem = ErrCouponNotFound
+		case err != nil:
+			return err
+		default:
+			problem, err = e.check(ctx, *p, request, quote, now)
+			if err != nil {
+				return err
+			}
+		}
+		coupon := Coupon{Code: code}
+		if problem != nil {
+			coupon.err = fmt.Errorf("Coupon %s %w", code, problem)
+			coupon.Error = coupon.err.Error()
+		} else {
+			candidates = append(candidates, candidate{promotion: *p, coupon: len(quote.Coupons)})
+		}
+		quote.Coupons = append(quote.Coupons, coupon)
+	}
+
+	best, err := bestOutcome(candidates, request, quote)
+	if err != nil {
+		return err
+	}
+	chosen := map[int]bool{}
+	if best != nil {
+		for i, discount := range best.discounts {
+			quote.Lines[i].Discount = quote.Lines[i].Discount.Add(money.New(discount, quote.Currency))
+			quote.Discount = quote.Discount.Add(money.New(discount, quote.Currency))
+		}
+		quote.Promotions = best.applied
+		if best.freeShipping {
+			quote.FreeShipping = true
+			quote.Shipping = money.Zero(quote.Currency)
+		}
+		for _, c := range best.candidates {
+			if c.coupon >= 0 {
+				chosen[c.coupon] = true
+			}
+		}
+	}
+	for i := range quote.Coupons {
+		coupon := &quote.Coupons[i]
+		if coupon.err != nil {
+			continue
+		}
+		if chosen[i] {
+			coupon.Applied = true
+			continue
+		}
+		coupon.err = fmt.Errorf("Coupon %s %w", coupon.Code, ErrNotCombinable)
+		coupon.Error = coupon.err.Error()
+	}
+	return nil
+}
+
+// check returns why the promotion cannot apply to the priced cart, or nil if it can. The error is only
+// set if the redemptions of the customer cannot be read.
+func (e *Engine) check(ctx context.Context, p promotion.Promotion, request Request, quote *Quote, now time.Time) (error, error) {
+	if problem := p.Available(now); problem != nil {
+		return problem, nil
+	}
+	if p.UsageLimitPerUser > 0 && !request.UserID.IsZero() {
+		used, err := e.promotions.CountRedemptions(ctx, p.PromotionID, request.UserID)
+		if err != nil {
+			return nil, err
+		}
+		if used >= p.UsageLimitPerUser {
+			return ErrCouponUsed, nil
+		}
+	}
+	if p.MinimumSpend != nil {
+		minimum, err := request.Rates.Convert(*p.MinimumSpend, quote.Currency)
+		if err != nil {
+			return nil, err
+		}
+		if quote.Subtotal.Cmp(minimum) < 0 {
+			return fmt.Errorf("%w of %s", ErrMinimumSpend, minimum), nil
+		}
+	}
+	units := 0
+	for _, line := range quote.Lines {
+		if line.priced() && p.Covers(*line.Product) {
+			units += line.Quantity
+		}
+	}
+	if units == 0 {
+		return ErrNoEligibleItems, nil
+	}
+	if p.Kind == promotion.KindBuyXGetY && units < p.BuyQuantity+p.GetQuantity {
+		return fmt.Errorf("%w, buy %d to get %d free", ErrNoEligibleItems, p.BuyQuantity, p.GetQuantity), nil
+	}
+	return nil, nil
+}
+
+// bestOutcome evaluates the options of the candidates, all stackable ones together and every other one
+// alone, and returns the one with the largest discount, or nil if there are no candidates. Of options
+// with the same discount the first wins, and the stackable one comes first.
+func bestOutcome(candidates []candidate, request Request, quote *Quote) (*outcome, error) {
+	options := [][]candidate{}
+	stackable := []candidate{}
+	for _, c := range candidates {
+		if c.promotion.Stackable {
+			stackable = append(stackable, c)
+		}
+	}
+	if len(stackable) > 0 {
+		options = append(options, stackable)
+	}
+	for _, c := range candidates {
+		if !c.promotion.Stackable {
+			options = append(options, []candidate{c})
+		}
+	}
+	var best *outcome
+	for _, option := range options {
+		result, err := evaluate(option, request, quote)
+		if err != nil {
+			return nil, err
+		}
+		if best == nil || result.total > best.total {
+			best = result
+		}
+	}
+	return best, nil
+}
+
+// evaluate computes the discount of an option of promotions on every line of the quote.
+func evaluate(option []candidate, request Request, quote *Quote) (*outcome, error) {
+	ordered := append([]candidate{}, option...)
+	sort.SliceStable(ordered, func(i, j int) bool {
+		return kindOrder[ordered[i].promotion.Kind] < kindOrder[ordered[j].promotion.Kind]
+	})
+	result := &outcome{candidates: ordered, discounts: make([]int64, len(quote.Lines)), applied: []promotion.Applied{}}
+	remaining := make([]int64, len(quote.Lines))
+	for i, line := range quote.Lines {
+		if line.priced() {
+			remaining[i] = line.LineTotal.Amount
+		}
+	}
+	for _, c := range ordered {
+		p := c.promotion
+		covered := make([]bool, len(quote.Lines))
+		for i, line := range quote.Lines {
+			covered[i] = line.priced() && p.Covers(*line.Product)
+		}
+		taken := make([]int64, len(quote.Lines))
+		var waived int64
+		switch p.Kind {
+		case promotion.KindBuyXGetY:
+			taken = freeUnits(p, quote.Lines, covered, remaining)
+		case promotion.KindPercentage:
+			for i := range quote.Lines {
+				if covered[i] {
+					// Half up, the amounts are never negative
+					taken[i] = (remaining[i]*int64(p.Percent) + 50) / 100
+				}
+			}
+		case promotion.KindFixedAmount:
+			amount, err := request.Rates.Convert(*p.Amount, quote.Currency)
+			if err != nil {
+				return nil, err
+			}
+			taken = spread(amount.Amount, remaining, covered)
+		case promotion.KindFreeShipping:
+			if !result.freeShipping {
+				waived = quote.Shipping.Amount
+			}
+			result.freeShipping = true
+		}
+		discount := waived
+		for i := range taken {
+			remaining[i] -= taken[i]
+			result.discounts[i] += taken[i]
+			discount += taken[i]
+		}
+		result.total += discount
+		result.applied = append(result.applied, promotion.Applied{
+			PromotionID: p.PromotionID,
+			Code:        p.Code,
+			Name:        p.Name,
+			Kind:        p.Kind,
+			Discount:    money.New(discount, quote.Currency),
+		})
+	}
+	return result, nil
+}
+
+// freeUnits returns the discount of a buy X get Y promotion on every line: the covered units are
+// sorted from the most to the least expensive and split in groups of X+Y, of which the last Y units,
+// the cheapest, are free.
+func freeUnits(p promotion.Promotion, lines []Line, covered []bool, remaining []int64) []int64 {
+	type unit struct {
+		line  int
+		price int64
+	}
+	units := []unit{}
+	for i, line := range lines {
+		if !covered[i] {
+			continue
+		}
+		for n := 0; n < line.Quantity; n++ {
+			units = append(units, unit{line: i, price: line.UnitPrice.Amount})
+		}
+	}
+	sort.SliceStable(units, func(i, j int) bool { return units[i].price > units[j].price })
+	taken := make([]int64, len(lines))
+	group := p.BuyQuantity + p.GetQuantity
+	for k, u := range units {
+		if k%group < p.BuyQuantity {
+			continue
+		}
+		free := u.price
+		if left := remaining[u.line] - taken[u.line]; free > left {
+			free = left
+		}
+		taken[u.line] += free
+	}
+	return taken
+}
+
+// spread splits an amount over the covered lines in proportion to what is left of them, giving the
+// minor units lost to rounding to the lines with the largest remainders. The amount is capped at what
+// is left of the covered lines.
+func spread(amount int64, remaining []int64, covered []bool) []int64 {
+	taken := make([]int64, len(remaining))
+	var total int64
+	for i := range remaining {
+		if covered[i] {
+			total += remaining[i]
+		}
+	}
+	if total <= 0 || amount <= 0 {
+		return taken
+	}
+	if amount > total {
+		amount = total
+	}
+	type share struct {
+		line      int
+		remainder int64
+	}
+	shares := []share{}
+	var given int64
+	for i := range remaining {
+		if !covered[i] {
+			continue
+		}
+		taken[i] = amount * remaining[i] / total
+		given += taken[i]
+		shares = append(shares, share{line: i, remainder: amount * remaining[i] % total})
+	}
+	sort.SliceStable(shares, func(i, j int) bool { return shares[i].remainder > shares[j].remainder })
+	for k := 0; given < amount; k++ {
+		taken[shares[k].line]++
+		given++
+	}
+	return taken
+}
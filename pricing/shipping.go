@@ -0,0 +1,80 @@
+package pricing
+
+import (
+	"context"
+	"errors"
+	"sort"
+
+	shippingModel "github.com/YassinNouh21/GoShopCart-Ecommerce/models/shipping"
+)
+
+/*
+	Shipping is computed once the lines are priced, from the shipping zone of the address.
+
+	Every active method of the zone the cart can use is an option, cheapest first, costed on the
+	subtotal before discounts and the weight of the cart. The cart ships with the method of the
+	request, or else with the cheapest one. A free shipping promotion then waives its cost.
+
+	Stores without shipping zones ship for free, as do quotes without an address.
+*/
+
+var (
+	// ErrNoShipping is the problem of a cart no shipping method is available for at its address.
+	ErrNoShipping = errors.New("No shipping method is available for the address")
+
+	// ErrShippingMethodNotFound is the problem of a cart whose chosen shipping method is not one of
+	// the options for its address.
+	ErrShippingMethodNotFound = errors.New("Shipping method is not available for the address")
+)
+
+// applyShipping finds the shipping options of the priced cart and sets the cost of the chosen one.
+func (e *Engine) applyShipping(ctx context.Context, request Request, quote *Quote) error {
+	if request.Address == nil {
+		return nil
+	}
+	zones, err := e.zones.FindAll(ctx)
+	if err != nil || len(zones) == 0 {
+		return err
+	}
+	address := request.Address
+	zone := shippingModel.Match(zones, address.CountryCode, address.State, address.PostalCode)
+	options := []shippingModel.Option{}
+	if zone != nil {
+		for _, method := range zone.Methods {
+			if !method.Active {
+				continue
+			}
+			cost, available, err := method.Cost(quote.Subtotal, quote.Weight, request.Rates)
+			if err != nil {
+				return err
+			}
+			if available {
+				options = append(options, shippingModel.Option{ZoneID: zone.ZoneID, MethodID: method.MethodID, Name: method.Name, Cost: cost})
+			}
+		}
+	}
+	sort.SliceStable(options, func(i, j int) bool { return options[i].Cost.Cmp(options[j].Cost) < 0 })
+	quote.ShippingOptions = options
+	if len(options) == 0 {
+		quote.shippingErr = ErrNoShipping
+		quote.ShippingError = ErrNoShipping.Error()
+		return nil
+	}
+	chosen := options[0]
+	if request.ShippingMethodID != nil {
+		found := false
+		for _, option := range options {
+			if option.MethodID == *request.ShippingMethodID {
+				chosen, found = option, true
+			}
+		}
+		if !found {
+			quote.shippingErr = ErrShippingMethodNotFound
+			quote.ShippingError = ErrShippingMethodNotFound.Error()
+			return nil
+		}
+	}
+	quote.ShippingMethod = &chosen
+	quote.Shipping = chosen.Cost
+	return nil
+}
@@ -0,0 +1,168 @@
+package pricing
+
+import (
+	"context"
+
+	productModel "github.com/YassinNouh21/GoShopCart-Ecommerce/models/product"
+	taxModel "github.com/YassinNouh21/GoShopCart-Ecommerce/models/tax"
+	userModel "github.com/YassinNouh21/GoShopCart-Ecommerce/models/user"
+	"github.com/YassinNouh21/GoShopCart-Ecommerce/repositories"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+/*
+	Tax is computed per line once the promotions are applied, on what the customer pays for the line.
+
+	A line with an exclusive rate, such as a sales tax, adds its tax to the total. A line with an
+	inclusive rate, such as a VAT, already holds its tax in its price; the tax is shown on the line and
+	in Quote.TaxIncluded but does not change the total. Tax-exempt customers pay no tax: the prices that
+	include one are reduced by it and no tax is added to the others.
+*/
+
+// TaxCalculator finds the tax rates of the products of a cart.
+type TaxCalculator interface {
+	// Rates returns the rate of every product shipped to the address, in the order of the products.
+	// The rate of a product that is not taxed is nil.
+	Rates(ctx context.Context, address userModel.Address, products []*productModel.Product) ([]*taxModel.Rate, error)
+}
+
+// TableTaxCalculator is the TaxCalculator that looks the rates up in the table of tax rates, by the
+// country and state of the address and the tax class of the categories of the products.
+type TableTaxCalculator struct {
+	rates      repositories.TaxRateRepository
+	categories repositories.CategoryRepository
+}
+
+// NewTableTaxCalculator creates a TableTaxCalculator that reads the rates from the tax rate repository
+// and the tax classes from the category repository.
+func NewTableTaxCalculator(rates repositories.TaxRateRepository, categories repositories.CategoryRepository) *TableTaxCalculator {
+	return &TableTaxCalculator{rates: rates, categories: categories}
+}
+
+// Rates returns the rate of every product shipped to the address. A product takes the tax class of
+// its first category that has one, either set on the category or inherited from its nearest ancestor
+// with one, and otherwise the standard class.
+func (t *TableTaxCalculator) Rates(ctx context.Context, address userModel.Address, products []*productModel.Product) ([]*taxModel.Rate, error) {
+	result := make([]*taxModel.Rate, len(products))
+	table, err := t.rates.FindByCountry(ctx, taxModel.NormalizeRegion(address.CountryCode))
+	if err != nil || len(table) == 0 {
+		return result, err
+	}
+	categories := map[primitive.ObjectID]*productModel.Category{}
+	for i, product := range products {
+		if product == nil {
+			continue
+		}
+		class, err := t.taxClass(ctx, product, categories)
+		if err != nil {
+			return nil, err
+		}
+		result[i] = taxModel.Select(table, address.State, class)
+	}
+	return result, nil
+}
+
+// taxClass returns the tax class of the product, reading its categories and their ancestors through
+// the cache. Categories that no longer exist are skipped.
+func (t *TableTaxCalculator) taxClass(ctx context.Context, product *productModel.Product, cache map[primitive.ObjectID]*productModel.Category) (string, error) {
+	for _, categoryID := range product.CategoryIDs {
+		category, err := t.category(ctx, categoryID, cache)
+		if err != nil {
+			return "", err
+		}
+		if category == nil {
+			continue
+		}
+		if category.TaxClass != taxModel.StandardClass {
+			return category.TaxClass, nil
+		}
+		for i := len(category.Ancestors) - 1; i >= 0; i-- {
+			ancestor, err := t.category(ctx, category.Ancestors[i].CategoryID, cache)
+			if err != nil {
+				return "", err
+			}
+			if ancestor != nil && ancestor.TaxClass != taxModel.StandardClass {
+				return ancestor.TaxClass, nil
+			}
+		}
+	}
+	return taxModel.StandardClass, nil
+}
+
+// category returns the category with the ID through the cache, or nil if it does not exist.
+func (t *TableTaxCalculator) category(ctx context.Context, id primitive.ObjectID, cache map[primitive.ObjectID]*productModel.Category) (*productModel.Category, error) {
+	if category, found := cache[id]; found {
+		return category, nil
+	}
+	category, err := t.categories.FindByID(ctx, id)
+	if err == repositories.ErrNotFound {
+		category, err = nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	cache[id] = category
+	return category, nil
+}
+
+// resolveTaxes finds the rate of every priced line for the address of the request. The lines of a
+// tax-exempt customer get no rate, and the unit prices of those whose rate is inclusive are reduced
+// by the tax they hold.
+func (e *Engine) resolveTaxes(ctx context.Context, request Request, quote *Quote) error {
+	if request.Address == nil {
+		return nil
+	}
+	products := make([]*productModel.Product, len(quote.Lines))
+	for i, line := range quote.Lines {
+		if line.priced() {
+			products[i] = line.Product
+		}
+	}
+	rates, err := e.taxes.Rates(ctx, *request.Address, products)
+	if err != nil {
+		return err
+	}
+	for i, rate := range rates {
+		if rate == nil {
+			continue
+		}
+		line := &quote.Lines[i]
+		if !request.TaxExempt {
+			line.rate = rate
+			continue
+		}
+		if rate.Inclusive {
+			tax, err := rate.Tax(line.UnitPrice)
+			if err != nil {
+				return err
+			}
+			line.UnitPrice = line.UnitPrice.Sub(tax)
+			line.LineTotal = line.UnitPrice.Mul(line.Quantity)
+		}
+	}
+	return nil
+}
+
+// applyTaxes computes the tax of every line with a rate on what is left of it after the discount, and
+// adds it up on the quote.
+func applyTaxes(quote *Quote) error {
+	for i := range quote.Lines {
+		line := &quote.Lines[i]
+		if line.rate == nil {
+			continue
+		}
+		tax, err := line.rate.Tax(line.LineTotal.Sub(line.Discount))
+		if err != nil {
+			return err
+		}
+		line.TaxName = line.rate.Name
+		line.TaxRate = line.rate.Percent
+		line.TaxIncluded = line.rate.Inclusive
+		line.Tax = tax
+		quote.Tax = quote.Tax.Add(tax)
+		if line.rate.Inclusive {
+			quote.TaxIncluded = quote.TaxIncluded.Add(tax)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,29 @@
+package repositories
+
+import (
+	"context"
+
+	userModel "github.com/YassinNouh21/GoShopCart-Ecommerce/models/user"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// CartRepository stores the cart items of the users.
+type CartRepository interface {
+	// GetItems returns the cart items of the user.
+	GetItems(ctx context.Context, userID primitive.ObjectID) ([]userModel.Cart, error)
+	// AddItem appends a new item to the user's cart.
+	AddItem(ctx context.Context, userID primitive.ObjectID, item userModel.Cart) error
+	// IncrementQuantity adds quantity to the cart item holding the product in the given variant
+	// or returns ErrNotFound.
+	IncrementQuantity(ctx context.Context, userID primitive.ObjectID, productID primitive.ObjectID, variantID *primitive.ObjectID, quantity int) error
+	// RemoveItem removes the cart item with the cart ID from the user's cart or returns ErrNotFound.
+	RemoveItem(ctx context.Context, userID primitive.ObjectID, cartID primitive.ObjectID) error
+	// SetItems replaces every item of the user's cart, keeping its coupons.
+	SetItems(ctx context.Context, userID primitive.ObjectID, items []userModel.Cart) error
+	// GetCoupons returns the coupon codes applied to the user's cart.
+	GetCoupons(ctx context.Context, userID primitive.ObjectID) ([]string, error)
+	// SetCoupons replaces the coupon codes applied to the user's cart.
+	SetCoupons(ctx context.Context, userID primitive.ObjectID, codes []string) error
+	// Clear removes every item and coupon from the user's cart.
+	Clear(ctx context.Context, userID primitive.ObjectID) error
+}
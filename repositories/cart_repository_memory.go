@@ -0,0 +1,100 @@
+package repositories
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	userModel "github.com/YassinNouh21/GoShopCart-Ecommerce/models/user"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// memoryCartRepository is a CartRepository that keeps the carts in memory.
+type memoryCartRepository struct {
+	mu      sync.RWMutex
+	carts   map[primitive.ObjectID][]userModel.Cart
+	coupons map[primitive.ObjectID][]string
+}
+
+// NewMemoryCartRepository creates an empty in-memory CartRepository.
+func NewMemoryCartRepository() CartRepository {
+	return &memoryCartRepository{carts: map[primitive.ObjectID][]userModel.Cart{}, coupons: map[primitive.ObjectID][]string{}}
+}
+
+func (r *memoryCartRepository) GetItems(ctx context.Context, userID primitive.ObjectID) ([]userModel.Cart, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	items := []userModel.Cart{}
+	for _, item := range r.carts[userID] {
+		items = append(items, cloneDocument(item))
+	}
+	return items, nil
+}
+
+func (r *memoryCartRepository) AddItem(ctx context.Context, userID primitive.ObjectID, item userModel.Cart) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.carts[userID] = append(r.carts[userID], cloneDocument(item))
+	return nil
+}
+
+func (r *memoryCartRepository) IncrementQuantity(ctx context.Context, userID primitive.ObjectID, productID primitive.ObjectID, variantID *primitive.ObjectID, quantity int) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for i, item := range r.carts[userID] {
+		if item.Holds(productID, variantID) {
+			r.carts[userID][i].Quantity += quantity
+			r.carts[userID][i].UpdatedAt = time.Now().UTC()
+			return nil
+		}
+	}
+	return ErrNotFound
+}
+
+func (r *memoryCartRepository) RemoveItem(ctx context.Context, userID primitive.ObjectID, cartID primitive.ObjectID) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for i, stored := range r.carts[userID] {
+		if stored.CartID == cartID {
+			r.carts[userID] = append(r.carts[userID][:i:i], r.carts[userID][i+1:]...)
+			return nil
+		}
+	}
+	return ErrNotFound
+}
+
+func (r *memoryCartRepository) SetItems(ctx context.Context, userID primitive.ObjectID, items []userModel.Cart) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	stored := []userModel.Cart{}
+	for _, item := range items {
+		stored = append(stored, cloneDocument(item))
+	}
+	r.carts[userID] = stored
+	return nil
+}
+
+func (r *memoryCartRepository) GetCoupons(ctx context.Context, userID primitive.ObjectID) ([]string, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return append([]string{}, r.coupons[userID]...), nil
+}
+
+func (r *memoryCartRepository) SetCoupons(ctx context.Context, userID primitive.ObjectID, codes []string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if len(codes) == 0 {
+		delete(r.coupons, userID)
+		return nil
+	}
+	r.coupons[userID] = append([]string{}, codes...)
+	return nil
+}
+
+func (r *memoryCartRepository) Clear(ctx context.Context, userID primitive.ObjectID) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.carts, userID)
+	delete(r.coupons, userID)
+	return nil
+}
@@ -0,0 +1,146 @@
+package repositories
+
+import (
+	"context"
+	"time"
+
+	userModel "github.com/YassinNouh21/GoShopCart-Ecommerce/models/user"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// mongoCartRepository is the CartRepository backed by the carts collection, which holds one document
+// per user with a cart, keyed by the ID of the user. A user without a document has an empty cart.
+type mongoCartRepository struct {
+	collection *mongo.Collection
+}
+
+// cartDocument is the document of the cart of a user in the carts collection.
+type cartDocument struct {
+	UserID    primitive.ObjectID `bson:"_id"`
+	Items     []userModel.Cart   `bson:"items"`
+	Coupons   []string           `bson:"coupons,omitempty"`
+	UpdatedAt time.Time          `bson:"updated_at"`
+}
+
+// NewMongoCartRepository creates a CartRepository on top of the carts collection.
+func NewMongoCartRepository(collection *mongo.Collection) CartRepository {
+	return &mongoCartRepository{collection: collection}
+}
+
+// find returns the cart document of the user with the given fields, or an empty one if the user has
+// no cart.
+func (r *mongoCartRepository) find(ctx context.Context, userID primitive.ObjectID, projection bson.M) (cartDocument, error) {
+	cart := cartDocument{UserID: userID}
+	err := r.collection.FindOne(ctx, bson.M{"_id": userID}, options.FindOne().SetProjection(projection)).Decode(&cart)
+	if err == mongo.ErrNoDocuments {
+		err = nil
+	}
+	return cart, err
+}
+
+func (r *mongoCartRepository) GetItems(ctx context.Context, userID primitive.ObjectID) ([]userModel.Cart, error) {
+	cart, err := r.find(ctx, userID, bson.M{"items": 1})
+	if err != nil {
+		return nil, err
+	}
+	if cart.Items == nil {
+		return []userModel.Cart{}, nil
+	}
+	return cart.Items, nil
+}
+
+func (r *mongoCartRepository) AddItem(ctx context.Context, userID primitive.ObjectID, item userModel.Cart) error {
+	update := bson.M{
+		"$push": bson.M{"items": item},
+		"$set":  bson.M{"updated_at": time.Now().UTC()},
+	}
+	return r.upsert(ctx, userID, update)
+}
+
+func (r *mongoCartRepository) IncrementQuantity(ctx context.Context, userID primitive.ObjectID, productID primitive.ObjectID, variantID *primitive.ObjectID, quantity int) error {
+	filter := bson.M{
+		"_id":   userID,
+		"items": itemFilter(productID, variantID),
+	}
+	now := time.Now().UTC()
+	update := bson.M{
+		"$inc": bson.M{
+			"items.$.quantity": quantity,
+		},
+		"$set": bson.M{
+			"items.$.updated_at": now,
+			"updated_at":         now,
+		},
+	}
+	return r.updateOne(ctx, filter, update)
+}
+
+func (r *mongoCartRepository) RemoveItem(ctx context.Context, userID primitive.ObjectID, cartID primitive.ObjectID) error {
+	filter := bson.M{
+		"_id":       userID,
+		"items._id": cartID,
+	}
+	update := bson.M{
+		"$pull": bson.M{"items": bson.M{"_id": cartID}},
+		"$set":  bson.M{"updated_at": time.Now().UTC()},
+	}
+	return r.updateOne(ctx, filter, update)
+}
+
+func (r *mongoCartRepository) SetItems(ctx context.Context, userID primitive.ObjectID, items []userModel.Cart) error {
+	if items == nil {
+		items = []userModel.Cart{}
+	}
+	return r.upsert(ctx, userID, bson.M{"$set": bson.M{"items": items, "updated_at": time.Now().UTC()}})
+}
+
+func (r *mongoCartRepository) GetCoupons(ctx context.Context, userID primitive.ObjectID) ([]string, error) {
+	cart, err := r.find(ctx, userID, bson.M{"coupons": 1})
+	if err != nil {
+		return nil, err
+	}
+	if cart.Coupons == nil {
+		return []string{}, nil
+	}
+	return cart.Coupons, nil
+}
+
+func (r *mongoCartRepository) SetCoupons(ctx context.Context, userID primitive.ObjectID, codes []string) error {
+	if len(codes) == 0 {
+		_, err := r.collection.UpdateOne(ctx, bson.M{"_id": userID}, bson.M{"$unset": bson.M{"coupons": ""}})
+		return err
+	}
+	return r.upsert(ctx, userID, bson.M{"$set": bson.M{"coupons": codes, "updated_at": time.Now().UTC()}})
+}
+
+func (r *mongoCartRepository) Clear(ctx context.Context, userID primitive.ObjectID) error {
+	_, err := r.collection.DeleteOne(ctx, bson.M{"_id": userID})
+	return err
+}
+
+// itemFilter matches the cart item holding the product in the given variant.
+// Items of products without variants have no variant_id, which a null filter matches.
+func itemFilter(productID primitive.ObjectID, variantID *primitive.ObjectID) bson.M {
+	return bson.M{"$elemMatch": bson.M{"product_id": productID, "variant_id": variantID}}
+}
+
+// upsert applies the update to the cart of the user, creating the cart if the user has none.
+func (r *mongoCartRepository) upsert(ctx context.Context, userID primitive.ObjectID, update bson.M) error {
+	_, err := r.collection.UpdateOne(ctx, bson.M{"_id": userID}, update, options.Update().SetUpsert(true))
+	return err
+}
+
+// updateOne applies the update and returns ErrNotFound if the filter matched no cart.
+func (r *mongoCartRepository) updateOne(ctx context.Context, filter bson.M, update bson.M) error {
+	result, err := r.collection.UpdateOne(ctx, filter, update)
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
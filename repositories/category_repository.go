@@ -0,0 +1,28 @@
+package repositories
+
+import (
+	"context"
+
+	productModel "github.com/YassinNouh21/GoShopCart-Ecommerce/models/product"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// CategoryRepository stores the categories of the product taxonomy.
+type CategoryRepository interface {
+	// Create inserts a new category.
+	Create(ctx context.Context, category *productModel.Category) error
+	// FindByID returns the category with the given ID or ErrNotFound.
+	FindByID(ctx context.Context, id primitive.ObjectID) (*productModel.Category, error)
+	// FindBySlug returns the category with the given slug or ErrNotFound.
+	FindBySlug(ctx context.Context, slug string) (*productModel.Category, error)
+	// FindAll returns every category, sorted by slug.
+	FindAll(ctx context.Context) ([]productModel.Category, error)
+	// FindDescendants returns the categories below the given one at any depth, sorted by slug.
+	FindDescendants(ctx context.Context, id primitive.ObjectID) ([]productModel.Category, error)
+	// HasChildren reports whether a category has the given one as parent.
+	HasChildren(ctx context.Context, id primitive.ObjectID) (bool, error)
+	// Replace stores the category or returns ErrNotFound.
+	Replace(ctx context.Context, category *productModel.Category) error
+	// Delete removes the category with the given ID or returns ErrNotFound.
+	Delete(ctx context.Context, id primitive.ObjectID) error
+}
@@ -0,0 +1,96 @@
+package repositories
+
+import (
+	"context"
+	"sort"
+	"sync"
+
+	productModel "github.com/YassinNouh21/GoShopCart-Ecommerce/models/product"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// memoryCategoryRepository is a CategoryRepository that keeps the categories in memory.
+type memoryCategoryRepository struct {
+	mu         sync.RWMutex
+	categories map[primitive.ObjectID]productModel.Category
+}
+
+// NewMemoryCategoryRepository creates an empty in-memory CategoryRepository.
+func NewMemoryCategoryRepository() CategoryRepository {
+	return &memoryCategoryRepository{categories: map[primitive.ObjectID]productModel.Category{}}
+}
+
+func (r *memoryCategoryRepository) Create(ctx context.Context, category *productModel.Category) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.categories[category.CategoryID] = cloneDocument(*category)
+	return nil
+}
+
+func (r *memoryCategoryRepository) FindByID(ctx context.Context, id primitive.ObjectID) (*productModel.Category, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	category, found := r.categories[id]
+	if !found {
+		return nil, ErrNotFound
+	}
+	category = cloneDocument(category)
+	return &category, nil
+}
+
+func (r *memoryCategoryRepository) FindBySlug(ctx context.Context, slug string) (*productModel.Category, error) {
+	categories := r.filter(func(category productModel.Category) bool { return category.Slug == slug })
+	if len(categories) == 0 {
+		return nil, ErrNotFound
+	}
+	return &categories[0], nil
+}
+
+func (r *memoryCategoryRepository) FindAll(ctx context.Context) ([]productModel.Category, error) {
+	return r.filter(func(productModel.Category) bool { return true }), nil
+}
+
+func (r *memoryCategoryRepository) FindDescendants(ctx context.Context, id primitive.ObjectID) ([]productModel.Category, error) {
+	return r.filter(func(category productModel.Category) bool { return category.HasAncestor(id) }), nil
+}
+
+func (r *memoryCategoryRepository) HasChildren(ctx context.Context, id primitive.ObjectID) (bool, error) {
+	children := r.filter(func(category productModel.Category) bool {
+		return category.ParentID != nil && *category.ParentID == id
+	})
+	return len(children) > 0, nil
+}
+
+func (r *memoryCategoryRepository) Replace(ctx context.Context, category *productModel.Category) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, found := r.categories[category.CategoryID]; !found {
+		return ErrNotFound
+	}
+	r.categories[category.CategoryID] = cloneDocument(*category)
+	return nil
+}
+
+func (r *memoryCategoryRepository) Delete(ctx context.Context, id primitive.ObjectID) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, found := r.categories[id]; !found {
+		return ErrNotFound
+	}
+	delete(r.categories, id)
+	return nil
+}
+
+// filter returns copies of the categories matching the predicate, sorted by slug.
+func (r *memoryCategoryRepository) filter(match func(productModel.Category) bool) []productModel.Category {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	categories := []productModel.Category{}
+	for _, category := range r.categories {
+		if match(category) {
+			categories = append(categories, cloneDocument(category))
+		}
+	}
+	sort.Slice(categories, func(i, j int) bool { return categories[i].Slug < categories[j].Slug })
+	return categories
+}
@@ -0,0 +1,94 @@
+package repositories
+
+import (
+	"context"
+
+	productModel "github.com/YassinNouh21/GoShopCart-Ecommerce/models/product"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// mongoCategoryRepository is the CategoryRepository backed by the categories collection.
+type mongoCategoryRepository struct {
+	collection *mongo.Collection
+}
+
+// NewMongoCategoryRepository creates a CategoryRepository on top of the given collection.
+func NewMongoCategoryRepository(collection *mongo.Collection) CategoryRepository {
+	return &mongoCategoryRepository{collection: collection}
+}
+
+func (r *mongoCategoryRepository) Create(ctx context.Context, category *productModel.Category) error {
+	_, err := r.collection.InsertOne(ctx, category)
+	return err
+}
+
+func (r *mongoCategoryRepository) FindByID(ctx context.Context, id primitive.ObjectID) (*productModel.Category, error) {
+	return r.findOne(ctx, bson.M{"_id": id})
+}
+
+func (r *mongoCategoryRepository) FindBySlug(ctx context.Context, slug string) (*productModel.Category, error) {
+	return r.findOne(ctx, bson.M{"slug": slug})
+}
+
+func (r *mongoCategoryRepository) FindAll(ctx context.Context) ([]productModel.Category, error) {
+	return r.find(ctx, bson.M{})
+}
+
+func (r *mongoCategoryRepository) FindDescendants(ctx context.Context, id primitive.ObjectID) ([]productModel.Category, error) {
+	return r.find(ctx, bson.M{"ancestors._id": id})
+}
+
+func (r *mongoCategoryRepository) HasChildren(ctx context.Context, id primitive.ObjectID) (bool, error) {
+	count, err := r.collection.CountDocuments(ctx, bson.M{"parent_id": id}, options.Count().SetLimit(1))
+	return count > 0, err
+}
+
+func (r *mongoCategoryRepository) Replace(ctx context.Context, category *productModel.Category) error {
+	result, err := r.collection.ReplaceOne(ctx, bson.M{"_id": category.CategoryID}, category)
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+func (r *mongoCategoryRepository) Delete(ctx context.Context, id primitive.ObjectID) error {
+	result, err := r.collection.DeleteOne(ctx, bson.M{"_id": id})
+	if err != nil {
+		return err
+	}
+	if result.DeletedCount == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+func (r *mongoCategoryRepository) findOne(ctx context.Context, filter bson.M) (*productModel.Category, error) {
+	var category productModel.Category
+	err := r.collection.FindOne(ctx, filter).Decode(&category)
+	if err == mongo.ErrNoDocuments {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &category, nil
+}
+
+// find returns the categories matching the filter, sorted by slug.
+func (r *mongoCategoryRepository) find(ctx context.Context, filter bson.M) ([]productModel.Category, error) {
+	cursor, err := r.collection.Find(ctx, filter, options.Find().SetSort(bson.D{{Key: "slug", Value: 1}}))
+	if err != nil {
+		return nil, err
+	}
+	categories := []productModel.Category{}
+	if err := cursor.All(ctx, &categories); err != nil {
+		return nil, err
+	}
+	return categories, nil
+}
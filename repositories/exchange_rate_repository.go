@@ -0,0 +1,17 @@
+package repositories
+
+import (
+	"context"
+
+	"github.com/YassinNouh21/GoShopCart-Ecommerce/money"
+)
+
+// ExchangeRateRepository stores the exchange rates from the store currency to the other currencies.
+type ExchangeRateRepository interface {
+	// FindAll returns every exchange rate, sorted by currency.
+	FindAll(ctx context.Context) ([]money.ExchangeRate, error)
+	// Save creates the exchange rate of its currency or replaces it.
+	Save(ctx context.Context, rate *money.ExchangeRate) error
+	// Delete removes the exchange rate of the currency or returns ErrNotFound.
+	Delete(ctx context.Context, currency money.Currency) error
+}
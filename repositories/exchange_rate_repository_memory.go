@@ -0,0 +1,48 @@
+package repositories
+
+import (
+	"context"
+	"sort"
+	"sync"
+
+	"github.com/YassinNouh21/GoShopCart-Ecommerce/money"
+)
+
+// memoryExchangeRateRepository is an ExchangeRateRepository that keeps the exchange rates in memory.
+type memoryExchangeRateRepository struct {
+	mu    sync.RWMutex
+	rates map[money.Currency]money.ExchangeRate
+}
+
+// NewMemoryExchangeRateRepository creates an empty in-memory ExchangeRateRepository.
+func NewMemoryExchangeRateRepository() ExchangeRateRepository {
+	return &memoryExchangeRateRepository{rates: map[money.Currency]money.ExchangeRate{}}
+}
+
+func (r *memoryExchangeRateRepository) FindAll(ctx context.Context) ([]money.ExchangeRate, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	rates := []money.ExchangeRate{}
+	for _, rate := range r.rates {
+		rates = append(rates, cloneDocument(rate))
+	}
+	sort.Slice(rates, func(i, j int) bool { return rates[i].Currency < rates[j].Currency })
+	return rates, nil
+}
+
+func (r *memoryExchangeRateRepository) Save(ctx context.Context, rate *money.ExchangeRate) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.rates[rate.Currency] = cloneDocument(*rate)
+	return nil
+}
+
+func (r *memoryExchangeRateRepository) Delete(ctx context.Context, currency money.Currency) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, found := r.rates[currency]; !found {
+		return ErrNotFound
+	}
+	delete(r.rates, currency)
+	return nil
+}
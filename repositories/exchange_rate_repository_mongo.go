@@ -0,0 +1,48 @@
+package repositories
+
+import (
+	"context"
+
+	"github.com/YassinNouh21/GoShopCart-Ecommerce/money"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// mongoExchangeRateRepository is the ExchangeRateRepository backed by the exchange_rates collection.
+type mongoExchangeRateRepository struct {
+	collection *mongo.Collection
+}
+
+// NewMongoExchangeRateRepository creates an ExchangeRateRepository on top of the given collection.
+func NewMongoExchangeRateRepository(collection *mongo.Collection) ExchangeRateRepository {
+	return &mongoExchangeRateRepository{collection: collection}
+}
+
+func (r *mongoExchangeRateRepository) FindAll(ctx context.Context) ([]money.ExchangeRate, error) {
+	cursor, err := r.collection.Find(ctx, bson.M{}, options.Find().SetSort(bson.D{{Key: "_id", Value: 1}}))
+	if err != nil {
+		return nil, err
+	}
+	rates := []money.ExchangeRate{}
+	if err := cursor.All(ctx, &rates); err != nil {
+		return nil, err
+	}
+	return rates, nil
+}
+
+func (r *mongoExchangeRateRepository) Save(ctx context.Context, rate *money.ExchangeRate) error {
+	_, err := r.collection.ReplaceOne(ctx, bson.M{"_id": rate.Currency}, rate, options.Replace().SetUpsert(true))
+	return err
+}
+
+func (r *mongoExchangeRateRepository) Delete(ctx context.Context, currency money.Currency) error {
+	result, err := r.collection.DeleteOne(ctx, bson.M{"_id": currency})
+	if err != nil {
+		return err
+	}
+	if result.DeletedCount == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
@@ -0,0 +1,29 @@
+package repositories
+
+import (
+	"context"
+	"time"
+
+	userModel "github.com/YassinNouh21/GoShopCart-Ecommerce/models/user"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// GuestCartRepository stores the carts of the visitors who have not signed in. Every change of a cart
+// moves its expiry to the given time, and expired carts are treated as missing.
+type GuestCartRepository interface {
+	// FindByID returns the guest cart with the given ID or ErrNotFound if it does not exist or expired.
+	FindByID(ctx context.Context, id primitive.ObjectID) (*userModel.GuestCart, error)
+	// Create stores a new guest cart.
+	Create(ctx context.Context, cart *userModel.GuestCart) error
+	// AddItem appends a new item to the guest cart or returns ErrNotFound.
+	AddItem(ctx context.Context, id primitive.ObjectID, item userModel.Cart, expiresAt time.Time) error
+	// IncrementQuantity adds quantity to the item of the guest cart holding the product in the given
+	// variant or returns ErrNotFound.
+	IncrementQuantity(ctx context.Context, id primitive.ObjectID, productID primitive.ObjectID, variantID *primitive.ObjectID, quantity int, expiresAt time.Time) error
+	// SetItems replaces every item of the guest cart or returns ErrNotFound.
+	SetItems(ctx context.Context, id primitive.ObjectID, items []userModel.Cart, expiresAt time.Time) error
+	// RemoveItem removes the item with the cart ID from the guest cart or returns ErrNotFound.
+	RemoveItem(ctx context.Context, id primitive.ObjectID, cartID primitive.ObjectID, expiresAt time.Time) error
+	// Delete removes the guest cart with the given ID or returns ErrNotFound.
+	Delete(ctx context.Context, id primitive.ObjectID) error
+}
@@ -0,0 +1,136 @@
+package repositories
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	userModel "github.com/YassinNouh21/GoShopCart-Ecommerce/models/user"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// memoryGuestCartRepository is a GuestCartRepository that keeps the guest carts in memory. Expired
+// carts are removed when they are next looked up.
+type memoryGuestCartRepository struct {
+	mu    sync.Mutex
+	carts map[primitive.ObjectID]userModel.GuestCart
+}
+
+// NewMemoryGuestCartRepository creates an empty in-memory GuestCartRepository.
+func NewMemoryGuestCartRepository() GuestCartRepository {
+	return &memoryGuestCartRepository{carts: map[primitive.ObjectID]userModel.GuestCart{}}
+}
+
+// live returns the guest cart with the ID if it has not expired, removing it if it has.
+// The caller must hold the lock.
+func (r *memoryGuestCartRepository) live(id primitive.ObjectID) (userModel.GuestCart, bool) {
+	cart, found := r.carts[id]
+	if found && !cart.ExpiresAt.After(time.Now()) {
+		delete(r.carts, id)
+		return cart, false
+	}
+	return cart, found
+}
+
+// touch stores the changed guest cart with its new expiry. The caller must hold the lock.
+func (r *memoryGuestCartRepository) touch(cart userModel.GuestCart, expiresAt time.Time) {
+	cart.UpdatedAt = time.Now().UTC()
+	cart.ExpiresAt = expiresAt
+	r.carts[cart.GuestCartID] = cart
+}
+
+func (r *memoryGuestCartRepository) FindByID(ctx context.Context, id primitive.ObjectID) (*userModel.GuestCart, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	cart, found := r.live(id)
+	if !found {
+		return nil, ErrNotFound
+	}
+	cart = cloneDocument(cart)
+	if cart.Items == nil {
+		cart.Items = []userModel.Cart{}
+	}
+	return &cart, nil
+}
+
+func (r *memoryGuestCartRepository) Create(ctx context.Context, cart *userModel.GuestCart) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, found := r.carts[cart.GuestCartID]; found {
+		return ErrDuplicate
+	}
+	r.carts[cart.GuestCartID] = cloneDocument(*cart)
+	return nil
+}
+
+func (r *memoryGuestCartRepository) AddItem(ctx context.Context, id primitive.ObjectID, item userModel.Cart, expiresAt time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	cart, found := r.live(id)
+	if !found {
+		return ErrNotFound
+	}
+	cart.Items = append(cart.Items, cloneDocument(item))
+	r.touch(cart, expiresAt)
+	return nil
+}
+
+func (r *memoryGuestCartRepository) IncrementQuantity(ctx context.Context, id primitive.ObjectID, productID primitive.ObjectID, variantID *primitive.ObjectID, quantity int, expiresAt time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	cart, found := r.live(id)
+	if !found {
+		return ErrNotFound
+	}
+	for i, item := range cart.Items {
+		if item.Holds(productID, variantID) {
+			cart.Items[i].Quantity += quantity
+			cart.Items[i].UpdatedAt = time.Now().UTC()
+			r.touch(cart, expiresAt)
+			return nil
+		}
+	}
+	return ErrNotFound
+}
+
+func (r *memoryGuestCartRepository) SetItems(ctx context.Context, id primitive.ObjectID, items []userModel.Cart, expiresAt time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	cart, found := r.live(id)
+	if !found {
+		return ErrNotFound
+	}
+	cart.Items = []userModel.Cart{}
+	for _, item := range items {
+		cart.Items = append(cart.Items, cloneDocument(item))
+	}
+	r.touch(cart, expiresAt)
+	return nil
+}
+
+func (r *memoryGuestCartRepository) RemoveItem(ctx context.Context, id primitive.ObjectID, cartID primitive.ObjectID, expiresAt time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	cart, found := r.live(id)
+	if !found {
+		return ErrNotFound
+	}
+	for i, stored := range cart.Items {
+		if stored.CartID == cartID {
+			cart.Items = append(cart.Items[:i:i], cart.Items[i+1:]...)
+			r.touch(cart, expiresAt)
+			return nil
+		}
+	}
+	return ErrNotFound
+}
+
+func (r *memoryGuestCartRepository) Delete(ctx context.Context, id primitive.ObjectID) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, found := r.live(id); !found {
+		return ErrNotFound
+	}
+	delete(r.carts, id)
+	return nil
+}
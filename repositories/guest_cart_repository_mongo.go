@@ -0,0 +1,107 @@
+package repositories
+
+import (
+	"context"
+	"time"
+
+	userModel "github.com/YassinNouh21/GoShopCart-Ecommerce/models/user"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// mongoGuestCartRepository is the GuestCartRepository backed by the guest_carts collection. The TTL
+// index on expires_at removes the expired carts; until it does, the queries skip them.
+type mongoGuestCartRepository struct {
+	collection *mongo.Collection
+}
+
+// NewMongoGuestCartRepository creates a GuestCartRepository on top of the given collection.
+func NewMongoGuestCartRepository(collection *mongo.Collection) GuestCartRepository {
+	return &mongoGuestCartRepository{collection: collection}
+}
+
+// liveCart matches the guest cart with the ID if it has not expired.
+func liveCart(id primitive.ObjectID) bson.M {
+	return bson.M{"_id": id, "expires_at": bson.M{"$gt": time.Now().UTC()}}
+}
+
+func (r *mongoGuestCartRepository) FindByID(ctx context.Context, id primitive.ObjectID) (*userModel.GuestCart, error) {
+	var cart userModel.GuestCart
+	err := r.collection.FindOne(ctx, liveCart(id)).Decode(&cart)
+	if err == mongo.ErrNoDocuments {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	if cart.Items == nil {
+		cart.Items = []userModel.Cart{}
+	}
+	return &cart, nil
+}
+
+func (r *mongoGuestCartRepository) Create(ctx context.Context, cart *userModel.GuestCart) error {
+	_, err := r.collection.InsertOne(ctx, cart)
+	return err
+}
+
+func (r *mongoGuestCartRepository) AddItem(ctx context.Context, id primitive.ObjectID, item userModel.Cart, expiresAt time.Time) error {
+	update := bson.M{
+		"$push": bson.M{"items": item},
+		"$set":  bson.M{"updated_at": time.Now().UTC(), "expires_at": expiresAt},
+	}
+	return r.updateOne(ctx, liveCart(id), update)
+}
+
+func (r *mongoGuestCartRepository) IncrementQuantity(ctx context.Context, id primitive.ObjectID, productID primitive.ObjectID, variantID *primitive.ObjectID, quantity int, expiresAt time.Time) error {
+	filter := liveCart(id)
+	filter["items"] = itemFilter(productID, variantID)
+	now := time.Now().UTC()
+	update := bson.M{
+		"$inc": bson.M{"items.$.quantity": quantity},
+		"$set": bson.M{"items.$.updated_at": now, "updated_at": now, "expires_at": expiresAt},
+	}
+	return r.updateOne(ctx, filter, update)
+}
+
+func (r *mongoGuestCartRepository) SetItems(ctx context.Context, id primitive.ObjectID, items []userModel.Cart, expiresAt time.Time) error {
+	if items == nil {
+		items = []userModel.Cart{}
+	}
+	update := bson.M{"$set": bson.M{"items": items, "updated_at": time.Now().UTC(), "expires_at": expiresAt}}
+	return r.updateOne(ctx, liveCart(id), update)
+}
+
+func (r *mongoGuestCartRepository) RemoveItem(ctx context.Context, id primitive.ObjectID, cartID primitive.ObjectID, expiresAt time.Time) error {
+	filter := liveCart(id)
+	filter["items._id"] = cartID
+	update := bson.M{
+		"$pull": bson.M{"items": bson.M{"_id": cartID}},
+		"$set":  bson.M{"updated_at": time.Now().UTC(), "expires_at": expiresAt},
+	}
+	return r.updateOne(ctx, filter, update)
+}
+
+func (r *mongoGuestCartRepository) Delete(ctx context.Context, id primitive.ObjectID) error {
+	result, err := r.collection.DeleteOne(ctx, liveCart(id))
+	if err != nil {
+		return err
+	}
+	if result.DeletedCount == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// updateOne applies the update and returns ErrNotFound if the filter matched no guest cart.
+func (r *mongoGuestCartRepository) updateOne(ctx context.Context, filter bson.M, update bson.M) error {
+	result, err := r.collection.UpdateOne(ctx, filter, update)
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
@@ -0,0 +1,33 @@
+package repositories
+
+import (
+	"context"
+	"time"
+
+	userModel "github.com/YassinNouh21/GoShopCart-Ecommerce/models/user"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// OrderRepository stores the orders placed at checkout.
+type OrderRepository interface {
+	// Create inserts a new order.
+	Create(ctx context.Context, order *userModel.Order) error
+	// FindByID returns the order with the given ID or ErrNotFound.
+	FindByID(ctx context.Context, id primitive.ObjectID) (*userModel.Order, error)
+	// FindByUser returns the orders of the user, newest first.
+	FindByUser(ctx context.Context, userID primitive.ObjectID) ([]userModel.Order, error)
+	// FindByStatus returns the orders in the given status, newest first.
+	// An empty status returns every order.
+	FindByStatus(ctx context.Context, status userModel.OrderStatus) ([]userModel.Order, error)
+	// FindExpiredReservations returns the orders still awaiting payment whose stock reservation
+	// expired before the given time, oldest first.
+	FindExpiredReservations(ctx context.Context, before time.Time) ([]userModel.Order, error)
+	// UpdateStatus applies the status change if the order is still in change.From and
+	// appends it to the status history. It returns ErrNotFound if the order does not exist
+	// and ErrVersionConflict if its status changed in the meantime.
+	UpdateStatus(ctx context.Context, id primitive.ObjectID, change userModel.OrderStatusChange) error
+	// HasDeliveredProduct reports whether the user has a delivered order containing the product.
+	HasDeliveredProduct(ctx context.Context, userID, productID primitive.ObjectID) (bool, error)
+	// UpdatePayment replaces the payment details of the order or returns ErrNotFound.
+	UpdatePayment(ctx context.Context, id primitive.ObjectID, payment userModel.OrderPayment) error
+}
@@ -0,0 +1,117 @@
+package repositories
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	userModel "github.com/YassinNouh21/GoShopCart-Ecommerce/models/user"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// memoryOrderRepository is an OrderRepository that keeps the orders in memory.
+type memoryOrderRepository struct {
+	mu     sync.RWMutex
+	orders map[primitive.ObjectID]userModel.Order
+}
+
+// NewMemoryOrderRepository creates an empty in-memory OrderRepository.
+func NewMemoryOrderRepository() OrderRepository {
+	return &memoryOrderRepository{orders: map[primitive.ObjectID]userModel.Order{}}
+}
+
+func (r *memoryOrderRepository) Create(ctx context.Context, order *userModel.Order) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.orders[order.OrderID] = cloneDocument(*order)
+	return nil
+}
+
+func (r *memoryOrderRepository) FindByID(ctx context.Context, id primitive.ObjectID) (*userModel.Order, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	order, found := r.orders[id]
+	if !found {
+		return nil, ErrNotFound
+	}
+	order = cloneDocument(order)
+	return &order, nil
+}
+
+func (r *memoryOrderRepository) FindByUser(ctx context.Context, userID primitive.ObjectID) ([]userModel.Order, error) {
+	return r.filter(func(order userModel.Order) bool { return order.UserID == userID }), nil
+}
+
+func (r *memoryOrderRepository) FindByStatus(ctx context.Context, status userModel.OrderStatus) ([]userModel.Order, error) {
+	return r.filter(func(order userModel.Order) bool { return status == "" || order.Status == status }), nil
+}
+
+func (r *memoryOrderRepository) FindExpiredReservations(ctx context.Context, before time.Time) ([]userModel.Order, error) {
+	orders := r.filter(func(order userModel.Order) bool {
+		return order.Status == userModel.OrderStatusPendingPayment && order.ReservedUntil != nil && order.ReservedUntil.Before(before)
+	})
+	sort.SliceStable(orders, func(i, j int) bool { return orders[i].ReservedUntil.Before(*orders[j].ReservedUntil) })
+	return orders, nil
+}
+
+func (r *memoryOrderRepository) UpdateStatus(ctx context.Context, id primitive.ObjectID, change userModel.OrderStatusChange) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	order, found := r.orders[id]
+	if !found {
+		return ErrNotFound
+	}
+	if order.Status != change.From {
+		return ErrVersionConflict
+	}
+	order = cloneDocument(order)
+	order.Status = change.To
+	order.UpdatedAt = change.ChangedAt
+	order.StatusHistory = append(order.StatusHistory, change)
+	r.orders[id] = cloneDocument(order)
+	return nil
+}
+
+func (r *memoryOrderRepository) HasDeliveredProduct(ctx context.Context, userID, productID primitive.ObjectID) (bool, error) {
+	orders := r.filter(func(order userModel.Order) bool {
+		if order.UserID != userID || order.Status != userModel.OrderStatusDelivered {
+			return false
+		}
+		for _, item := range order.Items {
+			if item.ProductID == productID {
+				return true
+			}
+		}
+		return false
+	})
+	return len(orders) > 0, nil
+}
+
+func (r *memoryOrderRepository) UpdatePayment(ctx context.Context, id primitive.ObjectID, payment userModel.OrderPayment) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	order, found := r.orders[id]
+	if !found {
+		return ErrNotFound
+	}
+	order = cloneDocument(order)
+	order.Payment = &payment
+	order.UpdatedAt = payment.UpdatedAt
+	r.orders[id] = cloneDocument(order)
+	return nil
+}
+
+// filter returns copies of the orders matching the predicate, newest first.
+func (r *memoryOrderRepository) filter(match func(userModel.Order) bool) []userModel.Order {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	orders := []userModel.Order{}
+	for _, order := range r.orders {
+		if match(order) {
+			orders = append(orders, cloneDocument(order))
+		}
+	}
+	sort.Slice(orders, func(i, j int) bool { return orders[i].CreatedAt.After(orders[j].CreatedAt) })
+	return orders
+}
@@ -0,0 +1,122 @@
+package repositories
+
+import (
+	"context"
+	"time"
+
+	userModel "github.com/YassinNouh21/GoShopCart-Ecommerce/models/user"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// mongoOrderRepository is the OrderRepository backed by the orders collection.
+type mongoOrderRepository struct {
+	collection *mongo.Collection
+}
+
+// NewMongoOrderRepository creates an OrderRepository on top of the given collection.
+func NewMongoOrderRepository(collection *mongo.Collection) OrderRepository {
+	return &mongoOrderRepository{collection: collection}
+}
+
+func (r *mongoOrderRepository) Create(ctx context.Context, order *userModel.Order) error {
+	_, err := r.collection.InsertOne(ctx, order)
+	return err
+}
+
+func (r *mongoOrderRepository) FindByID(ctx context.Context, id primitive.ObjectID) (*userModel.Order, error) {
+	var order userModel.Order
+	err := r.collection.FindOne(ctx, bson.M{"_id": id}).Decode(&order)
+	if err == mongo.ErrNoDocuments {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &order, nil
+}
+
+func (r *mongoOrderRepository) FindByUser(ctx context.Context, userID primitive.ObjectID) ([]userModel.Order, error) {
+	return r.find(ctx, bson.M{"user_id": userID})
+}
+
+func (r *mongoOrderRepository) FindByStatus(ctx context.Context, status userModel.OrderStatus) ([]userModel.Order, error) {
+	filter := bson.M{}
+	if status != "" {
+		filter["status"] = status
+	}
+	return r.find(ctx, filter)
+}
+
+func (r *mongoOrderRepository) FindExpiredReservations(ctx context.Context, before time.Time) ([]userModel.Order, error) {
+	filter := bson.M{
+		"status":         userModel.OrderStatusPendingPayment,
+		"reserved_until": bson.M{"$lt": before},
+	}
+	opts := options.Find().SetSort(bson.D{{Key: "reserved_until", Value: 1}})
+	cursor, err := r.collection.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, err
+	}
+	orders := []userModel.Order{}
+	if err := cursor.All(ctx, &orders); err != nil {
+		return nil, err
+	}
+	return orders, nil
+}
+
+func (r *mongoOrderRepository) UpdateStatus(ctx context.Context, id primitive.ObjectID, change userModel.OrderStatusChange) error {
+	filter := bson.M{"_id": id, "status": change.From}
+	update := bson.M{
+		"$set":  bson.M{"status": change.To, "updated_at": change.ChangedAt},
+		"$push": bson.M{"status_history": change},
+	}
+	result, err := r.collection.UpdateOne(ctx, filter, update)
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		if _, err := r.FindByID(ctx, id); err != nil {
+			return err
+		}
+		return ErrVersionConflict
+	}
+	return nil
+}
+
+func (r *mongoOrderRepository) HasDeliveredProduct(ctx context.Context, userID, productID primitive.ObjectID) (bool, error) {
+	filter := bson.M{"user_id": userID, "status": userModel.OrderStatusDelivered, "items.product_id": productID}
+	count, err := r.collection.CountDocuments(ctx, filter, options.Count().SetLimit(1))
+	if err != nil {
+		return false, err
+	}
+	return count > 0, nil
+}
+
+func (r *mongoOrderRepository) UpdatePayment(ctx context.Context, id primitive.ObjectID, payment userModel.OrderPayment) error {
+	update := bson.M{"$set": bson.M{"payment": payment, "updated_at": payment.UpdatedAt}}
+	result, err := r.collection.UpdateOne(ctx, bson.M{"_id": id}, update)
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// find returns the orders matching the filter, newest first.
+func (r *mongoOrderRepository) find(ctx context.Context, filter bson.M) ([]userModel.Order, error) {
+	opts := options.Find().SetSort(bson.D{{Key: "created_at", Value: -1}})
+	cursor, err := r.collection.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, err
+	}
+	orders := []userModel.Order{}
+	if err := cursor.All(ctx, &orders); err != nil {
+		return nil, err
+	}
+	return orders, nil
+}
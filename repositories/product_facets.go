@@ -0,0 +1,84 @@
+package repositories
+
+import (
+	productModel "github.com/YassinNouh21/GoShopCart-Ecommerce/models/product"
+	"github.com/YassinNouh21/GoShopCart-Ecommerce/money"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+var (
+	// PriceFacetBoundaries are the lower bounds of the price buckets, in whole units of the store
+	// currency. The last bucket has no upper bound.
+	PriceFacetBoundaries = []int64{0, 25, 50, 100, 200, 500}
+
+	// RatingFacetThresholds are the minimum ratings the rating facet counts products for.
+	RatingFacetThresholds = []float64{4, 3, 2, 1}
+)
+
+// PriceBucket counts the products priced from Min up to, but excluding, Max. A nil Max is unbounded.
+type PriceBucket struct {
+	Min   money.Money  `json:"min"`
+	Max   *money.Money `json:"max"`
+	Count int64        `json:"count"`
+}
+
+// RatingBucket counts the products rated at least MinRating.
+type RatingBucket struct {
+	MinRating float64 `json:"min_rating"`
+	Count     int64   `json:"count"`
+}
+
+// CategoryCount counts the products listed in a category.
+type CategoryCount struct {
+	CategoryID primitive.ObjectID `json:"category_id"`
+	Count      int64              `json:"count"`
+}
+
+// ProductFacets summarizes the products matching a filter, so clients can show how many products
+// each refinement of the filter would leave.
+type ProductFacets struct {
+	Prices []PriceBucket `json:"prices"`
+	// Ratings holds one bucket per threshold of RatingFacetThresholds, in the same order.
+	Ratings []RatingBucket `json:"ratings"`
+	// Categories is sorted by count, largest first, and leaves out categories without products.
+	Categories []CategoryCount `json:"categories"`
+}
+
+// newProductFacets returns facets with every price and rating bucket and no products.
+func newProductFacets() *ProductFacets {
+	facets := &ProductFacets{Prices: []PriceBucket{}, Ratings: []RatingBucket{}, Categories: []CategoryCount{}}
+	boundaries := priceFacetBoundaries()
+	for i, boundary := range boundaries {
+		bucket := PriceBucket{Min: boundary}
+		if i+1 < len(boundaries) {
+			upper := boundaries[i+1]
+			bucket.Max = &upper
+		}
+		facets.Prices = append(facets.Prices, bucket)
+	}
+	for _, threshold := range RatingFacetThresholds {
+		facets.Ratings = append(facets.Ratings, RatingBucket{MinRating: threshold})
+	}
+	return facets
+}
+
+// priceFacetBoundaries returns the lower bounds of the price buckets in the store currency.
+func priceFacetBoundaries() []money.Money {
+	currency := money.StoreCurrency()
+	boundaries := []money.Money{}
+	for _, boundary := range PriceFacetBoundaries {
+		boundaries = append(boundaries, money.FromMajor(boundary, currency))
+	}
+	return boundaries
+}
+
+// priceBucketOf returns the index of the price bucket of the product.
+func priceBucketOf(product productModel.Product) int {
+	bucket := 0
+	for i, boundary := range priceFacetBoundaries() {
+		if product.Price.Amount >= boundary.Amount {
+			bucket = i
+		}
+	}
+	return bucket
+}
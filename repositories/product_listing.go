@@ -0,0 +1,232 @@
+package repositories
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"reflect"
+	"strings"
+	"time"
+
+	productModel "github.com/YassinNouh21/GoShopCart-Ecommerce/models/product"
+	"github.com/YassinNouh21/GoShopCart-Ecommerce/money"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+var (
+	// ErrInvalidCursor is returned when a pagination cursor is malformed or was issued for another sort.
+	ErrInvalidCursor = errors.New("invalid cursor")
+
+	// ErrInvalidSort is returned when a product listing is sorted by an unknown key.
+	ErrInvalidSort = errors.New("invalid sort")
+
+	// ErrInvalidField is returned when a projection names a field the product does not have.
+	ErrInvalidField = errors.New("invalid field")
+)
+
+// ProductFilter selects the products of a listing. Zero fields do not filter.
+type ProductFilter struct {
+	// IDs matches the products with one of the IDs, such as the results of a search. Nil does not
+	// filter, while an empty slice matches no product.
+	IDs []primitive.ObjectID
+	// MinPrice and MaxPrice bound the price, in the store currency; a nil bound leaves that side of
+	// the range open.
+	MinPrice *money.Money
+	MaxPrice *money.Money
+	// MinRating matches the products rated at least this much.
+	MinRating *float64
+	// CategoryIDs matches the products listed in at least one of the categories.
+	CategoryIDs []primitive.ObjectID
+	// InStock matches the products with stock left, or with a variant that has stock left.
+	InStock bool
+}
+
+// ProductSort orders a product listing by one field. Products with the same value are ordered by ID,
+// so every listing has a stable order that cursors can resume from.
+type ProductSort struct {
+	key        string
+	field      string
+	descending bool
+}
+
+// productSorts lists the sort keys accepted by ParseProductSort.
+var productSorts = map[string]ProductSort{
+	"price":   {key: "price", field: "price.amount"},
+	"-price":  {key: "-price", field: "price.amount", descending: true},
+	"rating":  {key: "rating", field: "rating"},
+	"-rating": {key: "-rating", field: "rating", descending: true},
+	"name":    {key: "name", field: "product_name"},
+	"-name":   {key: "-name", field: "product_name", descending: true},
+	"newest":  {key: "newest", field: "created_at", descending: true},
+	"oldest":  {key: "oldest", field: "created_at"},
+}
+
+// ParseProductSort returns the sort for a key: price, rating or name, prefixed with "-" to sort
+// descending, newest or oldest. It returns ErrInvalidSort for any other key.
+func ParseProductSort(key string) (ProductSort, error) {
+	sort, found := productSorts[key]
+	if !found {
+		return ProductSort{}, ErrInvalidSort
+	}
+	return sort, nil
+}
+
+// Key returns the key the sort was parsed from.
+func (s ProductSort) Key() string {
+	return s.key
+}
+
+// ProductListOptions selects the page of a listing.
+type ProductListOptions struct {
+	// Sort orders the products.
+	Sort ProductSort
+	// Limit is the maximum number of products on the page.
+	Limit int
+	// Cursor resumes the listing after the last product of a previous page.
+	Cursor string
+	// Offset skips products at the start of the listing, or after the cursor.
+	Offset int
+	// Fields restricts the stored fields that are read, by JSON name. The product ID and the sort
+	// field are always read. Empty reads every field.
+	Fields []string
+}
+
+// ProductPage is one page of a product listing.
+type ProductPage struct {
+	Products []productModel.Product
+	// NextCursor resumes the listing after this page. It is empty on the last page.
+	NextCursor string
+	// Total is the number of products matching the filter, on all pages.
+	Total int64
+}
+
+// productFields maps the JSON name of every product field to its BSON name.
+var productFields = fieldNames(reflect.TypeOf(productModel.Product{}))
+
+// ParseProductFields splits a comma separated list of product fields, by JSON name.
+// It returns ErrInvalidField if one of them is not a field of the product.
+func ParseProductFields(value string) ([]string, error) {
+	if value == "" {
+		return nil, nil
+	}
+	fields := []string{}
+	for _, field := range strings.Split(value, ",") {
+		field = strings.TrimSpace(field)
+		if _, found := productFields[field]; !found {
+			return nil, ErrInvalidField
+		}
+		fields = append(fields, field)
+	}
+	return fields, nil
+}
+
+// fieldNames maps the JSON names of the fields of a struct type to their BSON names.
+func fieldNames(structType reflect.Type) map[string]string {
+	names := map[string]string{}
+	for i := 0; i < structType.NumField(); i++ {
+		field := structType.Field(i)
+		jsonName := strings.Split(field.Tag.Get("json"), ",")[0]
+		bsonName := strings.Split(field.Tag.Get("bson"), ",")[0]
+		if jsonName != "" && jsonName != "-" && bsonName != "" && bsonName != "-" {
+			names[jsonName] = bsonName
+		}
+	}
+	return names
+}
+
+// productCursor is the decoded form of a pagination cursor: the sort it was issued for and the
+// sort value and ID of the last product of the page.
+type productCursor struct {
+	Sort  string             `json:"s"`
+	Value json.RawMessage    `json:"v"`
+	ID    primitive.ObjectID `json:"id"`
+}
+
+// encodeCursor returns the cursor resuming the listing after the product.
+func (s ProductSort) encodeCursor(product productModel.Product) string {
+	value, _ := json.Marshal(s.value(product))
+	data, _ := json.Marshal(productCursor{Sort: s.key, Value: value, ID: product.ProductID})
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// decodeCursor returns the sort value and product ID encoded in the cursor.
+func (s ProductSort) decodeCursor(cursor string) (interface{}, primitive.ObjectID, error) {
+	data, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return nil, primitive.NilObjectID, ErrInvalidCursor
+	}
+	var decoded productCursor
+	if err := json.Unmarshal(data, &decoded); err != nil || decoded.Sort != s.key {
+		return nil, primitive.NilObjectID, ErrInvalidCursor
+	}
+	var value interface{}
+	switch s.field {
+	case "price.amount":
+		var amount int64
+		err = json.Unmarshal(decoded.Value, &amount)
+		value = amount
+	case "rating":
+		var number float64
+		err = json.Unmarshal(decoded.Value, &number)
+		value = number
+	case "product_name":
+		var name string
+		err = json.Unmarshal(decoded.Value, &name)
+		value = name
+	case "created_at":
+		var createdAt time.Time
+		err = json.Unmarshal(decoded.Value, &createdAt)
+		value = createdAt
+	}
+	if err != nil {
+		return nil, primitive.NilObjectID, ErrInvalidCursor
+	}
+	return value, decoded.ID, nil
+}
+
+// value returns the value of the sort field of the product.
+func (s ProductSort) value(product productModel.Product) interface{} {
+	switch s.field {
+	case "price.amount":
+		return product.Price.Amount
+	case "rating":
+		return float64(product.Rating)
+	case "product_name":
+		return product.ProductName
+	default:
+		return product.CreatedAt
+	}
+}
+
+// compare orders the product against a sort value and ID, in the direction of the sort.
+// It returns a negative number if the product comes first and a positive one if it comes after.
+func (s ProductSort) compare(product productModel.Product, value interface{}, id primitive.ObjectID) int {
+	result := 0
+	switch current := s.value(product).(type) {
+	case int64:
+		result = compareOrdered(current, value.(int64))
+	case float64:
+		result = compareOrdered(current, value.(float64))
+	case string:
+		result = compareOrdered(current, value.(string))
+	case time.Time:
+		result = compareOrdered(current.UnixMilli(), value.(time.Time).UnixMilli())
+	}
+	if s.descending {
+		result = -result
+	}
+	if result == 0 {
+		result = compareOrdered(product.ProductID.Hex(), id.Hex())
+	}
+	return result
+}
+
+func compareOrdered[T float64 | int64 | string](a, b T) int {
+	if a < b {
+		return -1
+	}
+	if a > b {
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,59 @@
+package repositories
+
+import (
+	"context"
+
+	productModel "github.com/YassinNouh21/GoShopCart-Ecommerce/models/product"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// BulkWriteBatchSize is the number of products written by one bulk write.
+const BulkWriteBatchSize = 500
+
+// ProductUpsert is one write of a bulk upsert. A new product is inserted, and an existing one
+// replaces the stored product if that is still at Version.
+type ProductUpsert struct {
+	Product  *productModel.Product
+	Existing bool
+	Version  int
+}
+
+// ProductRepository stores the products of the catalog.
+type ProductRepository interface {
+	// Create inserts a new product.
+	Create(ctx context.Context, product *productModel.Product) error
+	// FindByID returns the product with the given ID or ErrNotFound.
+	FindByID(ctx context.Context, id primitive.ObjectID) (*productModel.Product, error)
+	// FindByName returns the product with the given name or ErrNotFound.
+	FindByName(ctx context.Context, name string) (*productModel.Product, error)
+	// Exists reports whether a product with the given ID exists.
+	Exists(ctx context.Context, id primitive.ObjectID) (bool, error)
+	// Replace stores the product if the stored one is still at the given version.
+	// It returns ErrNotFound if the product does not exist and ErrVersionConflict if it was modified.
+	Replace(ctx context.Context, product *productModel.Product, version int) error
+	// Delete removes the product with the given ID or returns ErrNotFound.
+	Delete(ctx context.Context, id primitive.ObjectID) error
+	// List returns one page of the products matching the filter, in the order of the sort.
+	// It returns ErrInvalidCursor if the cursor was not issued for the same sort.
+	List(ctx context.Context, filter ProductFilter, listOptions ProductListOptions) (*ProductPage, error)
+	// Facets counts the products matching the filter by price bucket, minimum rating and category.
+	Facets(ctx context.Context, filter ProductFilter) (*ProductFacets, error)
+	// RemoveCategory removes the category from every product listed in it.
+	RemoveCategory(ctx context.Context, categoryID primitive.ObjectID) error
+	// FindBySKU returns the product with a variant of the given SKU or ErrNotFound.
+	FindBySKU(ctx context.Context, sku string) (*productModel.Product, error)
+	// AdjustStock atomically adds delta, which may be negative, to the stock of the variant, or of the
+	// product if variantID is nil, and increments the product version. It returns the updated product,
+	// ErrNotFound if the product or variant does not exist and ErrInsufficientStock if the stock would
+	// drop below zero.
+	AdjustStock(ctx context.Context, id primitive.ObjectID, variantID *primitive.ObjectID, delta int) (*productModel.Product, error)
+	// BulkUpsert applies the upserts in batches of BulkWriteBatchSize. It returns the IDs of the
+	// existing products that were not replaced because they were modified or deleted in the meantime.
+	BulkUpsert(ctx context.Context, upserts []ProductUpsert) ([]primitive.ObjectID, error)
+	// UpdateRating stores the rating summary of the reviews of the product and increments its version.
+	// It returns the updated product or ErrNotFound.
+	UpdateRating(ctx context.Context, id primitive.ObjectID, summary productModel.RatingSummary) (*productModel.Product, error)
+	// FindLowStock returns the products without variants that have at most the given stock and the
+	// products with a variant that has at most the given stock, ordered by ID.
+	FindLowStock(ctx context.Context, threshold int) ([]productModel.Product, error)
+}
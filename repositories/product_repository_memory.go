@@ -0,0 +1,319 @@
+package repositories
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	productModel "github.com/YassinNouh21/GoShopCart-Ecommerce/models/product"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// memoryProductRepository is a ProductRepository that keeps the products in memory.
+type memoryProductRepository struct {
+	mu       sync.RWMutex
+	products map[primitive.ObjectID]productModel.Product
+}
+
+// NewMemoryProductRepository creates an empty in-memory ProductRepository.
+func NewMemoryProductRepository() ProductRepository {
+	return &memoryProductRepository{products: map[primitive.ObjectID]productModel.Product{}}
+}
+
+func (r *memoryProductRepository) Create(ctx context.Context, product *productModel.Product) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.products[product.ProductID] = cloneDocument(*product)
+	return nil
+}
+
+func (r *memoryProductRepository) FindByID(ctx context.Context, id primitive.ObjectID) (*productModel.Product, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	product, found := r.products[id]
+	if !found {
+		return nil, ErrNotFound
+	}
+	product = cloneDocument(product)
+	return &product, nil
+}
+
+func (r *memoryProductRepository) FindByName(ctx context.Context, name string) (*productModel.Product, error) {
+	products := r.filter(func(product productModel.Product) bool { return product.ProductName == name })
+	if len(products) == 0 {
+		return nil, ErrNotFound
+	}
+	return &products[0], nil
+}
+
+func (r *memoryProductRepository) Exists(ctx context.Context, id primitive.ObjectID) (bool, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	_, found := r.products[id]
+	return found, nil
+}
+
+func (r *memoryProductRepository) Replace(ctx context.Context, product *productModel.Product, version int) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	stored, found := r.products[product.ProductID]
+	if !found {
+		return ErrNotFound
+	}
+	if stored.Version != version {
+		return ErrVersionConflict
+	}
+	r.products[product.ProductID] = cloneDocument(*product)
+	return nil
+}
+
+func (r *memoryProductRepository) Delete(ctx context.Context, id primitive.ObjectID) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, found := r.products[id]; !found {
+		return ErrNotFound
+	}
+	delete(r.products, id)
+	return nil
+}
+
+func (r *memoryProductRepository) List(ctx context.Context, filter ProductFilter, listOptions ProductListOptions) (*ProductPage, error) {
+	products := r.filter(func(product productModel.Product) bool { return matchesFilter(product, filter) })
+	sortOrder := listOptions.Sort
+	sort.SliceStable(products, func(i, j int) bool {
+		return sortOrder.compare(products[i], sortOrder.value(products[j]), products[j].ProductID) < 0
+	})
+	page := &ProductPage{Total: int64(len(products))}
+	if listOptions.Cursor != "" {
+		value, id, err := sortOrder.decodeCursor(listOptions.Cursor)
+		if err != nil {
+			return nil, err
+		}
+		start := 0
+		for start < len(products) && sortOrder.compare(products[start], value, id) <= 0 {
+			start++
+		}
+		products = products[start:]
+	}
+	if listOptions.Offset >= len(products) {
+		products = products[:0]
+	} else {
+		products = products[listOptions.Offset:]
+	}
+	if len(products) > listOptions.Limit {
+		products = products[:listOptions.Limit]
+		page.NextCursor = sortOrder.encodeCursor(products[len(products)-1])
+	}
+	page.Products = products
+	return page, nil
+}
+
+func (r *memoryProductRepository) Facets(ctx context.Context, filter ProductFilter) (*ProductFacets, error) {
+	facets := newProductFacets()
+	categoryCounts := map[primitive.ObjectID]int64{}
+	for _, product := range r.filter(func(product productModel.Product) bool { return matchesFilter(product, filter) }) {
+		facets.Prices[priceBucketOf(product)].Count++
+		for i, threshold := range RatingFacetThresholds {
+			if float64(product.Rating) >= threshold {
+				facets.Ratings[i].Count++
+			}
+		}
+		for _, categoryID := range product.CategoryIDs {
+			categoryCounts[categoryID]++
+		}
+	}
+	for categoryID, count := range categoryCounts {
+		facets.Categories = append(facets.Categories, CategoryCount{CategoryID: categoryID, Count: count})
+	}
+	sort.Slice(facets.Categories, func(i, j int) bool {
+		if facets.Categories[i].Count != facets.Categories[j].Count {
+			return facets.Categories[i].Count > facets.Categories[j].Count
+		}
+		return facets.Categories[i].CategoryID.Hex() < facets.Categories[j].CategoryID.Hex()
+	})
+	return facets, nil
+}
+
+func (r *memoryProductRepository) RemoveCategory(ctx context.Context, categoryID primitive.ObjectID) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for id, product := range r.products {
+		if !hasCategory(product, categoryID) {
+			continue
+		}
+		product = cloneDocument(product)
+		categoryIDs := []primitive.ObjectID{}
+		for _, existing := range product.CategoryIDs {
+			if existing != categoryID {
+				categoryIDs = append(categoryIDs, existing)
+			}
+		}
+		product.CategoryIDs = categoryIDs
+		product.Version++
+		product.UpdatedAt = time.Now().UTC()
+		r.products[id] = cloneDocument(product)
+	}
+	return nil
+}
+
+func (r *memoryProductRepository) FindBySKU(ctx context.Context, sku string) (*productModel.Product, error) {
+	products := r.filter(func(product productModel.Product) bool {
+		for _, variant := range product.Variants {
+			if variant.SKU == sku {
+				return true
+			}
+		}
+		return false
+	})
+	if len(products) == 0 {
+		return nil, ErrNotFound
+	}
+	return &products[0], nil
+}
+
+func (r *memoryProductRepository) AdjustStock(ctx context.Context, id primitive.ObjectID, variantID *primitive.ObjectID, delta int) (*productModel.Product, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	product, found := r.products[id]
+	if !found {
+		return nil, ErrNotFound
+	}
+	product = cloneDocument(product)
+	stock := &product.Stock
+	if variantID != nil {
+		variant, found := product.FindVariant(*variantID)
+		if !found {
+			return nil, ErrNotFound
+		}
+		stock = &variant.Stock
+	}
+	if *stock+delta < 0 {
+		return nil, ErrInsufficientStock
+	}
+	*stock += delta
+	product.Version++
+	product.UpdatedAt = time.Now().UTC()
+	r.products[id] = cloneDocument(product)
+	return &product, nil
+}
+
+func (r *memoryProductRepository) BulkUpsert(ctx context.Context, upserts []ProductUpsert) ([]primitive.ObjectID, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	conflicts := []primitive.ObjectID{}
+	for _, upsert := range upserts {
+		if upsert.Existing {
+			stored, found := r.products[upsert.Product.ProductID]
+			if !found || stored.Version != upsert.Version {
+				conflicts = append(conflicts, upsert.Product.ProductID)
+				continue
+			}
+		}
+		r.products[upsert.Product.ProductID] = cloneDocument(*upsert.Product)
+	}
+	return conflicts, nil
+}
+
+func (r *memoryProductRepository) UpdateRating(ctx context.Context, id primitive.ObjectID, summary productModel.RatingSummary) (*productModel.Product, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	product, found := r.products[id]
+	if !found {
+		return nil, ErrNotFound
+	}
+	product = cloneDocument(product)
+	product.Rating = summary.Average
+	product.ReviewCount = summary.Count
+	product.Version++
+	product.UpdatedAt = time.Now().UTC()
+	r.products[id] = cloneDocument(product)
+	return &product, nil
+}
+
+func (r *memoryProductRepository) FindLowStock(ctx context.Context, threshold int) ([]productModel.Product, error) {
+	return r.filter(func(product productModel.Product) bool {
+		if !product.HasVariants() {
+			return product.Stock <= threshold
+		}
+		for _, variant := range product.Variants {
+			if variant.Stock <= threshold {
+				return true
+			}
+		}
+		return false
+	}), nil
+}
+
+// filter returns copies of the products matching the predicate, ordered by ID.
+func (r *memoryProductRepository) filter(match func(productModel.Product) bool) []productModel.Product {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	products := []productModel.Product{}
+	for _, product := range r.products {
+		if match(product) {
+			products = append(products, cloneDocument(product))
+		}
+	}
+	sort.Slice(products, func(i, j int) bool {
+		return products[i].ProductID.Hex() < products[j].ProductID.Hex()
+	})
+	return products
+}
+
+// inStock reports whether the product, or one of its variants, has stock left.
+func inStock(product productModel.Product) bool {
+	if product.Stock > 0 {
+		return true
+	}
+	for _, variant := range product.Variants {
+		if variant.Stock > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+func hasCategory(product productModel.Product, categoryID primitive.ObjectID) bool {
+	for _, existing := range product.CategoryIDs {
+		if existing == categoryID {
+			return true
+		}
+	}
+	return false
+}
+
+// matchesFilter reports whether the product is selected by the filter.
+func matchesFilter(product productModel.Product, filter ProductFilter) bool {
+	if filter.IDs != nil && !containsID(filter.IDs, product.ProductID) {
+		return false
+	}
+	price := product.Price.Amount
+	if (filter.MinPrice != nil && price < filter.MinPrice.Amount) || (filter.MaxPrice != nil && price > filter.MaxPrice.Amount) {
+		return false
+	}
+	if filter.MinRating != nil && float64(product.Rating) < *filter.MinRating {
+		return false
+	}
+	if filter.InStock && !inStock(product) {
+		return false
+	}
+	if len(filter.CategoryIDs) == 0 {
+		return true
+	}
+	for _, categoryID := range filter.CategoryIDs {
+		if hasCategory(product, categoryID) {
+			return true
+		}
+	}
+	return false
+}
+
+func containsID(ids []primitive.ObjectID, id primitive.ObjectID) bool {
+	for _, existing := range ids {
+		if existing == id {
+			return true
+		}
+	}
+	return false
+}
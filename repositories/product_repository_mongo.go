@@ -0,0 +1,400 @@
+package repositories
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	productModel "github.com/YassinNouh21/GoShopCart-Ecommerce/models/product"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// mongoProductRepository is the ProductRepository backed by the products collection.
+type mongoProductRepository struct {
+	collection *mongo.Collection
+}
+
+// NewMongoProductRepository creates a ProductRepository on top of the given collection.
+func NewMongoProductRepository(collection *mongo.Collection) ProductRepository {
+	return &mongoProductRepository{collection: collection}
+}
+
+func (r *mongoProductRepository) Create(ctx context.Context, product *productModel.Product) error {
+	_, err := r.collection.InsertOne(ctx, product)
+	return err
+}
+
+func (r *mongoProductRepository) FindByID(ctx context.Context, id primitive.ObjectID) (*productModel.Product, error) {
+	return r.findOne(ctx, bson.M{"_id": id})
+}
+
+func (r *mongoProductRepository) FindByName(ctx context.Context, name string) (*productModel.Product, error) {
+	return r.findOne(ctx, bson.M{"product_name": name})
+}
+
+func (r *mongoProductRepository) Exists(ctx context.Context, id primitive.ObjectID) (bool, error) {
+	count, err := r.collection.CountDocuments(ctx, bson.M{"_id": id})
+	return count > 0, err
+}
+
+func (r *mongoProductRepository) Replace(ctx context.Context, product *productModel.Product, version int) error {
+	result, err := r.collection.ReplaceOne(ctx, versionFilter(product.ProductID, version), product)
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		// The product may have been deleted or modified since it was read
+		exists, err := r.Exists(ctx, product.ProductID)
+		if err != nil {
+			return err
+		}
+		if !exists {
+			return ErrNotFound
+		}
+		return ErrVersionConflict
+	}
+	return nil
+}
+
+func (r *mongoProductRepository) Delete(ctx context.Context, id primitive.ObjectID) error {
+	result, err := r.collection.DeleteOne(ctx, bson.M{"_id": id})
+	if err != nil {
+		return err
+	}
+	if result.DeletedCount == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+func (r *mongoProductRepository) List(ctx context.Context, filter ProductFilter, listOptions ProductListOptions) (*ProductPage, error) {
+	query := productQuery(filter)
+	total, err := r.collection.CountDocuments(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	sortOrder := listOptions.Sort
+	if listOptions.Cursor != "" {
+		value, id, err := sortOrder.decodeCursor(listOptions.Cursor)
+		if err != nil {
+			return nil, err
+		}
+		// Resume after the last product of the previous page, comparing by sort value and then by ID
+		comparison := "$gt"
+		if sortOrder.descending {
+			comparison = "$lt"
+		}
+		query = bson.M{"$and": bson.A{query, bson.M{"$or": bson.A{
+			bson.M{sortOrder.field: bson.M{comparison: value}},
+			bson.M{sortOrder.field: value, "_id": bson.M{"$gt": id}},
+		}}}}
+	}
+	direction := 1
+	if sortOrder.descending {
+		direction = -1
+	}
+	// One product more than the page is read to know whether another page follows
+	findOptions := options.Find().
+		SetSort(bson.D{{Key: sortOrder.field, Value: direction}, {Key: "_id", Value: 1}}).
+		SetSkip(int64(listOptions.Offset)).
+		SetLimit(int64(listOptions.Limit + 1))
+	if len(listOptions.Fields) > 0 {
+		projection := bson.M{"_id": 1}
+		for _, field := range listOptions.Fields {
+			projection[productFields[field]] = 1
+		}
+		// A sort on a subfield, such as price.amount, is already read with its parent field
+		if _, found := projection[strings.Split(sortOrder.field, ".")[0]]; !found {
+			projection[sortOrder.field] = 1
+		}
+		findOptions.SetProjection(projection)
+	}
+	products, err := r.find(ctx, query, findOptions)
+	if err != nil {
+		return nil, err
+	}
+	page := &ProductPage{Products: products, Total: total}
+	if len(products) > listOptions.Limit {
+		page.Products = products[:listOptions.Limit]
+		page.NextCursor = sortOrder.encodeCursor(page.Products[len(page.Products)-1])
+	}
+	return page, nil
+}
+
+func (r *mongoProductRepository) Facets(ctx context.Context, filter ProductFilter) (*ProductFacets, error) {
+	ratings := bson.M{"_id": nil}
+	for i, threshold := range RatingFacetThresholds {
+		ratings[fmt.Sprintf("r%d", i)] = bson.M{"$sum": bson.M{"$cond": bson.A{bson.M{"$gte": bson.A{"$rating", threshold}}, 1, 0}}}
+	}
+	boundaries := bson.A{}
+	for _, boundary := range priceFacetBoundaries() {
+		boundaries = append(boundaries, boundary.Amount)
+	}
+	// The default bucket collects the prices at or above the last boundary, which has no upper bound
+	lastBoundary := boundaries[len(boundaries)-1]
+	// Every facet is computed over the matching products in a single pass
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: productQuery(filter)}},
+		{{Key: "$facet", Value: bson.M{
+			"prices": bson.A{bson.M{"$bucket": bson.M{
+				"groupBy":    "$price.amount",
+				"boundaries": boundaries,
+				"default":    lastBoundary,
+				"output":     bson.M{"count": bson.M{"$sum": 1}},
+			}}},
+			"ratings": bson.A{bson.M{"$group": ratings}},
+			"categories": bson.A{
+				bson.M{"$unwind": "$category_ids"},
+				bson.M{"$group": bson.M{"_id": "$category_ids", "count": bson.M{"$sum": 1}}},
+				bson.M{"$sort": bson.D{{Key: "count", Value: -1}, {Key: "_id", Value: 1}}},
+			},
+		}}},
+	}
+	cursor, err := r.collection.Aggregate(ctx, pipeline)
+	if err != nil {
+		return nil, err
+	}
+	var results []struct {
+		Prices []struct {
+			Min   int64 `bson:"_id"`
+			Count int64 `bson:"count"`
+		} `bson:"prices"`
+		Ratings    []map[string]int64 `bson:"ratings"`
+		Categories []struct {
+			CategoryID primitive.ObjectID `bson:"_id"`
+			Count      int64              `bson:"count"`
+		} `bson:"categories"`
+	}
+	if err := cursor.All(ctx, &results); err != nil {
+		return nil, err
+	}
+	facets := newProductFacets()
+	if len(results) == 0 {
+		return facets, nil
+	}
+	for _, bucket := range results[0].Prices {
+		for i := range facets.Prices {
+			if facets.Prices[i].Min.Amount == bucket.Min {
+				facets.Prices[i].Count += bucket.Count
+			}
+		}
+	}
+	for _, counts := range results[0].Ratings {
+		for i := range facets.Ratings {
+			facets.Ratings[i].Count = counts[fmt.Sprintf("r%d", i)]
+		}
+	}
+	for _, category := range results[0].Categories {
+		facets.Categories = append(facets.Categories, CategoryCount{CategoryID: category.CategoryID, Count: category.Count})
+	}
+	return facets, nil
+}
+
+func (r *mongoProductRepository) RemoveCategory(ctx context.Context, categoryID primitive.ObjectID) error {
+	update := bson.M{
+		"$pull": bson.M{"category_ids": categoryID},
+		"$inc":  bson.M{"version": 1},
+		"$set":  bson.M{"updated_at": time.Now().UTC()},
+	}
+	_, err := r.collection.UpdateMany(ctx, bson.M{"category_ids": categoryID}, update)
+	return err
+}
+
+func (r *mongoProductRepository) FindBySKU(ctx context.Context, sku string) (*productModel.Product, error) {
+	return r.findOne(ctx, bson.M{"variants.sku": sku})
+}
+
+func (r *mongoProductRepository) AdjustStock(ctx context.Context, id primitive.ObjectID, variantID *primitive.ObjectID, delta int) (*productModel.Product, error) {
+	// The condition and the change are applied in one update, so concurrent checkouts cannot oversell
+	filter := bson.M{"_id": id}
+	stockField := "stock"
+	if variantID != nil {
+		variantFilter := bson.M{"_id": *variantID}
+		if delta < 0 {
+			variantFilter["stock"] = bson.M{"$gte": -delta}
+		}
+		filter["variants"] = bson.M{"$elemMatch": variantFilter}
+		stockField = "variants.$.stock"
+	} else if delta < 0 {
+		filter["stock"] = bson.M{"$gte": -delta}
+	}
+	update := bson.M{
+		"$inc": bson.M{stockField: delta, "version": 1},
+		"$set": bson.M{"updated_at": time.Now().UTC()},
+	}
+	opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
+	var product productModel.Product
+	err := r.collection.FindOneAndUpdate(ctx, filter, update, opts).Decode(&product)
+	if err == mongo.ErrNoDocuments {
+		existing, err := r.FindByID(ctx, id)
+		if err != nil {
+			return nil, err
+		}
+		if variantID != nil {
+			if _, found := existing.FindVariant(*variantID); !found {
+				return nil, ErrNotFound
+			}
+		}
+		return nil, ErrInsufficientStock
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &product, nil
+}
+
+func (r *mongoProductRepository) BulkUpsert(ctx context.Context, upserts []ProductUpsert) ([]primitive.ObjectID, error) {
+	conflicts := []primitive.ObjectID{}
+	for start := 0; start < len(upserts); start += BulkWriteBatchSize {
+		end := start + BulkWriteBatchSize
+		if end > len(upserts) {
+			end = len(upserts)
+		}
+		models := []mongo.WriteModel{}
+		replaced := []primitive.ObjectID{}
+		for _, upsert := range upserts[start:end] {
+			if upsert.Existing {
+				models = append(models, mongo.NewReplaceOneModel().SetFilter(versionFilter(upsert.Product.ProductID, upsert.Version)).SetReplacement(upsert.Product))
+				replaced = append(replaced, upsert.Product.ProductID)
+			} else {
+				models = append(models, mongo.NewInsertOneModel().SetDocument(upsert.Product))
+			}
+		}
+		result, err := r.collection.BulkWrite(ctx, models, options.BulkWrite().SetOrdered(false))
+		if err != nil {
+			return nil, err
+		}
+		if int(result.MatchedCount) == len(replaced) {
+			continue
+		}
+		// Find the products that were not replaced: they do not have the version they were given
+		versions := map[primitive.ObjectID]int{}
+		for _, upsert := range upserts[start:end] {
+			if upsert.Existing {
+				versions[upsert.Product.ProductID] = upsert.Product.Version
+			}
+		}
+		stored, err := r.find(ctx, bson.M{"_id": bson.M{"$in": replaced}}, options.Find().SetProjection(bson.M{"version": 1}))
+		if err != nil {
+			return nil, err
+		}
+		for _, product := range stored {
+			if product.Version == versions[product.ProductID] {
+				delete(versions, product.ProductID)
+			}
+		}
+		for _, productID := range replaced {
+			if _, conflict := versions[productID]; conflict {
+				conflicts = append(conflicts, productID)
+			}
+		}
+	}
+	return conflicts, nil
+}
+
+func (r *mongoProductRepository) UpdateRating(ctx context.Context, id primitive.ObjectID, summary productModel.RatingSummary) (*productModel.Product, error) {
+	update := bson.M{
+		"$set": bson.M{"rating": summary.Average, "review_count": summary.Count, "updated_at": time.Now().UTC()},
+		"$inc": bson.M{"version": 1},
+	}
+	opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
+	var product productModel.Product
+	err := r.collection.FindOneAndUpdate(ctx, bson.M{"_id": id}, update, opts).Decode(&product)
+	if err == mongo.ErrNoDocuments {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &product, nil
+}
+
+func (r *mongoProductRepository) FindLowStock(ctx context.Context, threshold int) ([]productModel.Product, error) {
+	// Products created before inventory tracking have no stock field and count as out of stock
+	filter := bson.M{"$or": bson.A{
+		bson.M{"$and": bson.A{
+			bson.M{"$or": bson.A{
+				bson.M{"variants": bson.M{"$exists": false}},
+				bson.M{"variants": nil},
+				bson.M{"variants": bson.M{"$size": 0}},
+			}},
+			bson.M{"$or": bson.A{
+				bson.M{"stock": bson.M{"$lte": threshold}},
+				bson.M{"stock": bson.M{"$exists": false}},
+			}},
+		}},
+		bson.M{"variants.stock": bson.M{"$lte": threshold}},
+	}}
+	return r.find(ctx, filter, options.Find().SetSort(bson.D{{Key: "_id", Value: 1}}))
+}
+
+func (r *mongoProductRepository) findOne(ctx context.Context, filter bson.M) (*productModel.Product, error) {
+	var product productModel.Product
+	err := r.collection.FindOne(ctx, filter).Decode(&product)
+	if err == mongo.ErrNoDocuments {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &product, nil
+}
+
+func (r *mongoProductRepository) find(ctx context.Context, filter bson.M, opts ...*options.FindOptions) ([]productModel.Product, error) {
+	cursor, err := r.collection.Find(ctx, filter, opts...)
+	if err != nil {
+		return nil, err
+	}
+	products := []productModel.Product{}
+	if err := cursor.All(ctx, &products); err != nil {
+		return nil, err
+	}
+	return products, nil
+}
+
+// productQuery returns the query selecting the products of the filter.
+func productQuery(filter ProductFilter) bson.M {
+	query := bson.M{}
+	if filter.IDs != nil {
+		query["_id"] = bson.M{"$in": filter.IDs}
+	}
+	if filter.MinPrice != nil || filter.MaxPrice != nil {
+		priceFilter := bson.M{}
+		if filter.MinPrice != nil {
+			priceFilter["$gte"] = filter.MinPrice.Amount
+		}
+		if filter.MaxPrice != nil {
+			priceFilter["$lte"] = filter.MaxPrice.Amount
+		}
+		query["price.amount"] = priceFilter
+	}
+	if filter.MinRating != nil {
+		query["rating"] = bson.M{"$gte": *filter.MinRating}
+	}
+	if len(filter.CategoryIDs) > 0 {
+		query["category_ids"] = bson.M{"$in": filter.CategoryIDs}
+	}
+	if filter.InStock {
+		query["$or"] = bson.A{
+			bson.M{"stock": bson.M{"$gt": 0}},
+			bson.M{"variants.stock": bson.M{"$gt": 0}},
+		}
+	}
+	return query
+}
+
+// versionFilter matches a product by ID and version.
+// Products created before versioning have no version field and are treated as version 0.
+func versionFilter(productID primitive.ObjectID, version int) bson.M {
+	if version == 0 {
+		return bson.M{"_id": productID, "$or": bson.A{
+			bson.M{"version": 0},
+			bson.M{"version": bson.M{"$exists": false}},
+		}}
+	}
+	return bson.M{"_id": productID, "version": version}
+}
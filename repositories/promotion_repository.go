@@ -0,0 +1,36 @@
+package repositories
+
+import (
+	"context"
+
+	"github.com/YassinNouh21/GoShopCart-Ecommerce/models/promotion"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// PromotionRepository stores the promotions and the orders that used them.
+type PromotionRepository interface {
+	// Create inserts a new promotion or returns ErrDuplicate if another promotion has its code.
+	Create(ctx context.Context, promotion *promotion.Promotion) error
+	// FindByID returns the promotion with the given ID or ErrNotFound.
+	FindByID(ctx context.Context, id primitive.ObjectID) (*promotion.Promotion, error)
+	// FindByCode returns the coupon with the given code or ErrNotFound.
+	FindByCode(ctx context.Context, code string) (*promotion.Promotion, error)
+	// FindAutomatic returns the active promotions without a code, oldest first.
+	FindAutomatic(ctx context.Context) ([]promotion.Promotion, error)
+	// List returns every promotion, newest first.
+	List(ctx context.Context) ([]promotion.Promotion, error)
+	// Update replaces the promotion if its usage count is still the one of the given promotion. It
+	// returns ErrNotFound if the promotion does not exist, ErrVersionConflict if it was used in the
+	// meantime and ErrDuplicate if another promotion has its code.
+	Update(ctx context.Context, promotion *promotion.Promotion) error
+	// Delete removes the promotion with the given ID or returns ErrNotFound.
+	Delete(ctx context.Context, id primitive.ObjectID) error
+	// CountRedemptions returns how many orders of the user use the promotion.
+	CountRedemptions(ctx context.Context, promotionID primitive.ObjectID, userID primitive.ObjectID) (int, error)
+	// Redeem records the use of the promotion by an order and increments its usage count. It returns
+	// ErrLimitReached if the promotion reached its usage limit, or the user its usage limit per user,
+	// and ErrNotFound if the promotion does not exist.
+	Redeem(ctx context.Context, redemption promotion.Redemption) error
+	// ReleaseRedemptions removes the redemptions of the order and gives their uses back.
+	ReleaseRedemptions(ctx context.Context, orderID primitive.ObjectID) error
+}
@@ -0,0 +1,182 @@
+package repositories
+
+import (
+	"context"
+	"sort"
+	"sync"
+
+	"github.com/YassinNouh21/GoShopCart-Ecommerce/models/promotion"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// memoryPromotionRepository is a PromotionRepository that keeps the promotions and their
+// redemptions in memory.
+type memoryPromotionRepository struct {
+	mu          sync.RWMutex
+	promotions  map[primitive.ObjectID]promotion.Promotion
+	redemptions map[primitive.ObjectID]promotion.Redemption
+}
+
+// NewMemoryPromotionRepository creates an empty in-memory PromotionRepository.
+func NewMemoryPromotionRepository() PromotionRepository {
+	return &memoryPromotionRepository{
+		promotions:  map[primitive.ObjectID]promotion.Promotion{},
+		redemptions: map[primitive.ObjectID]promotion.Redemption{},
+	}
+}
+
+func (r *memoryPromotionRepository) Create(ctx context.Context, p *promotion.Promotion) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.codeTaken(p.Code, p.PromotionID) {
+		return ErrDuplicate
+	}
+	r.promotions[p.PromotionID] = cloneDocument(*p)
+	return nil
+}
+
+func (r *memoryPromotionRepository) FindByID(ctx context.Context, id primitive.ObjectID) (*promotion.Promotion, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	p, found := r.promotions[id]
+	if !found {
+		return nil, ErrNotFound
+	}
+	p = cloneDocument(p)
+	return &p, nil
+}
+
+func (r *memoryPromotionRepository) FindByCode(ctx context.Context, code string) (*promotion.Promotion, error) {
+	promotions := r.filter(func(p promotion.Promotion) bool { return code != "" && p.Code == code })
+	if len(promotions) == 0 {
+		return nil, ErrNotFound
+	}
+	return &promotions[0], nil
+}
+
+func (r *memoryPromotionRepository) FindAutomatic(ctx context.Context) ([]promotion.Promotion, error) {
+	promotions := r.filter(func(p promotion.Promotion) bool { return p.Code == "" && p.Active })
+	sort.Slice(promotions, func(i, j int) bool {
+		if !promotions[i].CreatedAt.Equal(promotions[j].CreatedAt) {
+			return promotions[i].CreatedAt.Before(promotions[j].CreatedAt)
+		}
+		return promotions[i].PromotionID.Hex() < promotions[j].PromotionID.Hex()
+	})
+	return promotions, nil
+}
+
+func (r *memoryPromotionRepository) List(ctx context.Context) ([]promotion.Promotion, error) {
+	promotions := r.filter(func(p promotion.Promotion) bool { return true })
+	sort.Slice(promotions, func(i, j int) bool {
+		if !promotions[i].CreatedAt.Equal(promotions[j].CreatedAt) {
+			return promotions[i].CreatedAt.After(promotions[j].CreatedAt)
+		}
+		return promotions[i].PromotionID.Hex() > promotions[j].PromotionID.Hex()
+	})
+	return promotions, nil
+}
+
+func (r *memoryPromotionRepository) Update(ctx context.Context, p *promotion.Promotion) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	stored, found := r.promotions[p.PromotionID]
+	if !found {
+		return ErrNotFound
+	}
+	if stored.UsageCount != p.UsageCount {
+		return ErrVersionConflict
+	}
+	if r.codeTaken(p.Code, p.PromotionID) {
+		return ErrDuplicate
+	}
+	r.promotions[p.PromotionID] = cloneDocument(*p)
+	return nil
+}
+
+func (r *memoryPromotionRepository) Delete(ctx context.Context, id primitive.ObjectID) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, found := r.promotions[id]; !found {
+		return ErrNotFound
+	}
+	delete(r.promotions, id)
+	return nil
+}
+
+func (r *memoryPromotionRepository) CountRedemptions(ctx context.Context, promotionID primitive.ObjectID, userID primitive.ObjectID) (int, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.countRedemptions(promotionID, userID), nil
+}
+
+func (r *memoryPromotionRepository) Redeem(ctx context.Context, redemption promotion.Redemption) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	p, found := r.promotions[redemption.PromotionID]
+	if !found {
+		return ErrNotFound
+	}
+	if p.UsageLimit > 0 && p.UsageCount >= p.UsageLimit {
+		return ErrLimitReached
+	}
+	if p.UsageLimitPerUser > 0 && r.countRedemptions(p.PromotionID, redemption.UserID) >= p.UsageLimitPerUser {
+		return ErrLimitReached
+	}
+	p.UsageCount++
+	r.promotions[p.PromotionID] = p
+	r.redemptions[redemption.RedemptionID] = redemption
+	return nil
+}
+
+func (r *memoryPromotionRepository) ReleaseRedemptions(ctx context.Context, orderID primitive.ObjectID) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for id, redemption := range r.redemptions {
+		if redemption.OrderID != orderID {
+			continue
+		}
+		delete(r.redemptions, id)
+		if p, found := r.promotions[redemption.PromotionID]; found && p.UsageCount > 0 {
+			p.UsageCount--
+			r.promotions[p.PromotionID] = p
+		}
+	}
+	return nil
+}
+
+// codeTaken reports whether another promotion has the code. The caller holds the lock.
+func (r *memoryPromotionRepository) codeTaken(code string, id primitive.ObjectID) bool {
+	if code == "" {
+		return false
+	}
+	for _, p := range r.promotions {
+		if p.Code == code && p.PromotionID != id {
+			return true
+		}
+	}
+	return false
+}
+
+// countRedemptions counts the redemptions of the promotion by the user. The caller holds the lock.
+func (r *memoryPromotionRepository) countRedemptions(promotionID primitive.ObjectID, userID primitive.ObjectID) int {
+	count := 0
+	for _, redemption := range r.redemptions {
+		if redemption.PromotionID == promotionID && redemption.UserID == userID {
+			count++
+		}
+	}
+	return count
+}
+
+// filter returns copies of the promotions matching the predicate.
+func (r *memoryPromotionRepository) filter(match func(promotion.Promotion) bool) []promotion.Promotion {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	promotions := []promotion.Promotion{}
+	for _, p := range r.promotions {
+		if match(p) {
+			promotions = append(promotions, cloneDocument(p))
+		}
+	}
+	return promotions
+}
@@ -0,0 +1,177 @@
+package repositories
+
+import (
+	"context"
+
+	"github.com/YassinNouh21/GoShopCart-Ecommerce/models/promotion"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// mongoPromotionRepository is the PromotionRepository backed by the promotions and
+// promotion_redemptions collections.
+type mongoPromotionRepository struct {
+	collection  *mongo.Collection
+	redemptions *mongo.Collection
+}
+
+// NewMongoPromotionRepository creates a PromotionRepository on top of the given collections.
+func NewMongoPromotionRepository(collection *mongo.Collection, redemptions *mongo.Collection) PromotionRepository {
+	return &mongoPromotionRepository{collection: collection, redemptions: redemptions}
+}
+
+func (r *mongoPromotionRepository) Create(ctx context.Context, p *promotion.Promotion) error {
+	if p.Code == "" {
+		_, err := r.collection.InsertOne(ctx, p)
+		return err
+	}
+	// The coupon is only inserted if no promotion has its code, in the same operation
+	result, err := r.collection.UpdateOne(ctx, bson.M{"code": p.Code}, bson.M{"$setOnInsert": p}, options.Update().SetUpsert(true))
+	if err != nil {
+		return err
+	}
+	if result.UpsertedCount == 0 {
+		return ErrDuplicate
+	}
+	return nil
+}
+
+func (r *mongoPromotionRepository) FindByID(ctx context.Context, id primitive.ObjectID) (*promotion.Promotion, error) {
+	return r.findOne(ctx, bson.M{"_id": id})
+}
+
+func (r *mongoPromotionRepository) FindByCode(ctx context.Context, code string) (*promotion.Promotion, error) {
+	return r.findOne(ctx, bson.M{"code": code})
+}
+
+func (r *mongoPromotionRepository) FindAutomatic(ctx context.Context) ([]promotion.Promotion, error) {
+	filter := bson.M{"code": bson.M{"$exists": false}, "active": true}
+	return r.find(ctx, filter, options.Find().SetSort(bson.D{{Key: "created_at", Value: 1}, {Key: "_id", Value: 1}}))
+}
+
+func (r *mongoPromotionRepository) List(ctx context.Context) ([]promotion.Promotion, error) {
+	return r.find(ctx, bson.M{}, options.Find().SetSort(bson.D{{Key: "created_at", Value: -1}, {Key: "_id", Value: -1}}))
+}
+
+func (r *mongoPromotionRepository) Update(ctx context.Context, p *promotion.Promotion) error {
+	if p.Code != "" {
+		count, err := r.collection.CountDocuments(ctx, bson.M{"code": p.Code, "_id": bson.M{"$ne": p.PromotionID}})
+		if err != nil {
+			return err
+		}
+		if count > 0 {
+			return ErrDuplicate
+		}
+	}
+	// The promotion is only replaced while its usage count is unchanged, so no use is lost
+	result, err := r.collection.ReplaceOne(ctx, bson.M{"_id": p.PromotionID, "usage_count": p.UsageCount}, p)
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		if _, err := r.FindByID(ctx, p.PromotionID); err != nil {
+			return err
+		}
+		return ErrVersionConflict
+	}
+	return nil
+}
+
+func (r *mongoPromotionRepository) Delete(ctx context.Context, id primitive.ObjectID) error {
+	result, err := r.collection.DeleteOne(ctx, bson.M{"_id": id})
+	if err != nil {
+		return err
+	}
+	if result.DeletedCount == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+func (r *mongoPromotionRepository) CountRedemptions(ctx context.Context, promotionID primitive.ObjectID, userID primitive.ObjectID) (int, error) {
+	count, err := r.redemptions.CountDocuments(ctx, bson.M{"promotion_id": promotionID, "user_id": userID})
+	return int(count), err
+}
+
+func (r *mongoPromotionRepository) Redeem(ctx context.Context, redemption promotion.Redemption) error {
+	stored, err := r.FindByID(ctx, redemption.PromotionID)
+	if err != nil {
+		return err
+	}
+	if stored.UsageLimitPerUser > 0 {
+		used, err := r.CountRedemptions(ctx, redemption.PromotionID, redemption.UserID)
+		if err != nil {
+			return err
+		}
+		if used >= stored.UsageLimitPerUser {
+			return ErrLimitReached
+		}
+	}
+	// The usage count is only incremented while it is below the limit, so two orders can never take
+	// the last use together
+	filter := bson.M{"_id": redemption.PromotionID, "$or": bson.A{
+		bson.M{"usage_limit": 0},
+		bson.M{"$expr": bson.M{"$lt": bson.A{"$usage_count", "$usage_limit"}}},
+	}}
+	result, err := r.collection.UpdateOne(ctx, filter, bson.M{"$inc": bson.M{"usage_count": 1}})
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return ErrLimitReached
+	}
+	_, err = r.redemptions.InsertOne(ctx, redemption)
+	return err
+}
+
+func (r *mongoPromotionRepository) ReleaseRedemptions(ctx context.Context, orderID primitive.ObjectID) error {
+	cursor, err := r.redemptions.Find(ctx, bson.M{"order_id": orderID})
+	if err != nil {
+		return err
+	}
+	var redemptions []promotion.Redemption
+	if err := cursor.All(ctx, &redemptions); err != nil {
+		return err
+	}
+	for _, redemption := range redemptions {
+		// Only the redemption actually removed gives its use back, so releasing twice is harmless
+		result, err := r.redemptions.DeleteOne(ctx, bson.M{"_id": redemption.RedemptionID})
+		if err != nil {
+			return err
+		}
+		if result.DeletedCount == 0 {
+			continue
+		}
+		_, err = r.collection.UpdateOne(ctx, bson.M{"_id": redemption.PromotionID, "usage_count": bson.M{"$gt": 0}}, bson.M{"$inc": bson.M{"usage_count": -1}})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (r *mongoPromotionRepository) findOne(ctx context.Context, filter bson.M) (*promotion.Promotion, error) {
+	var p promotion.Promotion
+	err := r.collection.FindOne(ctx, filter).Decode(&p)
+	if err == mongo.ErrNoDocuments {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &p, nil
+}
+
+func (r *mongoPromotionRepository) find(ctx context.Context, filter bson.M, findOptions *options.FindOptions) ([]promotion.Promotion, error) {
+	cursor, err := r.collection.Find(ctx, filter, findOptions)
+	if err != nil {
+		return nil, err
+	}
+	promotions := []promotion.Promotion{}
+	if err := cursor.All(ctx, &promotions); err != nil {
+		return nil, err
+	}
+	return promotions, nil
+}
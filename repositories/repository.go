@@ -0,0 +1,99 @@
+package repositories
+
+import (
+	"errors"
+
+	"github.com/YassinNouh21/GoShopCart-Ecommerce/database"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+/*
+	Package repositories provides the storage layer of the application.
+
+	Controllers depend on the interfaces declared here instead of reaching into MongoDB directly.
+	Every interface has a MongoDB implementation used by the server and an in-memory implementation
+	that needs no database, so handlers can be exercised in tests.
+*/
+
+var (
+	// ErrNotFound is returned when the requested document does not exist.
+	ErrNotFound = errors.New("document not found")
+
+	// ErrVersionConflict is returned when a document was modified since it was read.
+	ErrVersionConflict = errors.New("document was modified by another request")
+
+	// ErrInsufficientStock is returned when a product does not have enough stock left.
+	ErrInsufficientStock = errors.New("insufficient stock")
+
+	// ErrDuplicate is returned when a document that may only exist once already exists.
+	ErrDuplicate = errors.New("document already exists")
+
+	// ErrLimitReached is returned when a promotion cannot be used again.
+	ErrLimitReached = errors.New("usage limit reached")
+)
+
+// Repositories groups the repositories the application is built on.
+type Repositories struct {
+	Users         UserRepository
+	Products      ProductRepository
+	Categories    CategoryRepository
+	Carts         CartRepository
+	GuestCarts    GuestCartRepository
+	Orders        OrderRepository
+	Reviews       ReviewRepository
+	ExchangeRates ExchangeRateRepository
+	Promotions    PromotionRepository
+	TaxRates      TaxRateRepository
+	ShippingZones ShippingZoneRepository
+	Transactor    Transactor
+}
+
+// NewMongoRepositories creates the MongoDB backed repositories for the given collections.
+func NewMongoRepositories(db *database.DatabaseCollection) Repositories {
+	return Repositories{
+		Users:         NewMongoUserRepository(db.UserCollection),
+		Products:      NewMongoProductRepository(db.ProductCollection),
+		Categories:    NewMongoCategoryRepository(db.CategoryCollection),
+		Carts:         NewMongoCartRepository(db.CartCollection),
+		GuestCarts:    NewMongoGuestCartRepository(db.GuestCartCollection),
+		Orders:        NewMongoOrderRepository(db.OrderCollection),
+		Reviews:       NewMongoReviewRepository(db.ReviewCollection),
+		ExchangeRates: NewMongoExchangeRateRepository(db.ExchangeRateCollection),
+		Promotions:    NewMongoPromotionRepository(db.PromotionCollection, db.RedemptionCollection),
+		TaxRates:      NewMongoTaxRateRepository(db.TaxRateCollection),
+		ShippingZones: NewMongoShippingZoneRepository(db.ShippingZoneCollection),
+		Transactor:    NewMongoTransactor(db.Client),
+	}
+}
+
+// NewMemoryRepositories creates empty in-memory repositories.
+func NewMemoryRepositories() Repositories {
+	return Repositories{
+		Users:         NewMemoryUserRepository(),
+		Products:      NewMemoryProductRepository(),
+		Categories:    NewMemoryCategoryRepository(),
+		Carts:         NewMemoryCartRepository(),
+		GuestCarts:    NewMemoryGuestCartRepository(),
+		Orders:        NewMemoryOrderRepository(),
+		Reviews:       NewMemoryReviewRepository(),
+		ExchangeRates: NewMemoryExchangeRateRepository(),
+		Promotions:    NewMemoryPromotionRepository(),
+		TaxRates:      NewMemoryTaxRateRepository(),
+		ShippingZones: NewMemoryShippingZoneRepository(),
+		Transactor:    NewMemoryTransactor(),
+	}
+}
+
+// cloneDocument copies a document through its BSON encoding, so the in-memory repositories
+// never share slices with their callers and store exactly what MongoDB would.
+func cloneDocument[T any](document T) T {
+	var cloned T
+	data, err := bson.Marshal(document)
+	if err != nil {
+		panic(err)
+	}
+	if err := bson.Unmarshal(data, &cloned); err != nil {
+		panic(err)
+	}
+	return cloned
+}
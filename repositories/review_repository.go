@@ -0,0 +1,45 @@
+package repositories
+
+import (
+	"context"
+
+	productModel "github.com/YassinNouh21/GoShopCart-Ecommerce/models/product"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// ReviewSort orders the reviews of a product.
+type ReviewSort string
+
+const (
+	// ReviewSortNewest lists the most recent reviews first.
+	ReviewSortNewest ReviewSort = "newest"
+	// ReviewSortHelpful lists the reviews marked helpful by the most customers first, then the most recent.
+	ReviewSortHelpful ReviewSort = "helpful"
+)
+
+// Valid reports whether the sort is one of the known review sorts.
+func (s ReviewSort) Valid() bool {
+	return s == ReviewSortNewest || s == ReviewSortHelpful
+}
+
+// ReviewRepository stores the reviews of the products.
+type ReviewRepository interface {
+	// Create inserts a new review or returns ErrDuplicate if the user already reviewed the product.
+	Create(ctx context.Context, review *productModel.Review) error
+	// FindByID returns the review with the given ID or ErrNotFound.
+	FindByID(ctx context.Context, id primitive.ObjectID) (*productModel.Review, error)
+	// FindByProductAndUser returns the review of the product written by the user or ErrNotFound.
+	FindByProductAndUser(ctx context.Context, productID, userID primitive.ObjectID) (*productModel.Review, error)
+	// FindByProduct returns at most limit reviews of the product after skipping offset of them, in the
+	// order of the sort, and the number of reviews of the product.
+	FindByProduct(ctx context.Context, productID primitive.ObjectID, sort ReviewSort, offset, limit int) ([]productModel.Review, int64, error)
+	// Update replaces the rating, title and body of the review or returns ErrNotFound.
+	Update(ctx context.Context, review *productModel.Review) error
+	// Delete removes the review with the given ID or returns ErrNotFound.
+	Delete(ctx context.Context, id primitive.ObjectID) error
+	// AddHelpfulVote records that the user found the review helpful. It returns ErrNotFound if the
+	// review does not exist and ErrDuplicate if the user already marked it.
+	AddHelpfulVote(ctx context.Context, id, userID primitive.ObjectID) error
+	// Summarize returns the average rating and the number of reviews of the product.
+	Summarize(ctx context.Context, productID primitive.ObjectID) (productModel.RatingSummary, error)
+}
@@ -0,0 +1,147 @@
+package repositories
+
+import (
+	"context"
+	"math"
+	"sort"
+	"sync"
+
+	productModel "github.com/YassinNouh21/GoShopCart-Ecommerce/models/product"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// memoryReviewRepository is a ReviewRepository that keeps the reviews in memory.
+type memoryReviewRepository struct {
+	mu      sync.RWMutex
+	reviews map[primitive.ObjectID]productModel.Review
+}
+
+// NewMemoryReviewRepository creates an empty in-memory ReviewRepository.
+func NewMemoryReviewRepository() ReviewRepository {
+	return &memoryReviewRepository{reviews: map[primitive.ObjectID]productModel.Review{}}
+}
+
+func (r *memoryReviewRepository) Create(ctx context.Context, review *productModel.Review) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, stored := range r.reviews {
+		if stored.ProductID == review.ProductID && stored.UserID == review.UserID {
+			return ErrDuplicate
+		}
+	}
+	r.reviews[review.ReviewID] = cloneDocument(*review)
+	return nil
+}
+
+func (r *memoryReviewRepository) FindByID(ctx context.Context, id primitive.ObjectID) (*productModel.Review, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	review, found := r.reviews[id]
+	if !found {
+		return nil, ErrNotFound
+	}
+	review = cloneDocument(review)
+	return &review, nil
+}
+
+func (r *memoryReviewRepository) FindByProductAndUser(ctx context.Context, productID, userID primitive.ObjectID) (*productModel.Review, error) {
+	reviews := r.filter(func(review productModel.Review) bool {
+		return review.ProductID == productID && review.UserID == userID
+	})
+	if len(reviews) == 0 {
+		return nil, ErrNotFound
+	}
+	return &reviews[0], nil
+}
+
+func (r *memoryReviewRepository) FindByProduct(ctx context.Context, productID primitive.ObjectID, sortBy ReviewSort, offset, limit int) ([]productModel.Review, int64, error) {
+	reviews := r.filter(func(review productModel.Review) bool { return review.ProductID == productID })
+	sort.SliceStable(reviews, func(i, j int) bool {
+		if sortBy == ReviewSortHelpful && reviews[i].HelpfulCount != reviews[j].HelpfulCount {
+			return reviews[i].HelpfulCount > reviews[j].HelpfulCount
+		}
+		return reviews[i].CreatedAt.After(reviews[j].CreatedAt)
+	})
+	total := int64(len(reviews))
+	if offset > len(reviews) {
+		offset = len(reviews)
+	}
+	reviews = reviews[offset:]
+	if len(reviews) > limit {
+		reviews = reviews[:limit]
+	}
+	return reviews, total, nil
+}
+
+func (r *memoryReviewRepository) Update(ctx context.Context, review *productModel.Review) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	stored, found := r.reviews[review.ReviewID]
+	if !found {
+		return ErrNotFound
+	}
+	stored.Rating = review.Rating
+	stored.Title = review.Title
+	stored.Body = review.Body
+	stored.UpdatedAt = review.UpdatedAt
+	r.reviews[review.ReviewID] = stored
+	return nil
+}
+
+func (r *memoryReviewRepository) Delete(ctx context.Context, id primitive.ObjectID) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, found := r.reviews[id]; !found {
+		return ErrNotFound
+	}
+	delete(r.reviews, id)
+	return nil
+}
+
+func (r *memoryReviewRepository) AddHelpfulVote(ctx context.Context, id, userID primitive.ObjectID) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	review, found := r.reviews[id]
+	if !found {
+		return ErrNotFound
+	}
+	review = cloneDocument(review)
+	if containsID(review.HelpfulVoters, userID) {
+		return ErrDuplicate
+	}
+	review.HelpfulVoters = append(review.HelpfulVoters, userID)
+	review.HelpfulCount++
+	r.reviews[id] = review
+	return nil
+}
+
+func (r *memoryReviewRepository) Summarize(ctx context.Context, productID primitive.ObjectID) (productModel.RatingSummary, error) {
+	reviews := r.filter(func(review productModel.Review) bool { return review.ProductID == productID })
+	if len(reviews) == 0 {
+		return productModel.RatingSummary{}, nil
+	}
+	total := 0
+	for _, review := range reviews {
+		total += review.Rating
+	}
+	return productModel.RatingSummary{Average: roundRating(float64(total) / float64(len(reviews))), Count: len(reviews)}, nil
+}
+
+// filter returns copies of the reviews matching the predicate, ordered by ID.
+func (r *memoryReviewRepository) filter(match func(productModel.Review) bool) []productModel.Review {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	reviews := []productModel.Review{}
+	for _, review := range r.reviews {
+		if match(review) {
+			reviews = append(reviews, cloneDocument(review))
+		}
+	}
+	sort.Slice(reviews, func(i, j int) bool { return reviews[i].ReviewID.Hex() < reviews[j].ReviewID.Hex() })
+	return reviews
+}
+
+// roundRating rounds an average rating to two decimals.
+func roundRating(average float64) float32 {
+	return float32(math.Round(average*100) / 100)
+}
@@ -0,0 +1,145 @@
+package repositories
+
+import (
+	"context"
+
+	productModel "github.com/YassinNouh21/GoShopCart-Ecommerce/models/product"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// mongoReviewRepository is the ReviewRepository backed by the reviews collection.
+type mongoReviewRepository struct {
+	collection *mongo.Collection
+}
+
+// NewMongoReviewRepository creates a ReviewRepository on top of the given collection.
+func NewMongoReviewRepository(collection *mongo.Collection) ReviewRepository {
+	return &mongoReviewRepository{collection: collection}
+}
+
+func (r *mongoReviewRepository) Create(ctx context.Context, review *productModel.Review) error {
+	// The review is only inserted if the user has none for the product, in the same operation
+	filter := bson.M{"product_id": review.ProductID, "user_id": review.UserID}
+	result, err := r.collection.UpdateOne(ctx, filter, bson.M{"$setOnInsert": review}, options.Update().SetUpsert(true))
+	if err != nil {
+		return err
+	}
+	if result.UpsertedCount == 0 {
+		return ErrDuplicate
+	}
+	return nil
+}
+
+func (r *mongoReviewRepository) FindByID(ctx context.Context, id primitive.ObjectID) (*productModel.Review, error) {
+	return r.findOne(ctx, bson.M{"_id": id})
+}
+
+func (r *mongoReviewRepository) FindByProductAndUser(ctx context.Context, productID, userID primitive.ObjectID) (*productModel.Review, error) {
+	return r.findOne(ctx, bson.M{"product_id": productID, "user_id": userID})
+}
+
+func (r *mongoReviewRepository) FindByProduct(ctx context.Context, productID primitive.ObjectID, sort ReviewSort, offset, limit int) ([]productModel.Review, int64, error) {
+	filter := bson.M{"product_id": productID}
+	total, err := r.collection.CountDocuments(ctx, filter)
+	if err != nil {
+		return nil, 0, err
+	}
+	order := bson.D{{Key: "created_at", Value: -1}}
+	if sort == ReviewSortHelpful {
+		order = append(bson.D{{Key: "helpful_count", Value: -1}}, order...)
+	}
+	findOptions := options.Find().SetSort(order).SetSkip(int64(offset)).SetLimit(int64(limit))
+	cursor, err := r.collection.Find(ctx, filter, findOptions)
+	if err != nil {
+		return nil, 0, err
+	}
+	reviews := []productModel.Review{}
+	if err := cursor.All(ctx, &reviews); err != nil {
+		return nil, 0, err
+	}
+	return reviews, total, nil
+}
+
+func (r *mongoReviewRepository) Update(ctx context.Context, review *productModel.Review) error {
+	update := bson.M{"$set": bson.M{
+		"rating":     review.Rating,
+		"title":      review.Title,
+		"body":       review.Body,
+		"updated_at": review.UpdatedAt,
+	}}
+	result, err := r.collection.UpdateOne(ctx, bson.M{"_id": review.ReviewID}, update)
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+func (r *mongoReviewRepository) Delete(ctx context.Context, id primitive.ObjectID) error {
+	result, err := r.collection.DeleteOne(ctx, bson.M{"_id": id})
+	if err != nil {
+		return err
+	}
+	if result.DeletedCount == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+func (r *mongoReviewRepository) AddHelpfulVote(ctx context.Context, id, userID primitive.ObjectID) error {
+	// The vote is only recorded if the user is not among the voters yet, in the same update
+	update := bson.M{
+		"$push": bson.M{"helpful_voters": userID},
+		"$inc":  bson.M{"helpful_count": 1},
+	}
+	result, err := r.collection.UpdateOne(ctx, bson.M{"_id": id, "helpful_voters": bson.M{"$ne": userID}}, update)
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		if _, err := r.FindByID(ctx, id); err != nil {
+			return err
+		}
+		return ErrDuplicate
+	}
+	return nil
+}
+
+func (r *mongoReviewRepository) Summarize(ctx context.Context, productID primitive.ObjectID) (productModel.RatingSummary, error) {
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: bson.M{"product_id": productID}}},
+		{{Key: "$group", Value: bson.M{"_id": nil, "average": bson.M{"$avg": "$rating"}, "count": bson.M{"$sum": 1}}}},
+	}
+	cursor, err := r.collection.Aggregate(ctx, pipeline)
+	if err != nil {
+		return productModel.RatingSummary{}, err
+	}
+	var results []struct {
+		Average float64 `bson:"average"`
+		Count   int     `bson:"count"`
+	}
+	if err := cursor.All(ctx, &results); err != nil {
+		return productModel.RatingSummary{}, err
+	}
+	if len(results) == 0 {
+		return productModel.RatingSummary{}, nil
+	}
+	return productModel.RatingSummary{Average: roundRating(results[0].Average), Count: results[0].Count}, nil
+}
+
+func (r *mongoReviewRepository) findOne(ctx context.Context, filter bson.M) (*productModel.Review, error) {
+	var review productModel.Review
+	err := r.collection.FindOne(ctx, filter).Decode(&review)
+	if err == mongo.ErrNoDocuments {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &review, nil
+}
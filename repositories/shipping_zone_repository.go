@@ -0,0 +1,22 @@
+package repositories
+
+import (
+	"context"
+
+	shippingModel "github.com/YassinNouh21/GoShopCart-Ecommerce/models/shipping"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// ShippingZoneRepository stores the shipping zones and their methods.
+type ShippingZoneRepository interface {
+	// FindAll returns every shipping zone, sorted by name.
+	FindAll(ctx context.Context) ([]shippingModel.Zone, error)
+	// FindByID returns the shipping zone with the given ID or ErrNotFound.
+	FindByID(ctx context.Context, id primitive.ObjectID) (*shippingModel.Zone, error)
+	// Create stores a new shipping zone.
+	Create(ctx context.Context, zone *shippingModel.Zone) error
+	// Update replaces the shipping zone with the same ID or returns ErrNotFound.
+	Update(ctx context.Context, zone *shippingModel.Zone) error
+	// Delete removes the shipping zone with the given ID or returns ErrNotFound.
+	Delete(ctx context.Context, id primitive.ObjectID) error
+}
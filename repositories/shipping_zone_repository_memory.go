@@ -0,0 +1,75 @@
+package repositories
+
+import (
+	"context"
+	"sort"
+	"sync"
+
+	shippingModel "github.com/YassinNouh21/GoShopCart-Ecommerce/models/shipping"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// memoryShippingZoneRepository is a ShippingZoneRepository that keeps the shipping zones in memory.
+type memoryShippingZoneRepository struct {
+	mu    sync.RWMutex
+	zones map[primitive.ObjectID]shippingModel.Zone
+}
+
+// NewMemoryShippingZoneRepository creates an empty in-memory ShippingZoneRepository.
+func NewMemoryShippingZoneRepository() ShippingZoneRepository {
+	return &memoryShippingZoneRepository{zones: map[primitive.ObjectID]shippingModel.Zone{}}
+}
+
+func (r *memoryShippingZoneRepository) FindAll(ctx context.Context) ([]shippingModel.Zone, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	zones := []shippingModel.Zone{}
+	for _, zone := range r.zones {
+		zones = append(zones, cloneDocument(zone))
+	}
+	sort.Slice(zones, func(i, j int) bool {
+		if zones[i].Name != zones[j].Name {
+			return zones[i].Name < zones[j].Name
+		}
+		return zones[i].ZoneID.Hex() < zones[j].ZoneID.Hex()
+	})
+	return zones, nil
+}
+
+func (r *memoryShippingZoneRepository) FindByID(ctx context.Context, id primitive.ObjectID) (*shippingModel.Zone, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	zone, found := r.zones[id]
+	if !found {
+		return nil, ErrNotFound
+	}
+	zone = cloneDocument(zone)
+	return &zone, nil
+}
+
+func (r *memoryShippingZoneRepository) Create(ctx context.Context, zone *shippingModel.Zone) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.zones[zone.ZoneID] = cloneDocument(*zone)
+	return nil
+}
+
+func (r *memoryShippingZoneRepository) Update(ctx context.Context, zone *shippingModel.Zone) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, found := r.zones[zone.ZoneID]; !found {
+		return ErrNotFound
+	}
+	r.zones[zone.ZoneID] = cloneDocument(*zone)
+	return nil
+}
+
+func (r *memoryShippingZoneRepository) Delete(ctx context.Context, id primitive.ObjectID) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, found := r.zones[id]; !found {
+		return ErrNotFound
+	}
+	delete(r.zones, id)
+	return nil
+}
@@ -0,0 +1,73 @@
+package repositories
+
+import (
+	"context"
+
+	shippingModel "github.com/YassinNouh21/GoShopCart-Ecommerce/models/shipping"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// mongoShippingZoneRepository is the ShippingZoneRepository backed by the shipping_zones collection.
+type mongoShippingZoneRepository struct {
+	collection *mongo.Collection
+}
+
+// NewMongoShippingZoneRepository creates a ShippingZoneRepository on top of the given collection.
+func NewMongoShippingZoneRepository(collection *mongo.Collection) ShippingZoneRepository {
+	return &mongoShippingZoneRepository{collection: collection}
+}
+
+func (r *mongoShippingZoneRepository) FindAll(ctx context.Context) ([]shippingModel.Zone, error) {
+	order := bson.D{{Key: "name", Value: 1}, {Key: "_id", Value: 1}}
+	cursor, err := r.collection.Find(ctx, bson.M{}, options.Find().SetSort(order))
+	if err != nil {
+		return nil, err
+	}
+	zones := []shippingModel.Zone{}
+	if err := cursor.All(ctx, &zones); err != nil {
+		return nil, err
+	}
+	return zones, nil
+}
+
+func (r *mongoShippingZoneRepository) FindByID(ctx context.Context, id primitive.ObjectID) (*shippingModel.Zone, error) {
+	var zone shippingModel.Zone
+	err := r.collection.FindOne(ctx, bson.M{"_id": id}).Decode(&zone)
+	if err == mongo.ErrNoDocuments {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &zone, nil
+}
+
+func (r *mongoShippingZoneRepository) Create(ctx context.Context, zone *shippingModel.Zone) error {
+	_, err := r.collection.InsertOne(ctx, zone)
+	return err
+}
+
+func (r *mongoShippingZoneRepository) Update(ctx context.Context, zone *shippingModel.Zone) error {
+	result, err := r.collection.ReplaceOne(ctx, bson.M{"_id": zone.ZoneID}, zone)
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+func (r *mongoShippingZoneRepository) Delete(ctx context.Context, id primitive.ObjectID) error {
+	result, err := r.collection.DeleteOne(ctx, bson.M{"_id": id})
+	if err != nil {
+		return err
+	}
+	if result.DeletedCount == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
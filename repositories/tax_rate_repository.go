@@ -0,0 +1,21 @@
+package repositories
+
+import (
+	"context"
+
+	taxModel "github.com/YassinNouh21/GoShopCart-Ecommerce/models/tax"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// TaxRateRepository stores the table of tax rates.
+type TaxRateRepository interface {
+	// FindAll returns every tax rate, sorted by country, state and tax class.
+	FindAll(ctx context.Context) ([]taxModel.Rate, error)
+	// FindByCountry returns the tax rates of the country, sorted by state and tax class.
+	FindByCountry(ctx context.Context, countryCode string) ([]taxModel.Rate, error)
+	// Save creates the tax rate of its country, state and tax class with its ID, or replaces it. The
+	// ID of a replaced rate is kept and set on the given rate.
+	Save(ctx context.Context, rate *taxModel.Rate) error
+	// Delete removes the tax rate with the given ID or returns ErrNotFound.
+	Delete(ctx context.Context, id primitive.ObjectID) error
+}
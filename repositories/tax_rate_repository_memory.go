@@ -0,0 +1,73 @@
+package repositories
+
+import (
+	"context"
+	"sort"
+	"sync"
+
+	taxModel "github.com/YassinNouh21/GoShopCart-Ecommerce/models/tax"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// memoryTaxRateRepository is a TaxRateRepository that keeps the tax rates in memory.
+type memoryTaxRateRepository struct {
+	mu    sync.RWMutex
+	rates map[primitive.ObjectID]taxModel.Rate
+}
+
+// NewMemoryTaxRateRepository creates an empty in-memory TaxRateRepository.
+func NewMemoryTaxRateRepository() TaxRateRepository {
+	return &memoryTaxRateRepository{rates: map[primitive.ObjectID]taxModel.Rate{}}
+}
+
+func (r *memoryTaxRateRepository) FindAll(ctx context.Context) ([]taxModel.Rate, error) {
+	return r.filter(func(rate taxModel.Rate) bool { return true }), nil
+}
+
+func (r *memoryTaxRateRepository) FindByCountry(ctx context.Context, countryCode string) ([]taxModel.Rate, error) {
+	return r.filter(func(rate taxModel.Rate) bool { return rate.CountryCode == countryCode }), nil
+}
+
+func (r *memoryTaxRateRepository) Save(ctx context.Context, rate *taxModel.Rate) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for id, stored := range r.rates {
+		if stored.CountryCode == rate.CountryCode && stored.State == rate.State && stored.TaxClass == rate.TaxClass {
+			rate.RateID = id
+		}
+	}
+	r.rates[rate.RateID] = cloneDocument(*rate)
+	return nil
+}
+
+func (r *memoryTaxRateRepository) Delete(ctx context.Context, id primitive.ObjectID) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, found := r.rates[id]; !found {
+		return ErrNotFound
+	}
+	delete(r.rates, id)
+	return nil
+}
+
+// filter returns copies of the rates matching the predicate, sorted by country, state and tax class.
+func (r *memoryTaxRateRepository) filter(match func(taxModel.Rate) bool) []taxModel.Rate {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	rates := []taxModel.Rate{}
+	for _, rate := range r.rates {
+		if match(rate) {
+			rates = append(rates, cloneDocument(rate))
+		}
+	}
+	sort.Slice(rates, func(i, j int) bool {
+		if rates[i].CountryCode != rates[j].CountryCode {
+			return rates[i].CountryCode < rates[j].CountryCode
+		}
+		if rates[i].State != rates[j].State {
+			return rates[i].State < rates[j].State
+		}
+		return rates[i].TaxClass < rates[j].TaxClass
+	})
+	return rates
+}
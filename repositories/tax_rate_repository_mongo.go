@@ -0,0 +1,70 @@
+package repositories
+
+import (
+	"context"
+
+	taxModel "github.com/YassinNouh21/GoShopCart-Ecommerce/models/tax"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// mongoTaxRateRepository is the TaxRateRepository backed by the tax_rates collection.
+type mongoTaxRateRepository struct {
+	collection *mongo.Collection
+}
+
+// NewMongoTaxRateRepository creates a TaxRateRepository on top of the given collection.
+func NewMongoTaxRateRepository(collection *mongo.Collection) TaxRateRepository {
+	return &mongoTaxRateRepository{collection: collection}
+}
+
+// taxRateOrder sorts the tax rates by country, state and tax class.
+var taxRateOrder = bson.D{{Key: "country_code", Value: 1}, {Key: "state", Value: 1}, {Key: "tax_class", Value: 1}}
+
+func (r *mongoTaxRateRepository) FindAll(ctx context.Context) ([]taxModel.Rate, error) {
+	return r.find(ctx, bson.M{})
+}
+
+func (r *mongoTaxRateRepository) FindByCountry(ctx context.Context, countryCode string) ([]taxModel.Rate, error) {
+	return r.find(ctx, bson.M{"country_code": countryCode})
+}
+
+func (r *mongoTaxRateRepository) Save(ctx context.Context, rate *taxModel.Rate) error {
+	filter := bson.M{"country_code": rate.CountryCode, "state": rate.State, "tax_class": rate.TaxClass}
+	update := bson.M{
+		"$set": bson.M{
+			"name":       rate.Name,
+			"percent":    rate.Percent,
+			"inclusive":  rate.Inclusive,
+			"updated_at": rate.UpdatedAt,
+		},
+		"$setOnInsert": bson.M{"_id": rate.RateID},
+	}
+	opts := options.FindOneAndUpdate().SetUpsert(true).SetReturnDocument(options.After)
+	return r.collection.FindOneAndUpdate(ctx, filter, update, opts).Decode(rate)
+}
+
+func (r *mongoTaxRateRepository) Delete(ctx context.Context, id primitive.ObjectID) error {
+	result, err := r.collection.DeleteOne(ctx, bson.M{"_id": id})
+	if err != nil {
+		return err
+	}
+	if result.DeletedCount == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+func (r *mongoTaxRateRepository) find(ctx context.Context, filter bson.M) ([]taxModel.Rate, error) {
+	cursor, err := r.collection.Find(ctx, filter, options.Find().SetSort(taxRateOrder))
+	if err != nil {
+		return nil, err
+	}
+	rates := []taxModel.Rate{}
+	if err := cursor.All(ctx, &rates); err != nil {
+		return nil, err
+	}
+	return rates, nil
+}
@@ -0,0 +1,51 @@
+package repositories
+
+import (
+	"context"
+	"sync"
+
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// Transactor runs a function atomically across repositories.
+// The repositories must be called with the context passed to the function.
+type Transactor interface {
+	WithTransaction(ctx context.Context, fn func(ctx context.Context) error) error
+}
+
+// mongoTransactor runs the function in a MongoDB multi-document transaction.
+type mongoTransactor struct {
+	client *mongo.Client
+}
+
+// NewMongoTransactor creates a Transactor that uses sessions of the given client.
+// MongoDB only supports transactions on replica sets and sharded clusters.
+func NewMongoTransactor(client *mongo.Client) Transactor {
+	return &mongoTransactor{client: client}
+}
+
+func (t *mongoTransactor) WithTransaction(ctx context.Context, fn func(ctx context.Context) error) error {
+	return t.client.UseSession(ctx, func(session mongo.SessionContext) error {
+		_, err := session.WithTransaction(session, func(session mongo.SessionContext) (interface{}, error) {
+			return nil, fn(session)
+		})
+		return err
+	})
+}
+
+// memoryTransactor serializes the functions it runs.
+// It does not roll back changes made before an error.
+type memoryTransactor struct {
+	mu sync.Mutex
+}
+
+// NewMemoryTransactor creates a Transactor for the in-memory repositories.
+func NewMemoryTransactor() Transactor {
+	return &memoryTransactor{}
+}
+
+func (t *memoryTransactor) WithTransaction(ctx context.Context, fn func(ctx context.Context) error) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return fn(ctx)
+}
@@ -0,0 +1,48 @@
+package repositories
+
+import (
+	"context"
+
+	userModel "github.com/YassinNouh21/GoShopCart-Ecommerce/models/user"
+	"github.com/YassinNouh21/GoShopCart-Ecommerce/money"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// ProfileUpdate holds the profile fields a user can change.
+type ProfileUpdate struct {
+	FirstName      string
+	LastName       string
+	Email          string
+	AddressDetails []userModel.Address
+}
+
+// UserRepository stores the users and their addresses.
+type UserRepository interface {
+	// Create inserts a new user.
+	Create(ctx context.Context, user *userModel.User) error
+	// FindByID returns the user with the given ID or ErrNotFound.
+	FindByID(ctx context.Context, id primitive.ObjectID) (*userModel.User, error)
+	// FindByEmail returns the user with the given email or ErrNotFound.
+	FindByEmail(ctx context.Context, email string) (*userModel.User, error)
+	// ExistsByEmail reports whether a user with the given email exists.
+	ExistsByEmail(ctx context.Context, email string) (bool, error)
+	// UpdateTokens stores a new access and refresh token for the user.
+	UpdateTokens(ctx context.Context, id primitive.ObjectID, token string, refreshToken string) error
+	// UpdateAccessToken stores a new access token for the user.
+	UpdateAccessToken(ctx context.Context, id primitive.ObjectID, token string) error
+	// UpdateRole changes the role of the user or returns ErrNotFound.
+	UpdateRole(ctx context.Context, id primitive.ObjectID, role userModel.Role) error
+	// UpdateCurrency sets the preferred currency of the user, or clears it when empty, or returns ErrNotFound.
+	UpdateCurrency(ctx context.Context, id primitive.ObjectID, currency money.Currency) error
+	// UpdateTaxExempt sets whether the user pays no tax or returns ErrNotFound.
+	UpdateTaxExempt(ctx context.Context, id primitive.ObjectID, exempt bool) error
+	// UpdateProfile replaces the profile fields of the user or returns ErrNotFound.
+	UpdateProfile(ctx context.Context, id primitive.ObjectID, profile ProfileUpdate) error
+	// AddAddress appends an address to the user or returns ErrNotFound.
+	AddAddress(ctx context.Context, id primitive.ObjectID, address userModel.Address) error
+	// DeleteAddresses removes every address of the user or returns ErrNotFound.
+	DeleteAddresses(ctx context.Context, id primitive.ObjectID) error
+	// DeleteAddress removes one address of the user.
+	// It returns ErrNotFound if the user or the address does not exist.
+	DeleteAddress(ctx context.Context, id primitive.ObjectID, addressID primitive.ObjectID) error
+}
@@ -0,0 +1,153 @@
+package repositories
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	userModel "github.com/YassinNouh21/GoShopCart-Ecommerce/models/user"
+	"github.com/YassinNouh21/GoShopCart-Ecommerce/money"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// memoryUserRepository is a UserRepository that keeps the users in memory.
+type memoryUserRepository struct {
+	mu    sync.RWMutex
+	users map[primitive.ObjectID]userModel.User
+}
+
+// NewMemoryUserRepository creates an empty in-memory UserRepository.
+func NewMemoryUserRepository() UserRepository {
+	return &memoryUserRepository{users: map[primitive.ObjectID]userModel.User{}}
+}
+
+func (r *memoryUserRepository) Create(ctx context.Context, user *userModel.User) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.users[user.ID] = cloneDocument(*user)
+	return nil
+}
+
+func (r *memoryUserRepository) FindByID(ctx context.Context, id primitive.ObjectID) (*userModel.User, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	user, found := r.users[id]
+	if !found {
+		return nil, ErrNotFound
+	}
+	user = cloneDocument(user)
+	return &user, nil
+}
+
+func (r *memoryUserRepository) FindByEmail(ctx context.Context, email string) (*userModel.User, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	for _, user := range r.users {
+		if user.Email == email {
+			user = cloneDocument(user)
+			return &user, nil
+		}
+	}
+	return nil, ErrNotFound
+}
+
+func (r *memoryUserRepository) ExistsByEmail(ctx context.Context, email string) (bool, error) {
+	_, err := r.FindByEmail(ctx, email)
+	if err == ErrNotFound {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+func (r *memoryUserRepository) UpdateTokens(ctx context.Context, id primitive.ObjectID, token string, refreshToken string) error {
+	return r.update(id, func(user *userModel.User) error {
+		user.Token = token
+		user.RefreshToken = refreshToken
+		user.UpdatedAt = time.Now().UTC()
+		return nil
+	})
+}
+
+func (r *memoryUserRepository) UpdateAccessToken(ctx context.Context, id primitive.ObjectID, token string) error {
+	return r.update(id, func(user *userModel.User) error {
+		user.Token = token
+		return nil
+	})
+}
+
+func (r *memoryUserRepository) UpdateRole(ctx context.Context, id primitive.ObjectID, role userModel.Role) error {
+	return r.update(id, func(user *userModel.User) error {
+		user.Role = role
+		user.UpdatedAt = time.Now().UTC()
+		return nil
+	})
+}
+
+func (r *memoryUserRepository) UpdateCurrency(ctx context.Context, id primitive.ObjectID, currency money.Currency) error {
+	return r.update(id, func(user *userModel.User) error {
+		user.Currency = currency
+		user.UpdatedAt = time.Now().UTC()
+		return nil
+	})
+}
+
+func (r *memoryUserRepository) UpdateTaxExempt(ctx context.Context, id primitive.ObjectID, exempt bool) error {
+	return r.update(id, func(user *userModel.User) error {
+		user.TaxExempt = exempt
+		user.UpdatedAt = time.Now().UTC()
+		return nil
+	})
+}
+
+func (r *memoryUserRepository) UpdateProfile(ctx context.Context, id primitive.ObjectID, profile ProfileUpdate) error {
+	return r.update(id, func(user *userModel.User) error {
+		user.FirstName = profile.FirstName
+		user.LastName = profile.LastName
+		user.Email = profile.Email
+		user.AddressDetails = profile.AddressDetails
+		user.UpdatedAt = time.Now().UTC()
+		return nil
+	})
+}
+
+func (r *memoryUserRepository) AddAddress(ctx context.Context, id primitive.ObjectID, address userModel.Address) error {
+	return r.update(id, func(user *userModel.User) error {
+		user.AddressDetails = append(user.AddressDetails, address)
+		return nil
+	})
+}
+
+func (r *memoryUserRepository) DeleteAddresses(ctx context.Context, id primitive.ObjectID) error {
+	return r.update(id, func(user *userModel.User) error {
+		user.AddressDetails = []userModel.Address{}
+		return nil
+	})
+}
+
+func (r *memoryUserRepository) DeleteAddress(ctx context.Context, id primitive.ObjectID, addressID primitive.ObjectID) error {
+	return r.update(id, func(user *userModel.User) error {
+		for i, address := range user.AddressDetails {
+			if address.AddressID == addressID {
+				user.AddressDetails = append(user.AddressDetails[:i], user.AddressDetails[i+1:]...)
+				return nil
+			}
+		}
+		return ErrNotFound
+	})
+}
+
+// update applies the change to a copy of the user and stores it if the change succeeds.
+func (r *memoryUserRepository) update(id primitive.ObjectID, change func(user *userModel.User) error) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	user, found := r.users[id]
+	if !found {
+		return ErrNotFound
+	}
+	user = cloneDocument(user)
+	if err := change(&user); err != nil {
+		return err
+	}
+	r.users[id] = cloneDocument(user)
+	return nil
+}
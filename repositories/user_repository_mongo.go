@@ -0,0 +1,140 @@
+package repositories
+
+import (
+	"context"
+	"time"
+
+	userModel "github.com/YassinNouh21/GoShopCart-Ecommerce/models/user"
+	"github.com/YassinNouh21/GoShopCart-Ecommerce/money"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// mongoUserRepository is the UserRepository backed by the users collection.
+type mongoUserRepository struct {
+	collection *mongo.Collection
+}
+
+// NewMongoUserRepository creates a UserRepository on top of the given collection.
+func NewMongoUserRepository(collection *mongo.Collection) UserRepository {
+	return &mongoUserRepository{collection: collection}
+}
+
+func (r *mongoUserRepository) Create(ctx context.Context, user *userModel.User) error {
+	_, err := r.collection.InsertOne(ctx, user)
+	return err
+}
+
+func (r *mongoUserRepository) FindByID(ctx context.Context, id primitive.ObjectID) (*userModel.User, error) {
+	return r.findOne(ctx, bson.M{"_id": id})
+}
+
+func (r *mongoUserRepository) FindByEmail(ctx context.Context, email string) (*userModel.User, error) {
+	return r.findOne(ctx, bson.M{"email": email})
+}
+
+func (r *mongoUserRepository) ExistsByEmail(ctx context.Context, email string) (bool, error) {
+	count, err := r.collection.CountDocuments(ctx, bson.M{"email": email})
+	return count > 0, err
+}
+
+func (r *mongoUserRepository) UpdateTokens(ctx context.Context, id primitive.ObjectID, token string, refreshToken string) error {
+	return r.updateOne(ctx, bson.M{"_id": id}, bson.M{"$set": bson.M{
+		"token":         token,
+		"refresh_token": refreshToken,
+		"updated_at":    time.Now().UTC(),
+	}})
+}
+
+func (r *mongoUserRepository) UpdateAccessToken(ctx context.Context, id primitive.ObjectID, token string) error {
+	return r.updateOne(ctx, bson.M{"_id": id}, bson.M{"$set": bson.M{"token": token}})
+}
+
+func (r *mongoUserRepository) UpdateRole(ctx context.Context, id primitive.ObjectID, role userModel.Role) error {
+	return r.updateOne(ctx, bson.M{"_id": id}, bson.M{"$set": bson.M{
+		"role":       role,
+		"updated_at": time.Now().UTC(),
+	}})
+}
+
+func (r *mongoUserRepository) UpdateCurrency(ctx context.Context, id primitive.ObjectID, currency money.Currency) error {
+	if currency == "" {
+		return r.updateOne(ctx, bson.M{"_id": id}, bson.M{
+			"$unset": bson.M{"currency": ""},
+			"$set":   bson.M{"updated_at": time.Now().UTC()},
+		})
+	}
+	return r.updateOne(ctx, bson.M{"_id": id}, bson.M{"$set": bson.M{
+		"currency":   currency,
+		"updated_at": time.Now().UTC(),
+	}})
+}
+
+func (r *mongoUserRepository) UpdateTaxExempt(ctx context.Context, id primitive.ObjectID, exempt bool) error {
+	if !exempt {
+		return r.updateOne(ctx, bson.M{"_id": id}, bson.M{
+			"$unset": bson.M{"tax_exempt": ""},
+			"$set":   bson.M{"updated_at": time.Now().UTC()},
+		})
+	}
+	return r.updateOne(ctx, bson.M{"_id": id}, bson.M{"$set": bson.M{
+		"tax_exempt": true,
+		"updated_at": time.Now().UTC(),
+	}})
+}
+
+func (r *mongoUserRepository) UpdateProfile(ctx context.Context, id primitive.ObjectID, profile ProfileUpdate) error {
+	return r.updateOne(ctx, bson.M{"_id": id}, bson.M{"$set": bson.M{
+		"firstname":       profile.FirstName,
+		"last_name":       profile.LastName,
+		"email":           profile.Email,
+		"address_details": profile.AddressDetails,
+		"updated_at":      time.Now().UTC(),
+	}})
+}
+
+func (r *mongoUserRepository) AddAddress(ctx context.Context, id primitive.ObjectID, address userModel.Address) error {
+	return r.updateOne(ctx, bson.M{"_id": id}, bson.M{"$push": bson.M{"address_details": address}})
+}
+
+func (r *mongoUserRepository) DeleteAddresses(ctx context.Context, id primitive.ObjectID) error {
+	return r.updateOne(ctx, bson.M{"_id": id}, bson.M{"$set": bson.M{"address_details": []userModel.Address{}}})
+}
+
+func (r *mongoUserRepository) DeleteAddress(ctx context.Context, id primitive.ObjectID, addressID primitive.ObjectID) error {
+	filter := bson.M{
+		"_id":                 id,
+		"address_details._id": addressID,
+	}
+	update := bson.M{
+		"$pull": bson.M{
+			"address_details": bson.M{"_id": addressID},
+		},
+	}
+	return r.updateOne(ctx, filter, update)
+}
+
+func (r *mongoUserRepository) findOne(ctx context.Context, filter bson.M) (*userModel.User, error) {
+	var user userModel.User
+	err := r.collection.FindOne(ctx, filter).Decode(&user)
+	if err == mongo.ErrNoDocuments {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
+
+// updateOne applies the update and returns ErrNotFound if the filter matched no user.
+func (r *mongoUserRepository) updateOne(ctx context.Context, filter bson.M, update bson.M) error {
+	result, err := r.collection.UpdateOne(ctx, filter, update)
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
@@ -0,0 +1,84 @@
+package routes
+
+import (
+	"github.com/YassinNouh21/GoShopCart-Ecommerce/controllers/admin"
+	productController "github.com/YassinNouh21/GoShopCart-Ecommerce/controllers/product"
+	"github.com/YassinNouh21/GoShopCart-Ecommerce/middlewares"
+	userModel "github.com/YassinNouh21/GoShopCart-Ecommerce/models/user"
+	"github.com/gin-gonic/gin"
+)
+
+// AdminUserRoutes sets up the admin routes that manage users.
+// Changing roles and tax exemptions is restricted to admins.
+func AdminUserRoutes(adminRoutes *gin.RouterGroup, controller *admin.UserController) {
+	adminOnly := middlewares.Authorization(userModel.RoleAdmin)
+	adminRoutes.PUT("/users/:user_id/role", adminOnly, controller.UpdateUserRole)
+	adminRoutes.PUT("/users/:user_id/tax-exempt", adminOnly, controller.UpdateTaxExempt)
+}
+
+// AdminOrderRoutes sets up the admin routes that manage orders.
+func AdminOrderRoutes(adminRoutes *gin.RouterGroup, controller *admin.OrderController) {
+	adminRoutes.GET("/orders", controller.GetOrders)
+	adminRoutes.GET("/orders/:id", controller.GetOrder)
+	adminRoutes.PUT("/orders/:id/status", controller.UpdateOrderStatus)
+}
+
+// AdminInventoryRoutes sets up the admin routes that manage the stock of the products.
+func AdminInventoryRoutes(adminRoutes *gin.RouterGroup, controller *admin.InventoryController) {
+	adminRoutes.GET("/products/low-stock", controller.GetLowStock)
+	adminRoutes.POST("/products/:id/stock", controller.AdjustStock)
+}
+
+// AdminCatalogRoutes sets up the admin routes that import and export the catalog in bulk.
+// Like creating products, importing them is restricted to admins.
+func AdminCatalogRoutes(adminRoutes *gin.RouterGroup, controller *admin.CatalogController) {
+	adminRoutes.POST("/products/import", middlewares.Authorization(userModel.RoleAdmin), controller.ImportProducts)
+	adminRoutes.GET("/products/export", controller.ExportProducts)
+}
+
+// AdminCategoryRoutes sets up the admin routes that manage the product taxonomy.
+// Like products, categories are managed by admins only.
+func AdminCategoryRoutes(adminRoutes *gin.RouterGroup, controller *productController.CategoryController) {
+	adminOnly := middlewares.Authorization(userModel.RoleAdmin)
+	adminRoutes.POST("/categories", adminOnly, controller.CreateCategory)
+	adminRoutes.PUT("/categories/:id", adminOnly, controller.UpdateCategory)
+	adminRoutes.DELETE("/categories/:id", adminOnly, controller.DeleteCategory)
+}
+
+// AdminExchangeRateRoutes sets up the admin routes that manage the exchange rates.
+// Staff can read the rates; changing them is restricted to admins.
+func AdminExchangeRateRoutes(adminRoutes *gin.RouterGroup, controller *admin.ExchangeRateController) {
+	adminOnly := middlewares.Authorization(userModel.RoleAdmin)
+	adminRoutes.GET("/exchange-rates", controller.GetExchangeRates)
+	adminRoutes.PUT("/exchange-rates/:currency", adminOnly, controller.SetExchangeRate)
+	adminRoutes.DELETE("/exchange-rates/:currency", adminOnly, controller.DeleteExchangeRate)
+}
+
+// AdminPromotionRoutes sets up the admin routes that manage the promotions and coupons.
+// Staff can read the promotions; changing them is restricted to admins.
+func AdminPromotionRoutes(adminRoutes *gin.RouterGroup, controller *admin.PromotionController) {
+	adminOnly := middlewares.Authorization(userModel.RoleAdmin)
+	adminRoutes.GET("/promotions", controller.GetPromotions)
+	adminRoutes.POST("/promotions", adminOnly, controller.CreatePromotion)
+	adminRoutes.PUT("/promotions/:id", adminOnly, controller.UpdatePromotion)
+	adminRoutes.DELETE("/promotions/:id", adminOnly, controller.DeletePromotion)
+}
+
+// AdminTaxRateRoutes sets up the admin routes that manage the tax rates.
+// Staff can read the rates; changing them is restricted to admins.
+func AdminTaxRateRoutes(adminRoutes *gin.RouterGroup, controller *admin.TaxRateController) {
+	adminOnly := middlewares.Authorization(userModel.RoleAdmin)
+	adminRoutes.GET("/tax-rates", controller.GetTaxRates)
+	adminRoutes.PUT("/tax-rates", adminOnly, controller.SetTaxRate)
+	adminRoutes.DELETE("/tax-rates/:id", adminOnly, controller.DeleteTaxRate)
+}
+
+// AdminShippingZoneRoutes sets up the admin routes that manage the shipping zones and methods.
+// Staff can read the zones; changing them is restricted to admins.
+func AdminShippingZoneRoutes(adminRoutes *gin.RouterGroup, controller *admin.ShippingZoneController) {
+	adminOnly := middlewares.Authorization(userModel.RoleAdmin)
+	adminRoutes.GET("/shipping-zones", controller.GetShippingZones)
+	adminRoutes.POST("/shipping-zones", adminOnly, controller.CreateShippingZone)
+	adminRoutes.PUT("/shipping-zones/:id", adminOnly, controller.UpdateShippingZone)
+	adminRoutes.DELETE("/shipping-zones/:id", adminOnly, controller.DeleteShippingZone)
+}
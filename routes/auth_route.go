@@ -12,8 +12,8 @@ import (
 */
 
 // GetAuthRoutes sets up the authentication routes for user authentication.
-func GetAuthRoutes(userRoutes *gin.RouterGroup) {
-	userRoutes.POST("/signin", auth.SignInController)
-	userRoutes.POST("/signup", auth.SignUpController)
-	userRoutes.POST("/tokenrefresh", auth.TokenRefreshController)
+func GetAuthRoutes(userRoutes *gin.RouterGroup, authController *auth.AuthController) {
+	userRoutes.POST("/signin", authController.SignIn)
+	userRoutes.POST("/signup", authController.SignUp)
+	userRoutes.POST("/tokenrefresh", authController.TokenRefresh)
 }
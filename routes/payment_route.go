@@ -0,0 +1,12 @@
+package routes
+
+import (
+	"github.com/YassinNouh21/GoShopCart-Ecommerce/controllers/payment"
+
+	"github.com/gin-gonic/gin"
+)
+
+// PaymentRoutes sets up the routes the payment provider calls.
+func PaymentRoutes(paymentRoutes *gin.RouterGroup, controller *payment.WebhookController) {
+	paymentRoutes.POST("/webhook", controller.HandleWebhook)
+}
@@ -2,20 +2,57 @@ package routes
 
 import (
 	productController "github.com/YassinNouh21/GoShopCart-Ecommerce/controllers/product"
+	"github.com/YassinNouh21/GoShopCart-Ecommerce/middlewares"
+	userModel "github.com/YassinNouh21/GoShopCart-Ecommerce/models/user"
 	"github.com/gin-gonic/gin"
 )
 
 // ProductRoutes sets up the routes for the product endpoints.
-func ProductRoutes(productRoutes *gin.RouterGroup) {
-	productRoutes.POST("/", productController.CreateProductController)
-	productRoutes.GET("/:id", productController.GetProductController)
-	productRoutes.PUT("/:id", productController.UpdateProductController)
-	productRoutes.DELETE("/:id", productController.DeleteProductController)
+// Creating, updating and deleting products is restricted to admins.
+func ProductRoutes(productRoutes *gin.RouterGroup, controller *productController.ProductController) {
+	adminOnly := middlewares.Authorization(userModel.RoleAdmin)
+	productRoutes.POST("/", adminOnly, controller.CreateProduct)
+	productRoutes.GET("/:id", controller.GetProduct)
+	productRoutes.PUT("/:id", adminOnly, controller.UpdateProduct)
+	productRoutes.PATCH("/:id", adminOnly, controller.PatchProduct)
+	productRoutes.DELETE("/:id", adminOnly, controller.DeleteProduct)
 }
 
 // ProductFilterRoutes sets up the routes for the product filter endpoints.
-func ProductFilterRoutes(productRoutes *gin.RouterGroup) {
-	productRoutes.GET("/price", productController.GetProductsByPriceRangeController)
-	productRoutes.GET("/price/:price", productController.GetProductsByPriceController)
-	productRoutes.GET("/keyword", productController.GetProductsByKeyword)
+func ProductFilterRoutes(productRoutes *gin.RouterGroup, controller *productController.ProductController) {
+	productRoutes.GET("/price", controller.GetProductsByPriceRange)
+	productRoutes.GET("/price/:price", controller.GetProductsByPrice)
+	productRoutes.GET("/keyword", controller.GetProductsByKeyword)
+	productRoutes.GET("/search", controller.SearchProducts)
+	productRoutes.GET("/suggest", controller.SuggestProducts)
+	productRoutes.GET("/currencies", controller.GetCurrencies)
+}
+
+// CategoryRoutes sets up the public routes for browsing the product taxonomy.
+func CategoryRoutes(productRoutes *gin.RouterGroup, controller *productController.CategoryController) {
+	productRoutes.GET("/categories", controller.GetCategories)
+	productRoutes.GET("/category/:slug", controller.GetProductsByCategory)
+}
+
+// ReviewRoutes sets up the routes for the reviews of the products.
+// Authors edit their own reviews; admins may also delete any review.
+func ReviewRoutes(productRoutes *gin.RouterGroup, controller *productController.ReviewController) {
+	productRoutes.GET("/:id/reviews", controller.GetReviews)
+	productRoutes.POST("/:id/reviews", controller.CreateReview)
+	productRoutes.PUT("/:id/reviews/:review_id", controller.UpdateReview)
+	productRoutes.DELETE("/:id/reviews/:review_id", controller.DeleteReview)
+	productRoutes.POST("/:id/reviews/:review_id/helpful", controller.MarkReviewHelpful)
+}
+
+// ProductImageRoutes sets up the routes that manage the images of the products (admin only).
+func ProductImageRoutes(productRoutes *gin.RouterGroup, controller *productController.ImageController) {
+	adminOnly := middlewares.Authorization(userModel.RoleAdmin)
+	productRoutes.POST("/:id/images", adminOnly, controller.UploadProductImage)
+	productRoutes.PUT("/:id/images", adminOnly, controller.ReorderProductImages)
+	productRoutes.DELETE("/:id/images/:image_id", adminOnly, controller.DeleteProductImage)
+}
+
+// ImageRoutes sets up the public route serving the images, so they can be embedded in pages.
+func ImageRoutes(router *gin.Engine, controller *productController.ImageController) {
+	router.GET(productController.ImagesPath+"*key", controller.ServeImage)
 }
@@ -0,0 +1,102 @@
+package routes
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/YassinNouh21/GoShopCart-Ecommerce/controllers/admin"
+	"github.com/YassinNouh21/GoShopCart-Ecommerce/controllers/auth"
+	"github.com/YassinNouh21/GoShopCart-Ecommerce/controllers/payment"
+	productController "github.com/YassinNouh21/GoShopCart-Ecommerce/controllers/product"
+	"github.com/YassinNouh21/GoShopCart-Ecommerce/controllers/user"
+	"github.com/YassinNouh21/GoShopCart-Ecommerce/inventory"
+	"github.com/YassinNouh21/GoShopCart-Ecommerce/middlewares"
+	userModel "github.com/YassinNouh21/GoShopCart-Ecommerce/models/user"
+	"github.com/YassinNouh21/GoShopCart-Ecommerce/payments"
+	"github.com/YassinNouh21/GoShopCart-Ecommerce/pricing"
+	"github.com/YassinNouh21/GoShopCart-Ecommerce/repositories"
+	"github.com/YassinNouh21/GoShopCart-Ecommerce/search"
+	"github.com/YassinNouh21/GoShopCart-Ecommerce/storage"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Services groups the external services the controllers depend on besides the repositories.
+type Services struct {
+	Payments  payments.PaymentProvider
+	Inventory *inventory.Reservations
+	Search    search.Backend
+	Suggester *search.Suggester
+	Blobs     storage.BlobStore
+	// GuestCartTTL is how long a guest cart is kept after its last change.
+	GuestCartTTL time.Duration
+}
+
+// SetupRouter creates the Gin router with every route of the application.
+// The controllers are built on the given repositories and services, so the router can run on top of
+// MongoDB or the in-memory repositories and against real or fake services.
+func SetupRouter(repos repositories.Repositories, services Services) *gin.Engine {
+	// Create a new Gin router with default middleware
+	router := gin.Default()
+
+	// Prices of the guest, user and product routes are in the currency selected by the request, and
+	// the currency preferred at sign up must have an exchange rate
+	currency := middlewares.Currency(repos.Users, repos.ExchangeRates)
+
+	authRoutes := router.Group("/auth", currency)
+	GetAuthRoutes(authRoutes, auth.NewAuthController(repos.Users, repos.Products, repos.Carts, repos.GuestCarts, repos.Transactor))
+
+	// The payment webhook is authenticated by its signature instead of a user token
+	paymentRoutes := router.Group("/payments")
+	PaymentRoutes(paymentRoutes, payment.NewWebhookController(repos.Orders, services.Payments, services.Inventory))
+
+	// Images are public, so they can be embedded in pages without a token
+	images := productController.NewImageController(repos.Products, services.Blobs)
+	ImageRoutes(router, images)
+
+	engine := pricing.NewEngine(repos.Products, repos.Promotions, repos.ShippingZones, pricing.NewTableTaxCalculator(repos.TaxRates, repos.Categories))
+
+	// Guest carts are identified by their cart token, so visitors can fill a cart before signing in
+	guestRoutes := router.Group("/guest", currency)
+	GuestCartRoutes(guestRoutes, user.NewGuestCartController(repos.Products, repos.GuestCarts, engine, services.GuestCartTTL))
+
+	// Use Authentication middleware
+	router.Use(middlewares.Authentication(repos.Users))
+
+	// Set up user-related routes under /user
+	userRoutes := router.Group("/user", currency)
+	ProfileRoutes(userRoutes, user.NewProfileController(repos.Users))
+	AddressRoutes(userRoutes, user.NewAddressController(repos.Users))
+	CartRoutes(userRoutes, user.NewCartController(repos.Users, repos.Products, repos.Carts, repos.Transactor, engine))
+	OrderRoutes(userRoutes, user.NewOrderController(repos.Users, repos.Carts, repos.Orders, repos.Transactor, services.Payments, services.Inventory, engine))
+
+	// Set up product-related routes under /product
+	products := productController.NewProductController(repos.Products, repos.Categories, services.Search, services.Suggester, services.Blobs)
+	categories := productController.NewCategoryController(repos.Categories, repos.Products, repos.Transactor)
+	productRoutes := router.Group("/product", currency)
+	ProductRoutes(productRoutes, products)
+	ProductFilterRoutes(productRoutes, products)
+	ProductImageRoutes(productRoutes, images)
+	CategoryRoutes(productRoutes, categories)
+	ReviewRoutes(productRoutes, productController.NewReviewController(repos.Reviews, repos.Products, repos.Orders, repos.Users, repos.Transactor, services.Suggester))
+
+	// Set up admin routes under /admin, restricted to staff and admins
+	adminRoutes := router.Group("/admin", middlewares.Authorization(userModel.RoleAdmin, userModel.RoleStaff))
+	AdminUserRoutes(adminRoutes, admin.NewUserController(repos.Users))
+	AdminOrderRoutes(adminRoutes, admin.NewOrderController(repos.Orders, services.Payments, services.Inventory))
+	AdminInventoryRoutes(adminRoutes, admin.NewInventoryController(repos.Products))
+	AdminCategoryRoutes(adminRoutes, categories)
+	AdminCatalogRoutes(adminRoutes, admin.NewCatalogController(repos.Products, repos.Categories, services.Search, services.Suggester))
+	AdminExchangeRateRoutes(adminRoutes, admin.NewExchangeRateController(repos.ExchangeRates))
+	AdminPromotionRoutes(adminRoutes, admin.NewPromotionController(repos.Promotions))
+	AdminTaxRateRoutes(adminRoutes, admin.NewTaxRateController(repos.TaxRates))
+	AdminShippingZoneRoutes(adminRoutes, admin.NewShippingZoneController(repos.ShippingZones))
+
+	router.NoRoute(func(c *gin.Context) {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "Route not defined",
+		})
+	})
+
+	return router
+}
@@ -6,24 +6,44 @@ import (
 )
 
 // ProfileRoutes sets up the profile routes for the User.
-func ProfileRoutes(userRoutes *gin.RouterGroup) {
-	userRoutes.GET("/profile", user.GetProfileController)
-	userRoutes.POST("/profile/update", user.UpdateProfileController)
+func ProfileRoutes(userRoutes *gin.RouterGroup, controller *user.ProfileController) {
+	userRoutes.GET("/profile", controller.GetProfile)
+	userRoutes.POST("/profile/update", controller.UpdateProfile)
+	userRoutes.PUT("/profile/currency", controller.UpdateCurrency)
 }
 
 // AddressRoutes sets up the address routes of the user.
-func AddressRoutes(addressRoutes *gin.RouterGroup) {
-	addressRoutes.GET("/address", user.GetAddressController)
-	addressRoutes.POST("/address", user.AddAddressController)
-	addressRoutes.DELETE("/address", user.DeleteAllAddressController)
-	addressRoutes.DELETE("/address/:address_id", user.DeleteAddressWithIdController)
+func AddressRoutes(addressRoutes *gin.RouterGroup, controller *user.AddressController) {
+	addressRoutes.GET("/address", controller.GetAddress)
+	addressRoutes.POST("/address", controller.AddAddress)
+	addressRoutes.DELETE("/address", controller.DeleteAllAddress)
+	addressRoutes.DELETE("/address/:address_id", controller.DeleteAddressWithId)
 }
 
 // CartRoutes sets up the cart routes for the application.
-func CartRoutes(cartRoutes *gin.RouterGroup) {
-	cartRoutes.GET("/cart", user.GetCartController)
-	cartRoutes.POST("/cart", user.AddCartController)
-	cartRoutes.DELETE("/cart", user.DeleteAllCartController)
-	// cartRoutes.DELETE("/cart/:cart_id", user.DeleteCartWithIdController)
-	cartRoutes.PUT("/cart/:cart_id", user.UpdateCartController)
+func CartRoutes(cartRoutes *gin.RouterGroup, controller *user.CartController) {
+	cartRoutes.GET("/cart", controller.GetCart)
+	cartRoutes.POST("/cart", controller.AddCart)
+	cartRoutes.DELETE("/cart", controller.DeleteAllCart)
+	cartRoutes.GET("/cart/shipping-options", controller.GetShippingOptions)
+	cartRoutes.POST("/cart/coupon", controller.ApplyCoupon)
+	cartRoutes.POST("/cart/bulk", controller.BulkUpdateCart)
+	cartRoutes.DELETE("/cart/:cart_id", controller.DeleteCartWithId)
+	cartRoutes.PUT("/cart/:cart_id", controller.UpdateCart)
+}
+
+// GuestCartRoutes sets up the cart routes of the visitors who have not signed in.
+func GuestCartRoutes(guestRoutes *gin.RouterGroup, controller *user.GuestCartController) {
+	guestRoutes.GET("/cart", controller.GetGuestCart)
+	guestRoutes.POST("/cart", controller.AddGuestCart)
+	guestRoutes.DELETE("/cart", controller.DeleteGuestCart)
+	guestRoutes.PUT("/cart/:cart_id", controller.UpdateGuestCart)
+}
+
+// OrderRoutes sets up the checkout and order routes of the user.
+func OrderRoutes(orderRoutes *gin.RouterGroup, controller *user.OrderController) {
+	orderRoutes.POST("/checkout", controller.Checkout)
+	orderRoutes.GET("/orders", controller.GetOrders)
+	orderRoutes.GET("/orders/:id", controller.GetOrder)
+	orderRoutes.POST("/orders/:id/cancel", controller.CancelOrder)
 }
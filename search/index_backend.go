@@ -0,0 +1,207 @@
+package search
+
+import (
+	"context"
+	"math"
+	"sort"
+	"sync"
+
+	productModel "github.com/YassinNouh21/GoShopCart-Ecommerce/models/product"
+	"github.com/YassinNouh21/GoShopCart-Ecommerce/repositories"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const (
+	// nameWeight and descriptionWeight weigh the matches in the product name and description.
+	nameWeight        = 3
+	descriptionWeight = 1
+)
+
+// indexedProduct holds the stems of the name and the description of a product, in order,
+// so phrases can be matched.
+type indexedProduct struct {
+	fields [][]string
+}
+
+// indexBackend is the embedded Backend: an inverted index from word stems to the products that
+// contain them, ranked by TF-IDF. It lives in memory and is rebuilt from the catalog on Load.
+type indexBackend struct {
+	mu       sync.RWMutex
+	products map[primitive.ObjectID]indexedProduct
+	// postings maps every stem to the weighted number of its occurrences in each product
+	postings map[string]map[primitive.ObjectID]float64
+}
+
+// NewIndexBackend creates an empty embedded Backend.
+func NewIndexBackend() Backend {
+	return &indexBackend{
+		products: map[primitive.ObjectID]indexedProduct{},
+		postings: map[string]map[primitive.ObjectID]float64{},
+	}
+}
+
+func (b *indexBackend) Name() string {
+	return "index"
+}
+
+// Load indexes every product of the catalog.
+func (b *indexBackend) Load(ctx context.Context, products repositories.ProductRepository) error {
+	return forEachProduct(ctx, products, func(product productModel.Product) error {
+		return b.Index(ctx, product)
+	})
+}
+
+func (b *indexBackend) Index(ctx context.Context, product productModel.Product) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.remove(product.ProductID)
+	indexed := indexedProduct{fields: [][]string{stems(product.ProductName), stems(product.Description)}}
+	for i, weight := range []float64{nameWeight, descriptionWeight} {
+		for _, stem := range indexed.fields[i] {
+			if IsStopWord(stem) {
+				continue
+			}
+			if b.postings[stem] == nil {
+				b.postings[stem] = map[primitive.ObjectID]float64{}
+			}
+			b.postings[stem][product.ProductID] += weight
+		}
+	}
+	b.products[product.ProductID] = indexed
+	return nil
+}
+
+func (b *indexBackend) Remove(ctx context.Context, productID primitive.ObjectID) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.remove(productID)
+	return nil
+}
+
+// remove drops the product from the postings. The caller holds the write lock.
+func (b *indexBackend) remove(productID primitive.ObjectID) {
+	indexed, found := b.products[productID]
+	if !found {
+		return
+	}
+	for _, field := range indexed.fields {
+		for _, stem := range field {
+			delete(b.postings[stem], productID)
+			if len(b.postings[stem]) == 0 {
+				delete(b.postings, stem)
+			}
+		}
+	}
+	delete(b.products, productID)
+}
+
+// Search follows the semantics of a MongoDB text search: without phrases a product matches if it
+// contains any term, with phrases it must contain all of them, and it never contains an excluded word.
+func (b *indexBackend) Search(ctx context.Context, query Query, limit int) ([]Hit, error) {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	scored := []string{}
+	for _, term := range query.Terms {
+		scored = append(scored, Stem(term))
+	}
+	phrases := [][]string{}
+	for _, phrase := range query.Phrases {
+		phrase = stemAll(phrase)
+		phrases = append(phrases, phrase)
+		scored = append(scored, phrase...)
+	}
+
+	candidates := map[primitive.ObjectID]bool{}
+	if len(phrases) > 0 {
+		for productID, indexed := range b.products {
+			if indexed.containsAll(phrases) {
+				candidates[productID] = true
+			}
+		}
+	} else {
+		for _, stem := range scored {
+			for productID := range b.postings[stem] {
+				candidates[productID] = true
+			}
+		}
+	}
+	for _, excluded := range query.Excluded {
+		for productID := range b.postings[Stem(excluded)] {
+			delete(candidates, productID)
+		}
+	}
+
+	hits := []Hit{}
+	for productID := range candidates {
+		score := 0.0
+		for _, stem := range scored {
+			if occurrences := b.postings[stem][productID]; occurrences > 0 {
+				score += occurrences * b.idf(stem)
+			}
+		}
+		hits = append(hits, Hit{ProductID: productID, Score: score})
+	}
+	sort.Slice(hits, func(i, j int) bool {
+		if hits[i].Score != hits[j].Score {
+			return hits[i].Score > hits[j].Score
+		}
+		return hits[i].ProductID.Hex() < hits[j].ProductID.Hex()
+	})
+	if len(hits) > limit {
+		hits = hits[:limit]
+	}
+	return hits, nil
+}
+
+// idf returns the inverse document frequency of the stem: rare words weigh more than common ones.
+// The caller holds the read lock.
+func (b *indexBackend) idf(stem string) float64 {
+	return math.Log(1 + float64(len(b.products))/float64(len(b.postings[stem])))
+}
+
+// containsAll reports whether every phrase appears, word after word, in one field of the product.
+func (p indexedProduct) containsAll(phrases [][]string) bool {
+	for _, phrase := range phrases {
+		found := false
+		for _, field := range p.fields {
+			if containsSequence(field, phrase) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
+
+func containsSequence(words []string, sequence []string) bool {
+	for start := 0; start+len(sequence) <= len(words); start++ {
+		matched := true
+		for i := range sequence {
+			if words[start+i] != sequence[i] {
+				matched = false
+				break
+			}
+		}
+		if matched {
+			return true
+		}
+	}
+	return false
+}
+
+// stems tokenizes the text and stems every word.
+func stems(text string) []string {
+	return stemAll(Tokenize(text))
+}
+
+func stemAll(words []string) []string {
+	stemmed := make([]string, len(words))
+	for i, word := range words {
+		stemmed[i] = Stem(word)
+	}
+	return stemmed
+}
@@ -0,0 +1,76 @@
+package search
+
+import (
+	"context"
+
+	productModel "github.com/YassinNouh21/GoShopCart-Ecommerce/models/product"
+	"github.com/YassinNouh21/GoShopCart-Ecommerce/repositories"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// TextIndexName is the name of the text index the MongoDB backend creates on the products collection.
+const TextIndexName = "product_text"
+
+// mongoBackend is the Backend that searches the products collection through its text index.
+// MongoDB keeps the index up to date, so indexing and removing products does nothing.
+type mongoBackend struct {
+	collection *mongo.Collection
+}
+
+// NewMongoBackend creates a Backend that searches the given products collection.
+func NewMongoBackend(collection *mongo.Collection) Backend {
+	return &mongoBackend{collection: collection}
+}
+
+func (b *mongoBackend) Name() string {
+	return "mongo"
+}
+
+// Load creates the text index over the product name and description, if it does not exist yet.
+// Matches in the name weigh three times as much as matches in the description.
+func (b *mongoBackend) Load(ctx context.Context, products repositories.ProductRepository) error {
+	_, err := b.collection.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys: bson.D{{Key: "product_name", Value: "text"}, {Key: "description", Value: "text"}},
+		Options: options.Index().
+			SetName(TextIndexName).
+			SetWeights(bson.M{"product_name": 3, "description": 1}).
+			SetDefaultLanguage("english"),
+	})
+	return err
+}
+
+func (b *mongoBackend) Index(ctx context.Context, product productModel.Product) error {
+	return nil
+}
+
+func (b *mongoBackend) Remove(ctx context.Context, productID primitive.ObjectID) error {
+	return nil
+}
+
+func (b *mongoBackend) Search(ctx context.Context, query Query, limit int) ([]Hit, error) {
+	// The query is rebuilt from its parsed words, so user input never reaches $search verbatim
+	score := bson.M{"$meta": "textScore"}
+	findOptions := options.Find().
+		SetProjection(bson.M{"_id": 1, "score": score}).
+		SetSort(bson.D{{Key: "score", Value: score}, {Key: "_id", Value: 1}}).
+		SetLimit(int64(limit))
+	cursor, err := b.collection.Find(ctx, bson.M{"$text": bson.M{"$search": query.String()}}, findOptions)
+	if err != nil {
+		return nil, err
+	}
+	var results []struct {
+		ProductID primitive.ObjectID `bson:"_id"`
+		Score     float64            `bson:"score"`
+	}
+	if err := cursor.All(ctx, &results); err != nil {
+		return nil, err
+	}
+	hits := []Hit{}
+	for _, result := range results {
+		hits = append(hits, Hit{ProductID: result.ProductID, Score: result.Score})
+	}
+	return hits, nil
+}
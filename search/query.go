@@ -0,0 +1,106 @@
+package search
+
+import (
+	"errors"
+	"strings"
+)
+
+const (
+	// MaxQueryLength is the number of characters of a query that are read; the rest is ignored.
+	MaxQueryLength = 256
+	// MaxQueryWords is the number of words of a query that are kept; the rest is ignored.
+	MaxQueryWords = 32
+)
+
+// ErrEmptyQuery is returned when a query has nothing to search for.
+var ErrEmptyQuery = errors.New("Search query must contain a word or a phrase to look for")
+
+// Query is a parsed search query. Words are lowercase and only hold letters and digits, so a
+// query never carries operators or patterns of the backend it is sent to.
+type Query struct {
+	// Terms are the words of which a matching product contains at least one, unless the query has phrases.
+	Terms []string
+	// Phrases are the sequences of words every matching product contains.
+	Phrases [][]string
+	// Excluded are the words no matching product contains.
+	Excluded []string
+}
+
+/*
+ParseQuery parses a search query typed by a user.
+
+	Words are separated by spaces and punctuation. Words in double quotes form a phrase, and a word
+	prefixed with a minus sign is excluded: `running shoes "trail" -kids`. An unterminated quote
+	runs to the end of the query.
+
+Possible Errors:
+  - ErrEmptyQuery: If the query has no term and no phrase.
+*/
+func ParseQuery(raw string) (Query, error) {
+	query := Query{}
+	runes := []rune(raw)
+	if len(runes) > MaxQueryLength {
+		runes = runes[:MaxQueryLength]
+	}
+	words := 0
+	for i := 0; i < len(runes); {
+		switch {
+		case runes[i] == '"':
+			end := i + 1
+			for end < len(runes) && runes[end] != '"' {
+				end++
+			}
+			if phrase := Tokenize(string(runes[i+1 : end])); len(phrase) > 0 && words < MaxQueryWords {
+				query.Phrases = append(query.Phrases, phrase)
+				words += len(phrase)
+			}
+			i = end + 1
+		case runes[i] == '-' && (i == 0 || isSeparator(runes[i-1])) && i+1 < len(runes) && !isSeparator(runes[i+1]):
+			end := nextSeparator(runes, i+1)
+			if excluded := Tokenize(string(runes[i+1 : end])); len(excluded) > 0 && words < MaxQueryWords {
+				query.Excluded = append(query.Excluded, excluded...)
+				words += len(excluded)
+			}
+			i = end
+		case isSeparator(runes[i]):
+			i++
+		default:
+			end := nextSeparator(runes, i)
+			for _, term := range Tokenize(string(runes[i:end])) {
+				if words < MaxQueryWords {
+					query.Terms = append(query.Terms, term)
+					words++
+				}
+			}
+			i = end
+		}
+	}
+	if len(query.Terms) == 0 && len(query.Phrases) == 0 {
+		return query, ErrEmptyQuery
+	}
+	return query, nil
+}
+
+// String formats the query back into the query syntax.
+func (q Query) String() string {
+	parts := append([]string{}, q.Terms...)
+	for _, phrase := range q.Phrases {
+		parts = append(parts, `"`+strings.Join(phrase, " ")+`"`)
+	}
+	for _, excluded := range q.Excluded {
+		parts = append(parts, "-"+excluded)
+	}
+	return strings.Join(parts, " ")
+}
+
+func isSeparator(r rune) bool {
+	return r == ' ' || r == '\t' || r == '\n' || r == '"'
+}
+
+func nextSeparator(runes []rune, start int) int {
+	end := start
+	for end < len(runes) && !isSeparator(runes[end]) {
+		end++
+	}
+	return end
+}
@@ -0,0 +1,98 @@
+package search
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseQuery(t *testing.T) {
+	tests := []struct {
+		name  string
+		raw   string
+		want  Query
+		empty bool
+	}{
+		{
+			name: "terms",
+			raw:  "Running Shoes",
+			want: Query{Terms: []string{"running", "shoes"}},
+		},
+		{
+			name: "phrase and exclusion",
+			raw:  `running shoes "trail run" -kids`,
+			want: Query{Terms: []string{"running", "shoes"}, Phrases: [][]string{{"trail", "run"}}, Excluded: []string{"kids"}},
+		},
+		{
+			name: "unterminated quote runs to the end",
+			raw:  `"red wool scarf`,
+			want: Query{Phrases: [][]string{{"red", "wool", "scarf"}}},
+		},
+		{
+			name: "hyphen inside a word is not an exclusion",
+			raw:  "t-shirt",
+			want: Query{Terms: []string{"t", "shirt"}},
+		},
+		{
+			name: "punctuation and operators are dropped",
+			raw:  `shoes {$where: 1} .*`,
+			want: Query{Terms: []string{"shoes", "where", "1"}},
+		},
+		{
+			name: "lone minus sign is ignored",
+			raw:  "shoes - kids",
+			want: Query{Terms: []string{"shoes", "kids"}},
+		},
+		{
+			name:  "only exclusions",
+			raw:   "-kids -socks",
+			want:  Query{Excluded: []string{"kids", "socks"}},
+			empty: true,
+		},
+		{
+			name:  "blank",
+			raw:   `   "" `,
+			empty: true,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got, err := ParseQuery(test.raw)
+			if test.empty {
+				if err != ErrEmptyQuery {
+					t.Fatalf("ParseQuery(%q) error = %v, want ErrEmptyQuery", test.raw, err)
+				}
+			} else if err != nil {
+				t.Fatalf("ParseQuery(%q) error = %v", test.raw, err)
+			}
+			if !reflect.DeepEqual(got, test.want) {
+				t.Errorf("ParseQuery(%q) = %#v, want %#v", test.raw, got, test.want)
+			}
+		})
+	}
+}
+
+func TestParseQueryLimits(t *testing.T) {
+	got, err := ParseQuery(strings.Repeat("word ", MaxQueryWords+10))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got.Terms) != MaxQueryWords {
+		t.Errorf("got %d terms, want %d", len(got.Terms), MaxQueryWords)
+	}
+
+	got, err = ParseQuery(strings.Repeat("x", MaxQueryLength) + " tail")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got.Terms, []string{strings.Repeat("x", MaxQueryLength)}) {
+		t.Errorf("got terms %v, want the query cut at %d characters", got.Terms, MaxQueryLength)
+	}
+}
+
+func TestQueryString(t *testing.T) {
+	query := Query{Terms: []string{"running"}, Phrases: [][]string{{"trail", "run"}}, Excluded: []string{"kids"}}
+	if got, want := query.String(), `running "trail run" -kids`; got != want {
+		t.Errorf("String() = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,92 @@
+package search
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+
+	productModel "github.com/YassinNouh21/GoShopCart-Ecommerce/models/product"
+	"github.com/YassinNouh21/GoShopCart-Ecommerce/repositories"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+/*
+	Package search provides the full-text search over the name and description of the products.
+
+	Search queries are parsed into terms, quoted phrases and excluded terms, then answered by a
+	Backend that ranks the matching products by relevance. The MongoDB backend relies on a text
+	index of the products collection; the embedded backend keeps its own inverted index in memory
+	and is updated by the product controllers whenever a product changes. The Suggester completes
+	and corrects queries as they are typed and is kept up to date the same way.
+*/
+
+const (
+	// MaxHits is the largest number of products a search returns.
+	MaxHits = 1000
+	// loadPageSize is the number of products read at a time when the catalog is loaded.
+	loadPageSize = 100
+)
+
+// ErrUnknownBackend is returned when SEARCH_BACKEND names a backend that does not exist.
+var ErrUnknownBackend = errors.New("unknown search backend")
+
+// Hit is a product matching a search, with the relevance score the backend gave it.
+type Hit struct {
+	ProductID primitive.ObjectID
+	Score     float64
+}
+
+// Indexer is kept up to date with the catalog by the product controllers.
+type Indexer interface {
+	// Load prepares the indexer for the catalog, adding every product if it keeps its own index.
+	Load(ctx context.Context, products repositories.ProductRepository) error
+	// Index adds the product, replacing any previous version of it.
+	Index(ctx context.Context, product productModel.Product) error
+	// Remove removes the product.
+	Remove(ctx context.Context, productID primitive.ObjectID) error
+}
+
+// Backend answers search queries over the product catalog.
+type Backend interface {
+	Indexer
+	// Name returns the identifier of the backend, as used in SEARCH_BACKEND.
+	Name() string
+	// Search returns at most limit products matching the query, most relevant first.
+	Search(ctx context.Context, query Query, limit int) ([]Hit, error)
+}
+
+// NewBackendFromEnv creates the backend selected by SEARCH_BACKEND: "mongo" (the default), which
+// searches the given products collection, or "index", the embedded in-memory index.
+func NewBackendFromEnv(collection *mongo.Collection) (Backend, error) {
+	switch backend := os.Getenv("SEARCH_BACKEND"); backend {
+	case "", "mongo":
+		return NewMongoBackend(collection), nil
+	case "index":
+		return NewIndexBackend(), nil
+	default:
+		return nil, fmt.Errorf("%w %q", ErrUnknownBackend, backend)
+	}
+}
+
+// forEachProduct calls fn with every product of the catalog, reading it one page at a time.
+func forEachProduct(ctx context.Context, products repositories.ProductRepository, fn func(productModel.Product) error) error {
+	oldest, _ := repositories.ParseProductSort("oldest")
+	listOptions := repositories.ProductListOptions{Sort: oldest, Limit: loadPageSize}
+	for {
+		page, err := products.List(ctx, repositories.ProductFilter{}, listOptions)
+		if err != nil {
+			return err
+		}
+		for _, product := range page.Products {
+			if err := fn(product); err != nil {
+				return err
+			}
+		}
+		if page.NextCursor == "" {
+			return nil
+		}
+		listOptions.Cursor = page.NextCursor
+	}
+}
@@ -0,0 +1,260 @@
+package search
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"sync"
+
+	productModel "github.com/YassinNouh21/GoShopCart-Ecommerce/models/product"
+	"github.com/YassinNouh21/GoShopCart-Ecommerce/repositories"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Completion is a product whose name completes a partially typed query.
+type Completion struct {
+	ProductID   primitive.ObjectID `json:"product_id"`
+	ProductName string             `json:"product_name"`
+}
+
+// suggestionEntry starts at one word of a product name and runs to its end, so a query completes
+// names from any of their words: "run" completes "Road running shoe" through "running shoe".
+type suggestionEntry struct {
+	key       string
+	productID primitive.ObjectID
+	// fromStart is true for the entry that starts at the first word of the name.
+	fromStart bool
+}
+
+// suggestedProduct is the part of a product the suggester keeps.
+type suggestedProduct struct {
+	name   string
+	words  []string
+	rating float32
+}
+
+/*
+Suggester completes partially typed queries with product names and corrects misspelled ones.
+
+	It keeps the product names in memory, sorted by every word they contain, together with the
+	vocabulary of the names. Like the search backends, it is loaded from the catalog at startup and
+	updated by the product controllers whenever a product is created, updated or deleted.
+*/
+type Suggester struct {
+	mu       sync.RWMutex
+	products map[primitive.ObjectID]suggestedProduct
+	entries  []suggestionEntry
+	// words counts the products whose name contains every word
+	words map[string]int
+}
+
+// NewSuggester creates an empty Suggester.
+func NewSuggester() *Suggester {
+	return &Suggester{products: map[primitive.ObjectID]suggestedProduct{}, words: map[string]int{}}
+}
+
+// Load adds every product of the catalog to the suggester.
+func (s *Suggester) Load(ctx context.Context, products repositories.ProductRepository) error {
+	return forEachProduct(ctx, products, func(product productModel.Product) error {
+		return s.Index(ctx, product)
+	})
+}
+
+// Index adds the product to the suggester, replacing any previous version of it.
+func (s *Suggester) Index(ctx context.Context, product productModel.Product) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.remove(product.ProductID)
+	words := Tokenize(product.ProductName)
+	if len(words) == 0 {
+		return nil
+	}
+	s.products[product.ProductID] = suggestedProduct{name: product.ProductName, words: words, rating: product.Rating}
+	for i := range words {
+		s.insert(suggestionEntry{key: strings.Join(words[i:], " "), productID: product.ProductID, fromStart: i == 0})
+	}
+	for _, word := range distinct(words) {
+		s.words[word]++
+	}
+	return nil
+}
+
+// Remove removes the product from the suggester.
+func (s *Suggester) Remove(ctx context.Context, productID primitive.ObjectID) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.remove(productID)
+	return nil
+}
+
+// remove drops the entries and words of the product. The caller holds the write lock.
+func (s *Suggester) remove(productID primitive.ObjectID) {
+	product, found := s.products[productID]
+	if !found {
+		return
+	}
+	entries := s.entries[:0]
+	for _, entry := range s.entries {
+		if entry.productID != productID {
+			entries = append(entries, entry)
+		}
+	}
+	s.entries = entries
+	for _, word := range distinct(product.words) {
+		if s.words[word]--; s.words[word] <= 0 {
+			delete(s.words, word)
+		}
+	}
+	delete(s.products, productID)
+}
+
+// insert adds the entry to the sorted entries. The caller holds the write lock.
+func (s *Suggester) insert(entry suggestionEntry) {
+	i := sort.Search(len(s.entries), func(i int) bool { return s.entries[i].key >= entry.key })
+	s.entries = append(s.entries, suggestionEntry{})
+	copy(s.entries[i+1:], s.entries[i:])
+	s.entries[i] = entry
+}
+
+// Complete returns at most limit products whose name has a word starting with the query. Names that
+// start with the query come first, then the best rated products.
+func (s *Suggester) Complete(query string, limit int) []Completion {
+	prefix := strings.Join(Tokenize(query), " ")
+	if prefix == "" {
+		return []Completion{}
+	}
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	fromStart := map[primitive.ObjectID]bool{}
+	for i := sort.Search(len(s.entries), func(i int) bool { return s.entries[i].key >= prefix }); i < len(s.entries); i++ {
+		if !strings.HasPrefix(s.entries[i].key, prefix) {
+			break
+		}
+		fromStart[s.entries[i].productID] = fromStart[s.entries[i].productID] || s.entries[i].fromStart
+	}
+	completions := []Completion{}
+	for productID := range fromStart {
+		completions = append(completions, Completion{ProductID: productID, ProductName: s.products[productID].name})
+	}
+	sort.Slice(completions, func(i, j int) bool {
+		a, b := completions[i], completions[j]
+		if fromStart[a.ProductID] != fromStart[b.ProductID] {
+			return fromStart[a.ProductID]
+		}
+		if s.products[a.ProductID].rating != s.products[b.ProductID].rating {
+			return s.products[a.ProductID].rating > s.products[b.ProductID].rating
+		}
+		if a.ProductName != b.ProductName {
+			return a.ProductName < b.ProductName
+		}
+		return a.ProductID.Hex() < b.ProductID.Hex()
+	})
+	if len(completions) > limit {
+		completions = completions[:limit]
+	}
+	return completions
+}
+
+/*
+Correct returns the query with every misspelled word replaced by the closest word of the product
+names, and whether any word was replaced.
+
+	A word is misspelled when no product name contains it. The last word is left alone while it is
+	the beginning of a known word, since the user may still be typing it. Words of up to four letters
+	are corrected by one edit at most and longer words by two, where an edit inserts, deletes or
+	replaces a letter or swaps two adjacent letters. Among equally close words the most common wins.
+*/
+func (s *Suggester) Correct(query string) (string, bool) {
+	words := Tokenize(query)
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	corrected := false
+	for i, word := range words {
+		if s.words[word] > 0 || (i == len(words)-1 && s.completesWord(word)) {
+			continue
+		}
+		if replacement, found := s.closestWord(word); found {
+			words[i] = replacement
+			corrected = true
+		}
+	}
+	return strings.Join(words, " "), corrected
+}
+
+// completesWord reports whether a word of the product names starts with the prefix.
+// The caller holds the read lock.
+func (s *Suggester) completesWord(prefix string) bool {
+	i := sort.Search(len(s.entries), func(i int) bool { return s.entries[i].key >= prefix })
+	return i < len(s.entries) && strings.HasPrefix(s.entries[i].key, prefix)
+}
+
+// closestWord returns the known word with the fewest edits from the word, within the allowed edits.
+// The caller holds the read lock.
+func (s *Suggester) closestWord(word string) (string, bool) {
+	maxEdits := 2
+	if len([]rune(word)) <= 4 {
+		maxEdits = 1
+	}
+	best, bestEdits, bestCount := "", maxEdits+1, 0
+	for candidate, count := range s.words {
+		lengthDifference := len([]rune(candidate)) - len([]rune(word))
+		if lengthDifference > maxEdits || -lengthDifference > maxEdits {
+			continue
+		}
+		edits := editDistance(word, candidate)
+		if edits < bestEdits || (edits == bestEdits && (count > bestCount || (count == bestCount && candidate < best))) {
+			best, bestEdits, bestCount = candidate, edits, count
+		}
+	}
+	return best, bestEdits <= maxEdits
+}
+
+// editDistance returns the number of insertions, deletions, replacements and swaps of adjacent
+// letters that turn a into b (the optimal string alignment distance).
+func editDistance(a, b string) int {
+	x, y := []rune(a), []rune(b)
+	distances := make([][]int, len(x)+1)
+	for i := range distances {
+		distances[i] = make([]int, len(y)+1)
+		distances[i][0] = i
+	}
+	for j := range distances[0] {
+		distances[0][j] = j
+	}
+	for i := 1; i <= len(x); i++ {
+		for j := 1; j <= len(y); j++ {
+			cost := 1
+			if x[i-1] == y[j-1] {
+				cost = 0
+			}
+			distance := distances[i-1][j] + 1
+			if insertion := distances[i][j-1] + 1; insertion < distance {
+				distance = insertion
+			}
+			if replacement := distances[i-1][j-1] + cost; replacement < distance {
+				distance = replacement
+			}
+			if i > 1 && j > 1 && x[i-1] == y[j-2] && x[i-2] == y[j-1] {
+				if swap := distances[i-2][j-2] + 1; swap < distance {
+					distance = swap
+				}
+			}
+			distances[i][j] = distance
+		}
+	}
+	return distances[len(x)][len(y)]
+}
+
+func distinct(words []string) []string {
+	seen := map[string]bool{}
+	unique := []string{}
+	for _, word := range words {
+		if !seen[word] {
+			seen[word] = true
+			unique = append(unique, word)
+		}
+	}
+	return unique
+}
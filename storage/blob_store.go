@@ -0,0 +1,92 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+)
+
+/*
+	Package storage provides the BlobStore the application keeps uploaded files in.
+
+	Blobs are addressed by slash separated keys such as products/<id>/<image>.jpg. The local store
+	writes them below a directory of the server, and the S3 store keeps them in a bucket of any
+	S3-compatible service, such as AWS S3 or a local MinIO, signing its requests with AWS Signature
+	Version 4.
+*/
+
+var (
+	// ErrBlobNotFound is returned when no blob is stored under the requested key.
+	ErrBlobNotFound = errors.New("blob not found")
+
+	// ErrInvalidKey is returned when a key is empty or contains an empty, "." or ".." segment or a
+	// character other than letters, digits, dots, dashes and underscores.
+	ErrInvalidKey = errors.New("invalid blob key")
+)
+
+// Blob is the content of a stored file.
+type Blob struct {
+	Data        []byte
+	ContentType string
+}
+
+// BlobStore stores files by key.
+type BlobStore interface {
+	// Name returns the identifier of the store, as used in BLOB_STORE.
+	Name() string
+	// Put stores the blob under the key, replacing any blob stored under it.
+	Put(ctx context.Context, key string, blob Blob) error
+	// Get returns the blob stored under the key or ErrBlobNotFound.
+	Get(ctx context.Context, key string) (*Blob, error)
+	// Delete removes the blob stored under the key. Deleting a missing blob is not an error.
+	Delete(ctx context.Context, key string) error
+}
+
+// NewBlobStoreFromEnv creates the store selected by BLOB_STORE: "local" (the default), which keeps
+// the blobs below BLOB_DIR ("uploads" by default), or "s3", configured by S3_ENDPOINT, S3_BUCKET,
+// S3_REGION ("us-east-1" by default), S3_ACCESS_KEY_ID and S3_SECRET_ACCESS_KEY.
+func NewBlobStoreFromEnv() (BlobStore, error) {
+	switch store := os.Getenv("BLOB_STORE"); store {
+	case "", "local":
+		dir := os.Getenv("BLOB_DIR")
+		if dir == "" {
+			dir = "uploads"
+		}
+		return NewLocalStore(dir), nil
+	case "s3":
+		region := os.Getenv("S3_REGION")
+		if region == "" {
+			region = "us-east-1"
+		}
+		return NewS3Store(S3Config{
+			Endpoint:        os.Getenv("S3_ENDPOINT"),
+			Bucket:          os.Getenv("S3_BUCKET"),
+			Region:          region,
+			AccessKeyID:     os.Getenv("S3_ACCESS_KEY_ID"),
+			SecretAccessKey: os.Getenv("S3_SECRET_ACCESS_KEY"),
+		})
+	default:
+		return nil, fmt.Errorf("unknown BLOB_STORE %q", store)
+	}
+}
+
+// validKey reports whether the key can be stored. Keys never escape the directory of the local
+// store and need no escaping beyond the URL encoding of the S3 store.
+func validKey(key string) bool {
+	if key == "" {
+		return false
+	}
+	for _, segment := range strings.Split(key, "/") {
+		if segment == "" || segment == "." || segment == ".." {
+			return false
+		}
+		for _, r := range segment {
+			if !(r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || r == '.' || r == '-' || r == '_') {
+				return false
+			}
+		}
+	}
+	return true
+}
@@ -0,0 +1,85 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"io/fs"
+	"mime"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+)
+
+// localStore is the BlobStore that keeps the blobs as files below a directory.
+type localStore struct {
+	dir string
+}
+
+// NewLocalStore creates a BlobStore that keeps the blobs below the directory, which is created when
+// the first blob is stored. The content type of a blob is derived from the extension of its key.
+func NewLocalStore(dir string) BlobStore {
+	return &localStore{dir: dir}
+}
+
+func (s *localStore) Name() string {
+	return "local"
+}
+
+func (s *localStore) Put(ctx context.Context, key string, blob Blob) error {
+	if !validKey(key) {
+		return ErrInvalidKey
+	}
+	name := s.path(key)
+	if err := os.MkdirAll(filepath.Dir(name), 0o755); err != nil {
+		return err
+	}
+	// Write to a temporary file first, so readers never see a partially written blob
+	file, err := os.CreateTemp(filepath.Dir(name), ".upload-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(file.Name())
+	if _, err := file.Write(blob.Data); err != nil {
+		file.Close()
+		return err
+	}
+	if err := file.Close(); err != nil {
+		return err
+	}
+	return os.Rename(file.Name(), name)
+}
+
+func (s *localStore) Get(ctx context.Context, key string) (*Blob, error) {
+	if !validKey(key) {
+		return nil, ErrBlobNotFound
+	}
+	data, err := os.ReadFile(s.path(key))
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, ErrBlobNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	contentType := mime.TypeByExtension(path.Ext(key))
+	if contentType == "" {
+		contentType = http.DetectContentType(data)
+	}
+	return &Blob{Data: data, ContentType: contentType}, nil
+}
+
+func (s *localStore) Delete(ctx context.Context, key string) error {
+	if !validKey(key) {
+		return ErrInvalidKey
+	}
+	err := os.Remove(s.path(key))
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	return err
+}
+
+// path returns the file of the key. The key is valid, so the file is always below the directory.
+func (s *localStore) path(key string) string {
+	return filepath.Join(s.dir, filepath.FromSlash(key))
+}
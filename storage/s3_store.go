@@ -0,0 +1,138 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// S3Config configures the connection of the S3 store to an S3-compatible service.
+type S3Config struct {
+	// Endpoint is the base URL of the service, such as https://s3.eu-west-1.amazonaws.com or
+	// http://localhost:9000 for a local MinIO.
+	Endpoint string
+	// Bucket is the bucket the blobs are stored in. It must already exist.
+	Bucket string
+	// Region is the region the requests are signed for.
+	Region          string
+	AccessKeyID     string
+	SecretAccessKey string
+}
+
+// s3Store is the BlobStore that keeps the blobs as objects of a bucket. It addresses the bucket in
+// the path of the requests (http://endpoint/bucket/key), which every S3-compatible service accepts.
+type s3Store struct {
+	endpoint    *url.URL
+	bucket      string
+	credentials credentials
+	client      *http.Client
+}
+
+// NewS3Store creates a BlobStore on top of a bucket of an S3-compatible service.
+func NewS3Store(config S3Config) (BlobStore, error) {
+	if config.Endpoint == "" || config.Bucket == "" || config.AccessKeyID == "" || config.SecretAccessKey == "" {
+		return nil, errors.New("s3: S3_ENDPOINT, S3_BUCKET, S3_ACCESS_KEY_ID and S3_SECRET_ACCESS_KEY are required")
+	}
+	endpoint, err := url.Parse(strings.TrimSuffix(config.Endpoint, "/"))
+	if err != nil || endpoint.Host == "" || (endpoint.Scheme != "http" && endpoint.Scheme != "https") {
+		return nil, fmt.Errorf("s3: invalid endpoint %q", config.Endpoint)
+	}
+	return &s3Store{
+		endpoint: endpoint,
+		bucket:   config.Bucket,
+		credentials: credentials{
+			accessKeyID:     config.AccessKeyID,
+			secretAccessKey: config.SecretAccessKey,
+			region:          config.Region,
+			service:         "s3",
+		},
+		client: &http.Client{Timeout: 30 * time.Second},
+	}, nil
+}
+
+func (s *s3Store) Name() string {
+	return "s3"
+}
+
+func (s *s3Store) Put(ctx context.Context, key string, blob Blob) error {
+	if !validKey(key) {
+		return ErrInvalidKey
+	}
+	response, err := s.do(ctx, http.MethodPut, key, blob)
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+	if response.StatusCode != http.StatusOK {
+		return s.responseError(http.MethodPut, key, response)
+	}
+	return nil
+}
+
+func (s *s3Store) Get(ctx context.Context, key string) (*Blob, error) {
+	if !validKey(key) {
+		return nil, ErrBlobNotFound
+	}
+	response, err := s.do(ctx, http.MethodGet, key, Blob{})
+	if err != nil {
+		return nil, err
+	}
+	defer response.Body.Close()
+	if response.StatusCode == http.StatusNotFound {
+		return nil, ErrBlobNotFound
+	}
+	if response.StatusCode != http.StatusOK {
+		return nil, s.responseError(http.MethodGet, key, response)
+	}
+	data, err := io.ReadAll(response.Body)
+	if err != nil {
+		return nil, err
+	}
+	return &Blob{Data: data, ContentType: response.Header.Get("Content-Type")}, nil
+}
+
+func (s *s3Store) Delete(ctx context.Context, key string) error {
+	if !validKey(key) {
+		return ErrInvalidKey
+	}
+	response, err := s.do(ctx, http.MethodDelete, key, Blob{})
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+	// S3 answers 204 whether or not the object existed
+	if response.StatusCode != http.StatusNoContent && response.StatusCode != http.StatusOK && response.StatusCode != http.StatusNotFound {
+		return s.responseError(http.MethodDelete, key, response)
+	}
+	return nil
+}
+
+// do sends a signed request for the object of the key, with the blob as body.
+func (s *s3Store) do(ctx context.Context, method, key string, blob Blob) (*http.Response, error) {
+	objectURL := *s.endpoint
+	objectURL.Path = s.endpoint.Path + "/" + s.bucket + "/" + key
+	request, err := http.NewRequestWithContext(ctx, method, objectURL.String(), bytes.NewReader(blob.Data))
+	if err != nil {
+		return nil, err
+	}
+	if method == http.MethodPut {
+		request.Header.Set("Content-Type", blob.ContentType)
+	}
+	payloadHash := sha256.Sum256(blob.Data)
+	s.credentials.sign(request, hex.EncodeToString(payloadHash[:]), time.Now())
+	return s.client.Do(request)
+}
+
+// responseError describes an unexpected response, including the S3 error document if there is one.
+func (s *s3Store) responseError(method, key string, response *http.Response) error {
+	body, _ := io.ReadAll(io.LimitReader(response.Body, 1024))
+	return fmt.Errorf("s3: %s %s: %s %s", method, key, response.Status, strings.TrimSpace(string(body)))
+}